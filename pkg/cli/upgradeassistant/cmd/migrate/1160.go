@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"github.com/koderover/zadig/pkg/cli/upgradeassistant/internal/secret"
+	"github.com/koderover/zadig/pkg/cli/upgradeassistant/internal/upgradepath"
+	"github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
+)
+
+func init() {
+	upgradepath.RegisterHandler("1.15.0", "1.16.0", V1150ToV1160)
+	upgradepath.RegisterHandler("1.16.0", "1.15.0", V1160ToV1150)
+}
+
+func V1150ToV1160() error {
+	// save credentials in the secret store
+	if config.SecretStoreBackend() == "" {
+		log.Info("secret store backend is not configured, credentials are kept in the database")
+		return nil
+	}
+	if err := secret.Transform(secretstore.Seal); err != nil {
+		log.Errorf("sealCredentials err:%s", err)
+		return err
+	}
+	return nil
+}
+
+func V1160ToV1150() error {
+	// move credentials back to the database
+	if err := secret.Transform(func(_, value string) (string, error) {
+		return secretstore.Open(value)
+	}); err != nil {
+		log.Errorf("openCredentials err:%s", err)
+		return err
+	}
+	return nil
+}
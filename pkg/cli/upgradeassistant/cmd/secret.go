@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/koderover/zadig/pkg/cli/upgradeassistant/internal/secret"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
+)
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(rotateCmd)
+}
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "manage credentials in the secret store",
+	Long:  `manage credentials in the secret store.`,
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "rewrap credentials with the primary master key",
+	Long: `rewrap credentials sealed by the local secret store with the primary master key.
+Add the new key to the end of the master keys before the rotation, and remove the old keys after it.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return preRun()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := secret.Transform(func(_, value string) (string, error) {
+			return secretstore.Rewrap(value)
+		}); err != nil {
+			log.Fatal(err)
+		}
+		log.Info("Rotation finished")
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		if err := postRun(); err != nil {
+			log.Error(err)
+		}
+	},
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/tool/log"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
+)
+
+type collection struct {
+	name    string
+	idField string
+	fields  []string
+}
+
+// collections are the collections whose credentials are saved in the secret store.
+var collections = []*collection{
+	{name: "private_key", idField: "_id", fields: []string{"private_key"}},
+	{name: "k8s_cluster", idField: "_id", fields: []string{"kube_config"}},
+	{name: "registry_namespace", idField: "_id", fields: []string{"secret_key"}},
+	{name: "code_host", idField: "_id", fields: []string{"password", "client_secret", "ssh_key", "private_access_token", "access_token", "refresh_token"}},
+}
+
+// TransformFunc returns the new value of a credential, name identifies the credential in the secret store.
+type TransformFunc func(name, value string) (string, error)
+
+// Transform replaces every credential with the value returned by fn.
+func Transform(fn TransformFunc) error {
+	for _, c := range collections {
+		if err := c.transform(fn); err != nil {
+			return fmt.Errorf("failed to transform credentials in `%s`, err: %s", c.name, err)
+		}
+	}
+	return nil
+}
+
+func (c *collection) transform(fn TransformFunc) error {
+	coll := mongotool.Database(config.MongoDatabase()).Collection(c.name)
+	cursor, err := coll.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return err
+	}
+
+	var ms []mongo.WriteModel
+	for cursor.Next(context.TODO()) {
+		doc := bson.M{}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		id := idString(doc[c.idField])
+
+		change := bson.M{}
+		for _, field := range c.fields {
+			value, ok := doc[field].(string)
+			if !ok || value == "" {
+				continue
+			}
+			newValue, err := fn(secretstore.Name(c.name, id, field), value)
+			if err != nil {
+				return fmt.Errorf("%s of %s: %s", field, id, err)
+			}
+			if newValue != value {
+				change[field] = newValue
+			}
+		}
+		if len(change) == 0 {
+			continue
+		}
+		ms = append(ms,
+			mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": doc["_id"]}).
+				SetUpdate(bson.M{"$set": change}),
+		)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(ms) > 0 {
+		if _, err := coll.BulkWrite(context.TODO(), ms); err != nil {
+			return err
+		}
+	}
+	log.Infof("%d documents in `%s` are updated", len(ms), c.name)
+	return nil
+}
+
+func idString(id interface{}) string {
+	if oid, ok := id.(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(id)
+}
@@ -45,6 +45,45 @@ func Mode() string {
 	return mode
 }
 
+// SecretStoreBackend is the backend where the credentials are saved, local or vault.
+// Credentials are saved in the database as they are if it is empty.
+func SecretStoreBackend() string {
+	return viper.GetString(setting.ENVSecretStoreBackend)
+}
+
+func SecretStoreMasterKeyFile() string {
+	return viper.GetString(setting.ENVSecretStoreMasterKeyFile)
+}
+
+func SecretStoreMasterKeys() string {
+	return viper.GetString(setting.ENVSecretStoreMasterKeys)
+}
+
+func VaultAddress() string {
+	return viper.GetString(setting.ENVVaultAddress)
+}
+
+func VaultToken() string {
+	return viper.GetString(setting.ENVVaultToken)
+}
+
+func VaultMountPath() string {
+	return viper.GetString(setting.ENVVaultMountPath)
+}
+
+// TraceExporter is where the spans are exported to, otlp, file, stdout or empty to disable tracing.
+func TraceExporter() string {
+	return viper.GetString(setting.ENVTraceExporter)
+}
+
+func TraceOTLPEndpoint() string {
+	return viper.GetString(setting.ENVTraceOTLPEndpoint)
+}
+
+func TraceFile() string {
+	return viper.GetString(setting.ENVTraceFile)
+}
+
 func LogLevel() string {
 	return "debug"
 }
@@ -113,6 +113,12 @@ const (
 	StatusNotRun     Status = "notRun"
 	StatusPrepare    Status = "prepare"
 	StatusReject     Status = "reject"
+	// StatusApprovalTimeout means nobody approved the workflow v4 stage in time.
+	StatusApprovalTimeout Status = "approvalTimeout"
+	// StatusWaitingApprove is only used as a notify type of the workflow v4 for now.
+	StatusWaitingApprove Status = "waitforapprove"
+	// StatusPaused means the workflow v4 job is paused at a breakpoint.
+	StatusPaused Status = "paused"
 )
 
 type TaskStatus string
@@ -174,9 +180,13 @@ const (
 	JobBuild           JobType = "build"
 	JobDeploy          JobType = "deploy"
 	JobZadigBuild      JobType = "zadig-build"
+	JobZadigTest       JobType = "zadig-test"
+	JobZadigScanning   JobType = "zadig-scanning"
 	JobCustomDeploy    JobType = "custom-deploy"
+	JobCanaryDeploy    JobType = "canary-deploy"
 	JobZadigDeploy     JobType = "zadig-deploy"
 	JobZadigHelmDeploy JobType = "zadig-helm-deploy"
+	JobTriggerWorkflow JobType = "trigger-workflow"
 	JobFreestyle       JobType = "freestyle"
 	JobPlugin          JobType = "plugin"
 )
@@ -195,6 +205,43 @@ const (
 	SourceFromJob DeploySourceType = "fromjob"
 )
 
+// TaskPriority decides which waiting workflow v4 task runs first, the higher the earlier.
+type TaskPriority int
+
+const (
+	// e.g. the tasks of pull requests.
+	TaskPriorityLow    TaskPriority = 1
+	TaskPriorityNormal TaskPriority = 2
+	// e.g. the release tasks.
+	TaskPriorityHigh TaskPriority = 3
+	// e.g. the hotfix tasks.
+	TaskPriorityUrgent TaskPriority = 4
+)
+
+// ScheduleConcurrencyPolicy decides what to do when the task of the last run of a schedule is not done yet.
+type ScheduleConcurrencyPolicy string
+
+const (
+	ScheduleSkip  ScheduleConcurrencyPolicy = "skip"
+	ScheduleQueue ScheduleConcurrencyPolicy = "queue"
+)
+
+type DeployStrategy string
+
+const (
+	DeployStrategyCanary    DeployStrategy = "canary"
+	DeployStrategyBlueGreen DeployStrategy = "blue-green"
+)
+
+type TrafficRouter string
+
+const (
+	// weight the traffic by the replicas behind the k8s service.
+	TrafficRouterService TrafficRouter = "service"
+	// weight the traffic by the istio virtual service.
+	TrafficRouterIstio TrafficRouter = "istio"
+)
+
 type StageType string
 
 const (
@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ApprovalDelegation lets the delegate approve the workflow v4 stages on behalf of the delegator
+// between StartTime and EndTime, e.g. while the delegator is on leave.
+type ApprovalDelegation struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"    json:"id,omitempty"`
+	DelegatorID   string             `bson:"delegator_id"     json:"delegator_id"`
+	DelegatorName string             `bson:"delegator_name"   json:"delegator_name"`
+	DelegateID    string             `bson:"delegate_id"      json:"delegate_id"`
+	DelegateName  string             `bson:"delegate_name"    json:"delegate_name"`
+	StartTime     int64              `bson:"start_time"       json:"start_time"`
+	EndTime       int64              `bson:"end_time"         json:"end_time"`
+	CreatedBy     string             `bson:"created_by"       json:"created_by"`
+	CreateTime    int64              `bson:"create_time"      json:"create_time"`
+}
+
+func (ApprovalDelegation) TableName() string {
+	return "approval_delegation"
+}
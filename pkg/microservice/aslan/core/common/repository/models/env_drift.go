@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EnvDrift records a resource of an env service whose live object in the cluster differs from the one rendered
+// from the service template and the renderset. There is at most one unresolved drift for each resource.
+type EnvDrift struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"             json:"id,omitempty"`
+	ProductName string             `bson:"product_name"              json:"product_name"`
+	EnvName     string             `bson:"env_name"                  json:"env_name"`
+	ServiceName string             `bson:"service_name"              json:"service_name"`
+	Kind        string             `bson:"kind"                      json:"kind"`
+	Name        string             `bson:"name"                      json:"name"`
+	Namespace   string             `bson:"namespace"                 json:"namespace"`
+	// Type is modified if the live object differs from the rendered one, or missing if it's not found in the cluster.
+	Type  string           `bson:"type"                      json:"type"`
+	Diffs []*EnvDriftField `bson:"diffs"                     json:"diffs"`
+	// Expected and Live are the rendered manifest and the live object in yaml.
+	Expected string `bson:"expected"                  json:"expected,omitempty"`
+	Live     string `bson:"live"                      json:"live,omitempty"`
+	// DetectTime is when the drift is found first, UpdateTime is the last time it's found.
+	DetectTime int64 `bson:"detect_time"               json:"detect_time"`
+	UpdateTime int64 `bson:"update_time"               json:"update_time"`
+	Resolved   bool  `bson:"resolved"                  json:"resolved"`
+	// Resolution is reapply, adopt, or synced if the drift is gone by itself.
+	Resolution  string `bson:"resolution,omitempty"      json:"resolution,omitempty"`
+	ResolvedBy  string `bson:"resolved_by,omitempty"     json:"resolved_by,omitempty"`
+	ResolveTime int64  `bson:"resolve_time,omitempty"    json:"resolve_time,omitempty"`
+}
+
+// EnvDriftField is a field of the resource whose live value differs from the expected one,
+// Path is like spec.template.spec.containers[0].image.
+type EnvDriftField struct {
+	Path     string `bson:"path"                      json:"path"`
+	Expected string `bson:"expected"                  json:"expected"`
+	Live     string `bson:"live"                      json:"live"`
+}
+
+func (EnvDrift) TableName() string {
+	return "env_drift"
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EnvSnapshot records an environment right after a successful update, it's never changed once created.
+type EnvSnapshot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"             json:"id,omitempty"`
+	ProductName string             `bson:"product_name"              json:"product_name"`
+	EnvName     string             `bson:"env_name"                  json:"env_name"`
+	// Revision increases by one for every snapshot of the env.
+	Revision int64 `bson:"revision"                  json:"revision"`
+	// Source is what updated the env, env_update/helm_update/workflow/rollback.
+	Source     string               `bson:"source"                    json:"source"`
+	CreateBy   string               `bson:"create_by"                 json:"create_by"`
+	CreateTime int64                `bson:"create_time"               json:"create_time"`
+	Services   [][]*ProductService  `bson:"services"                  json:"services"`
+	Render     *RenderInfo          `bson:"render"                    json:"render"`
+	RenderSet  *RenderSet           `bson:"render_set"                json:"render_set"`
+	EnvConfigs []*EnvSnapshotConfig `bson:"env_configs"               json:"env_configs"`
+}
+
+// EnvSnapshotConfig is an env config (configmap, secret, ingress and pvc) in the snapshot.
+type EnvSnapshotConfig struct {
+	Name     string `bson:"name"                      json:"name"`
+	Type     string `bson:"type"                      json:"type"`
+	YamlData string `bson:"yaml_data"                 json:"yaml_data"`
+}
+
+func (EnvSnapshot) TableName() string {
+	return "env_snapshot"
+}
+
+func (s *EnvSnapshot) GetServiceMap() map[string]*ProductService {
+	resp := make(map[string]*ProductService)
+	for _, group := range s.Services {
+		for _, service := range group {
+			resp[service.ServiceName] = service
+		}
+	}
+	return resp
+}
@@ -71,6 +71,7 @@ type Queue struct {
 	Features                []string                     `bson:"features"                                   json:"features"`
 	IsRestart               bool                         `bson:"is_restart"                                 json:"is_restart"`
 	StorageEndpoint         string                       `bson:"storage_endpoint"                           json:"storage_endpoint"`
+	TraceContext            map[string]string            `bson:"trace_context,omitempty"                    json:"trace_context,omitempty"`
 }
 
 type TriggerBy struct {
@@ -24,6 +24,15 @@ type SystemSetting struct {
 	BuildConcurrency    int64              `bson:"build_concurrency" json:"build_concurrency"`
 	DefaultLogin        string             `bson:"default_login" json:"default_login"`
 	UpdateTime          int64              `bson:"update_time" json:"update_time"`
+	// max running workflow v4 tasks of each project, 0 means no limit.
+	DefaultProjectConcurrency int64                 `bson:"default_project_concurrency" json:"default_project_concurrency"`
+	ProjectConcurrency        []*ProjectConcurrency `bson:"project_concurrency"         json:"project_concurrency"`
+}
+
+// ProjectConcurrency overrides the default concurrency of a project.
+type ProjectConcurrency struct {
+	ProjectName string `bson:"project_name" json:"project_name"`
+	Concurrency int64  `bson:"concurrency"  json:"concurrency"`
 }
 
 func (SystemSetting) TableName() string {
@@ -25,6 +25,9 @@ const (
 	// 工作流任务的留存
 	WorkflowTaskRetention     CapacityTarget = "WorkflowTaskRetention"
 	DefaultWorkflowRemainDays int            = 365
+	// 环境快照的留存
+	EnvSnapshotRetention          CapacityTarget = "EnvSnapshotRetention"
+	DefaultEnvSnapshotRemainItems int            = 100
 )
 
 var DefaultWorkflowTaskRetention = &CapacityStrategy{
@@ -34,6 +37,14 @@ var DefaultWorkflowTaskRetention = &CapacityStrategy{
 	},
 }
 
+// DefaultEnvSnapshotRetention keeps the latest snapshots of every env.
+var DefaultEnvSnapshotRetention = &CapacityStrategy{
+	Target: EnvSnapshotRetention,
+	Retention: &RetentionConfig{
+		MaxItems: DefaultEnvSnapshotRemainItems,
+	},
+}
+
 // RetentionConfig 资源留存相关的配置
 type RetentionConfig struct {
 	MaxDays  int `bson:"max_days"      json:"max_days"`  // 最多几天
@@ -86,6 +86,8 @@ type Task struct {
 	Features         []string                     `bson:"features"               json:"features"`
 	IsRestart        bool                         `bson:"is_restart"             json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"       json:"storage_endpoint"`
+	// TraceContext is sent to warpdrive along with the task, it continues the trace of the task.
+	TraceContext map[string]string `bson:"trace_context,omitempty" json:"trace_context,omitempty"`
 }
 
 func (Task) TableName() string {
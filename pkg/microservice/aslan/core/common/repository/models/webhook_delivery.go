@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+)
+
+// WebhookDelivery records a webhook request received from a code host and what it triggered.
+type WebhookDelivery struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"             json:"id,omitempty"`
+	// Provider is the code host which sent the webhook, github/gitlab/gitee/gerrit/codehub.
+	Provider   string `bson:"provider"                  json:"provider"`
+	Event      string `bson:"event"                     json:"event"`
+	DeliveryID string `bson:"delivery_id,omitempty"     json:"delivery_id,omitempty"`
+	RequestID  string `bson:"request_id"                json:"request_id"`
+	// URI is the request uri of the webhook, gerrit hooks carry the code host in it.
+	URI string `bson:"uri"                       json:"-"`
+	// Repo is the full name of the repository, such as owner/name.
+	Repo string `bson:"repo"                      json:"repo"`
+	Ref  string `bson:"ref"                       json:"ref"`
+	// HookEvent and Branch are what the hooks are matched against, Branch is the target branch of pull requests.
+	HookEvent config.HookEventType `bson:"hook_event,omitempty"      json:"hook_event,omitempty"`
+	Branch    string               `bson:"branch,omitempty"          json:"branch,omitempty"`
+	// Signature is the result of verifying the signature or token of the request.
+	Signature string `bson:"signature"                 json:"signature"`
+	// Headers and Payload are kept to replay the delivery.
+	Headers    map[string]string       `bson:"headers"                   json:"-"`
+	Payload    string                  `bson:"payload"                   json:"payload,omitempty"`
+	Matches    []*WebhookDeliveryMatch `bson:"matches"                   json:"matches"`
+	Error      string                  `bson:"error,omitempty"           json:"error,omitempty"`
+	Duration   int64                   `bson:"duration"                  json:"duration"`
+	CreateTime int64                   `bson:"create_time"               json:"create_time"`
+	// CreatedAt is CreateTime as a date, deliveries expire from it.
+	CreatedAt time.Time `bson:"created_at"                json:"-"`
+	// ReplayOf is the id of the delivery which is replayed by this one.
+	ReplayOf string `bson:"replay_of,omitempty"       json:"replay_of,omitempty"`
+	Replayer string `bson:"replayer,omitempty"        json:"replayer,omitempty"`
+}
+
+// WebhookDeliveryMatch is a hook of a workflow, test or scanning on the repository of the delivery.
+type WebhookDeliveryMatch struct {
+	// Type is workflow, workflow_v4, test or scanning.
+	Type        string `bson:"type"                      json:"type"`
+	Name        string `bson:"name"                      json:"name"`
+	ProjectName string `bson:"project_name"              json:"project_name"`
+	HookName    string `bson:"hook_name,omitempty"       json:"hook_name,omitempty"`
+	Matched     bool   `bson:"matched"                   json:"matched"`
+	SkipReason  string `bson:"skip_reason,omitempty"     json:"skip_reason,omitempty"`
+	TaskID      int64  `bson:"task_id,omitempty"         json:"task_id,omitempty"`
+	// CancelledTasks are the tasks of the same pull request which are cancelled by auto cancel.
+	CancelledTasks []int64 `bson:"cancelled_tasks,omitempty" json:"cancelled_tasks,omitempty"`
+	Error          string  `bson:"error,omitempty"           json:"error,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
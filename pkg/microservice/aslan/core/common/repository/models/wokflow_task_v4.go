@@ -33,6 +33,7 @@ type WorkflowTask struct {
 	GlobalContext      map[string]string  `bson:"global_context"            json:"global_context"`
 	Status             config.Status      `bson:"status"                    json:"status,omitempty"`
 	TaskCreator        string             `bson:"task_creator"              json:"task_creator,omitempty"`
+	TaskCreatorID      string             `bson:"task_creator_id"           json:"task_creator_id,omitempty"`
 	TaskRevoker        string             `bson:"task_revoker,omitempty"    json:"task_revoker,omitempty"`
 	CreateTime         int64              `bson:"create_time"               json:"create_time,omitempty"`
 	StartTime          int64              `bson:"start_time"                json:"start_time,omitempty"`
@@ -44,6 +45,27 @@ type WorkflowTask struct {
 	Error              string             `bson:"error,omitempty"           json:"error,omitempty"`
 	IsRestart          bool               `bson:"is_restart"                json:"is_restart"`
 	MultiRun           bool               `bson:"multi_run"                 json:"multi_run"`
+	// set when the task is triggered by a trigger-workflow job of another task.
+	ParentTask *WorkflowTaskLink `bson:"parent_task,omitempty"     json:"parent_task,omitempty"`
+	// name of the schedule which created the task.
+	ScheduleName string              `bson:"schedule_name,omitempty"   json:"schedule_name,omitempty"`
+	Priority     config.TaskPriority `bson:"priority"                  json:"priority"`
+	// max running tasks of the workflow, copied from the workflow like multi run.
+	Concurrency int64 `bson:"concurrency"               json:"concurrency"`
+	// trace context of the request which created the task, such as a webhook.
+	TraceContext map[string]string `bson:"trace_context,omitempty"   json:"-"`
+	// the aslan replica running the task, other replicas take the task over only after its lease expires.
+	Owner string `bson:"owner"                     json:"-"`
+	// unix time the lease of the owner expires at, the owner renews it while the task is running.
+	LeaseExpireTime int64 `bson:"lease_expire_time"         json:"-"`
+}
+
+// WorkflowTaskLink points to the job of a workflow task.
+type WorkflowTaskLink struct {
+	ProjectName  string `bson:"project_name"              json:"project_name"`
+	WorkflowName string `bson:"workflow_name"             json:"workflow_name"`
+	TaskID       int64  `bson:"task_id"                   json:"task_id"`
+	JobName      string `bson:"job_name,omitempty"        json:"job_name,omitempty"`
 }
 
 func (WorkflowTask) TableName() string {
@@ -72,6 +94,65 @@ type JobTask struct {
 	Retry     int64         `bson:"retry"               json:"retry"`
 	Spec      interface{}   `bson:"spec"                json:"spec"`
 	Outputs   []*Output     `bson:"outputs"             json:"outputs"`
+	// unit is second, the wait time doubles after every failed attempt.
+	RetryBackoff int64         `bson:"retry_backoff"       json:"retry_backoff"`
+	Attempts     []*JobAttempt `bson:"attempts"            json:"attempts"`
+	// name of the workflow job which generates this job task.
+	OriginName string `bson:"origin_name"         json:"origin_name"`
+	// origin names of the upstream jobs, only set when the workflow jobs run as a DAG.
+	DependsOn []string `bson:"depends_on"          json:"depends_on"`
+	Condition string   `bson:"if"                  json:"if"`
+	// why the job was skipped, e.g. its condition is false.
+	SkipReason string `bson:"skip_reason"         json:"skip_reason"`
+	// collected by the junit_report and html_report steps.
+	TestReport *JobTestReport `bson:"test_report,omitempty" json:"test_report,omitempty"`
+	// where the job is paused, nil if the job is not paused at a breakpoint.
+	Breakpoint *JobBreakpoint `bson:"breakpoint,omitempty"  json:"breakpoint,omitempty"`
+}
+
+// JobBreakpoint is where the job is paused, the job pod can be attached with the pod terminal by its cluster, namespace, pod and container.
+type JobBreakpoint struct {
+	Position      string `bson:"position"          json:"position"`
+	StepName      string `bson:"step_name"         json:"step_name"`
+	PausedAt      int64  `bson:"paused_at"         json:"paused_at"`
+	ClusterID     string `bson:"cluster_id"        json:"cluster_id"`
+	Namespace     string `bson:"namespace"         json:"namespace"`
+	PodName       string `bson:"pod_name"          json:"pod_name"`
+	ContainerName string `bson:"container_name"    json:"container_name"`
+}
+
+// JobTestReport is the summary of the test reports of a job.
+type JobTestReport struct {
+	Tests       int               `bson:"tests"               json:"tests"`
+	Successes   int               `bson:"successes"           json:"successes"`
+	Failures    int               `bson:"failures"            json:"failures"`
+	Errors      int               `bson:"errors"              json:"errors"`
+	Skips       int               `bson:"skips"               json:"skips"`
+	Time        float64           `bson:"time"                json:"time"`
+	FailedCases []*FailedTestCase `bson:"failed_cases"        json:"failed_cases"`
+	// object keys of the reports in the default s3 storage.
+	JunitReport string `bson:"junit_report"        json:"junit_report"`
+	HtmlReport  string `bson:"html_report"         json:"html_report"`
+}
+
+type FailedTestCase struct {
+	Name      string `bson:"name"                json:"name"`
+	ClassName string `bson:"classname"           json:"classname"`
+	// failure or error
+	Type    string `bson:"type"                json:"type"`
+	Message string `bson:"message"             json:"message"`
+	Text    string `bson:"text"                json:"text"`
+}
+
+// JobAttempt records one execution of a job, a job with retry may run several times.
+type JobAttempt struct {
+	Attempt   int           `bson:"attempt"             json:"attempt"`
+	Status    config.Status `bson:"status"              json:"status"`
+	Error     string        `bson:"error"               json:"error"`
+	StartTime int64         `bson:"start_time"          json:"start_time,omitempty"`
+	EndTime   int64         `bson:"end_time"            json:"end_time,omitempty"`
+	// name of the log file saved in s3, can be used to get the log of this attempt.
+	LogFile string `bson:"log_file"            json:"log_file"`
 }
 
 type JobTaskCustomDeploySpec struct {
@@ -86,6 +167,36 @@ type JobTaskCustomDeploySpec struct {
 	ReplaceResources   []Resource `bson:"replace_resources"      json:"replace_resources"     yaml:"replace_resources"`
 }
 
+type JobTaskCanaryDeploySpec struct {
+	Namespace      string                `bson:"namespace"              json:"namespace"             yaml:"namespace"`
+	ClusterID      string                `bson:"cluster_id"             json:"cluster_id"            yaml:"cluster_id"`
+	Timeout        int64                 `bson:"timeout"                json:"timeout"               yaml:"timeout"`
+	WorkloadName   string                `bson:"workload_name"          json:"workload_name"         yaml:"workload_name"`
+	ContainerName  string                `bson:"container_name"         json:"container_name"        yaml:"container_name"`
+	K8sServiceName string                `bson:"k8s_service_name"       json:"k8s_service_name"      yaml:"k8s_service_name"`
+	Image          string                `bson:"image"                  json:"image"                 yaml:"image"`
+	Strategy       config.DeployStrategy `bson:"strategy"               json:"strategy"              yaml:"strategy"`
+	TrafficRouter  config.TrafficRouter  `bson:"traffic_router"         json:"traffic_router"        yaml:"traffic_router"`
+	Steps          []*TrafficStep        `bson:"steps"                  json:"steps"                 yaml:"steps"`
+	HealthCheck    *DeployHealthCheck    `bson:"health_check"           json:"health_check"          yaml:"health_check"`
+	// filled by the job, the image before the deploy and the current traffic weight of the new version.
+	OriginImage   string `bson:"origin_image"           json:"origin_image"          yaml:"origin_image"`
+	CurrentWeight int    `bson:"current_weight"         json:"current_weight"        yaml:"current_weight"`
+	RolledBack    bool   `bson:"rolled_back"            json:"rolled_back"           yaml:"rolled_back"`
+}
+
+type JobTaskTriggerWorkflowSpec struct {
+	WorkflowName string    `bson:"workflow_name"          json:"workflow_name"         yaml:"workflow_name"`
+	ProjectName  string    `bson:"project_name"           json:"project_name"          yaml:"project_name"`
+	Params       []*Param  `bson:"params"                 json:"params"                yaml:"params"`
+	KeyVals      []*KeyVal `bson:"key_vals"               json:"key_vals"              yaml:"key_vals"`
+	Wait         bool      `bson:"wait"                   json:"wait"                  yaml:"wait"`
+	Timeout      int64     `bson:"timeout"                json:"timeout"               yaml:"timeout"`
+	// filled by the job, the task it triggered.
+	TaskID     int64         `bson:"task_id"                json:"task_id"               yaml:"task_id"`
+	TaskStatus config.Status `bson:"task_status"            json:"task_status"           yaml:"task_status"`
+}
+
 type JobTaskDeploySpec struct {
 	Env                string     `bson:"env"                              json:"env"                                 yaml:"env"`
 	ServiceName        string     `bson:"service_name"                     json:"service_name"                        yaml:"service_name"`
@@ -127,6 +238,23 @@ type JobTaskBuildSpec struct {
 	Steps      []*StepTask   `bson:"steps"               json:"steps"             yaml:"steps"`
 }
 
+type JobTaskTestingSpec struct {
+	Properties JobProperties `bson:"properties"          json:"properties"        yaml:"properties"`
+	Steps      []*StepTask   `bson:"steps"               json:"steps"             yaml:"steps"`
+	// name of the testing module, the results are counted in its statistics.
+	TestName string `bson:"test_name"           json:"test_name"         yaml:"test_name"`
+	// the job fails if the percent of the failed test cases is more than it.
+	Threshold int `bson:"threshold"           json:"threshold"         yaml:"threshold"`
+}
+
+type JobTaskScanningSpec struct {
+	Properties   JobProperties `bson:"properties"          json:"properties"        yaml:"properties"`
+	Steps        []*StepTask   `bson:"steps"               json:"steps"             yaml:"steps"`
+	ScanningName string        `bson:"scanning_name"       json:"scanning_name"     yaml:"scanning_name"`
+	// the quality gate of the analysis is checked if the sonar id is set.
+	SonarID string `bson:"sonar_id"            json:"sonar_id"          yaml:"sonar_id"`
+}
+
 type JobTaskPluginSpec struct {
 	Properties JobProperties   `bson:"properties"          json:"properties"        yaml:"properties"`
 	Plugin     *PluginTemplate `bson:"plugin"              json:"plugin"            yaml:"plugin"`
@@ -141,6 +269,9 @@ type StepTask struct {
 	Spec interface{} `bson:"spec"           json:"spec"   yaml:"spec"`
 	// step output results,like testing results,differ form steps
 	Result interface{} `bson:"result"         json:"result"  yaml:"result"`
+	// pause the job executor before or after running the step.
+	BreakpointBefore bool `bson:"breakpoint_before"  json:"breakpoint_before"  yaml:"breakpoint_before"`
+	BreakpointAfter  bool `bson:"breakpoint_after"   json:"breakpoint_after"   yaml:"breakpoint_after"`
 }
 
 type WorkflowTaskCtx struct {
@@ -153,6 +284,10 @@ type WorkflowTaskCtx struct {
 	DockerMountDir    string
 	ConfigMapMountDir string
 	WorkflowKeyVals   []*KeyVal
+	WorkflowParams    []*Param
+	TaskCreator       string
+	// info of the webhook event which triggered the task, nil if the task was created manually.
+	HookPayload       *HookPayload
 	GlobalContextGet  func(key string) (string, bool)
 	GlobalContextSet  func(key, value string)
 	GlobalContextEach func(f func(k, v string) bool)
@@ -196,6 +196,8 @@ type WorkflowTaskArgs struct {
 
 	Callback      *CallbackArgs   `bson:"callback"                    json:"callback"`
 	ReleaseImages []*ReleaseImage `bson:"release_images,omitempty"    json:"release_images,omitempty"`
+	// trace context of the request which creates the task, it is saved in the task instead.
+	TraceContext map[string]string `bson:"-"                           json:"-"`
 }
 
 type ScanningArgs struct {
@@ -364,6 +366,9 @@ type HookPayload struct {
 	CommitID       string `bson:"commit_id"        json:"commit_id,omitempty"`
 	DeliveryID     string `bson:"delivery_id"      json:"delivery_id,omitempty"`
 	CodehostID     int    `bson:"codehost_id"      json:"codehost_id"`
+	// used by the job conditions of workflow v4
+	EventType    string   `bson:"event_type"       json:"event_type,omitempty"`
+	ChangedFiles []string `bson:"changed_files"    json:"changed_files,omitempty"`
 }
 
 type TargetArgs struct {
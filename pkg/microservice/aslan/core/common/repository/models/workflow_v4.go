@@ -28,21 +28,64 @@ import (
 )
 
 type WorkflowV4 struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty"       yaml:"-"            json:"id"`
-	Name           string             `bson:"name"                yaml:"name"         json:"name"`
-	KeyVals        []*KeyVal          `bson:"key_vals"            yaml:"key_vals"     json:"key_vals"`
-	Params         []*Param           `bson:"params"              yaml:"params"       json:"params"`
-	Stages         []*WorkflowStage   `bson:"stages"              yaml:"stages"       json:"stages"`
-	Project        string             `bson:"project"             yaml:"project"      json:"project"`
-	Description    string             `bson:"description"         yaml:"description"  json:"description"`
-	CreatedBy      string             `bson:"created_by"          yaml:"created_by"   json:"created_by"`
-	CreateTime     int64              `bson:"create_time"         yaml:"create_time"  json:"create_time"`
-	UpdatedBy      string             `bson:"updated_by"          yaml:"updated_by"   json:"updated_by"`
-	UpdateTime     int64              `bson:"update_time"         yaml:"update_time"  json:"update_time"`
-	MultiRun       bool               `bson:"multi_run"           yaml:"multi_run"    json:"multi_run"`
-	HookCtls       []*WorkflowV4Hook  `bson:"hook_ctl"            yaml:"-"            json:"hook_ctl"`
-	NotificationID string             `bson:"notification_id"     yaml:"-"            json:"notification_id"`
-	HookPayload    *HookPayload       `bson:"hook_payload"        yaml:"-"            json:"hook_payload,omitempty"`
+	ID             primitive.ObjectID    `bson:"_id,omitempty"       yaml:"-"            json:"id"`
+	Name           string                `bson:"name"                yaml:"name"         json:"name"`
+	KeyVals        []*KeyVal             `bson:"key_vals"            yaml:"key_vals"     json:"key_vals"`
+	Params         []*Param              `bson:"params"              yaml:"params"       json:"params"`
+	Stages         []*WorkflowStage      `bson:"stages"              yaml:"stages"       json:"stages"`
+	Project        string                `bson:"project"             yaml:"project"      json:"project"`
+	Description    string                `bson:"description"         yaml:"description"  json:"description"`
+	CreatedBy      string                `bson:"created_by"          yaml:"created_by"   json:"created_by"`
+	CreateTime     int64                 `bson:"create_time"         yaml:"create_time"  json:"create_time"`
+	UpdatedBy      string                `bson:"updated_by"          yaml:"updated_by"   json:"updated_by"`
+	UpdateTime     int64                 `bson:"update_time"         yaml:"update_time"  json:"update_time"`
+	MultiRun       bool                  `bson:"multi_run"           yaml:"multi_run"    json:"multi_run"`
+	HookCtls       []*WorkflowV4Hook     `bson:"hook_ctl"            yaml:"-"            json:"hook_ctl"`
+	NotificationID string                `bson:"notification_id"     yaml:"-"            json:"notification_id"`
+	HookPayload    *HookPayload          `bson:"hook_payload"        yaml:"-"            json:"hook_payload,omitempty"`
+	NotifyCtls     []*NotifyCtlV4        `bson:"notify_ctls"         yaml:"notify_ctls"  json:"notify_ctls"`
+	Schedules      []*WorkflowV4Schedule `bson:"schedules"           yaml:"schedules"    json:"schedules"`
+	// priority of the tasks in the queue, normal by default.
+	Priority config.TaskPriority `bson:"priority"            yaml:"priority"     json:"priority"`
+	// max running tasks of the workflow, 0 means no limit, only works when multi run is enabled.
+	Concurrency int64 `bson:"concurrency"         yaml:"concurrency"  json:"concurrency"`
+}
+
+// WorkflowV4Schedule runs the workflow periodically with fixed params and keyvals.
+type WorkflowV4Schedule struct {
+	Name    string `bson:"name"                   yaml:"name"                   json:"name"`
+	Enabled bool   `bson:"enabled"                yaml:"enabled"                json:"enabled"`
+	// standard cron expression with 5 fields, e.g. "0 2 * * 1-5".
+	Cron string `bson:"cron"                   yaml:"cron"                   json:"cron"`
+	// IANA time zone like Asia/Shanghai, empty means the time zone of the cron service.
+	Timezone string    `bson:"timezone"               yaml:"timezone"               json:"timezone"`
+	Params   []*Param  `bson:"params"                 yaml:"params"                 json:"params"`
+	KeyVals  []*KeyVal `bson:"key_vals"               yaml:"key_vals"               json:"key_vals"`
+	// skip or queue the run when the task of the last run is not done yet, default is skip.
+	ConcurrencyPolicy config.ScheduleConcurrencyPolicy `bson:"concurrency_policy"     yaml:"concurrency_policy"     json:"concurrency_policy"`
+}
+
+// NotifyCtlV4 decides where and on which events the notifications of the workflow tasks are sent.
+type NotifyCtlV4 struct {
+	Enabled         bool     `bson:"enabled"                    yaml:"enabled"                    json:"enabled"`
+	WebHookType     string   `bson:"webhook_type"               yaml:"webhook_type"               json:"webhook_type"`
+	DingDingWebHook string   `bson:"dingding_webhook,omitempty" yaml:"dingding_webhook,omitempty" json:"dingding_webhook,omitempty"`
+	FeiShuWebHook   string   `bson:"feishu_webhook,omitempty"   yaml:"feishu_webhook,omitempty"   json:"feishu_webhook,omitempty"`
+	WeChatWebHook   string   `bson:"weChat_webHook,omitempty"   yaml:"weChat_webHook,omitempty"   json:"weChat_webHook,omitempty"`
+	AtMobiles       []string `bson:"at_mobiles,omitempty"       yaml:"at_mobiles,omitempty"       json:"at_mobiles,omitempty"`
+	IsAtAll         bool     `bson:"is_at_all,omitempty"        yaml:"is_at_all,omitempty"        json:"is_at_all,omitempty"`
+	// receivers of the mail notifications, approvers get the mail of the approval anyway.
+	MailUsers     []*User        `bson:"mail_users,omitempty"       yaml:"mail_users,omitempty"       json:"mail_users,omitempty"`
+	WebHookNotify *WebhookNotify `bson:"webhook_notify,omitempty"   yaml:"webhook_notify,omitempty"   json:"webhook_notify,omitempty"`
+	// events to notify: running, passed, failed, timeout, cancelled, reject and waitforapprove.
+	NotifyTypes []string `bson:"notify_type"                yaml:"notify_type"                json:"notify_type"`
+}
+
+// WebhookNotify is a generic webhook receiving the task events in json,
+// the body is signed with the secret in the X-Zadig-Signature header.
+type WebhookNotify struct {
+	Address string `bson:"address"          yaml:"address"          json:"address"`
+	Secret  string `bson:"secret,omitempty" yaml:"secret,omitempty" json:"secret,omitempty"`
 }
 
 type WorkflowStage struct {
@@ -59,6 +102,27 @@ type Approval struct {
 	NeededApprovers int                    `bson:"needed_approvers"            yaml:"needed_approvers"           json:"needed_approvers"`
 	Description     string                 `bson:"description"                 yaml:"description"                json:"description"`
 	RejectOrApprove config.ApproveOrReject `bson:"reject_or_approve"           yaml:"-"                          json:"reject_or_approve"`
+	// members of the groups are added to the approve users when the stage starts to wait for approval.
+	ApproveGroups []*ApproveGroup `bson:"approve_groups"              yaml:"approve_groups"             json:"approve_groups"`
+	// the approval is skipped once any of the rules matches.
+	AutoApproveRules []*AutoApproveRule `bson:"auto_approve_rules"          yaml:"auto_approve_rules"         json:"auto_approve_rules"`
+	// name of the auto approve rule which skipped the approval.
+	AutoApprovedBy string `bson:"auto_approved_by"            yaml:"-"                          json:"auto_approved_by"`
+}
+
+type ApproveGroup struct {
+	GroupID   string `bson:"group_id"                    yaml:"group_id"                   json:"group_id"`
+	GroupName string `bson:"group_name"                  yaml:"group_name"                 json:"group_name"`
+}
+
+// AutoApproveRule matches when all of its conditions which are set match, e.g. hotfix branches triggered by a release manager.
+type AutoApproveRule struct {
+	Name string `bson:"name"                        yaml:"name"                       json:"name"`
+	// regular expression every branch built by the task should match, e.g. ^hotfix/.
+	BranchRegex string `bson:"branch_regex"                yaml:"branch_regex"               json:"branch_regex"`
+	// the task creator should be one of the users or a member of the groups.
+	Creators      []*User         `bson:"creators"                    yaml:"creators"                   json:"creators"`
+	CreatorGroups []*ApproveGroup `bson:"creator_groups"              yaml:"creator_groups"             json:"creator_groups"`
 }
 
 type User struct {
@@ -67,6 +131,13 @@ type User struct {
 	RejectOrApprove config.ApproveOrReject `bson:"reject_or_approve"           yaml:"-"                          json:"reject_or_approve"`
 	Comment         string                 `bson:"comment"                     yaml:"-"                          json:"comment"`
 	OperationTime   int64                  `bson:"operation_time"              yaml:"-"                          json:"operation_time"`
+	// the approve group the user comes from.
+	GroupName string `bson:"group_name,omitempty"        yaml:"-"                          json:"group_name,omitempty"`
+	// the user approving on behalf of this approver by a delegation.
+	DelegateUserID   string `bson:"delegate_user_id,omitempty"  yaml:"-"                          json:"delegate_user_id,omitempty"`
+	DelegateUserName string `bson:"delegate_user_name,omitempty" yaml:"-"                         json:"delegate_user_name,omitempty"`
+	// who made the decision, the approver or the delegate.
+	Operator string `bson:"operator,omitempty"          yaml:"-"                          json:"operator,omitempty"`
 }
 
 type Job struct {
@@ -75,6 +146,12 @@ type Job struct {
 	// only for webhook workflow args to skip some tasks.
 	Skipped bool        `bson:"skipped"        yaml:"skipped"  json:"skipped"`
 	Spec    interface{} `bson:"spec"           yaml:"spec"     json:"spec"`
+	// names of the jobs this job depends on, can be jobs in the same stage or earlier stages.
+	// once any job in a workflow declares it, jobs run as a DAG instead of stage by stage.
+	DependsOn []string `bson:"depends_on"     yaml:"depends_on,omitempty"  json:"depends_on"`
+	// the job only runs when the condition is true, it is skipped otherwise.
+	// see pkg/util/condition for the syntax.
+	Condition string `bson:"if"             yaml:"if,omitempty"          json:"if"`
 }
 
 type CustomDeployJobSpec struct {
@@ -95,6 +172,57 @@ type DeployTargets struct {
 	Image  string `bson:"image,omitempty"  json:"image,omitempty"   yaml:"image,omitempty"`
 }
 
+type CanaryDeployJobSpec struct {
+	Namespace     string                `bson:"namespace"              json:"namespace"             yaml:"namespace"`
+	ClusterID     string                `bson:"cluster_id"             json:"cluster_id"            yaml:"cluster_id"`
+	Strategy      config.DeployStrategy `bson:"strategy"               json:"strategy"              yaml:"strategy"`
+	TrafficRouter config.TrafficRouter  `bson:"traffic_router"         json:"traffic_router"        yaml:"traffic_router"`
+	// traffic percents of the new version step by step, blue-green deploys switch all the traffic at once.
+	Steps       []*TrafficStep     `bson:"steps"                  json:"steps"                 yaml:"steps"`
+	HealthCheck *DeployHealthCheck `bson:"health_check"           json:"health_check"          yaml:"health_check"`
+	// unit is minute.
+	Timeout int64                 `bson:"timeout"                json:"timeout"               yaml:"timeout"`
+	Targets []*CanaryDeployTarget `bson:"targets"                json:"targets"               yaml:"targets"`
+}
+
+type CanaryDeployTarget struct {
+	// deployment_name/container_name.
+	Target string `bson:"target"           json:"target"            yaml:"target"`
+	// the k8s service of the deployment, traffic is shifted through it.
+	K8sServiceName string `bson:"k8s_service_name" json:"k8s_service_name"  yaml:"k8s_service_name"`
+	Image          string `bson:"image,omitempty"  json:"image,omitempty"   yaml:"image,omitempty"`
+}
+
+type TrafficStep struct {
+	Weight int `bson:"weight"           json:"weight"            yaml:"weight"`
+	// seconds to watch the new version before the next step.
+	Pause int64 `bson:"pause"            json:"pause"             yaml:"pause"`
+}
+
+type DeployHealthCheck struct {
+	// the http check passes if the url responds with the expected code.
+	URL          string `bson:"url"                json:"url"                yaml:"url"`
+	ExpectedCode int    `bson:"expected_code"      json:"expected_code"      yaml:"expected_code"`
+	// the metric check passes if the result of the prometheus query is not more than the threshold.
+	PrometheusURL   string  `bson:"prometheus_url"     json:"prometheus_url"     yaml:"prometheus_url"`
+	MetricQuery     string  `bson:"metric_query"       json:"metric_query"       yaml:"metric_query"`
+	MetricThreshold float64 `bson:"metric_threshold"   json:"metric_threshold"   yaml:"metric_threshold"`
+}
+
+type TriggerWorkflowJobSpec struct {
+	WorkflowName string `bson:"workflow_name"          json:"workflow_name"         yaml:"workflow_name"`
+	// the project of the target workflow, empty means the project of the current workflow. The creator of the task
+	// must be allowed to run the target workflow if it is in another project.
+	ProjectName string `bson:"project_name"           json:"project_name"          yaml:"project_name"`
+	// override the params and keyvals of the target workflow by name, values can quote the global variables like {{.workflow.job.output}}.
+	Params  []*Param  `bson:"params"                 json:"params"                yaml:"params"`
+	KeyVals []*KeyVal `bson:"key_vals"               json:"key_vals"              yaml:"key_vals"`
+	// wait for the triggered task to finish, the job passes once the task is created otherwise.
+	Wait bool `bson:"wait"                   json:"wait"                  yaml:"wait"`
+	// unit is minute, only works when wait is true.
+	Timeout int64 `bson:"timeout"                json:"timeout"               yaml:"timeout"`
+}
+
 type PluginJobSpec struct {
 	Properties *JobProperties  `bson:"properties"               yaml:"properties"              json:"properties"`
 	Plugin     *PluginTemplate `bson:"plugin"                   yaml:"plugin"                  json:"plugin"`
@@ -121,6 +249,29 @@ type ServiceAndBuild struct {
 	Repos         []*types.Repository `bson:"repos"               yaml:"repos"            json:"repos"`
 }
 
+type ZadigTestJobSpec struct {
+	TestModules []*TestModule `bson:"test_modules"     yaml:"test_modules"     json:"test_modules"`
+}
+
+type TestModule struct {
+	Name        string              `bson:"name"                yaml:"name"             json:"name"`
+	ProjectName string              `bson:"project_name"        yaml:"project_name"     json:"project_name"`
+	KeyVals     []*KeyVal           `bson:"key_vals"            yaml:"key_vals"         json:"key_vals"`
+	Repos       []*types.Repository `bson:"repos"               yaml:"repos"            json:"repos"`
+}
+
+type ZadigScanningJobSpec struct {
+	Scannings []*ScanningModule `bson:"scannings"        yaml:"scannings"        json:"scannings"`
+	// the zadig-build job whose repos are scanned, use the repos of the scannings if empty.
+	JobName string `bson:"job_name"         yaml:"job_name"         json:"job_name"`
+}
+
+type ScanningModule struct {
+	Name        string              `bson:"name"                yaml:"name"             json:"name"`
+	ProjectName string              `bson:"project_name"        yaml:"project_name"     json:"project_name"`
+	Repos       []*types.Repository `bson:"repos"               yaml:"repos"            json:"repos"`
+}
+
 type ZadigDeployJobSpec struct {
 	Env                string `bson:"env"                      yaml:"env"                         json:"env"`
 	DeployType         string `bson:"deploy_type"              yaml:"-"                           json:"deploy_type"`
@@ -141,6 +292,7 @@ type ServiceAndImage struct {
 type JobProperties struct {
 	Timeout         int64               `bson:"timeout"                json:"timeout"               yaml:"timeout"`
 	Retry           int64               `bson:"retry"                  json:"retry"                 yaml:"retry"`
+	RetryBackoff    int64               `bson:"retry_backoff"          json:"retry_backoff"         yaml:"retry_backoff"`
 	ResourceRequest setting.Request     `bson:"res_req"                json:"res_req"               yaml:"res_req"`
 	ResReqSpec      setting.RequestSpec `bson:"res_req_spec"           json:"res_req_spec"          yaml:"res_req_spec"`
 	ClusterID       string              `bson:"cluster_id"             json:"cluster_id"            yaml:"cluster_id"`
@@ -160,6 +312,8 @@ type JobProperties struct {
 	CacheEnable  bool                 `bson:"cache_enable"           json:"cache_enable"          yaml:"cache_enable"`
 	CacheDirType types.CacheDirType   `bson:"cache_dir_type"         json:"cache_dir_type"        yaml:"cache_dir_type"`
 	CacheUserDir string               `bson:"cache_user_dir"         json:"cache_user_dir"        yaml:"cache_user_dir"`
+	// pause the job after it failed, so the job pod can be debugged.
+	KeepPodOnFailure bool `bson:"keep_pod_on_failure"    json:"keep_pod_on_failure"   yaml:"keep_pod_on_failure,omitempty"`
 }
 
 type Step struct {
@@ -167,11 +321,16 @@ type Step struct {
 	Timeout  int64           `bson:"timeout"        json:"timeout"          yaml:"timeout"`
 	StepType config.StepType `bson:"type"           json:"type"             yaml:"type"`
 	Spec     interface{}     `bson:"spec"           json:"spec"             yaml:"spec"`
+	// pause the job before or after running the step, so the job pod can be debugged.
+	BreakpointBefore bool `bson:"breakpoint_before"  json:"breakpoint_before"  yaml:"breakpoint_before,omitempty"`
+	BreakpointAfter  bool `bson:"breakpoint_after"   json:"breakpoint_after"   yaml:"breakpoint_after,omitempty"`
 }
 
 type Output struct {
 	Name        string `bson:"name"           json:"name"             yaml:"name"`
 	Description string `bson:"description"    json:"description"      yaml:"description"`
+	// only set in job tasks once the job finished.
+	Value string `bson:"value,omitempty"      json:"value,omitempty"  yaml:"-"`
 }
 
 type WorkflowV4Hook struct {
@@ -183,6 +342,12 @@ type WorkflowV4Hook struct {
 	Description         string              `bson:"description,omitempty"     json:"description,omitempty"`
 	Repos               []*types.Repository `bson:"-"                         json:"repos,omitempty"`
 	WorkflowArg         *WorkflowV4         `bson:"workflow_arg"              json:"workflow_arg"`
+	// IsYaml runs the workflow defined in the yaml file of the main repo at the commit of the event instead of the stored one.
+	IsYaml bool `bson:"is_yaml"                   json:"is_yaml"`
+	// YamlPath is the path of the yaml file in the main repo, .zadig/workflows/<workflow name>.yaml by default.
+	YamlPath string `bson:"yaml_path,omitempty"       json:"yaml_path,omitempty"`
+	// SyncYaml keeps the stored workflow in line with the yaml file on the default branch of the main repo.
+	SyncYaml bool `bson:"sync_yaml"                 json:"sync_yaml"`
 }
 
 type Param struct {
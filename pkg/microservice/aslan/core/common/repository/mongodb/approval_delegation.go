@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ApprovalDelegationColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+type ListApprovalDelegationOption struct {
+	DelegatorIDs []string
+	DelegateID   string
+	// only the delegations active at the time, unix seconds.
+	ActiveAt int64
+}
+
+func NewApprovalDelegationColl() *ApprovalDelegationColl {
+	name := models.ApprovalDelegation{}.TableName()
+	return &ApprovalDelegationColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *ApprovalDelegationColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ApprovalDelegationColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "delegator_id", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys:    bson.D{bson.E{Key: "delegate_id", Value: 1}},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *ApprovalDelegationColl) Create(args *models.ApprovalDelegation) error {
+	if args == nil {
+		return errors.New("nil ApprovalDelegation")
+	}
+	args.CreateTime = time.Now().Unix()
+
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+func (c *ApprovalDelegationColl) Find(id string) (*models.ApprovalDelegation, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.ApprovalDelegation{}
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *ApprovalDelegationColl) List(opt *ListApprovalDelegationOption) ([]*models.ApprovalDelegation, error) {
+	query := bson.M{}
+	if len(opt.DelegatorIDs) > 0 {
+		query["delegator_id"] = bson.M{"$in": opt.DelegatorIDs}
+	}
+	if opt.DelegateID != "" {
+		query["delegate_id"] = opt.DelegateID
+	}
+	if opt.ActiveAt > 0 {
+		query["start_time"] = bson.M{"$lte": opt.ActiveAt}
+		query["end_time"] = bson.M{"$gte": opt.ActiveAt}
+	}
+
+	resp := make([]*models.ApprovalDelegation, 0)
+	ctx := context.Background()
+	opts := options.Find().SetSort(bson.D{{"start_time", -1}})
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(ctx, &resp)
+	return resp, err
+}
+
+func (c *ApprovalDelegationColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
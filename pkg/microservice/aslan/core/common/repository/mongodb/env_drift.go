@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvDriftColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvDriftColl() *EnvDriftColl {
+	name := models.EnvDrift{}.TableName()
+	return &EnvDriftColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *EnvDriftColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvDriftColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "resolved", Value: 1},
+			bson.E{Key: "service_name", Value: 1},
+		},
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+// Upsert updates the unresolved drift of the resource, or creates one if there isn't.
+func (c *EnvDriftColl) Upsert(args *models.EnvDrift) error {
+	query := bson.M{
+		"product_name": args.ProductName,
+		"env_name":     args.EnvName,
+		"service_name": args.ServiceName,
+		"kind":         args.Kind,
+		"name":         args.Name,
+		"resolved":     false,
+	}
+	now := time.Now().Unix()
+	change := bson.M{
+		"$set": bson.M{
+			"namespace":   args.Namespace,
+			"type":        args.Type,
+			"diffs":       args.Diffs,
+			"expected":    args.Expected,
+			"live":        args.Live,
+			"update_time": now,
+		},
+		"$setOnInsert": bson.M{"detect_time": now},
+	}
+
+	_, err := c.UpdateOne(context.TODO(), query, change, options.Update().SetUpsert(true))
+	return err
+}
+
+type ListEnvDriftOption struct {
+	ProductName string
+	EnvName     string
+	ServiceName string
+	// Resolved lists the resolved drifts instead of the unresolved ones.
+	Resolved bool
+	PageNum  int64
+	PageSize int64
+}
+
+// List returns the drifts of the env without the manifests, the latest first.
+func (c *EnvDriftColl) List(opt *ListEnvDriftOption) ([]*models.EnvDrift, int64, error) {
+	query := bson.M{"product_name": opt.ProductName, "env_name": opt.EnvName, "resolved": opt.Resolved}
+	if opt.ServiceName != "" {
+		query["service_name"] = opt.ServiceName
+	}
+
+	ctx := context.Background()
+	total, err := c.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"update_time", -1}}).SetProjection(bson.M{"expected": 0, "live": 0})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.EnvDrift, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, total, nil
+}
+
+func (c *EnvDriftColl) Find(id string) (*models.EnvDrift, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.EnvDrift{}
+	if err := c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Resolve resolves the drifts with the ids.
+func (c *EnvDriftColl) Resolve(ids []primitive.ObjectID, resolution, user string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := bson.M{"_id": bson.M{"$in": ids}, "resolved": false}
+	change := bson.M{"$set": bson.M{
+		"resolved":     true,
+		"resolution":   resolution,
+		"resolved_by":  user,
+		"resolve_time": time.Now().Unix(),
+	}}
+
+	_, err := c.UpdateMany(context.TODO(), query, change)
+	return err
+}
+
+// ResolveService resolves all the unresolved drifts of the service in the env.
+func (c *EnvDriftColl) ResolveService(productName, envName, serviceName, resolution, user string) error {
+	query := bson.M{"product_name": productName, "env_name": envName, "service_name": serviceName, "resolved": false}
+	change := bson.M{"$set": bson.M{
+		"resolved":     true,
+		"resolution":   resolution,
+		"resolved_by":  user,
+		"resolve_time": time.Now().Unix(),
+	}}
+
+	_, err := c.UpdateMany(context.TODO(), query, change)
+	return err
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EnvSnapshotColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEnvSnapshotColl() *EnvSnapshotColl {
+	name := models.EnvSnapshot{}.TableName()
+	return &EnvSnapshotColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *EnvSnapshotColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EnvSnapshotColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "product_name", Value: 1},
+			bson.E{Key: "env_name", Value: 1},
+			bson.E{Key: "revision", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+// Create sets the next revision of the env to the snapshot and saves it.
+func (c *EnvSnapshotColl) Create(args *models.EnvSnapshot) error {
+	rev, err := NewCounterColl().GetNextSeq(fmt.Sprintf("envsnapshot:%s:%s", args.ProductName, args.EnvName))
+	if err != nil {
+		return fmt.Errorf("get next env snapshot revision error: %v", err)
+	}
+	args.Revision = rev
+	args.CreateTime = time.Now().Unix()
+	_, err = c.InsertOne(context.TODO(), args)
+	return err
+}
+
+type ListEnvSnapshotOption struct {
+	ProductName string
+	EnvName     string
+	PageNum     int64
+	PageSize    int64
+}
+
+// List returns the snapshots of the env without the rendersets, the latest first.
+func (c *EnvSnapshotColl) List(opt *ListEnvSnapshotOption) ([]*models.EnvSnapshot, int64, error) {
+	query := bson.M{"product_name": opt.ProductName, "env_name": opt.EnvName}
+	ctx := context.Background()
+	total, err := c.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"revision", -1}}).SetProjection(bson.M{"render_set": 0, "env_configs": 0})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.EnvSnapshot, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, total, nil
+}
+
+func (c *EnvSnapshotColl) Find(productName, envName string, revision int64) (*models.EnvSnapshot, error) {
+	query := bson.M{"product_name": productName, "env_name": envName, "revision": revision}
+	resp := &models.EnvSnapshot{}
+	if err := c.FindOne(context.TODO(), query).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteHistory deletes the snapshots of the env which are neither the latest remain ones nor created in the last
+// remainDays days, the latest snapshot is always kept.
+func (c *EnvSnapshotColl) DeleteHistory(productName, envName string, remain, remainDays int) error {
+	if remain <= 0 && remainDays <= 0 {
+		return nil
+	}
+	if remain < 1 {
+		remain = 1
+	}
+	query := bson.M{"product_name": productName, "env_name": envName}
+	opts := options.FindOne().SetSort(bson.D{{"revision", -1}}).SetSkip(int64(remain - 1)).SetProjection(bson.M{"revision": 1})
+	oldest := &models.EnvSnapshot{}
+	if err := c.FindOne(context.TODO(), query, opts).Decode(oldest); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+	query["revision"] = bson.M{"$lt": oldest.Revision}
+	if remainDays > 0 {
+		query["create_time"] = bson.M{"$lt": time.Now().AddDate(0, 0, -remainDays).Unix()}
+	}
+	_, err := c.DeleteMany(context.TODO(), query)
+	return err
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/crypto"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
 )
 
 type ClusterListOpts struct {
@@ -63,10 +64,33 @@ func (c *K8SClusterColl) Delete(id string) error {
 	}
 
 	query := bson.M{"_id": oid}
+	cluster := &models.K8SCluster{}
+	if err := c.FindOne(context.TODO(), query).Decode(cluster); err == nil {
+		if err := secretstore.Delete(cluster.KubeConfig); err != nil {
+			return err
+		}
+	}
+
 	_, err = c.DeleteOne(context.TODO(), query)
 	return err
 }
 
+// openClusters replaces the references to the kube configs with the plaintext.
+func openClusters(clusters ...*models.K8SCluster) error {
+	for _, cluster := range clusters {
+		plaintext, err := secretstore.Open(cluster.KubeConfig)
+		if err != nil {
+			return err
+		}
+		cluster.KubeConfig = plaintext
+	}
+	return nil
+}
+
+func sealKubeConfig(id primitive.ObjectID, plaintext string) (string, error) {
+	return secretstore.Seal(secretstore.Name(models.K8SCluster{}.TableName(), id.Hex(), "kube_config"), plaintext)
+}
+
 func (c *K8SClusterColl) Create(cluster *models.K8SCluster, id string) error {
 	if id != "" {
 		cluster.ID, _ = primitive.ObjectIDFromHex(id)
@@ -75,17 +99,31 @@ func (c *K8SClusterColl) Create(cluster *models.K8SCluster, id string) error {
 			return nil
 		}
 	}
-	res, err := c.InsertOne(context.TODO(), cluster)
-	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
-		cluster.ID = oid
+	if cluster.ID.IsZero() {
+		cluster.ID = primitive.NewObjectID()
+	}
+
+	sealed := *cluster
+	kubeConfig, err := sealKubeConfig(cluster.ID, cluster.KubeConfig)
+	if err != nil {
+		return err
 	}
+	sealed.KubeConfig = kubeConfig
 
+	_, err = c.InsertOne(context.TODO(), &sealed)
 	return err
 }
 
 // Update ...
 func (c *K8SClusterColl) Update(cluster *models.K8SCluster) error {
-	_, err := c.UpdateOne(context.TODO(), bson.M{"_id": cluster.ID}, bson.M{"$set": cluster})
+	sealed := *cluster
+	kubeConfig, err := sealKubeConfig(cluster.ID, cluster.KubeConfig)
+	if err != nil {
+		return err
+	}
+	sealed.KubeConfig = kubeConfig
+
+	_, err = c.UpdateOne(context.TODO(), bson.M{"_id": cluster.ID}, bson.M{"$set": &sealed})
 	return err
 }
 
@@ -99,8 +137,11 @@ func (c *K8SClusterColl) Get(id string) (*models.K8SCluster, error) {
 	query := bson.M{"_id": oid}
 	res := &models.K8SCluster{}
 	err = c.FindOne(context.TODO(), query).Decode(res)
+	if err != nil {
+		return res, err
+	}
 
-	return res, err
+	return res, openClusters(res)
 }
 
 func (c *K8SClusterColl) HasDuplicateName(id, name string) (bool, error) {
@@ -161,7 +202,7 @@ func (c *K8SClusterColl) List(opts *ClusterListOpts) ([]*models.K8SCluster, erro
 		return nil, err
 	}
 
-	return clusters, err
+	return clusters, openClusters(clusters...)
 }
 
 func (c *K8SClusterColl) Find(clusterType string) ([]*models.K8SCluster, error) {
@@ -186,14 +227,17 @@ func (c *K8SClusterColl) Find(clusterType string) ([]*models.K8SCluster, error)
 		return nil, err
 	}
 
-	return clusters, err
+	return clusters, openClusters(clusters...)
 }
 
 func (c *K8SClusterColl) FindByName(name string) (*models.K8SCluster, error) {
 	res := &models.K8SCluster{}
 	err := c.FindOne(context.TODO(), bson.M{"name": name}).Decode(res)
+	if err != nil {
+		return res, err
+	}
 
-	return res, err
+	return res, openClusters(res)
 }
 
 func (c *K8SClusterColl) UpdateMutableFields(cluster *models.K8SCluster, id string) error {
@@ -202,6 +246,10 @@ func (c *K8SClusterColl) UpdateMutableFields(cluster *models.K8SCluster, id stri
 	if err != nil {
 		return err
 	}
+	kubeConfig, err := sealKubeConfig(cluster.ID, cluster.KubeConfig)
+	if err != nil {
+		return err
+	}
 	_, err = c.UpdateOne(context.TODO(),
 		bson.M{"_id": cluster.ID}, bson.M{"$set": bson.M{
 			"name":            cluster.Name,
@@ -212,7 +260,7 @@ func (c *K8SClusterColl) UpdateMutableFields(cluster *models.K8SCluster, id stri
 			"advanced_config": cluster.AdvancedConfig,
 			"cache":           cluster.Cache,
 			"dind_cfg":        cluster.DindCfg,
-			"kube_config":     cluster.KubeConfig,
+			"kube_config":     kubeConfig,
 			"type":            cluster.Type,
 		}},
 	)
@@ -250,8 +298,11 @@ func (c *K8SClusterColl) FindConnectedClusters() ([]*models.K8SCluster, error) {
 		return nil, err
 	}
 	err = cursor.All(context.TODO(), &clusters)
+	if err != nil {
+		return nil, err
+	}
 
-	return clusters, err
+	return clusters, openClusters(clusters...)
 }
 
 func (c *K8SClusterColl) UpdateConnectState(id string, disconnected bool) error {
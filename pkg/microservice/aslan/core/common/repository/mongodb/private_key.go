@@ -29,6 +29,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
 )
 
 type PrivateKeyArgs struct {
@@ -91,7 +92,26 @@ func (c *PrivateKeyColl) Find(option FindPrivateKeyOption) (*models.PrivateKey,
 	}
 
 	err := c.FindOne(context.TODO(), query).Decode(privateKey)
-	return privateKey, err
+	if err != nil {
+		return privateKey, err
+	}
+	return privateKey, openPrivateKeys(privateKey)
+}
+
+// openPrivateKeys replaces the references to the private keys with the plaintext.
+func openPrivateKeys(keys ...*models.PrivateKey) error {
+	for _, key := range keys {
+		plaintext, err := secretstore.Open(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+		key.PrivateKey = plaintext
+	}
+	return nil
+}
+
+func sealPrivateKey(id primitive.ObjectID, plaintext string) (string, error) {
+	return secretstore.Seal(secretstore.Name(models.PrivateKey{}.TableName(), id.Hex(), "private_key"), plaintext)
 }
 
 func (c *PrivateKeyColl) List(args *PrivateKeyArgs) ([]*models.PrivateKey, error) {
@@ -120,7 +140,7 @@ func (c *PrivateKeyColl) List(args *PrivateKeyArgs) ([]*models.PrivateKey, error
 		return nil, err
 	}
 
-	return resp, err
+	return resp, openPrivateKeys(resp...)
 }
 
 func (c *PrivateKeyColl) Create(args *models.PrivateKey) error {
@@ -130,8 +150,18 @@ func (c *PrivateKeyColl) Create(args *models.PrivateKey) error {
 
 	args.CreateTime = time.Now().Unix()
 	args.UpdateTime = time.Now().Unix()
+	if args.ID.IsZero() {
+		args.ID = primitive.NewObjectID()
+	}
+
+	key := *args
+	sealed, err := sealPrivateKey(key.ID, key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	key.PrivateKey = sealed
 
-	_, err := c.InsertOne(context.TODO(), args)
+	_, err = c.InsertOne(context.TODO(), &key)
 
 	return err
 }
@@ -153,6 +183,10 @@ func (c *PrivateKeyColl) Update(id string, args *models.PrivateKey) error {
 			"status": args.Status,
 		}}
 	} else {
+		sealed, err := sealPrivateKey(oid, args.PrivateKey)
+		if err != nil {
+			return err
+		}
 		change = bson.M{"$set": bson.M{
 			"name":        args.Name,
 			"user_name":   args.UserName,
@@ -160,7 +194,7 @@ func (c *PrivateKeyColl) Update(id string, args *models.PrivateKey) error {
 			"port":        args.Port,
 			"label":       args.Label,
 			"is_prod":     args.IsProd,
-			"private_key": args.PrivateKey,
+			"private_key": sealed,
 			"provider":    args.Provider,
 			"probe":       args.Probe,
 			"update_by":   args.UpdateBy,
@@ -180,6 +214,13 @@ func (c *PrivateKeyColl) Delete(id string) error {
 
 	query := bson.M{"_id": oid}
 
+	key := new(models.PrivateKey)
+	if err := c.FindOne(context.TODO(), query).Decode(key); err == nil {
+		if err := secretstore.Delete(key.PrivateKey); err != nil {
+			return err
+		}
+	}
+
 	_, err = c.DeleteOne(context.TODO(), query)
 	return err
 }
@@ -188,7 +229,21 @@ func (c *PrivateKeyColl) BulkDelete(projectName string) error {
 	query := bson.M{}
 	query["project_name"] = projectName
 
-	_, err := c.DeleteMany(context.TODO(), query)
+	keys := make([]*models.PrivateKey, 0)
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(context.TODO(), &keys); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := secretstore.Delete(key.PrivateKey); err != nil {
+			return err
+		}
+	}
+
+	_, err = c.DeleteMany(context.TODO(), query)
 	return err
 }
 
@@ -245,7 +300,7 @@ func (c *PrivateKeyColl) ListHostIPByArgs(args *ListHostIPArgs) ([]*models.Priva
 		return nil, err
 	}
 
-	return resp, err
+	return resp, openPrivateKeys(resp...)
 }
 
 // DistinctLabels returns distinct label
@@ -29,6 +29,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
 )
 
 type FindRegOps struct {
@@ -77,11 +78,37 @@ func (r *RegistryNamespaceColl) Create(args *models.RegistryNamespace) error {
 	}
 
 	args.UpdateTime = time.Now().Unix()
+	if args.ID.IsZero() {
+		args.ID = primitive.NewObjectID()
+	}
+
+	sealed := *args
+	secretKey, err := sealSecretKey(args.ID, args.SecretKey)
+	if err != nil {
+		return err
+	}
+	sealed.SecretKey = secretKey
 
-	_, err := r.InsertOne(context.TODO(), args)
+	_, err = r.InsertOne(context.TODO(), &sealed)
 	return err
 }
 
+// openRegistries replaces the references to the secret keys with the plaintext.
+func openRegistries(registries ...*models.RegistryNamespace) error {
+	for _, registry := range registries {
+		plaintext, err := secretstore.Open(registry.SecretKey)
+		if err != nil {
+			return err
+		}
+		registry.SecretKey = plaintext
+	}
+	return nil
+}
+
+func sealSecretKey(id primitive.ObjectID, plaintext string) (string, error) {
+	return secretstore.Seal(secretstore.Name(models.RegistryNamespace{}.TableName(), id.Hex(), "secret_key"), plaintext)
+}
+
 func (opt FindRegOps) getQuery() bson.M {
 	query := bson.M{}
 
@@ -119,8 +146,11 @@ func (r *RegistryNamespaceColl) Find(opt *FindRegOps) (*models.RegistryNamespace
 
 	res := &models.RegistryNamespace{}
 	err := r.FindOne(context.TODO(), query).Decode(res)
+	if err != nil {
+		return res, err
+	}
 
-	return res, err
+	return res, openRegistries(res)
 }
 
 func (r *RegistryNamespaceColl) FindAll(opt *FindRegOps) ([]*models.RegistryNamespace, error) {
@@ -140,7 +170,7 @@ func (r *RegistryNamespaceColl) FindAll(opt *FindRegOps) ([]*models.RegistryName
 		return nil, err
 	}
 
-	return resp, err
+	return resp, openRegistries(resp...)
 }
 
 func (r *RegistryNamespaceColl) Update(id string, args *models.RegistryNamespace) error {
@@ -157,7 +187,13 @@ func (r *RegistryNamespaceColl) Update(id string, args *models.RegistryNamespace
 	args.ID = oid
 	args.UpdateTime = time.Now().Unix()
 
-	change := bson.M{"$set": args}
+	sealed := *args
+	sealed.SecretKey, err = sealSecretKey(oid, args.SecretKey)
+	if err != nil {
+		return err
+	}
+
+	change := bson.M{"$set": &sealed}
 	_, err = r.UpdateOne(context.TODO(), query, change)
 	return err
 }
@@ -169,6 +205,13 @@ func (r *RegistryNamespaceColl) Delete(id string) error {
 	}
 
 	query := bson.M{"_id": oid}
+	registry := &models.RegistryNamespace{}
+	if err := r.FindOne(context.TODO(), query).Decode(registry); err == nil {
+		if err := secretstore.Delete(registry.SecretKey); err != nil {
+			return err
+		}
+	}
+
 	_, err = r.DeleteOne(context.TODO(), query)
 
 	return err
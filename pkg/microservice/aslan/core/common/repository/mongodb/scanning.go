@@ -148,6 +148,17 @@ func (c *ScanningColl) GetByID(idstring string) (*models.Scanning, error) {
 	return resp, nil
 }
 
+func (c *ScanningColl) Find(projectName, name string) (*models.Scanning, error) {
+	resp := new(models.Scanning)
+	query := bson.M{"project_name": projectName, "name": name}
+
+	err := c.FindOne(context.TODO(), query).Decode(&resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *ScanningColl) DeleteByID(idstring string) error {
 	id, err := primitive.ObjectIDFromHex(idstring)
 	if err != nil {
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+// webhookDeliveryTTL is how long the deliveries with their payload and headers are kept.
+const webhookDeliveryTTL = 30 * 24 * time.Hour
+
+type WebhookDeliveryColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewWebhookDeliveryColl() *WebhookDeliveryColl {
+	name := models.WebhookDelivery{}.TableName()
+	return &WebhookDeliveryColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *WebhookDeliveryColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *WebhookDeliveryColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "repo", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "matches.name", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+		},
+		{
+			Keys:    bson.M{"created_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookDeliveryTTL.Seconds())),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *WebhookDeliveryColl) Create(args *models.WebhookDelivery) error {
+	args.CreatedAt = time.Unix(args.CreateTime, 0)
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = id
+	}
+	return nil
+}
+
+type ListWebhookDeliveryOption struct {
+	Provider string
+	Repo     string
+	Event    string
+	// Name is the name of a workflow, test or scanning which has a hook on the repository.
+	Name        string
+	MatchedOnly bool
+	PageNum     int64
+	PageSize    int64
+}
+
+// List returns the deliveries without the payloads, the latest first.
+func (c *WebhookDeliveryColl) List(opt *ListWebhookDeliveryOption) ([]*models.WebhookDelivery, int64, error) {
+	query := bson.M{}
+	if opt.Provider != "" {
+		query["provider"] = opt.Provider
+	}
+	if opt.Repo != "" {
+		query["repo"] = opt.Repo
+	}
+	if opt.Event != "" {
+		query["event"] = opt.Event
+	}
+	if opt.Name != "" || opt.MatchedOnly {
+		match := bson.M{}
+		if opt.Name != "" {
+			match["name"] = opt.Name
+		}
+		if opt.MatchedOnly {
+			match["matched"] = true
+		}
+		query["matches"] = bson.M{"$elemMatch": match}
+	}
+
+	ctx := context.Background()
+	total, err := c.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}}).SetProjection(bson.M{"payload": 0, "headers": 0})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.WebhookDelivery, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, total, nil
+}
+
+func (c *WebhookDeliveryColl) Find(id string) (*models.WebhookDelivery, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.WebhookDelivery{}
+	if err := c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
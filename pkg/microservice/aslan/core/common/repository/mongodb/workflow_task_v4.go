@@ -34,6 +34,7 @@ import (
 type ListWorkflowTaskV4Option struct {
 	WorkflowName    string
 	WorkflowNames   []string
+	ProjectName     string
 	CreateTime      int64
 	BeforeCreatTime bool
 	Limit           int
@@ -110,6 +111,9 @@ func (c *WorkflowTaskv4Coll) List(opt *ListWorkflowTaskV4Option) ([]*models.Work
 	if opt.WorkflowNames != nil {
 		query["workflow_name"] = bson.M{"$in": opt.WorkflowNames}
 	}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
 	query["is_archived"] = false
 	query["is_deleted"] = false
 	if opt.CreateTime > 0 {
@@ -221,6 +225,143 @@ func (c *WorkflowTaskv4Coll) Update(idString string, obj *models.WorkflowTask) e
 	return err
 }
 
+// ClaimTask makes newOwner the owner of a running task whose lease has expired at now, tasks started before
+// leases were added have no lease and are claimable. Only one of the replicas claiming the same task succeeds.
+func (c *WorkflowTaskv4Coll) ClaimTask(workflowName string, taskID int64, newOwner string, now, leaseExpireTime int64) (bool, error) {
+	filter := bson.M{
+		"workflow_name": workflowName,
+		"task_id":       taskID,
+		"status":        config.StatusRunning,
+		"$or": bson.A{
+			bson.M{"lease_expire_time": bson.M{"$exists": false}},
+			bson.M{"lease_expire_time": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": newOwner, "lease_expire_time": leaseExpireTime}}
+	res, err := c.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// RenewLease extends the lease of the task, it returns false if the task is owned by another replica.
+func (c *WorkflowTaskv4Coll) RenewLease(workflowName string, taskID int64, owner string, leaseExpireTime int64) (bool, error) {
+	filter := bson.M{"workflow_name": workflowName, "task_id": taskID, "owner": owner}
+	res, err := c.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"lease_expire_time": leaseExpireTime}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// UpdateProgress writes the fields which the controller of a running task changes, approve users are only
+// written by AddApproveUsers and UpdateApproveUser, so approvals made on other replicas are never overwritten.
+// It returns false if the task is owned by another replica.
+func (c *WorkflowTaskv4Coll) UpdateProgress(obj *models.WorkflowTask) (bool, error) {
+	if obj == nil {
+		return false, fmt.Errorf("nil object")
+	}
+	set := bson.M{
+		"status":         obj.Status,
+		"start_time":     obj.StartTime,
+		"end_time":       obj.EndTime,
+		"error":          obj.Error,
+		"global_context": obj.GlobalContext,
+		"owner":          obj.Owner,
+	}
+	for i, stage := range obj.Stages {
+		prefix := fmt.Sprintf("stages.%d.", i)
+		set[prefix+"status"] = stage.Status
+		set[prefix+"start_time"] = stage.StartTime
+		set[prefix+"end_time"] = stage.EndTime
+		set[prefix+"error"] = stage.Error
+		set[prefix+"jobs"] = stage.Jobs
+		if stage.Approval != nil {
+			set[prefix+"approval.timeout"] = stage.Approval.Timeout
+			set[prefix+"approval.reject_or_approve"] = stage.Approval.RejectOrApprove
+			set[prefix+"approval.auto_approved_by"] = stage.Approval.AutoApprovedBy
+		}
+	}
+	// a task started before owners were added has no owner until its first update.
+	filter := bson.M{"_id": obj.ID, "owner": bson.M{"$in": bson.A{obj.Owner, "", nil}}}
+
+	// the lease may have been renewed after obj was loaded, it never goes back.
+	update := bson.M{"$set": set, "$max": bson.M{"lease_expire_time": obj.LeaseExpireTime}}
+
+	res, err := c.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// AddApproveUsers adds the users to the approve users of a stage unless they are in it already, and sets the
+// delegates of the users who have not approved yet. Approvals written by UpdateApproveUser meanwhile are kept.
+func (c *WorkflowTaskv4Coll) AddApproveUsers(workflowName string, taskID int64, stageName string, users []*models.User) error {
+	for _, user := range users {
+		filter := bson.M{
+			"workflow_name": workflowName,
+			"task_id":       taskID,
+			"stages": bson.M{"$elemMatch": bson.M{
+				"name":                           stageName,
+				"approval.approve_users.user_id": bson.M{"$ne": user.UserID},
+			}},
+		}
+		if _, err := c.UpdateOne(context.TODO(), filter, bson.M{"$push": bson.M{"stages.$.approval.approve_users": user}}); err != nil {
+			return err
+		}
+		if user.DelegateUserID == "" {
+			continue
+		}
+
+		filter = bson.M{"workflow_name": workflowName, "task_id": taskID}
+		update := bson.M{"$set": bson.M{
+			"stages.$[stage].approval.approve_users.$[user].delegate_user_id":   user.DelegateUserID,
+			"stages.$[stage].approval.approve_users.$[user].delegate_user_name": user.DelegateUserName,
+		}}
+		opts := options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{
+				bson.M{"stage.name": stageName},
+				bson.M{"user.user_id": user.UserID, "user.reject_or_approve": "", "user.delegate_user_id": bson.M{"$in": bson.A{"", nil}}},
+			},
+		})
+		if _, err := c.UpdateOne(context.TODO(), filter, update, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateApproveUser sets the approval result of one user on a stage which is still waiting for approval.
+// It returns false if the stage is no longer waiting or the user has approved already.
+func (c *WorkflowTaskv4Coll) UpdateApproveUser(workflowName string, taskID int64, stageName string, user *models.User) (bool, error) {
+	if user == nil {
+		return false, fmt.Errorf("nil object")
+	}
+	filter := bson.M{"workflow_name": workflowName, "task_id": taskID, "status": config.StatusRunning}
+	update := bson.M{"$set": bson.M{
+		"stages.$[stage].approval.approve_users.$[user].reject_or_approve":  user.RejectOrApprove,
+		"stages.$[stage].approval.approve_users.$[user].comment":            user.Comment,
+		"stages.$[stage].approval.approve_users.$[user].operation_time":     user.OperationTime,
+		"stages.$[stage].approval.approve_users.$[user].operator":           user.Operator,
+		"stages.$[stage].approval.approve_users.$[user].delegate_user_id":   user.DelegateUserID,
+		"stages.$[stage].approval.approve_users.$[user].delegate_user_name": user.DelegateUserName,
+	}}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{
+			bson.M{"stage.name": stageName, "stage.status": config.StatusRunning, "stage.approval.reject_or_approve": ""},
+			bson.M{"user.user_id": user.UserID, "user.reject_or_approve": ""},
+		},
+	})
+
+	res, err := c.UpdateOne(context.TODO(), filter, update, opts)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
 func (c *WorkflowTaskv4Coll) DeleteByWorkflowName(workflowName string) error {
 	query := bson.M{"workflow_name": workflowName}
 	change := bson.M{"$set": bson.M{
@@ -111,6 +111,21 @@ func (c *WorkflowV4Coll) List(opt *ListWorkflowV4Option, pageNum, pageSize int64
 	return resp, count, nil
 }
 
+func (c *WorkflowV4Coll) ListWithScheduleEnabled() ([]*models.WorkflowV4, error) {
+	resp := make([]*models.WorkflowV4, 0)
+	query := bson.M{"schedules.enabled": true}
+
+	cursor, err := c.Collection.Find(context.TODO(), query)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *WorkflowV4Coll) Find(name string) (*models.WorkflowV4, error) {
 	resp := new(models.WorkflowV4)
 	query := bson.M{"name": name}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envsnapshot
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+)
+
+const (
+	SourceEnvUpdate  = "env_update"
+	SourceHelmUpdate = "helm_update"
+	SourceWorkflow   = "workflow"
+	SourceRollback   = "rollback"
+	SourceDriftAdopt = "drift_adopt"
+)
+
+// Create records the services, the renderset and the env configs the env runs with now.
+func Create(productName, envName, source, user string, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find env %s/%s: %v", productName, envName, err)
+	}
+
+	snapshot := &commonmodels.EnvSnapshot{
+		ProductName: productName,
+		EnvName:     envName,
+		Source:      source,
+		CreateBy:    user,
+		Services:    env.Services,
+		Render:      env.Render,
+	}
+	if env.Render != nil && env.Render.Name != "" {
+		renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: env.Render.Name, Revision: env.Render.Revision})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find renderset %s/%d: %v", env.Render.Name, env.Render.Revision, err)
+		}
+		// the renderset may be updated in place by the workflow deploy, keep a copy of it.
+		snapshot.RenderSet = renderSet
+	}
+
+	envConfigs, err := commonrepo.NewEnvResourceColl().ListLatestResource(&commonrepo.QueryEnvResourceOption{ProductName: productName, EnvName: envName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list env configs of %s/%s: %v", productName, envName, err)
+	}
+	for _, envConfig := range envConfigs {
+		resource, err := commonrepo.NewEnvResourceColl().Find(&commonrepo.QueryEnvResourceOption{
+			ProductName: productName,
+			EnvName:     envName,
+			Name:        envConfig.ID.Name,
+			Type:        envConfig.ID.Type,
+		})
+		if err != nil {
+			log.Warnf("failed to find env config %s/%s of %s/%s: %v", envConfig.ID.Type, envConfig.ID.Name, productName, envName, err)
+			continue
+		}
+		snapshot.EnvConfigs = append(snapshot.EnvConfigs, &commonmodels.EnvSnapshotConfig{
+			Name:     resource.Name,
+			Type:     resource.Type,
+			YamlData: resource.YamlData,
+		})
+	}
+
+	if err := commonrepo.NewEnvSnapshotColl().Create(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot of env %s/%s: %v", productName, envName, err)
+	}
+	log.Infof("created snapshot %d of env %s/%s from %s", snapshot.Revision, productName, envName, source)
+	deleteHistory(productName, envName, log)
+	return snapshot, nil
+}
+
+// deleteHistory deletes the snapshots of the env beyond the EnvSnapshotRetention strategy.
+func deleteHistory(productName, envName string, log *zap.SugaredLogger) {
+	strategy, err := commonrepo.NewStrategyColl().GetByTarget(commonmodels.EnvSnapshotRetention)
+	if err != nil || strategy.Retention == nil {
+		strategy = commonmodels.DefaultEnvSnapshotRetention
+	}
+	if err := commonrepo.NewEnvSnapshotColl().DeleteHistory(productName, envName, strategy.Retention.MaxItems, strategy.Retention.MaxDays); err != nil {
+		log.Warnf("failed to delete history snapshots of env %s/%s: %v", productName, envName, err)
+	}
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package instantmessage
 
+import "context"
+
 const (
 	dingDingType = "dingding"
 )
@@ -36,7 +38,7 @@ type DingDingAt struct {
 	IsAtAll   bool     `json:"isAtAll"`
 }
 
-func (w *Service) sendDingDingMessage(uri, title, content string, atMobiles []string) error {
+func (w *Service) sendDingDingMessage(ctx context.Context, uri, title, content string, atMobiles []string) error {
 	message := &DingDingMessage{
 		MsgType: msgType,
 		MarkDown: &DingDingMarkDown{
@@ -55,6 +57,6 @@ func (w *Service) sendDingDingMessage(uri, title, content string, atMobiles []st
 		}
 	}
 
-	_, err := w.SendMessageRequest(uri, message)
+	_, err := w.sendMessageRequest(ctx, uri, message)
 	return err
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package instantmessage
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -179,12 +180,12 @@ func (lc *LarkCard) AddI18NElementsZhcnAction(content, url string) {
 	lc.I18NElements.ZhCn = append(lc.I18NElements.ZhCn, zhcnElem)
 }
 
-func (w *Service) sendFeishuMessage(uri string, lcMsg *LarkCard) error {
+func (w *Service) sendFeishuMessage(ctx context.Context, uri string, lcMsg *LarkCard) error {
 	message := LarkCardReq{
 		MsgType: feishuCardType,
 		Card:    lcMsg,
 	}
-	_, err := w.SendMessageRequest(uri, message)
+	_, err := w.sendMessageRequest(ctx, uri, message)
 	return err
 }
 
@@ -18,6 +18,7 @@ package instantmessage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -57,6 +58,7 @@ type Service struct {
 	pipelineColl     *mongodb.PipelineColl
 	testingColl      *mongodb.TestingColl
 	testTaskStatColl *mongodb.TestTaskStatColl
+	workflowV4Coll   *mongodb.WorkflowV4Coll
 }
 
 func NewWeChatClient() *Service {
@@ -66,6 +68,7 @@ func NewWeChatClient() *Service {
 		pipelineColl:     mongodb.NewPipelineColl(),
 		testingColl:      mongodb.NewTestingColl(),
 		testTaskStatColl: mongodb.NewTestTaskStatColl(),
+		workflowV4Coll:   mongodb.NewWorkflowV4Coll(),
 	}
 }
 
@@ -80,6 +83,11 @@ type wechatNotification struct {
 }
 
 func (w *Service) SendMessageRequest(uri string, message interface{}) ([]byte, error) {
+	return w.sendMessageRequest(context.Background(), uri, message)
+}
+
+// sendMessageRequest posts the message to the im webhook, the request is canceled once ctx is done.
+func (w *Service) sendMessageRequest(ctx context.Context, uri string, message interface{}) ([]byte, error) {
 	c := httpclient.New()
 
 	// 使用代理
@@ -89,7 +97,7 @@ func (w *Service) SendMessageRequest(uri string, message interface{}) ([]byte, e
 		fmt.Printf("send message is using proxy:%s\n", proxies[0].GetProxyURL())
 	}
 
-	res, err := c.Post(uri, httpclient.SetBody(message))
+	res, err := c.Post(uri, httpclient.SetContext(ctx), httpclient.SetBody(message))
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +248,7 @@ func (w *Service) sendMessage(task *task.Task, notifyCtl *models.NotifyCtl, test
 			if task.Type == config.SingleType {
 				title = "工作流状态"
 			}
-			err := w.sendDingDingMessage(uri, title, content, atMobiles)
+			err := w.sendDingDingMessage(context.Background(), uri, title, content, atMobiles)
 			if err != nil {
 				log.Errorf("sendDingDingMessage err : %s", err)
 				return err
@@ -255,7 +263,7 @@ func (w *Service) sendMessage(task *task.Task, notifyCtl *models.NotifyCtl, test
 				return nil
 			}
 
-			err := w.sendFeishuMessage(uri, larkCard)
+			err := w.sendFeishuMessage(context.Background(), uri, larkCard)
 			if err != nil {
 				log.Errorf("SendFeiShuMessageRequest err : %s", err)
 				return err
@@ -265,7 +273,7 @@ func (w *Service) sendMessage(task *task.Task, notifyCtl *models.NotifyCtl, test
 			if task.Type == config.SingleType {
 				typeText = weChatTextTypeText
 			}
-			err := w.SendWeChatWorkMessage(typeText, uri, content)
+			err := w.SendWeChatWorkMessage(context.Background(), typeText, uri, content)
 			if err != nil {
 				log.Errorf("SendWeChatWorkMessage err : %s", err)
 				return err
@@ -17,6 +17,7 @@ limitations under the License.
 package instantmessage
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -48,7 +49,7 @@ type Text struct {
 	Content string `json:"content"`
 }
 
-func (w *Service) SendWeChatWorkMessage(textType TextType, uri, content string) error {
+func (w *Service) SendWeChatWorkMessage(ctx context.Context, textType TextType, uri, content string) error {
 	var message interface{}
 	if textType == weChatTextTypeText {
 		message = &Messsage{
@@ -68,6 +69,6 @@ func (w *Service) SendWeChatWorkMessage(textType TextType, uri, content string)
 		return fmt.Errorf("SendWeChatWorkMessage err:%s", "WeChatWork textType is invalid")
 	}
 
-	_, err := w.SendMessageRequest(uri, message)
+	_, err := w.sendMessageRequest(ctx, uri, message)
 	return err
 }
@@ -0,0 +1,477 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/mail"
+)
+
+const (
+	mailType    = "mail"
+	webhookType = "webhook"
+
+	webhookEventHeader     = "X-Zadig-Event"
+	webhookSignatureHeader = "X-Zadig-Signature"
+)
+
+const workflowTaskMailTemplate = `<h3>{{.Title}}</h3>
+<table>
+{{range .Fields}}<tr><td><b>{{.Label}}</b></td><td>{{.Value}}</td></tr>
+{{end}}</table>
+<p><a href="{{.URL}}">{{.Button}}</a></p>`
+
+type notifyField struct {
+	Label string
+	Value string
+}
+
+// workflowTaskMessage is the content of a workflow v4 notification, rendered differently for each webhook type.
+type workflowTaskMessage struct {
+	Title  string
+	Event  config.Status
+	Fields []*notifyField
+	URL    string
+	Button string
+}
+
+// WorkflowTaskWebhookPayload is the body posted to the generic webhooks.
+type WorkflowTaskWebhookPayload struct {
+	Event        config.Status          `json:"event"`
+	ProjectName  string                 `json:"project_name"`
+	WorkflowName string                 `json:"workflow_name"`
+	TaskID       int64                  `json:"task_id"`
+	Status       config.Status          `json:"status"`
+	TaskCreator  string                 `json:"task_creator"`
+	CreateTime   int64                  `json:"create_time"`
+	StartTime    int64                  `json:"start_time"`
+	EndTime      int64                  `json:"end_time"`
+	Error        string                 `json:"error,omitempty"`
+	DetailURL    string                 `json:"detail_url"`
+	Stages       []*WebhookPayloadStage `json:"stages"`
+	// the stage waiting for approval, only set on the waitforapprove event.
+	ApprovalStage *WebhookPayloadStage `json:"approval_stage,omitempty"`
+}
+
+type WebhookPayloadStage struct {
+	Name      string               `json:"name"`
+	Status    config.Status        `json:"status"`
+	Approvers []string             `json:"approvers,omitempty"`
+	Jobs      []*WebhookPayloadJob `json:"jobs"`
+}
+
+type WebhookPayloadJob struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Status config.Status `json:"status"`
+}
+
+// ValidateNotifyCtlV4 checks the receiver of the notification is set for its webhook type.
+func ValidateNotifyCtlV4(notifyCtl *models.NotifyCtlV4) error {
+	if !notifyCtl.Enabled {
+		return nil
+	}
+	var receiver string
+	switch notifyCtl.WebHookType {
+	case dingDingType:
+		receiver = notifyCtl.DingDingWebHook
+	case feiShuType:
+		receiver = notifyCtl.FeiShuWebHook
+	case weChatWorkType:
+		receiver = notifyCtl.WeChatWebHook
+	case mailType:
+		// approvers receive the mail of the approval even if no mail users are set.
+		receiver = mailType
+	case webhookType:
+		if notifyCtl.WebHookNotify != nil {
+			receiver = notifyCtl.WebHookNotify.Address
+		}
+	default:
+		return fmt.Errorf("webhook type %s is not supported", notifyCtl.WebHookType)
+	}
+	if receiver == "" {
+		return fmt.Errorf("webhook address of %s notification is empty", notifyCtl.WebHookType)
+	}
+	return nil
+}
+
+// SendWorkflowTaskNotifications sends the notifications of the workflow v4 task with its current status as the event.
+// The im, webhook and mail requests are given up once ctx is done.
+func (w *Service) SendWorkflowTaskNotifications(ctx context.Context, task *models.WorkflowTask) error {
+	return w.sendWorkflowTaskNotifications(ctx, task, task.Status, nil)
+}
+
+// SendWorkflowTaskApproveNotifications tells the approvers of the stage that the workflow v4 task is waiting for them.
+func (w *Service) SendWorkflowTaskApproveNotifications(ctx context.Context, task *models.WorkflowTask, stage *models.StageTask) error {
+	return w.sendWorkflowTaskNotifications(ctx, task, config.StatusWaitingApprove, stage)
+}
+
+func (w *Service) sendWorkflowTaskNotifications(ctx context.Context, task *models.WorkflowTask, event config.Status, stage *models.StageTask) error {
+	workflow, err := w.workflowV4Coll.Find(task.WorkflowName)
+	if err != nil {
+		log.Errorf("failed to find workflow %s, err: %s", task.WorkflowName, err)
+		return err
+	}
+	var approvers []*user.User
+	for _, notifyCtl := range workflow.NotifyCtls {
+		if ctx.Err() != nil {
+			return fmt.Errorf("send notifications of workflow %s task %d: %s", task.WorkflowName, task.TaskID, ctx.Err())
+		}
+		if notifyCtl == nil || !notifyCtl.Enabled || !sets.NewString(notifyCtl.NotifyTypes...).Has(string(event)) {
+			continue
+		}
+		if stage != nil && approvers == nil {
+			approvers = getApprovers(stage)
+		}
+		if err := w.sendWorkflowTaskMessage(ctx, task, event, stage, approvers, notifyCtl); err != nil {
+			log.Errorf("send %s message of workflow %s task %d err: %s", notifyCtl.WebHookType, task.WorkflowName, task.TaskID, err)
+			continue
+		}
+	}
+	return nil
+}
+
+func (w *Service) sendWorkflowTaskMessage(ctx context.Context, task *models.WorkflowTask, event config.Status, stage *models.StageTask, approvers []*user.User, notifyCtl *models.NotifyCtlV4) error {
+	msg := newWorkflowTaskMessage(task, event, stage)
+	switch notifyCtl.WebHookType {
+	case dingDingType:
+		atMobiles := notifyCtl.AtMobiles
+		for _, approver := range approvers {
+			if approver.Phone != "" {
+				atMobiles = append(atMobiles, approver.Phone)
+			}
+		}
+		if notifyCtl.IsAtAll {
+			atMobiles = nil
+		}
+		return w.sendDingDingMessage(ctx, notifyCtl.DingDingWebHook, msg.Title, msg.markdown(dingDingType, atMobiles), atMobiles)
+	case feiShuType:
+		return w.sendFeishuMessage(ctx, notifyCtl.FeiShuWebHook, msg.larkCard())
+	case weChatWorkType:
+		return w.SendWeChatWorkMessage(ctx, weChatTextTypeMarkdown, notifyCtl.WeChatWebHook, msg.markdown(weChatWorkType, nil))
+	case mailType:
+		var uids []string
+		for _, u := range notifyCtl.MailUsers {
+			uids = append(uids, u.UserID)
+		}
+		users, err := listUsers(uids)
+		if err != nil {
+			return err
+		}
+		return sendWorkflowTaskMail(ctx, msg, append(users, approvers...))
+	case webhookType:
+		if notifyCtl.WebHookNotify == nil {
+			return fmt.Errorf("webhook of the notification is not set")
+		}
+		return w.sendWorkflowTaskWebhook(ctx, notifyCtl.WebHookNotify, newWorkflowTaskWebhookPayload(task, event, stage, msg.URL))
+	default:
+		return fmt.Errorf("webhook type %s is not supported", notifyCtl.WebHookType)
+	}
+}
+
+func newWorkflowTaskMessage(task *models.WorkflowTask, event config.Status, stage *models.StageTask) *workflowTaskMessage {
+	msg := &workflowTaskMessage{
+		Title:  fmt.Sprintf("%s工作流 %s #%d %s", workflowTaskIcon(event), task.WorkflowName, task.TaskID, workflowTaskStatusText(event)),
+		Event:  event,
+		URL:    fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d", configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID),
+		Button: "点击查看更多信息",
+	}
+	msg.addField("执行用户", task.TaskCreator)
+	msg.addField("项目名称", task.ProjectName)
+	if task.StartTime > 0 {
+		msg.addField("开始时间", time.Unix(task.StartTime, 0).Format("2006-01-02 15:04:05"))
+	}
+	if task.EndTime > 0 && task.StartTime > 0 {
+		msg.addField("持续时间", (time.Duration(task.EndTime-task.StartTime) * time.Second).String())
+	}
+
+	if event == config.StatusWaitingApprove && stage != nil {
+		msg.Button = "前往审批"
+		msg.addField("待审批阶段", stage.Name)
+		names := make([]string, 0)
+		if stage.Approval != nil {
+			for _, u := range stage.Approval.ApproveUsers {
+				names = append(names, u.UserName)
+			}
+			if stage.Approval.Description != "" {
+				msg.addField("审批说明", stage.Approval.Description)
+			}
+		}
+		msg.addField("审批人", strings.Join(names, ", "))
+		return msg
+	}
+
+	for _, s := range task.Stages {
+		jobs := make([]string, 0, len(s.Jobs))
+		for _, job := range s.Jobs {
+			status := job.Status
+			if status == "" {
+				status = config.StatusNotRun
+			}
+			jobs = append(jobs, fmt.Sprintf("%s(%s)", job.Name, status))
+		}
+		if len(jobs) > 0 {
+			msg.addField(s.Name, strings.Join(jobs, ", "))
+		}
+	}
+	if task.Error != "" {
+		msg.addField("错误信息", task.Error)
+	}
+	return msg
+}
+
+func (m *workflowTaskMessage) addField(label, value string) {
+	m.Fields = append(m.Fields, &notifyField{Label: label, Value: value})
+}
+
+func (m *workflowTaskMessage) markdown(webHookType string, atMobiles []string) string {
+	var b strings.Builder
+	if webHookType == weChatWorkType {
+		b.WriteString(fmt.Sprintf("#### <font color=\"%s\">%s</font> \n", workflowTaskMarkdownColor(m.Event), m.Title))
+	} else {
+		b.WriteString(fmt.Sprintf("#### %s \n", m.Title))
+	}
+	prefix := ""
+	if webHookType == dingDingType {
+		prefix = "##### "
+	}
+	for _, field := range m.Fields {
+		b.WriteString(fmt.Sprintf("%s**%s**：%s \n", prefix, field.Label, field.Value))
+	}
+	if len(atMobiles) > 0 {
+		b.WriteString(fmt.Sprintf("%s**相关人员**：@%s \n", prefix, strings.Join(atMobiles, "@")))
+	}
+	b.WriteString(fmt.Sprintf("%s[%s](%s)", prefix, m.Button, m.URL))
+	return b.String()
+}
+
+func (m *workflowTaskMessage) larkCard() *LarkCard {
+	lc := NewLarkCard()
+	lc.SetConfig(true)
+	lc.SetHeader(workflowTaskLarkColor(m.Event), m.Title, feiShuTagText)
+	for idx, field := range m.Fields {
+		lc.AddI18NElementsZhcnFeild(fmt.Sprintf("**%s**：%s \n", field.Label, field.Value), idx == 0)
+	}
+	lc.AddI18NElementsZhcnAction(m.Button, m.URL)
+	return lc
+}
+
+func (m *workflowTaskMessage) mailBody() (string, error) {
+	buf := new(bytes.Buffer)
+	t, err := template.New("workflow").Parse(workflowTaskMailTemplate)
+	if err != nil {
+		return "", err
+	}
+	if err := t.Execute(buf, m); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func sendWorkflowTaskMail(ctx context.Context, msg *workflowTaskMessage, users []*user.User) error {
+	body, err := msg.mailBody()
+	if err != nil {
+		return fmt.Errorf("render mail err: %s", err)
+	}
+	email, err := systemconfig.New().GetEmailHost()
+	if err != nil {
+		return fmt.Errorf("get email host err: %s", err)
+	}
+	sent := sets.NewString()
+	for _, u := range users {
+		if u.Email == "" || sent.Has(u.Email) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("send mail to %s: %s", u.Email, ctx.Err())
+		}
+		sent.Insert(u.Email)
+		err := mail.SendEmailWithContext(ctx, &mail.EmailParams{
+			From:     email.UserName,
+			To:       u.Email,
+			Subject:  msg.Title,
+			Host:     email.Name,
+			UserName: email.UserName,
+			Password: email.Password,
+			Port:     email.Port,
+			Body:     body,
+		})
+		if err != nil {
+			log.Errorf("send mail to %s err: %s", u.Email, err)
+		}
+	}
+	return nil
+}
+
+func newWorkflowTaskWebhookPayload(task *models.WorkflowTask, event config.Status, approvalStage *models.StageTask, detailURL string) *WorkflowTaskWebhookPayload {
+	payload := &WorkflowTaskWebhookPayload{
+		Event:        event,
+		ProjectName:  task.ProjectName,
+		WorkflowName: task.WorkflowName,
+		TaskID:       task.TaskID,
+		Status:       task.Status,
+		TaskCreator:  task.TaskCreator,
+		CreateTime:   task.CreateTime,
+		StartTime:    task.StartTime,
+		EndTime:      task.EndTime,
+		Error:        task.Error,
+		DetailURL:    detailURL,
+	}
+	for _, stage := range task.Stages {
+		s := &WebhookPayloadStage{Name: stage.Name, Status: stage.Status}
+		for _, job := range stage.Jobs {
+			s.Jobs = append(s.Jobs, &WebhookPayloadJob{Name: job.Name, Type: job.JobType, Status: job.Status})
+		}
+		if approvalStage != nil && stage.Name == approvalStage.Name {
+			if stage.Approval != nil {
+				for _, u := range stage.Approval.ApproveUsers {
+					s.Approvers = append(s.Approvers, u.UserName)
+				}
+			}
+			payload.ApprovalStage = s
+		}
+		payload.Stages = append(payload.Stages, s)
+	}
+	return payload
+}
+
+func (w *Service) sendWorkflowTaskWebhook(ctx context.Context, notify *models.WebhookNotify, payload *WorkflowTaskWebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		"Content-Type":     "application/json",
+		webhookEventHeader: string(payload.Event),
+	}
+	if notify.Secret != "" {
+		headers[webhookSignatureHeader] = "sha256=" + signWebhookBody(body, notify.Secret)
+	}
+
+	c := httpclient.New()
+	proxies, _ := w.proxyColl.List(&mongodb.ProxyArgs{})
+	if len(proxies) != 0 && proxies[0].EnableApplicationProxy {
+		c.SetProxy(proxies[0].GetProxyURL())
+	}
+	_, err = c.Post(notify.Address, httpclient.SetContext(ctx), httpclient.SetHeaders(headers), httpclient.SetBody(body))
+	return err
+}
+
+// signWebhookBody returns the hex encoded HMAC-SHA256 of the body, receivers verify the webhook with it.
+func signWebhookBody(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func getApprovers(stage *models.StageTask) []*user.User {
+	if stage.Approval == nil {
+		return []*user.User{}
+	}
+	var uids []string
+	for _, u := range stage.Approval.ApproveUsers {
+		uids = append(uids, u.UserID)
+		if u.DelegateUserID != "" {
+			uids = append(uids, u.DelegateUserID)
+		}
+	}
+	users, err := listUsers(uids)
+	if err != nil {
+		log.Errorf("failed to list approvers of stage %s, err: %s", stage.Name, err)
+		return []*user.User{}
+	}
+	return users
+}
+
+func listUsers(uids []string) ([]*user.User, error) {
+	if len(uids) == 0 {
+		return []*user.User{}, nil
+	}
+	return user.New().ListUsers(&user.SearchArgs{UIDs: uids})
+}
+
+func workflowTaskStatusText(event config.Status) string {
+	switch event {
+	case config.StatusRunning:
+		return "开始执行"
+	case config.StatusPassed:
+		return "执行成功"
+	case config.StatusCancelled:
+		return "执行取消"
+	case config.StatusTimeout:
+		return "执行超时"
+	case config.StatusReject:
+		return "审批拒绝"
+	case config.StatusApprovalTimeout:
+		return "审批超时"
+	case config.StatusWaitingApprove:
+		return "等待审批"
+	default:
+		return "执行失败"
+	}
+}
+
+func workflowTaskIcon(event config.Status) string {
+	switch event {
+	case config.StatusPassed:
+		return "👍"
+	case config.StatusRunning:
+		return "🚀"
+	case config.StatusWaitingApprove:
+		return "⏳"
+	default:
+		return "⚠️"
+	}
+}
+
+func workflowTaskMarkdownColor(event config.Status) string {
+	switch event {
+	case config.StatusPassed:
+		return markdownColorInfo
+	case config.StatusFailed, config.StatusReject:
+		return markdownColorWarning
+	default:
+		return markdownColorComment
+	}
+}
+
+func workflowTaskLarkColor(event config.Status) string {
+	if event == config.StatusRunning || event == config.StatusWaitingApprove {
+		return feishuHeaderTemplateTurquoise
+	}
+	return getColorTemplateWithStatus(event)
+}
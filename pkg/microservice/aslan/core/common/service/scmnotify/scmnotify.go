@@ -826,7 +826,7 @@ func getCheckStatus(status config.Status) github.CIStatus {
 	switch status {
 	case config.StatusCreated, config.StatusRunning:
 		return github.CIStatusNeutral
-	case config.StatusTimeout:
+	case config.StatusTimeout, config.StatusApprovalTimeout:
 		return github.CIStatusTimeout
 	case config.StatusFailed:
 		return github.CIStatusFailure
@@ -0,0 +1,264 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+// resolveApprovers adds the members of the approve groups to the approve users,
+// and records the delegates of the approvers who have an active delegation.
+func resolveApprovers(approval *commonmodels.Approval) error {
+	existed := sets.NewString()
+	for _, u := range approval.ApproveUsers {
+		existed.Insert(u.UserID)
+	}
+	for _, group := range approval.ApproveGroups {
+		members, err := user.New().ListUsers(&user.SearchArgs{GroupIDs: []string{group.GroupID}})
+		if err != nil {
+			return fmt.Errorf("list members of group %s error: %v", group.GroupName, err)
+		}
+		for _, member := range members {
+			if existed.Has(member.UID) {
+				continue
+			}
+			existed.Insert(member.UID)
+			approval.ApproveUsers = append(approval.ApproveUsers, &commonmodels.User{
+				UserID:    member.UID,
+				UserName:  member.Name,
+				GroupName: group.GroupName,
+			})
+		}
+	}
+
+	delegators := make([]string, 0)
+	for _, u := range approval.ApproveUsers {
+		if u.RejectOrApprove == "" && u.DelegateUserID == "" {
+			delegators = append(delegators, u.UserID)
+		}
+	}
+	if len(delegators) == 0 {
+		return nil
+	}
+	delegations, err := commonrepo.NewApprovalDelegationColl().List(&commonrepo.ListApprovalDelegationOption{
+		DelegatorIDs: delegators,
+		ActiveAt:     time.Now().Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("list approval delegations error: %v", err)
+	}
+	for _, delegation := range delegations {
+		for _, u := range approval.ApproveUsers {
+			if u.UserID == delegation.DelegatorID && u.DelegateUserID == "" {
+				u.DelegateUserID = delegation.DelegateID
+				u.DelegateUserName = delegation.DelegateName
+			}
+		}
+	}
+	return nil
+}
+
+// findApproveUser returns the approver the user approves as, the user may be the approver itself,
+// or an active delegate of an approver who has not approved yet.
+func findApproveUser(approval *commonmodels.Approval, userID string) (*commonmodels.User, error) {
+	for _, u := range approval.ApproveUsers {
+		if u.UserID == userID {
+			return u, nil
+		}
+	}
+	delegators := make([]string, 0)
+	for _, u := range approval.ApproveUsers {
+		if u.RejectOrApprove == "" {
+			delegators = append(delegators, u.UserID)
+		}
+	}
+	if len(delegators) == 0 {
+		return nil, nil
+	}
+	// the delegate recorded when the stage started to wait is not trusted, the delegation may have expired or been
+	// deleted since then, or been created after it, so the delegations are looked up at approval time.
+	now := time.Now().Unix()
+	delegations, err := commonrepo.NewApprovalDelegationColl().List(&commonrepo.ListApprovalDelegationOption{
+		DelegatorIDs: delegators,
+		DelegateID:   userID,
+		ActiveAt:     now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list approval delegations error: %v", err)
+	}
+	return delegatedApprover(approval, delegations, userID, now), nil
+}
+
+// delegatedApprover returns the approver who has not approved yet and whose delegation to the user is active at now.
+func delegatedApprover(approval *commonmodels.Approval, delegations []*commonmodels.ApprovalDelegation, userID string, now int64) *commonmodels.User {
+	for _, delegation := range delegations {
+		if delegation.DelegateID != userID || now < delegation.StartTime || now > delegation.EndTime {
+			continue
+		}
+		for _, u := range approval.ApproveUsers {
+			if u.UserID == delegation.DelegatorID && u.RejectOrApprove == "" {
+				u.DelegateUserID = delegation.DelegateID
+				u.DelegateUserName = delegation.DelegateName
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+// matchAutoApproveRule returns the first auto approve rule the task matches, nil if none matches.
+func matchAutoApproveRule(approval *commonmodels.Approval, task *commonmodels.WorkflowTask) (*commonmodels.AutoApproveRule, error) {
+	for _, rule := range approval.AutoApproveRules {
+		matched, err := autoApproveRuleMatched(rule, task)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+func autoApproveRuleMatched(rule *commonmodels.AutoApproveRule, task *commonmodels.WorkflowTask) (bool, error) {
+	// a rule without any condition never matches, otherwise it would turn off the approval silently.
+	if rule.BranchRegex == "" && len(rule.Creators) == 0 && len(rule.CreatorGroups) == 0 {
+		return false, nil
+	}
+	if rule.BranchRegex != "" {
+		reg, err := regexp.Compile(rule.BranchRegex)
+		if err != nil {
+			return false, fmt.Errorf("invalid branch regex of auto approve rule %s: %v", rule.Name, err)
+		}
+		branches := taskBranches(task)
+		if len(branches) == 0 {
+			return false, nil
+		}
+		for _, branch := range branches {
+			if !reg.MatchString(branch) {
+				return false, nil
+			}
+		}
+	}
+	if len(rule.Creators) > 0 || len(rule.CreatorGroups) > 0 {
+		return creatorMatched(task.TaskCreatorID, rule)
+	}
+	return true, nil
+}
+
+func creatorMatched(creatorID string, rule *commonmodels.AutoApproveRule) (bool, error) {
+	// tasks triggered by webhooks have no creator.
+	if creatorID == "" {
+		return false, nil
+	}
+	for _, u := range rule.Creators {
+		if u.UserID == creatorID {
+			return true, nil
+		}
+	}
+	if len(rule.CreatorGroups) == 0 {
+		return false, nil
+	}
+	groupIDs := make([]string, 0, len(rule.CreatorGroups))
+	for _, group := range rule.CreatorGroups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	members, err := user.New().ListUsers(&user.SearchArgs{GroupIDs: groupIDs})
+	if err != nil {
+		return false, fmt.Errorf("list members of creator groups of auto approve rule %s error: %v", rule.Name, err)
+	}
+	for _, member := range members {
+		if member.UID == creatorID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// taskBranches returns the branches the task works on, from the webhook payload and the repos of the build, test and freestyle jobs.
+func taskBranches(task *commonmodels.WorkflowTask) []string {
+	branches := sets.NewString()
+	if task.WorkflowArgs == nil {
+		return branches.List()
+	}
+	if task.WorkflowArgs.HookPayload != nil && task.WorkflowArgs.HookPayload.Branch != "" {
+		branches.Insert(task.WorkflowArgs.HookPayload.Branch)
+	}
+	for _, stage := range task.WorkflowArgs.Stages {
+		for _, job := range stage.Jobs {
+			if job.Skipped {
+				continue
+			}
+			switch job.JobType {
+			case config.JobZadigBuild:
+				spec := &commonmodels.ZadigBuildJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					continue
+				}
+				for _, build := range spec.ServiceAndBuilds {
+					for _, repo := range build.Repos {
+						if repo.Branch != "" {
+							branches.Insert(repo.Branch)
+						}
+					}
+				}
+			case config.JobZadigTest:
+				spec := &commonmodels.ZadigTestJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					continue
+				}
+				for _, testing := range spec.TestModules {
+					for _, repo := range testing.Repos {
+						if repo.Branch != "" {
+							branches.Insert(repo.Branch)
+						}
+					}
+				}
+			case config.JobFreestyle:
+				spec := &commonmodels.FreestyleJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					continue
+				}
+				for _, s := range spec.Steps {
+					if s.StepType != config.StepGit {
+						continue
+					}
+					gitSpec := &step.StepGitSpec{}
+					if err := commonmodels.IToi(s.Spec, gitSpec); err != nil {
+						continue
+					}
+					for _, repo := range gitSpec.Repos {
+						if repo.Branch != "" {
+							branches.Insert(repo.Branch)
+						}
+					}
+				}
+			}
+		}
+	}
+	return branches.List()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func hookTask(branch, creatorID string) *commonmodels.WorkflowTask {
+	return &commonmodels.WorkflowTask{
+		TaskCreatorID: creatorID,
+		WorkflowArgs: &commonmodels.WorkflowV4{
+			HookPayload: &commonmodels.HookPayload{Branch: branch},
+		},
+	}
+}
+
+var _ = Describe("auto approve rule", func() {
+	DescribeTable("matches the task",
+		func(rule *commonmodels.AutoApproveRule, task *commonmodels.WorkflowTask, expected bool) {
+			matched, err := autoApproveRuleMatched(rule, task)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matched).To(Equal(expected))
+		},
+		Entry("rule without condition", &commonmodels.AutoApproveRule{}, hookTask("hotfix/a", "u1"), false),
+		Entry("hotfix branch", &commonmodels.AutoApproveRule{BranchRegex: "^hotfix/"}, hookTask("hotfix/a", ""), true),
+		Entry("feature branch", &commonmodels.AutoApproveRule{BranchRegex: "^hotfix/"}, hookTask("feature/a", ""), false),
+		Entry("task without branch", &commonmodels.AutoApproveRule{BranchRegex: ".*"}, &commonmodels.WorkflowTask{}, false),
+		Entry("release manager", &commonmodels.AutoApproveRule{Creators: []*commonmodels.User{{UserID: "u1"}}}, hookTask("", "u1"), true),
+		Entry("hotfix branch by other user", &commonmodels.AutoApproveRule{BranchRegex: "^hotfix/", Creators: []*commonmodels.User{{UserID: "u1"}}}, hookTask("hotfix/a", "u2"), false),
+		Entry("webhook task without creator", &commonmodels.AutoApproveRule{Creators: []*commonmodels.User{{UserID: "u1"}}}, hookTask("hotfix/a", ""), false),
+	)
+
+	It("collects the branches of build jobs", func() {
+		task := &commonmodels.WorkflowTask{WorkflowArgs: &commonmodels.WorkflowV4{
+			Stages: []*commonmodels.WorkflowStage{{Jobs: []*commonmodels.Job{{
+				JobType: config.JobZadigBuild,
+				Spec: map[string]interface{}{"service_and_builds": []map[string]interface{}{
+					{"repos": []map[string]interface{}{{"branch": "hotfix/b"}, {"branch": "main"}}},
+				}},
+			}}}},
+		}}
+		Expect(taskBranches(task)).To(Equal([]string{"hotfix/b", "main"}))
+	})
+})
+
+var _ = Describe("approval delegation", func() {
+	newApproval := func() *commonmodels.Approval {
+		return &commonmodels.Approval{ApproveUsers: []*commonmodels.User{
+			{UserID: "u1"},
+			{UserID: "u2", RejectOrApprove: config.Approve},
+			// recorded when the stage started to wait, the delegation may be gone since then.
+			{UserID: "u3", DelegateUserID: "d1"},
+		}}
+	}
+	delegation := func(delegatorID, delegateID string, start, end int64) *commonmodels.ApprovalDelegation {
+		return &commonmodels.ApprovalDelegation{DelegatorID: delegatorID, DelegateID: delegateID, DelegateName: delegateID, StartTime: start, EndTime: end}
+	}
+
+	DescribeTable("finds the approver the delegate approves as",
+		func(delegations []*commonmodels.ApprovalDelegation, userID string, now int64, expected string) {
+			u := delegatedApprover(newApproval(), delegations, userID, now)
+			if expected == "" {
+				Expect(u).To(BeNil())
+				return
+			}
+			Expect(u).NotTo(BeNil())
+			Expect(u.UserID).To(Equal(expected))
+			Expect(u.DelegateUserID).To(Equal(userID))
+		},
+		Entry("active delegation", []*commonmodels.ApprovalDelegation{delegation("u1", "d1", 100, 200)}, "d1", int64(150), "u1"),
+		Entry("delegation not started", []*commonmodels.ApprovalDelegation{delegation("u1", "d1", 100, 200)}, "d1", int64(50), ""),
+		Entry("expired delegation", []*commonmodels.ApprovalDelegation{delegation("u1", "d1", 100, 200)}, "d1", int64(250), ""),
+		Entry("recorded delegate without delegation", nil, "d1", int64(150), ""),
+		Entry("delegation of other user", []*commonmodels.ApprovalDelegation{delegation("u1", "d2", 100, 200)}, "d1", int64(150), ""),
+		Entry("delegator approved already", []*commonmodels.ApprovalDelegation{delegation("u2", "d1", 100, 200)}, "d1", int64(150), ""),
+	)
+})
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
+	"github.com/koderover/zadig/pkg/tool/kube/podexec"
+	"github.com/koderover/zadig/pkg/types/job"
+)
+
+// ResumeBreakpoint resumes the job paused at the breakpoint, or fails the job if abort is true.
+func ResumeBreakpoint(bp *commonmodels.JobBreakpoint, abort bool) error {
+	var (
+		clientset  kubernetes.Interface
+		restConfig *rest.Config
+		err        error
+	)
+	switch bp.ClusterID {
+	case setting.LocalClusterID, "":
+		clientset = krkubeclient.Clientset()
+		restConfig = krkubeclient.RESTConfig()
+	default:
+		_, clientset, restConfig, err = GetK8sClients(config.HubServerAddress(), bp.ClusterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	file := job.BreakpointResumeFile
+	if abort {
+		file = job.BreakpointAbortFile
+	}
+	_, stderr, success, err := podexec.KubeExec(clientset, restConfig, podexec.ExecOptions{
+		Command:       []string{"/bin/sh", "-c", fmt.Sprintf("test -f %s && touch %s", job.BreakpointFile, file)},
+		Namespace:     bp.Namespace,
+		PodName:       bp.PodName,
+		ContainerName: bp.ContainerName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to exec in pod %s/%s: %s", bp.Namespace, bp.PodName, err)
+	}
+	if !success {
+		return fmt.Errorf("job is not paused in pod %s/%s: %s", bp.Namespace, bp.PodName, stderr)
+	}
+	return nil
+}
+
+func hasBreakpoints(spec *commonmodels.JobTaskBuildSpec) bool {
+	return breakpointCount(spec) > 0
+}
+
+func breakpointCount(spec *commonmodels.JobTaskBuildSpec) int64 {
+	var count int64
+	if spec.Properties.KeepPodOnFailure {
+		count++
+	}
+	for _, step := range spec.Steps {
+		if step.BreakpointBefore {
+			count++
+		}
+		if step.BreakpointAfter {
+			count++
+		}
+	}
+	return count
+}
+
+// breakpointDeadlineSeconds is how long the job pod may be paused at most, the pod must not be killed by its active deadline meanwhile.
+func breakpointDeadlineSeconds(spec *commonmodels.JobTaskBuildSpec) int64 {
+	return breakpointCount(spec) * int64(job.BreakpointTimeout.Seconds())
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"fmt"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/util/condition"
+)
+
+const manualEvent = "manual"
+
+// skipJob evaluates the condition of the job, the job is skipped with the reason if the condition is false.
+func skipJob(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx) (bool, string, error) {
+	if job.Condition == "" {
+		return false, "", nil
+	}
+	ok, err := condition.Evaluate(job.Condition, conditionContext(workflowCtx))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to evaluate condition `%s`: %v", job.Condition, err)
+	}
+	if ok {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("condition `%s` is false", job.Condition), nil
+}
+
+// conditionContext collects the variables can be used in job conditions:
+// workflow.params.<name>, workflow.keyvals.<key>, outputs of the finished jobs like workflow.<job>.<output>,
+// and trigger.event, trigger.branch, trigger.ref, trigger.repo, trigger.commit_id, trigger.pr about the webhook event.
+func conditionContext(workflowCtx *commonmodels.WorkflowTaskCtx) *condition.Context {
+	ctx := &condition.Context{Vars: map[string]string{
+		"workflow.name":    workflowCtx.WorkflowName,
+		"workflow.project": workflowCtx.ProjectName,
+		"workflow.task_id": fmt.Sprintf("%d", workflowCtx.TaskID),
+		"workflow.creator": workflowCtx.TaskCreator,
+		"trigger.event":    manualEvent,
+	}}
+	for _, param := range workflowCtx.WorkflowParams {
+		ctx.Vars["workflow.params."+param.Name] = param.Value
+	}
+	for _, kv := range workflowCtx.WorkflowKeyVals {
+		ctx.Vars["workflow.keyvals."+kv.Key] = kv.Value
+	}
+	workflowCtx.GlobalContextEach(func(k, v string) bool {
+		ctx.Vars[k] = v
+		return true
+	})
+
+	if payload := workflowCtx.HookPayload; payload != nil {
+		if payload.EventType != "" {
+			ctx.Vars["trigger.event"] = payload.EventType
+		}
+		ctx.Vars["trigger.branch"] = payload.Branch
+		ctx.Vars["trigger.ref"] = payload.Ref
+		ctx.Vars["trigger.repo"] = payload.Repo
+		ctx.Vars["trigger.commit_id"] = payload.CommitID
+		ctx.Vars["trigger.pr"] = payload.MergeRequestID
+		ctx.ChangedFiles = payload.ChangedFiles
+	}
+	return ctx
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// JobDAG tracks the jobs of a workflow task by their origin names,
+// a job in the DAG starts as soon as all of its upstream jobs passed.
+// The status of a job is written by the goroutine running it, so the DAG keeps its own copy of the finished ones.
+type JobDAG struct {
+	jobs     map[string][]*commonmodels.JobTask
+	enabled  bool
+	mu       sync.RWMutex
+	finished map[*commonmodels.JobTask]config.Status
+}
+
+func NewJobDAG(stages []*commonmodels.StageTask) *JobDAG {
+	dag := &JobDAG{
+		jobs:     make(map[string][]*commonmodels.JobTask),
+		finished: make(map[*commonmodels.JobTask]config.Status),
+	}
+	for _, stage := range stages {
+		for _, job := range stage.Jobs {
+			dag.jobs[job.OriginName] = append(dag.jobs[job.OriginName], job)
+			if len(job.DependsOn) > 0 {
+				dag.enabled = true
+			}
+			// jobs done before the task was retried are not run again.
+			if job.Status == config.StatusPassed || job.Status == config.StatusSkipped {
+				dag.finished[job] = job.Status
+			}
+		}
+	}
+	return dag
+}
+
+// Enabled reports whether the workflow task should be scheduled as a DAG.
+func (d *JobDAG) Enabled() bool {
+	return d != nil && d.enabled
+}
+
+// Finish records the final status of the job, it is called by the goroutine which ran the job.
+func (d *JobDAG) Finish(job *commonmodels.JobTask, status config.Status) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finished[job] = status
+}
+
+// upstreamsDone reports whether all the upstream jobs of the job finished,
+// it returns an error if any of them finished without passing.
+func (d *JobDAG) upstreamsDone(job *commonmodels.JobTask) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	done := true
+	for _, dep := range job.DependsOn {
+		// jobs skipped by webhook are not in the task.
+		for _, upstream := range d.jobs[dep] {
+			status, ok := d.finished[upstream]
+			if !ok {
+				done = false
+				continue
+			}
+			if status != config.StatusPassed && status != config.StatusSkipped {
+				return true, fmt.Errorf("upstream job %s is %s", upstream.Name, status)
+			}
+		}
+	}
+	return done, nil
+}
+
+// WaitUpstreams blocks until all the upstream jobs of the job passed.
+func (d *JobDAG) WaitUpstreams(ctx context.Context, job *commonmodels.JobTask) error {
+	for {
+		if done, err := d.upstreamsDone(job); done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("workflow was canceled")
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// WaitStage blocks until any job of the stage is able to start,
+// it returns an error if none of them ever will.
+func (d *JobDAG) WaitStage(ctx context.Context, stage *commonmodels.StageTask) error {
+	for {
+		var upstreamErr error
+		waiting := false
+		for _, job := range stage.Jobs {
+			done, err := d.upstreamsDone(job)
+			switch {
+			case !done:
+				waiting = true
+			case err == nil:
+				return nil
+			default:
+				upstreamErr = err
+			}
+		}
+		if !waiting {
+			return upstreamErr
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("workflow was canceled")
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -26,11 +26,14 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/envsnapshot"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/util/rand"
 )
 
@@ -38,6 +41,12 @@ type JobCtl interface {
 	Run(ctx context.Context)
 }
 
+const (
+	// unit is second, give the cleanup of the last attempt enough time to finish.
+	defaultRetryBackoff = 10
+	maxRetryBackoff     = 10 * time.Minute
+)
+
 func runJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger, ack func()) {
 	// render global variables for every job.
 	workflowCtx.GlobalContextEach(func(k, v string) bool {
@@ -46,16 +55,75 @@ func runJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonm
 		json.Unmarshal([]byte(replacedString), &job)
 		return true
 	})
+	skip, reason, err := skipJob(job, workflowCtx)
+	if err != nil || skip {
+		job.Status = config.StatusSkipped
+		job.SkipReason = reason
+		if err != nil {
+			job.Status = config.StatusFailed
+			job.Error = err.Error()
+		}
+		job.StartTime = time.Now().Unix()
+		job.EndTime = job.StartTime
+		logger.Infof("job %s not run, status: %s, reason: %s%s", job.Name, job.Status, reason, job.Error)
+		ack()
+		return
+	}
 	job.Status = config.StatusRunning
 	job.StartTime = time.Now().Unix()
 	ack()
 
+	ctx, span := tracing.Start(ctx, "job "+job.Name, attribute.String("zadig.job_type", job.JobType))
 	logger.Infof("start job: %s,status: %s", job.Name, job.Status)
 	defer func() {
 		job.EndTime = time.Now().Unix()
 		logger.Infof("finish job: %s,status: %s", job.Name, job.Status)
 		ack()
+		observeJob(job)
+		EndSpan(span, job.Status, job.Error)
 	}()
+
+	for attempt := 1; ; attempt++ {
+		jobAttempt := &commonmodels.JobAttempt{
+			Attempt:   attempt,
+			StartTime: time.Now().Unix(),
+			LogFile:   job.Name,
+		}
+		job.Attempts = append(job.Attempts, jobAttempt)
+		job.Status = config.StatusRunning
+		job.Error = ""
+
+		runJobAttempt(ctx, job, workflowCtx, logger, ack)
+
+		jobAttempt.Status = job.Status
+		jobAttempt.Error = job.Error
+		jobAttempt.EndTime = time.Now().Unix()
+		if !shouldRetry(ctx, job, attempt) {
+			return
+		}
+
+		// the log of the next attempt will be saved to the same file, keep a copy of this one.
+		logFile, err := archiveAttemptLog(workflowCtx.WorkflowName, job.Name, workflowCtx.TaskID, attempt)
+		if err != nil {
+			logger.Errorf("archive log of job %s attempt %d error: %v", job.Name, attempt, err)
+		} else {
+			jobAttempt.LogFile = logFile
+		}
+		job.Status = config.StatusRunning
+		ack()
+
+		backoff := retryBackoff(job.RetryBackoff, attempt)
+		logger.Infof("job %s failed at attempt %d, retry after %s", job.Name, attempt, backoff)
+		select {
+		case <-ctx.Done():
+			job.Status = config.StatusCancelled
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func runJobAttempt(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger, ack func()) {
 	var jobCtl JobCtl
 	switch job.JobType {
 	case string(config.JobZadigDeploy):
@@ -64,20 +132,125 @@ func runJob(ctx context.Context, job *commonmodels.JobTask, workflowCtx *commonm
 		jobCtl = NewHelmDeployJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobCustomDeploy):
 		jobCtl = NewCustomDeployJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobCanaryDeploy):
+		jobCtl = NewCanaryDeployJobCtl(job, workflowCtx, ack, logger)
 	case string(config.JobPlugin):
 		jobCtl = NewPluginsJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobZadigTest):
+		jobCtl = NewTestingJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobZadigScanning):
+		jobCtl = NewScanningJobCtl(job, workflowCtx, ack, logger)
+	case string(config.JobTriggerWorkflow):
+		jobCtl = NewTriggerWorkflowJobCtl(job, workflowCtx, ack, logger)
 	default:
 		jobCtl = NewFreestyleJobCtl(job, workflowCtx, ack, logger)
 	}
 
 	jobCtl.Run(ctx)
+	if job.Status == config.StatusPassed {
+		snapshotDeployedEnv(job, workflowCtx, logger)
+	}
+}
+
+// snapshotDeployedEnv records the env once a deploy job updated it.
+func snapshotDeployedEnv(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) {
+	var envName string
+	switch job.JobType {
+	case string(config.JobZadigDeploy):
+		spec := &commonmodels.JobTaskDeploySpec{}
+		if err := commonmodels.IToi(job.Spec, spec); err != nil {
+			logger.Errorf("decode job %s spec error: %v", job.Name, err)
+			return
+		}
+		envName = spec.Env
+	case string(config.JobZadigHelmDeploy):
+		spec := &commonmodels.JobTaskHelmDeploySpec{}
+		if err := commonmodels.IToi(job.Spec, spec); err != nil {
+			logger.Errorf("decode job %s spec error: %v", job.Name, err)
+			return
+		}
+		envName = spec.Env
+	default:
+		return
+	}
+	if _, err := envsnapshot.Create(workflowCtx.ProjectName, envName, envsnapshot.SourceWorkflow, workflowCtx.TaskCreator, logger); err != nil {
+		logger.Errorf("failed to create snapshot of env %s after job %s: %v", envName, job.Name, err)
+	}
+}
+
+// shouldRetry only retries failed or timeout jobs, a cancelled job never retries.
+func shouldRetry(ctx context.Context, job *commonmodels.JobTask, attempt int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if job.Status != config.StatusFailed && job.Status != config.StatusTimeout {
+		return false
+	}
+	return int64(attempt) <= job.Retry
+}
+
+func retryBackoff(base int64, attempt int) time.Duration {
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	backoff := time.Duration(base) * time.Second
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
 }
 
-func RunJobs(ctx context.Context, jobs []*commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, concurrency int, logger *zap.SugaredLogger, ack func()) {
-	jobPool := NewPool(ctx, jobs, workflowCtx, concurrency, logger, ack)
+func RunJobs(ctx context.Context, jobs []*commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, dag *JobDAG, concurrency int, logger *zap.SugaredLogger, ack func()) {
+	// jobs already done before the task was retried keep their results.
+	todoJobs := []*commonmodels.JobTask{}
+	for _, job := range jobs {
+		if job.Status == config.StatusPassed || job.Status == config.StatusSkipped {
+			continue
+		}
+		todoJobs = append(todoJobs, job)
+	}
+	if dag.Enabled() {
+		runJobsInDAG(ctx, todoJobs, workflowCtx, dag, concurrency, logger, ack)
+		return
+	}
+	jobPool := NewPool(ctx, todoJobs, workflowCtx, concurrency, logger, ack)
 	jobPool.Run()
 }
 
+// runJobsInDAG starts every job once its upstream jobs passed, with at most concurrency jobs running at the same time.
+func runJobsInDAG(ctx context.Context, jobs []*commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, dag *JobDAG, concurrency int, logger *zap.SugaredLogger, ack func()) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	workers := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *commonmodels.JobTask) {
+			defer wg.Done()
+			if err := dag.WaitUpstreams(ctx, job); err != nil {
+				job.Status = config.StatusNotRun
+				if ctx.Err() != nil {
+					job.Status = config.StatusCancelled
+				}
+				job.Error = err.Error()
+				logger.Infof("job %s not run: %v", job.Name, err)
+				dag.Finish(job, job.Status)
+				ack()
+				return
+			}
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			runJob(ctx, job, workflowCtx, logger, ack)
+			dag.Finish(job, job.Status)
+		}(job)
+	}
+	wg.Wait()
+}
+
 // Pool is a worker group that runs a number of tasks at a
 // configured concurrency.
 type Pool struct {
@@ -0,0 +1,643 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	istionetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	crClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/updater"
+)
+
+const (
+	// pods of the new version carry the label, canary or green.
+	deployTrackLabel  = "zadig-deploy-track"
+	deployTrackCanary = "canary"
+	deployTrackGreen  = "green"
+	// pods of the new version are selected by the track and the name of the new version deployment.
+	deployNameLabel = "zadig-deploy-name"
+	// the same name as the virtual services managed by the share env.
+	shareEnvVirtualServicePrefix = "zadig"
+
+	healthCheckTimeout = 10 * time.Second
+)
+
+// CanaryDeployJobCtl deploys the new image as a canary or green deployment next to the stable one,
+// shifts the traffic to it step by step, and promotes the stable deployment to the new image at last.
+// Everything is rolled back to the stable deployment once a step failed.
+type CanaryDeployJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	kubeClient  crClient.Client
+	istioClient versionedclient.Interface
+	jobTaskSpec *commonmodels.JobTaskCanaryDeploySpec
+	ack         func()
+
+	stable   *appsv1.Deployment
+	service  *corev1.Service
+	replicas int
+	track    string
+	// the virtual service the traffic is shifted by, and the routes of it before the deploy.
+	virtualService *istionetworkingv1alpha3.VirtualService
+	originRoutes   []*networkingv1alpha3.HTTPRoute
+	promoted       bool
+}
+
+func NewCanaryDeployJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *CanaryDeployJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskCanaryDeploySpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	track := deployTrackCanary
+	if jobTaskSpec.Strategy == config.DeployStrategyBlueGreen {
+		track = deployTrackGreen
+	}
+	return &CanaryDeployJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+		track:       track,
+	}
+}
+
+func (c *CanaryDeployJobCtl) Run(ctx context.Context) {
+	defer func() {
+		c.job.Spec = c.jobTaskSpec
+	}()
+
+	timeout := time.Duration(c.timeout()) * time.Second
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := c.run(runCtx); err != nil {
+		c.logger.Errorf("canary deploy job %s failed: %v", c.job.Name, err)
+		c.job.Error = err.Error()
+		switch {
+		case ctx.Err() != nil:
+			c.job.Status = config.StatusCancelled
+		case runCtx.Err() != nil:
+			c.job.Status = config.StatusTimeout
+		default:
+			c.job.Status = config.StatusFailed
+		}
+		if c.stable != nil {
+			if err := c.rollback(); err != nil {
+				c.logger.Errorf("failed to roll back canary deploy job %s: %v", c.job.Name, err)
+				c.job.Error = fmt.Sprintf("%s, rollback failed: %v", c.job.Error, err)
+			}
+		}
+		return
+	}
+	c.job.Status = config.StatusPassed
+}
+
+func (c *CanaryDeployJobCtl) run(ctx context.Context) error {
+	if err := c.init(); err != nil {
+		return err
+	}
+	if err := c.deployNewVersion(ctx); err != nil {
+		return err
+	}
+	for _, step := range c.trafficSteps() {
+		c.logger.Infof("shift %d%% traffic of %s to the new version", step.Weight, c.jobTaskSpec.WorkloadName)
+		if err := c.setWeight(ctx, step.Weight); err != nil {
+			return err
+		}
+		c.jobTaskSpec.CurrentWeight = step.Weight
+		c.job.Spec = c.jobTaskSpec
+		c.ack()
+		if err := c.analyze(ctx, time.Duration(step.Pause)*time.Second); err != nil {
+			return fmt.Errorf("check of the new version failed at %d%% traffic: %v", step.Weight, err)
+		}
+	}
+	return c.promote(ctx)
+}
+
+func (c *CanaryDeployJobCtl) init() error {
+	var err error
+	c.kubeClient, err = kubeclient.GetKubeClient(config.HubServerAddress(), c.jobTaskSpec.ClusterID)
+	if err != nil {
+		return fmt.Errorf("can't init k8s client: %v", err)
+	}
+	if c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio {
+		restConfig, err := kubeclient.GetRESTConfig(config.HubServerAddress(), c.jobTaskSpec.ClusterID)
+		if err != nil {
+			return fmt.Errorf("failed to get rest config: %v", err)
+		}
+		c.istioClient, err = versionedclient.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("failed to new istio client: %v", err)
+		}
+	}
+
+	ns := c.jobTaskSpec.Namespace
+	service, found, err := getter.GetService(ns, c.jobTaskSpec.K8sServiceName, c.kubeClient)
+	if err != nil || !found {
+		return fmt.Errorf("failed to get service %s/%s: %v", ns, c.jobTaskSpec.K8sServiceName, err)
+	}
+	c.service = service
+	stable, found, err := getter.GetDeployment(ns, c.jobTaskSpec.WorkloadName, c.kubeClient)
+	if err != nil || !found {
+		return fmt.Errorf("failed to get deployment %s/%s: %v", ns, c.jobTaskSpec.WorkloadName, err)
+	}
+	containerFound := false
+	for _, container := range stable.Spec.Template.Spec.Containers {
+		if container.Name == c.jobTaskSpec.ContainerName {
+			c.jobTaskSpec.OriginImage = container.Image
+			containerFound = true
+			break
+		}
+	}
+	if !containerFound {
+		return fmt.Errorf("container %s is not found in deployment %s/%s", c.jobTaskSpec.ContainerName, ns, c.jobTaskSpec.WorkloadName)
+	}
+	c.replicas = 1
+	if stable.Spec.Replicas != nil && *stable.Spec.Replicas > 0 {
+		c.replicas = int(*stable.Spec.Replicas)
+	}
+	c.stable = stable
+	return nil
+}
+
+func (c *CanaryDeployJobCtl) newVersionName() string {
+	return fmt.Sprintf("%s-zadig-%s", c.jobTaskSpec.WorkloadName, c.track)
+}
+
+func (c *CanaryDeployJobCtl) newVersionSelector() map[string]string {
+	return map[string]string{deployTrackLabel: c.track, deployNameLabel: c.newVersionName()}
+}
+
+// newVersionLabels returns the labels of the new version pods. Canary pods behind a k8s service are selected by
+// the service together with the stable pods. Green pods and the pods behind istio don't carry the labels of the
+// service selector, they are selected by the service only after the switch, or by the service of the new version.
+func (c *CanaryDeployJobCtl) newVersionLabels() map[string]string {
+	isolated := c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio || c.jobTaskSpec.Strategy == config.DeployStrategyBlueGreen
+	labels := map[string]string{}
+	for k, v := range c.stable.Spec.Template.Labels {
+		if _, ok := c.service.Spec.Selector[k]; ok && isolated {
+			continue
+		}
+		labels[k] = v
+	}
+	for k, v := range c.newVersionSelector() {
+		labels[k] = v
+	}
+	labels[setting.ProductLabel] = c.stable.Labels[setting.ProductLabel]
+	return labels
+}
+
+func (c *CanaryDeployJobCtl) deployNewVersion(ctx context.Context) error {
+	podLabels := c.newVersionLabels()
+	selector := c.newVersionSelector()
+
+	replicas := int32(c.replicas)
+	if c.jobTaskSpec.Strategy == config.DeployStrategyCanary && c.jobTaskSpec.TrafficRouter != config.TrafficRouterIstio {
+		// the traffic is weighted by the replicas, the canary is scaled at every step.
+		replicas = 0
+	}
+	template := c.stable.Spec.Template.DeepCopy()
+	template.Labels = podLabels
+	for i, container := range template.Spec.Containers {
+		if container.Name == c.jobTaskSpec.ContainerName {
+			template.Spec.Containers[i].Image = c.jobTaskSpec.Image
+		}
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.newVersionName(),
+			Namespace: c.stable.Namespace,
+			Labels:    map[string]string{deployTrackLabel: c.track},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: *template,
+		},
+	}
+	if err := updater.CreateOrPatchDeployment(deployment, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to create deployment %s: %v", deployment.Name, err)
+	}
+
+	if c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.newVersionName(),
+				Namespace: c.service.Namespace,
+				Labels:    map[string]string{deployTrackLabel: c.track},
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: selector,
+				Ports:    c.service.Spec.Ports,
+			},
+		}
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+		if err := updater.CreateOrPatchService(service, c.kubeClient); err != nil {
+			return fmt.Errorf("failed to create service %s: %v", service.Name, err)
+		}
+	}
+	if replicas == 0 {
+		return nil
+	}
+	return c.waitDeploymentReady(ctx, c.newVersionName())
+}
+
+// trafficSteps returns the weights the traffic is shifted by, the last one is always 100.
+func (c *CanaryDeployJobCtl) trafficSteps() []*commonmodels.TrafficStep {
+	if c.jobTaskSpec.Strategy == config.DeployStrategyBlueGreen {
+		pause := int64(0)
+		if len(c.jobTaskSpec.Steps) > 0 {
+			pause = c.jobTaskSpec.Steps[len(c.jobTaskSpec.Steps)-1].Pause
+		}
+		return []*commonmodels.TrafficStep{{Weight: 100, Pause: pause}}
+	}
+	steps := []*commonmodels.TrafficStep{}
+	for _, step := range c.jobTaskSpec.Steps {
+		if step.Weight <= 0 || step.Weight > 100 {
+			continue
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 || steps[len(steps)-1].Weight != 100 {
+		steps = append(steps, &commonmodels.TrafficStep{Weight: 100})
+	}
+	return steps
+}
+
+func (c *CanaryDeployJobCtl) setWeight(ctx context.Context, weight int) error {
+	switch {
+	case c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio:
+		return c.setVirtualServiceWeight(ctx, weight)
+	case c.jobTaskSpec.Strategy == config.DeployStrategyBlueGreen:
+		return c.switchServiceSelector(weight == 100)
+	default:
+		return c.setReplicasWeight(ctx, weight)
+	}
+}
+
+// replicasOfWeight returns the replicas of the canary and the stable deployment for the weight. The canary has one
+// replica at least, and the stable deployment keeps one replica until the traffic is fully shifted, so the weight
+// of a deployment with few replicas is rounded up to what the replicas can represent.
+func replicasOfWeight(replicas, weight int) (int, int) {
+	if weight >= 100 {
+		return replicas, 0
+	}
+	canary := int(math.Ceil(float64(replicas*weight) / 100))
+	if canary < 1 {
+		canary = 1
+	}
+	stable := replicas - canary
+	if stable < 1 {
+		stable = 1
+	}
+	return canary, stable
+}
+
+// setReplicasWeight scales the canary and the stable deployment, so the service sends the weight of the traffic to the canary.
+func (c *CanaryDeployJobCtl) setReplicasWeight(ctx context.Context, weight int) error {
+	canaryReplicas, stableReplicas := replicasOfWeight(c.replicas, weight)
+	if actual := canaryReplicas * 100 / (canaryReplicas + stableReplicas); actual != weight {
+		c.logger.Warnf("%d%% traffic can't be represented by %d replicas, %d%% traffic is shifted to the canary", weight, c.replicas, actual)
+	}
+	if err := updater.ScaleDeployment(c.stable.Namespace, c.newVersionName(), canaryReplicas, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %v", c.newVersionName(), err)
+	}
+	if err := c.waitDeploymentReady(ctx, c.newVersionName()); err != nil {
+		return err
+	}
+	if err := updater.ScaleDeployment(c.stable.Namespace, c.stable.Name, stableReplicas, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %v", c.stable.Name, err)
+	}
+	return nil
+}
+
+// switchServiceSelector makes the service select the green pods only, or the stable pods again.
+func (c *CanaryDeployJobCtl) switchServiceSelector(green bool) error {
+	selector := map[string]interface{}{}
+	for k, v := range c.service.Spec.Selector {
+		selector[k] = v
+		if green {
+			selector[k] = nil
+		}
+	}
+	for k, v := range c.newVersionSelector() {
+		selector[k] = nil
+		if green {
+			selector[k] = v
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"selector": selector}})
+	if err != nil {
+		return err
+	}
+	if err := updater.PatchService(c.service.Namespace, c.service.Name, patch, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to patch the selector of service %s: %v", c.service.Name, err)
+	}
+	return nil
+}
+
+func (c *CanaryDeployJobCtl) setVirtualServiceWeight(ctx context.Context, weight int) error {
+	vsClient := c.istioClient.NetworkingV1alpha3().VirtualServices(c.service.Namespace)
+	if c.virtualService == nil {
+		// reuse the virtual service of the share env if there is one.
+		vsName := fmt.Sprintf("%s-%s", shareEnvVirtualServicePrefix, c.service.Name)
+		vs, err := vsClient.Get(ctx, vsName, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			c.originRoutes = vs.Spec.Http
+		case apierrors.IsNotFound(err):
+			vs = &istionetworkingv1alpha3.VirtualService{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s", c.service.Name, "zadig-canary"),
+					Namespace: c.service.Namespace,
+					Labels:    map[string]string{deployTrackLabel: c.track},
+				},
+				Spec: networkingv1alpha3.VirtualService{Hosts: []string{c.service.Name}},
+			}
+			if vs, err = vsClient.Create(ctx, vs, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create virtual service for %s: %v", c.service.Name, err)
+			}
+		default:
+			return fmt.Errorf("failed to get virtual service %s: %v", vsName, err)
+		}
+		c.virtualService = vs
+	}
+
+	// the routes matching the headers of the share env are kept, only the default route is weighted.
+	routes := []*networkingv1alpha3.HTTPRoute{}
+	for _, route := range c.originRoutes {
+		if len(route.Match) > 0 {
+			routes = append(routes, route)
+		}
+	}
+	routes = append(routes, &networkingv1alpha3.HTTPRoute{
+		Route: []*networkingv1alpha3.HTTPRouteDestination{
+			{
+				Destination: &networkingv1alpha3.Destination{Host: fmt.Sprintf("%s.%s.svc.cluster.local", c.service.Name, c.service.Namespace)},
+				Weight:      int32(100 - weight),
+			},
+			{
+				Destination: &networkingv1alpha3.Destination{Host: fmt.Sprintf("%s.%s.svc.cluster.local", c.newVersionName(), c.service.Namespace)},
+				Weight:      int32(weight),
+			},
+		},
+	})
+	c.virtualService.Spec.Http = routes
+	vs, err := vsClient.Update(ctx, c.virtualService, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update virtual service %s: %v", c.virtualService.Name, err)
+	}
+	c.virtualService = vs
+	return nil
+}
+
+// restoreTraffic sends all the traffic to the stable deployment.
+func (c *CanaryDeployJobCtl) restoreTraffic(ctx context.Context) error {
+	switch {
+	case c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio:
+		if c.virtualService == nil {
+			return nil
+		}
+		vsClient := c.istioClient.NetworkingV1alpha3().VirtualServices(c.service.Namespace)
+		if c.originRoutes == nil {
+			if err := vsClient.Delete(ctx, c.virtualService.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete virtual service %s: %v", c.virtualService.Name, err)
+			}
+			return nil
+		}
+		c.virtualService.Spec.Http = c.originRoutes
+		if _, err := vsClient.Update(ctx, c.virtualService, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to restore virtual service %s: %v", c.virtualService.Name, err)
+		}
+		return nil
+	case c.jobTaskSpec.Strategy == config.DeployStrategyBlueGreen:
+		return c.switchServiceSelector(false)
+	default:
+		return nil
+	}
+}
+
+// analyze checks the new version till the pause is over, it fails at the first failed check.
+func (c *CanaryDeployJobCtl) analyze(ctx context.Context, pause time.Duration) error {
+	deadline := time.Now().Add(pause)
+	for {
+		if err := c.checkNewVersion(); err != nil {
+			return err
+		}
+		if !time.Now().Before(deadline) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (c *CanaryDeployJobCtl) checkNewVersion() error {
+	d, found, err := getter.GetDeployment(c.stable.Namespace, c.newVersionName(), c.kubeClient)
+	if err != nil || !found {
+		return fmt.Errorf("failed to get deployment %s: %v", c.newVersionName(), err)
+	}
+	if !deploymentRolledOut(d) {
+		return fmt.Errorf("deployment %s is not ready", d.Name)
+	}
+	return checkDeployHealth(c.jobTaskSpec.HealthCheck)
+}
+
+// promote updates the image of the stable deployment, and cleans up the new version once the stable one is ready.
+func (c *CanaryDeployJobCtl) promote(ctx context.Context) error {
+	c.promoted = true
+	ns := c.stable.Namespace
+	if err := updater.UpdateDeploymentImage(ns, c.stable.Name, c.jobTaskSpec.ContainerName, c.jobTaskSpec.Image, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to update the image of deployment %s: %v", c.stable.Name, err)
+	}
+	if err := updater.ScaleDeployment(ns, c.stable.Name, c.replicas, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %v", c.stable.Name, err)
+	}
+	if err := c.waitDeploymentReady(ctx, c.stable.Name); err != nil {
+		return err
+	}
+	if err := c.restoreTraffic(ctx); err != nil {
+		return err
+	}
+	c.jobTaskSpec.CurrentWeight = 0
+	c.cleanup()
+	return nil
+}
+
+// rollback restores the traffic and the image of the stable deployment, and deletes the new version.
+func (c *CanaryDeployJobCtl) rollback() error {
+	ctx := context.Background()
+	c.logger.Infof("roll back deployment %s/%s to image %s", c.stable.Namespace, c.stable.Name, c.jobTaskSpec.OriginImage)
+	if c.promoted {
+		if err := updater.UpdateDeploymentImage(c.stable.Namespace, c.stable.Name, c.jobTaskSpec.ContainerName, c.jobTaskSpec.OriginImage, c.kubeClient); err != nil {
+			return fmt.Errorf("failed to restore the image of deployment %s: %v", c.stable.Name, err)
+		}
+	}
+	if err := updater.ScaleDeployment(c.stable.Namespace, c.stable.Name, c.replicas, c.kubeClient); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %v", c.stable.Name, err)
+	}
+	if err := c.restoreTraffic(ctx); err != nil {
+		return err
+	}
+	c.cleanup()
+	c.jobTaskSpec.CurrentWeight = 0
+	c.jobTaskSpec.RolledBack = true
+	return nil
+}
+
+func (c *CanaryDeployJobCtl) cleanup() {
+	if err := updater.DeleteDeployment(c.stable.Namespace, c.newVersionName(), c.kubeClient); err != nil {
+		c.logger.Errorf("failed to delete deployment %s: %v", c.newVersionName(), err)
+	}
+	if c.jobTaskSpec.TrafficRouter == config.TrafficRouterIstio {
+		if err := updater.DeleteService(c.stable.Namespace, c.newVersionName(), c.kubeClient); err != nil {
+			c.logger.Errorf("failed to delete service %s: %v", c.newVersionName(), err)
+		}
+	}
+}
+
+func (c *CanaryDeployJobCtl) waitDeploymentReady(ctx context.Context, name string) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("deployment %s is not ready: %v", name, ctx.Err())
+		case <-time.After(2 * time.Second):
+		}
+		d, found, err := getter.GetDeployment(c.stable.Namespace, name, c.kubeClient)
+		if err != nil || !found {
+			c.logger.Errorf("failed to check deployment ready status %s/%s: %v", c.stable.Namespace, name, err)
+			continue
+		}
+		if deploymentRolledOut(d) {
+			return nil
+		}
+	}
+}
+
+// deploymentRolledOut returns true once the controller has observed the latest spec, and all the desired
+// replicas are updated and available.
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == desired &&
+		d.Status.AvailableReplicas == desired
+}
+
+// timeout returns the timeout in seconds, the timeout in the spec is in minutes.
+func (c *CanaryDeployJobCtl) timeout() int64 {
+	if c.jobTaskSpec.Timeout == 0 {
+		return setting.DeployTimeout
+	}
+	return c.jobTaskSpec.Timeout * 60
+}
+
+type prometheusQueryResp struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// checkDeployHealth runs the http and the metric check of the new version if they are set.
+func checkDeployHealth(check *commonmodels.DeployHealthCheck) error {
+	if check == nil {
+		return nil
+	}
+	client := &http.Client{Timeout: healthCheckTimeout}
+	if check.URL != "" {
+		expectedCode := check.ExpectedCode
+		if expectedCode == 0 {
+			expectedCode = http.StatusOK
+		}
+		resp, err := client.Get(check.URL)
+		if err != nil {
+			return fmt.Errorf("health check %s failed: %v", check.URL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expectedCode {
+			return fmt.Errorf("health check %s responded with code %d, expected %d", check.URL, resp.StatusCode, expectedCode)
+		}
+	}
+	if check.PrometheusURL != "" && check.MetricQuery != "" {
+		queryURL := fmt.Sprintf("%s/api/v1/query?query=%s", check.PrometheusURL, url.QueryEscape(check.MetricQuery))
+		resp, err := client.Get(queryURL)
+		if err != nil {
+			return fmt.Errorf("metric query %s failed: %v", check.MetricQuery, err)
+		}
+		defer resp.Body.Close()
+		result := &prometheusQueryResp{}
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode the result of metric query %s: %v", check.MetricQuery, err)
+		}
+		value, err := metricValue(result)
+		if err != nil {
+			return fmt.Errorf("metric query %s: %v", check.MetricQuery, err)
+		}
+		if value > check.MetricThreshold {
+			return fmt.Errorf("metric %s is %v, more than the threshold %v", check.MetricQuery, value, check.MetricThreshold)
+		}
+	}
+	return nil
+}
+
+// metricValue returns the value of the first sample, a vector value is like [ <unix_time>, "<sample_value>" ].
+func metricValue(result *prometheusQueryResp) (float64, error) {
+	if result.Status != "success" {
+		return 0, fmt.Errorf("query status %s", result.Status)
+	}
+	if len(result.Data.Result) == 0 || len(result.Data.Result[0].Value) != 2 {
+		return 0, fmt.Errorf("no data")
+	}
+	sample, ok := result.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample %v", result.Data.Result[0].Value[1])
+	}
+	return strconv.ParseFloat(sample, 64)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jobcontroller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func canaryCtl(strategy config.DeployStrategy, router config.TrafficRouter, steps ...*commonmodels.TrafficStep) *CanaryDeployJobCtl {
+	track := deployTrackCanary
+	if strategy == config.DeployStrategyBlueGreen {
+		track = deployTrackGreen
+	}
+	return &CanaryDeployJobCtl{
+		track: track,
+		jobTaskSpec: &commonmodels.JobTaskCanaryDeploySpec{
+			WorkloadName:  "api",
+			Strategy:      strategy,
+			TrafficRouter: router,
+			Steps:         steps,
+		},
+		stable: &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{setting.ProductLabel: "demo"}},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "api", "version": "v1"}},
+			}},
+		},
+		service: &corev1.Service{Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "api"}}},
+	}
+}
+
+func weights(steps []*commonmodels.TrafficStep) []int {
+	ws := []int{}
+	for _, step := range steps {
+		ws = append(ws, step.Weight)
+	}
+	return ws
+}
+
+func deployment(replicas *int32, generation, observed int64, updated, available int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: generation},
+		Spec:       appsv1.DeploymentSpec{Replicas: replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: observed,
+			UpdatedReplicas:    updated,
+			AvailableReplicas:  available,
+		},
+	}
+}
+
+func sample(status string, value ...interface{}) *prometheusQueryResp {
+	result := &prometheusQueryResp{Status: status}
+	if len(value) > 0 {
+		result.Data.Result = append(result.Data.Result, struct {
+			Value []interface{} `json:"value"`
+		}{Value: value})
+	}
+	return result
+}
+
+var _ = Describe("canary deploy", func() {
+	DescribeTable("scales the replicas by the weight",
+		func(replicas, weight, canary, stable int) {
+			c, s := replicasOfWeight(replicas, weight)
+			Expect(c).To(Equal(canary))
+			Expect(s).To(Equal(stable))
+		},
+		Entry("one replica keeps the stable one", 1, 10, 1, 1),
+		Entry("one replica at the last step", 1, 100, 1, 0),
+		Entry("small weight rounds up to one canary", 4, 10, 1, 3),
+		Entry("half of the replicas", 4, 50, 2, 2),
+		Entry("large weight keeps the stable one", 4, 90, 4, 1),
+		Entry("exact weight", 10, 30, 3, 7),
+		Entry("all the replicas at the last step", 10, 100, 10, 0),
+	)
+
+	DescribeTable("shifts the traffic by the steps",
+		func(c *CanaryDeployJobCtl, expected []int) {
+			Expect(weights(c.trafficSteps())).To(Equal(expected))
+		},
+		Entry("no steps", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterService), []int{100}),
+		Entry("appends the last step", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterService,
+			&commonmodels.TrafficStep{Weight: 10}, &commonmodels.TrafficStep{Weight: 50}), []int{10, 50, 100}),
+		Entry("skips invalid weights", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterIstio,
+			&commonmodels.TrafficStep{Weight: 0}, &commonmodels.TrafficStep{Weight: 20}, &commonmodels.TrafficStep{Weight: 120}), []int{20, 100}),
+		Entry("keeps the last step", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterService,
+			&commonmodels.TrafficStep{Weight: 30}, &commonmodels.TrafficStep{Weight: 100}), []int{30, 100}),
+		Entry("blue green switches at once", canaryCtl(config.DeployStrategyBlueGreen, config.TrafficRouterService,
+			&commonmodels.TrafficStep{Weight: 10}, &commonmodels.TrafficStep{Weight: 50}), []int{100}),
+	)
+
+	It("pauses the blue green switch by the last step", func() {
+		c := canaryCtl(config.DeployStrategyBlueGreen, config.TrafficRouterService,
+			&commonmodels.TrafficStep{Weight: 10, Pause: 30}, &commonmodels.TrafficStep{Weight: 50, Pause: 60})
+		Expect(c.trafficSteps()[0].Pause).To(Equal(int64(60)))
+	})
+
+	DescribeTable("labels the new version",
+		func(c *CanaryDeployJobCtl, expected map[string]string) {
+			Expect(c.newVersionLabels()).To(Equal(expected))
+		},
+		Entry("canary behind the service", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterService), map[string]string{
+			"app": "api", "version": "v1", deployTrackLabel: deployTrackCanary, deployNameLabel: "api-zadig-canary", setting.ProductLabel: "demo",
+		}),
+		Entry("canary behind istio", canaryCtl(config.DeployStrategyCanary, config.TrafficRouterIstio), map[string]string{
+			"version": "v1", deployTrackLabel: deployTrackCanary, deployNameLabel: "api-zadig-canary", setting.ProductLabel: "demo",
+		}),
+		Entry("green", canaryCtl(config.DeployStrategyBlueGreen, config.TrafficRouterService), map[string]string{
+			"version": "v1", deployTrackLabel: deployTrackGreen, deployNameLabel: "api-zadig-green", setting.ProductLabel: "demo",
+		}),
+	)
+
+	DescribeTable("checks the rollout of the deployment",
+		func(d *appsv1.Deployment, expected bool) {
+			Expect(deploymentRolledOut(d)).To(Equal(expected))
+		},
+		Entry("rolled out", deployment(int32Ptr(2), 3, 3, 2, 2), true),
+		Entry("one replica by default", deployment(nil, 1, 1, 1, 1), true),
+		Entry("spec is not observed", deployment(int32Ptr(2), 3, 2, 2, 2), false),
+		Entry("replicas are not updated", deployment(int32Ptr(2), 3, 3, 1, 2), false),
+		Entry("replicas are not available", deployment(int32Ptr(2), 3, 3, 2, 1), false),
+		Entry("scaled to zero", deployment(int32Ptr(0), 2, 2, 0, 0), true),
+	)
+
+	DescribeTable("reads the metric value",
+		func(result *prometheusQueryResp, expected float64, failed bool) {
+			value, err := metricValue(result)
+			if failed {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(expected))
+		},
+		Entry("sample value", sample("success", 1650000000.1, "0.25"), 0.25, false),
+		Entry("failed query", sample("error"), 0.0, true),
+		Entry("no data", sample("success"), 0.0, true),
+		Entry("not a vector value", sample("success", "0.25"), 0.0, true),
+		Entry("sample is not a string", sample("success", 1650000000.1, 0.25), 0.0, true),
+		Entry("sample is not a number", sample("success", 1650000000.1, "NaN?"), 0.0, true),
+	)
+})
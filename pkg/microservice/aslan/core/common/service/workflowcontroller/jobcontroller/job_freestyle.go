@@ -37,6 +37,9 @@ import (
 	"github.com/koderover/zadig/pkg/tool/dockerhost"
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/updater"
+	"github.com/koderover/zadig/pkg/tool/tracing"
+	"github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
 )
 
 const (
@@ -55,6 +58,8 @@ type FreestyleJobCtl struct {
 	paths       *string
 	jobTaskSpec *commonmodels.JobTaskBuildSpec
 	ack         func()
+	// where jobexecutor uploads the outputs to, nil if the outputs come from the termination message.
+	outputsStorage *step.S3
 }
 
 func NewFreestyleJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *FreestyleJobCtl {
@@ -83,6 +88,7 @@ func (c *FreestyleJobCtl) Run(ctx context.Context) {
 	}
 	c.wait(ctx)
 	c.complete(ctx)
+	updateTestTaskStat(TestTaskStatName(c.workflowCtx.WorkflowName, c.job.Name), c.job, c.logger)
 }
 
 func (c *FreestyleJobCtl) prepare(ctx context.Context) error {
@@ -160,7 +166,11 @@ func (c *FreestyleJobCtl) run(ctx context.Context) error {
 
 	c.jobTaskSpec.Properties.DockerHost = dockerHost
 
-	jobCtxBytes, err := yaml.Marshal(BuildJobExcutorContext(c.jobTaskSpec, c.job, c.workflowCtx, c.logger))
+	jobCtx := BuildJobExcutorContext(c.jobTaskSpec, c.job, c.workflowCtx, c.logger)
+	c.outputsStorage = jobCtx.OutputsStorage
+	jobCtx.TraceContext = tracing.Inject(ctx)
+	jobCtx.Tracing = jobExecutorTracing()
+	jobCtxBytes, err := yaml.Marshal(jobCtx)
 	if err != nil {
 		msg := fmt.Sprintf("cannot Jobexcutor.Context data: %v", err)
 		c.logger.Error(msg)
@@ -239,10 +249,36 @@ func (c *FreestyleJobCtl) run(ctx context.Context) error {
 }
 
 func (c *FreestyleJobCtl) wait(ctx context.Context) {
-	status := waitJobEndWithFile(ctx, int(c.jobTaskSpec.Properties.Timeout), c.jobTaskSpec.Properties.Namespace, c.jobName, true, c.kubeclient, c.clientset, c.restConfig, c.logger)
+	var onBreakpoint func(bp *job.Breakpoint, podName, containerName string)
+	if hasBreakpoints(c.jobTaskSpec) {
+		onBreakpoint = c.onBreakpoint
+	}
+	status := waitJobEndWithFile(ctx, int(c.jobTaskSpec.Properties.Timeout), c.jobTaskSpec.Properties.Namespace, c.jobName, true, c.kubeclient, c.clientset, c.restConfig, onBreakpoint, c.logger)
+	c.job.Breakpoint = nil
 	c.job.Status = status
 }
 
+// onBreakpoint shows where the job is paused in the workflow task, so the job pod can be attached and the job can be resumed.
+func (c *FreestyleJobCtl) onBreakpoint(bp *job.Breakpoint, podName, containerName string) {
+	if bp == nil {
+		c.job.Breakpoint = nil
+		c.job.Status = config.StatusRunning
+		c.ack()
+		return
+	}
+	c.job.Breakpoint = &commonmodels.JobBreakpoint{
+		Position:      bp.Position,
+		StepName:      bp.StepName,
+		PausedAt:      bp.PausedAt,
+		ClusterID:     c.jobTaskSpec.Properties.ClusterID,
+		Namespace:     c.jobTaskSpec.Properties.Namespace,
+		PodName:       podName,
+		ContainerName: containerName,
+	}
+	c.job.Status = config.StatusPaused
+	c.ack()
+}
+
 func (c *FreestyleJobCtl) complete(ctx context.Context) {
 	jobLabel := &JobLabel{
 		WorkflowName: c.workflowCtx.WorkflowName,
@@ -263,17 +299,19 @@ func (c *FreestyleJobCtl) complete(ctx context.Context) {
 		}()
 	}()
 
-	// get job outputs info from pod terminate message.
-	outputs, err := getJobOutput(c.jobTaskSpec.Properties.Namespace, c.job.Name, jobLabel, c.kubeclient)
+	// get job outputs info from the object storage or pod terminate message.
+	outputs, err := getJobOutput(c.jobTaskSpec.Properties.Namespace, c.job.Name, jobLabel, c.outputsStorage, c.kubeclient)
 	if err != nil {
 		c.logger.Error(err)
 		c.job.Error = err.Error()
+		// the jobs after it would run with empty outputs otherwise.
+		if errors.Is(err, errJobOutputsLost) {
+			c.job.Status = config.StatusFailed
+		}
 	}
 
 	// write jobs output info to globalcontext so other job can use like this $(jobName.outputName)
-	for _, output := range outputs {
-		c.workflowCtx.GlobalContextSet(strings.Join([]string{"workflow", c.job.Name, output.Name}, "."), output.Value)
-	}
+	setJobOutputs(c.job, c.workflowCtx, outputs)
 
 	if err := saveContainerLog(c.jobTaskSpec.Properties.Namespace, c.jobTaskSpec.Properties.ClusterID, c.workflowCtx.WorkflowName, c.job.Name, c.workflowCtx.TaskID, jobLabel, c.kubeclient); err != nil {
 		c.logger.Error(err)
@@ -285,6 +323,7 @@ func (c *FreestyleJobCtl) complete(ctx context.Context) {
 		c.job.Error = err.Error()
 		return
 	}
+	c.job.TestReport = collectTestReport(c.jobTaskSpec.Steps)
 }
 
 func BuildJobExcutorContext(jobTaskSpec *commonmodels.JobTaskBuildSpec, job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger) *JobContext {
@@ -302,15 +341,28 @@ func BuildJobExcutorContext(jobTaskSpec *commonmodels.JobTaskBuildSpec, job *com
 		outputs = append(outputs, output.Name)
 	}
 
+	var outputsStorage *step.S3
+	if len(outputs) > 0 {
+		storage, err := getOutputsStorage(workflowCtx.WorkflowName, job.Name, workflowCtx.TaskID)
+		if err != nil {
+			// outputs are still returned by the termination message, but limited to 4KB.
+			logger.Errorf("failed to get outputs storage of job %s: %v", job.Name, err)
+		} else {
+			outputsStorage = storage
+		}
+	}
+
 	return &JobContext{
-		Name:         job.Name,
-		Envs:         envVars,
-		SecretEnvs:   secretEnvVars,
-		WorkflowName: workflowCtx.WorkflowName,
-		Workspace:    workflowCtx.Workspace,
-		TaskID:       workflowCtx.TaskID,
-		Outputs:      outputs,
-		Steps:        jobTaskSpec.Steps,
-		Paths:        jobTaskSpec.Properties.Paths,
+		Name:             job.Name,
+		Envs:             envVars,
+		SecretEnvs:       secretEnvVars,
+		WorkflowName:     workflowCtx.WorkflowName,
+		Workspace:        workflowCtx.Workspace,
+		TaskID:           workflowCtx.TaskID,
+		Outputs:          outputs,
+		OutputsStorage:   outputsStorage,
+		Steps:            jobTaskSpec.Steps,
+		Paths:            jobTaskSpec.Properties.Paths,
+		KeepPodOnFailure: jobTaskSpec.Properties.KeepPodOnFailure,
 	}
 }
@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/sonar"
+	"github.com/koderover/zadig/pkg/types/job"
+)
+
+const (
+	// the scanning script writes the id of the sonar background task to it.
+	SonarCETaskIDOutput = "SONAR_CE_TASK_ID"
+	// status of the quality gate, OK, WARN, ERROR or NONE.
+	SonarQualityGateOutput = "SONAR_QUALITY_GATE"
+
+	qualityGateTimeout      = 10 * time.Minute
+	qualityGatePollInterval = 5 * time.Second
+)
+
+// ScanningJobCtl runs the scanning module like a freestyle job, and fails the job if the quality gate of the analysis failed.
+type ScanningJobCtl struct {
+	*FreestyleJobCtl
+	sonarID string
+}
+
+func NewScanningJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *ScanningJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskScanningSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	return &ScanningJobCtl{
+		FreestyleJobCtl: NewFreestyleJobCtl(job, workflowCtx, ack, logger),
+		sonarID:         jobTaskSpec.SonarID,
+	}
+}
+
+func (c *ScanningJobCtl) Run(ctx context.Context) {
+	if err := c.prepare(ctx); err != nil {
+		return
+	}
+	if err := c.run(ctx); err != nil {
+		return
+	}
+	c.wait(ctx)
+	c.complete(ctx)
+	if c.job.Status != config.StatusPassed || c.sonarID == "" {
+		return
+	}
+
+	c.logger.Infof("waiting for the quality gate of job %s", c.job.Name)
+	status, err := c.getQualityGateStatus(ctx)
+	if err != nil {
+		c.logger.Errorf("failed to get the quality gate of job %s: %v", c.job.Name, err)
+		c.job.Status = config.StatusFailed
+		if ctx.Err() != nil {
+			c.job.Status = config.StatusCancelled
+		}
+		c.job.Error = err.Error()
+		return
+	}
+	setJobOutputs(c.job, c.workflowCtx, []*job.JobOutput{{Name: SonarQualityGateOutput, Value: status.Status}})
+	if status.Status == sonar.QualityGateError {
+		c.job.Status = config.StatusFailed
+		c.job.Error = qualityGateError(status)
+	}
+}
+
+// getQualityGateStatus waits for sonar to finish the analysis uploaded by the scanner, then returns the quality gate status of it.
+func (c *ScanningJobCtl) getQualityGateStatus(ctx context.Context) (*sonar.ProjectStatus, error) {
+	ceTaskID := ""
+	for _, output := range c.job.Outputs {
+		if output.Name == SonarCETaskIDOutput {
+			ceTaskID = strings.TrimSpace(output.Value)
+		}
+	}
+	if ceTaskID == "" {
+		return nil, fmt.Errorf("no sonar analysis found")
+	}
+	sonarInfo, err := commonrepo.NewSonarIntegrationColl().GetByID(ctx, c.sonarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sonar integration %s: %v", c.sonarID, err)
+	}
+	client := sonar.NewSonarClient(sonarInfo.ServerAddress, sonarInfo.Token)
+
+	timeout := time.After(qualityGateTimeout)
+	for {
+		task, err := client.GetCETask(ceTaskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sonar analysis %s: %v", ceTaskID, err)
+		}
+		if task == nil {
+			return nil, fmt.Errorf("sonar analysis %s not found", ceTaskID)
+		}
+		switch task.Status {
+		case sonar.CETaskPending, sonar.CETaskInProgress:
+		case sonar.CETaskSuccess:
+			status, err := client.GetQualityGateStatus(task.AnalysisID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get quality gate of sonar analysis %s: %v", task.AnalysisID, err)
+			}
+			if status == nil {
+				return nil, fmt.Errorf("quality gate of sonar analysis %s not found", task.AnalysisID)
+			}
+			return status, nil
+		default:
+			return nil, fmt.Errorf("sonar analysis %s %s: %s", ceTaskID, strings.ToLower(task.Status), task.ErrorMessage)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for sonar analysis %s", ceTaskID)
+		case <-time.After(qualityGatePollInterval):
+		}
+	}
+}
+
+func qualityGateError(status *sonar.ProjectStatus) string {
+	conditions := []string{}
+	for _, condition := range status.Conditions {
+		if condition.Status != sonar.QualityGateError {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s (threshold %s %s)", condition.MetricKey, condition.ActualValue, condition.Comparator, condition.ErrorThreshold))
+	}
+	if len(conditions) == 0 {
+		return "quality gate failed"
+	}
+	return fmt.Sprintf("quality gate failed: %s", strings.Join(conditions, ", "))
+}
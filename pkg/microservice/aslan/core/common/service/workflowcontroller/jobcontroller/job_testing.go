@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// TestingJobCtl runs the testing module like a freestyle job, and checks the results with the threshold of it.
+type TestingJobCtl struct {
+	*FreestyleJobCtl
+	testName  string
+	threshold int
+}
+
+func NewTestingJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *TestingJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskTestingSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	return &TestingJobCtl{
+		FreestyleJobCtl: NewFreestyleJobCtl(job, workflowCtx, ack, logger),
+		testName:        jobTaskSpec.TestName,
+		threshold:       jobTaskSpec.Threshold,
+	}
+}
+
+func (c *TestingJobCtl) Run(ctx context.Context) {
+	if err := c.prepare(ctx); err != nil {
+		return
+	}
+	if err := c.run(ctx); err != nil {
+		return
+	}
+	c.wait(ctx)
+	c.complete(ctx)
+	if err := checkTestThreshold(c.job.TestReport, c.threshold); err != nil && c.job.Status == config.StatusPassed {
+		c.logger.Infof("job %s failed: %v", c.job.Name, err)
+		c.job.Status = config.StatusFailed
+		c.job.Error = err.Error()
+	}
+	// count in the statistics of the testing module, the same as the old product workflows.
+	updateTestTaskStat(c.testName, c.job, c.logger)
+}
+
+// checkTestThreshold returns an error if the percent of the failed test cases is more than the threshold.
+func checkTestThreshold(report *commonmodels.JobTestReport, threshold int) error {
+	if report == nil || report.Tests == 0 {
+		return nil
+	}
+	failures := report.Failures + report.Errors
+	// Integer operation: FAIL/TOTAL > V%  =>  FAIL*100 > TOTAL*V
+	if failures*100 > report.Tests*threshold {
+		return fmt.Errorf("%d of %d test cases failed, more than the threshold %d%%", failures, report.Tests, threshold)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/types/job"
+)
+
+const (
+	triggeredTaskPollInterval = 5 * time.Second
+	// unit is minute.
+	defaultTriggeredTaskTimeout = 60
+)
+
+// CreateWorkflowTask and CancelWorkflowTask are set by the workflow service when aslan starts,
+// the job controller can not depend on it directly.
+var (
+	CreateWorkflowTask func(ctx context.Context, spec *commonmodels.JobTaskTriggerWorkflowSpec, parent *commonmodels.WorkflowTaskLink, creator string, logger *zap.SugaredLogger) (int64, error)
+	CancelWorkflowTask func(userName, workflowName string, taskID int64, logger *zap.SugaredLogger) error
+)
+
+type TriggerWorkflowJobCtl struct {
+	job         *commonmodels.JobTask
+	workflowCtx *commonmodels.WorkflowTaskCtx
+	logger      *zap.SugaredLogger
+	jobTaskSpec *commonmodels.JobTaskTriggerWorkflowSpec
+	ack         func()
+}
+
+func NewTriggerWorkflowJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *TriggerWorkflowJobCtl {
+	jobTaskSpec := &commonmodels.JobTaskTriggerWorkflowSpec{}
+	if err := commonmodels.IToi(job.Spec, jobTaskSpec); err != nil {
+		logger.Error(err)
+	}
+	return &TriggerWorkflowJobCtl{
+		job:         job,
+		workflowCtx: workflowCtx,
+		logger:      logger,
+		ack:         ack,
+		jobTaskSpec: jobTaskSpec,
+	}
+}
+
+func (c *TriggerWorkflowJobCtl) Run(ctx context.Context) {
+	c.job.Spec = c.jobTaskSpec
+	if CreateWorkflowTask == nil {
+		c.fail("trigger workflow is not supported")
+		return
+	}
+	parent := &commonmodels.WorkflowTaskLink{
+		ProjectName:  c.workflowCtx.ProjectName,
+		WorkflowName: c.workflowCtx.WorkflowName,
+		TaskID:       c.workflowCtx.TaskID,
+		JobName:      c.job.Name,
+	}
+	taskID, err := CreateWorkflowTask(ctx, c.jobTaskSpec, parent, c.workflowCtx.TaskCreator, c.logger)
+	if err != nil {
+		c.fail(fmt.Sprintf("failed to trigger workflow %s: %v", c.jobTaskSpec.WorkflowName, err))
+		return
+	}
+	c.jobTaskSpec.TaskID = taskID
+	c.jobTaskSpec.TaskStatus = config.StatusCreated
+	c.ack()
+	c.logger.Infof("job %s triggered workflow %s task %d", c.job.Name, c.jobTaskSpec.WorkflowName, taskID)
+
+	if !c.jobTaskSpec.Wait {
+		c.job.Status = config.StatusPassed
+		return
+	}
+	task, err := c.wait(ctx)
+	if err != nil {
+		c.job.Error = err.Error()
+		return
+	}
+	c.importOutputs(task)
+	if task.Status != config.StatusPassed {
+		c.job.Status = config.StatusFailed
+		c.job.Error = fmt.Sprintf("workflow %s task %d finished with status %s", task.WorkflowName, task.TaskID, task.Status)
+		return
+	}
+	c.job.Status = config.StatusPassed
+}
+
+// wait polls the triggered task until it's done, the task is cancelled if the job is cancelled or timeout.
+func (c *TriggerWorkflowJobCtl) wait(ctx context.Context) (*commonmodels.WorkflowTask, error) {
+	timeout := c.jobTaskSpec.Timeout
+	if timeout <= 0 {
+		timeout = defaultTriggeredTaskTimeout
+	}
+	timer := time.NewTimer(time.Duration(timeout) * time.Minute)
+	defer timer.Stop()
+	ticker := time.NewTicker(triggeredTaskPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.job.Status = config.StatusCancelled
+			c.cancelTask()
+			return nil, fmt.Errorf("job cancelled")
+		case <-timer.C:
+			c.job.Status = config.StatusTimeout
+			c.cancelTask()
+			return nil, fmt.Errorf("workflow %s task %d not finished in %d minutes", c.jobTaskSpec.WorkflowName, c.jobTaskSpec.TaskID, timeout)
+		case <-ticker.C:
+		}
+
+		task, err := commonrepo.NewworkflowTaskv4Coll().Find(c.jobTaskSpec.WorkflowName, c.jobTaskSpec.TaskID)
+		if err != nil {
+			c.logger.Errorf("failed to find workflow %s task %d: %v", c.jobTaskSpec.WorkflowName, c.jobTaskSpec.TaskID, err)
+			continue
+		}
+		if task.Status != c.jobTaskSpec.TaskStatus {
+			c.jobTaskSpec.TaskStatus = task.Status
+			c.ack()
+		}
+		if taskDone(task.Status) {
+			return task, nil
+		}
+	}
+}
+
+func (c *TriggerWorkflowJobCtl) cancelTask() {
+	if CancelWorkflowTask == nil {
+		return
+	}
+	if err := CancelWorkflowTask(c.workflowCtx.TaskCreator, c.jobTaskSpec.WorkflowName, c.jobTaskSpec.TaskID, c.logger); err != nil {
+		c.logger.Errorf("failed to cancel workflow %s task %d: %v", c.jobTaskSpec.WorkflowName, c.jobTaskSpec.TaskID, err)
+		return
+	}
+	c.jobTaskSpec.TaskStatus = config.StatusCancelled
+}
+
+// importOutputs saves the outputs of the jobs in the triggered task as <job>.<output>,
+// so they can be quoted by the later jobs like {{.workflow.<this job>.<job>.<output>}}.
+func (c *TriggerWorkflowJobCtl) importOutputs(task *commonmodels.WorkflowTask) {
+	outputs := []*job.JobOutput{}
+	for _, stage := range task.Stages {
+		for _, jobTask := range stage.Jobs {
+			for _, output := range jobTask.Outputs {
+				if output.Value == "" {
+					continue
+				}
+				name := jobTask.Name + "." + output.Name
+				c.job.Outputs = append(c.job.Outputs, &commonmodels.Output{Name: name, Description: output.Description})
+				outputs = append(outputs, &job.JobOutput{Name: name, Value: output.Value})
+			}
+		}
+	}
+	setJobOutputs(c.job, c.workflowCtx, outputs)
+}
+
+func (c *TriggerWorkflowJobCtl) fail(msg string) {
+	c.logger.Error(msg)
+	c.job.Status = config.StatusFailed
+	c.job.Error = msg
+}
+
+func taskDone(status config.Status) bool {
+	switch status {
+	case config.StatusPassed, config.StatusFailed, config.StatusTimeout, config.StatusCancelled, config.StatusReject, config.StatusApprovalTimeout:
+		return true
+	}
+	return false
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -55,6 +56,7 @@ import (
 	"github.com/koderover/zadig/pkg/tool/log"
 	commontypes "github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -303,7 +305,7 @@ func buildJob(jobType, jobImage, jobName, clusterID, currentNamespace string, re
 			// in case finished zombie job not cleaned up by zadig
 			TTLSecondsAfterFinished: int32Ptr(3600),
 			// in case zombie job never stop
-			ActiveDeadlineSeconds: int64Ptr(jobTaskSpec.Properties.Timeout*60 + 3600),
+			ActiveDeadlineSeconds: int64Ptr(jobTaskSpec.Properties.Timeout*60 + 3600 + breakpointDeadlineSeconds(jobTaskSpec)),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -582,8 +584,11 @@ func waitPlainJobEnd(ctx context.Context, taskTimeout int, namespace, jobName st
 
 }
 
-func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName string, checkFile bool, kubeClient crClient.Client, clientset kubernetes.Interface, restConfig *rest.Config, xl *zap.SugaredLogger) (status config.Status) {
+// waitJobEndWithFile waits for the job to end, onBreakpoint is called when the job is paused at a breakpoint or resumed with a nil
+// breakpoint, the timeout of the job stops while it's paused. Breakpoints are not checked if onBreakpoint is nil.
+func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName string, checkFile bool, kubeClient crClient.Client, clientset kubernetes.Interface, restConfig *rest.Config, onBreakpoint func(bp *job.Breakpoint, podName, containerName string), xl *zap.SugaredLogger) (status config.Status) {
 	xl.Infof("wait job to start: %s/%s", namespace, jobName)
+	deadline := time.Now().Add(time.Duration(taskTimeout) * time.Minute)
 	timeout := time.After(time.Duration(taskTimeout) * time.Minute)
 	podTimeout := time.After(120 * time.Second)
 
@@ -615,6 +620,11 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 
 	// 等待job 运行结束
 	xl.Infof("wait job to end: %s %s", namespace, jobName)
+	var (
+		breakpoint, currentBreakpoint *job.Breakpoint
+		podName, containerName        string
+		remaining                     time.Duration
+	)
 	for {
 		select {
 		case <-ctx.Done():
@@ -641,8 +651,9 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 					xl.Errorf("failed to find pod with label job-name=%s %v", jobName, err)
 					return config.StatusFailed
 				}
-				var done, exists bool
+				var done, exists, breakpointChecked bool
 				var jobStatus commontypes.JobStatus
+				currentBreakpoint = nil
 
 				for _, pod := range pods {
 					ipod := wrapper.Pod(pod)
@@ -662,12 +673,41 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 							break
 						}
 						if !exists {
+							if onBreakpoint != nil {
+								podName, containerName = ipod.Name, ipod.ContainerNames()[0]
+								currentBreakpoint, err = checkBreakpointInContainer(clientset, restConfig, namespace, podName, containerName)
+								if err != nil {
+									xl.Infof("Result of checking breakpoint file %s: %s", podName, err)
+								} else {
+									breakpointChecked = true
+								}
+							}
 							break
 						}
 					}
 					done = true
 				}
 
+				if !done && breakpointChecked {
+					switch {
+					case currentBreakpoint != nil && (breakpoint == nil || *currentBreakpoint != *breakpoint):
+						if breakpoint == nil {
+							// stop the timeout while the job is paused.
+							remaining = time.Until(deadline)
+							timeout = nil
+						}
+						breakpoint = currentBreakpoint
+						xl.Infof("Job %s is paused %s step %s", job.Name, breakpoint.Position, breakpoint.StepName)
+						onBreakpoint(breakpoint, podName, containerName)
+					case currentBreakpoint == nil && breakpoint != nil:
+						breakpoint = nil
+						deadline = time.Now().Add(remaining)
+						timeout = time.After(remaining)
+						xl.Infof("Job %s is resumed", job.Name)
+						onBreakpoint(nil, podName, containerName)
+					}
+				}
+
 				if done {
 					xl.Infof("Dog food is found, stop to wait %s. Job status: %s.", job.Name, jobStatus)
 
@@ -689,7 +729,11 @@ func waitJobEndWithFile(ctx context.Context, taskTimeout int, namespace, jobName
 	}
 }
 
-func getJobOutput(namespace, containerName string, jobLabel *JobLabel, kubeClient crClient.Client) ([]*job.JobOutput, error) {
+// errJobOutputsLost is returned when the outputs are too large for the termination message and the download of them failed.
+var errJobOutputsLost = errors.New("outputs are larger than the termination message and can not be downloaded")
+
+// getJobOutput gets the outputs from the object storage if it's given, or from the termination message of the job container.
+func getJobOutput(namespace, containerName string, jobLabel *JobLabel, storage *step.S3, kubeClient crClient.Client) ([]*job.JobOutput, error) {
 	resp := []*job.JobOutput{}
 	ls := getJobLabels(jobLabel)
 	pods, err := getter.ListPods(namespace, labels.Set(ls).AsSelector(), kubeClient)
@@ -702,11 +746,24 @@ func getJobOutput(namespace, containerName string, jobLabel *JobLabel, kubeClien
 		if !ipod.Succeeded() {
 			return resp, nil
 		}
+		var downloadErr error
+		if storage != nil {
+			outputs, err := downloadJobOutputs(storage)
+			if err == nil {
+				return outputs, nil
+			}
+			downloadErr = err
+			// the job executor may be an old one which does not upload outputs.
+			log.Warnf("failed to download outputs of job %s, use the termination message instead: %v", jobLabel.JobName, err)
+		}
 		for _, containerStatus := range pod.Status.ContainerStatuses {
 			if containerStatus.Name != ls[containerName] {
 				continue
 			}
 			if containerStatus.State.Terminated != nil && len(containerStatus.State.Terminated.Message) != 0 {
+				if containerStatus.State.Terminated.Message == job.JobOutputsInStorage {
+					return resp, fmt.Errorf("%w: %v", errJobOutputsLost, downloadErr)
+				}
 				if err := json.Unmarshal([]byte(containerStatus.State.Terminated.Message), &resp); err != nil {
 					return resp, err
 				}
@@ -717,6 +774,75 @@ func getJobOutput(namespace, containerName string, jobLabel *JobLabel, kubeClien
 	return resp, nil
 }
 
+// setJobOutputs saves the outputs to the job task and the global context.
+func setJobOutputs(jobTask *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, outputs []*job.JobOutput) {
+	values := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		values[output.Name] = output.Value
+		workflowCtx.GlobalContextSet(strings.Join([]string{"workflow", jobTask.Name, output.Name}, "."), output.Value)
+	}
+	for _, output := range jobTask.Outputs {
+		if value, ok := values[output.Name]; ok {
+			output.Value = value
+		}
+	}
+}
+
+// getOutputsStorage returns the place in the default object storage where jobexecutor uploads the outputs of the job to.
+func getOutputsStorage(workflowName, jobName string, taskID int64) (*step.S3, error) {
+	store, err := commonrepo.NewS3StorageColl().FindDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default s3 storage: %s", err)
+	}
+	subfolder := fmt.Sprintf("%s/%d/%s/%s", strings.ToLower(workflowName), taskID, "outputs", strings.ToLower(jobName))
+	if store.Subfolder != "" {
+		subfolder = fmt.Sprintf("%s/%s", store.Subfolder, subfolder)
+	}
+	storage := &step.S3{
+		Ak:        store.Ak,
+		Sk:        store.Sk,
+		Endpoint:  store.Endpoint,
+		Bucket:    store.Bucket,
+		Subfolder: subfolder,
+		Insecure:  store.Insecure,
+		Provider:  store.Provider,
+	}
+	if store.Insecure {
+		storage.Protocol = "http"
+	}
+	return storage, nil
+}
+
+func downloadJobOutputs(storage *step.S3) ([]*job.JobOutput, error) {
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	s3client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %v", err)
+	}
+	tempFileName, err := util.GenerateTmpFile()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.Remove(tempFileName)
+	}()
+	if err := s3client.Download(storage.Bucket, GetObjectPath(storage.Subfolder, job.JobOutputsFile), tempFileName); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(tempFileName)
+	if err != nil {
+		return nil, err
+	}
+	resp := []*job.JobOutput{}
+	if err := json.Unmarshal(content, &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal outputs: %v", err)
+	}
+	return resp, nil
+}
+
 func saveContainerLog(namespace, clusterID, workflowName, jobName string, taskID int64, jobLabel *JobLabel, kubeClient crClient.Client) error {
 	selector := labels.Set(getJobLabels(jobLabel)).AsSelector()
 	pods, err := getter.ListPods(namespace, selector, kubeClient)
@@ -790,6 +916,34 @@ func saveContainerLog(namespace, clusterID, workflowName, jobName string, taskID
 	return nil
 }
 
+// archiveAttemptLog copies the saved log of a job to a file dedicated to the given attempt,
+// and returns the name of the new log file.
+func archiveAttemptLog(workflowName, jobName string, taskID int64, attempt int) (string, error) {
+	store, err := commonrepo.NewS3StorageColl().FindDefault()
+	if err != nil {
+		return "", fmt.Errorf("failed to get default s3 storage: %s", err)
+	}
+	if store.Subfolder != "" {
+		store.Subfolder = fmt.Sprintf("%s/%s/%d/%s", store.Subfolder, strings.ToLower(workflowName), taskID, "log")
+	} else {
+		store.Subfolder = fmt.Sprintf("%s/%d/%s", strings.ToLower(workflowName), taskID, "log")
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	s3client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		return "", fmt.Errorf("archiveAttemptLog s3 create client error: %v", err)
+	}
+	fileName := strings.Replace(strings.ToLower(jobName), "_", "-", -1)
+	attemptFileName := fmt.Sprintf("%s-attempt-%d", fileName, attempt)
+	if err := s3client.CopyObject(store.Bucket, GetObjectPath(store.Subfolder, fileName+".log"), GetObjectPath(store.Subfolder, attemptFileName+".log")); err != nil {
+		return "", fmt.Errorf("archiveAttemptLog s3 copy error: %v", err)
+	}
+	return attemptFileName, nil
+}
+
 func GetObjectPath(subFolder, name string) string {
 	// target should not be started with /
 	if subFolder != "" {
@@ -799,6 +953,25 @@ func GetObjectPath(subFolder, name string) string {
 	return strings.TrimLeft(name, "/")
 }
 
+// checkBreakpointInContainer returns where the job executor is paused, or nil if it's not paused.
+func checkBreakpointInContainer(clientset kubernetes.Interface, restConfig *rest.Config, namespace, pod, container string) (*job.Breakpoint, error) {
+	stdout, _, success, err := podexec.KubeExec(clientset, restConfig, podexec.ExecOptions{
+		Command:       []string{"/bin/sh", "-c", fmt.Sprintf("test -f %[1]s && cat %[1]s", job.BreakpointFile)},
+		Namespace:     namespace,
+		PodName:       pod,
+		ContainerName: container,
+	})
+	if err != nil || !success {
+		return nil, err
+	}
+
+	breakpoint := &job.Breakpoint{}
+	if err := json.Unmarshal([]byte(stdout), breakpoint); err != nil {
+		return nil, fmt.Errorf("invalid breakpoint %q: %s", stdout, err)
+	}
+	return breakpoint, nil
+}
+
 func checkDogFoodExistsInContainer(clientset kubernetes.Interface, restConfig *rest.Config, namespace, pod, container string) (commontypes.JobStatus, bool, error) {
 	stdout, _, success, err := podexec.KubeExec(clientset, restConfig, podexec.ExecOptions{
 		Command:       []string{"/bin/sh", "-c", fmt.Sprintf("test -f %[1]s && cat %[1]s", setting.DogFood)},
@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "workflow",
+	Name:      "job_duration_seconds",
+	Help:      "Duration of workflow v4 jobs by job type and status, retries are counted in the duration.",
+	Buckets:   metrics.TaskDurationBuckets,
+}, []string{"job_type", "status"})
+
+func observeJob(job *commonmodels.JobTask) {
+	jobDuration.WithLabelValues(job.JobType, string(job.Status)).Observe(float64(job.EndTime - job.StartTime))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+)
+
+// collectTestReport gathers the results of the junit_report and html_report steps, nil if there is none.
+func collectTestReport(steps []*commonmodels.StepTask) *commonmodels.JobTestReport {
+	var report *commonmodels.JobTestReport
+	htmlReport := ""
+	for _, step := range steps {
+		switch step.StepType {
+		case config.StepJunitReport:
+			if result, ok := step.Result.(*commonmodels.JobTestReport); ok && report == nil {
+				report = result
+			}
+		case config.StepHtmlReport:
+			if result, ok := step.Result.(string); ok && htmlReport == "" {
+				htmlReport = result
+			}
+		}
+	}
+	if htmlReport == "" {
+		return report
+	}
+	if report == nil {
+		report = &commonmodels.JobTestReport{FailedCases: []*commonmodels.FailedTestCase{}}
+	}
+	report.HtmlReport = htmlReport
+	return report
+}
+
+// TestTaskStatName is the name of the test statistics of a workflow v4 job.
+func TestTaskStatName(workflowName, jobName string) string {
+	return strings.Join([]string{workflowName, jobName}, "/")
+}
+
+// updateTestTaskStat counts the job in the test statistics if it has a junit report.
+func updateTestTaskStat(name string, job *commonmodels.JobTask, logger *zap.SugaredLogger) {
+	if job.TestReport == nil || job.TestReport.JunitReport == "" {
+		return
+	}
+	coll := commonrepo.NewTestTaskStatColl()
+	isNew := false
+	testTaskStat, _ := coll.FindTestTaskStat(&commonrepo.TestTaskStatOption{Name: name})
+	if testTaskStat == nil {
+		isNew = true
+		testTaskStat = &commonmodels.TestTaskStat{Name: name, CreateTime: time.Now().Unix()}
+	}
+	if job.TestReport.Tests != 0 {
+		testTaskStat.TestCaseNum = job.TestReport.Tests
+	}
+	// the end time of the job is not set yet.
+	testTaskStat.TotalDuration += time.Now().Unix() - job.StartTime
+	if job.Status == config.StatusPassed {
+		testTaskStat.TotalSuccess++
+	} else {
+		testTaskStat.TotalFailure++
+	}
+	testTaskStat.UpdateTime = time.Now().Unix()
+
+	var err error
+	if isNew {
+		err = coll.Create(testTaskStat)
+	} else {
+		err = coll.Update(testTaskStat)
+	}
+	if err != nil {
+		logger.Errorf("failed to update test statistics %s: %v", name, err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	zadigconfig "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/tool/tracing"
+)
+
+// EndSpan records the final status of a workflow, stage or job in its span and ends the span.
+func EndSpan(span trace.Span, status config.Status, errMsg string) {
+	span.SetAttributes(attribute.String("zadig.status", string(status)))
+	switch status {
+	case config.StatusFailed, config.StatusTimeout:
+		span.SetStatus(codes.Error, errMsg)
+	}
+	span.End()
+}
+
+// jobExecutorTracing returns the tracing config of the job executor, only the otlp exporter is passed on,
+// spans written to a file or stdout in the job pod would be lost or mixed with the job log.
+func jobExecutorTracing() *tracing.Config {
+	if zadigconfig.TraceExporter() != tracing.ExporterOTLP {
+		return nil
+	}
+	return &tracing.Config{
+		ServiceName: "jobexecutor",
+		Exporter:    tracing.ExporterOTLP,
+		Endpoint:    zadigconfig.TraceOTLPEndpoint(),
+	}
+}
@@ -18,6 +18,8 @@ package jobcontroller
 
 import (
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/tracing"
+	"github.com/koderover/zadig/pkg/types/step"
 )
 
 type JobContext struct {
@@ -38,6 +40,14 @@ type JobContext struct {
 
 	Steps   []*commonmodels.StepTask `yaml:"steps"`
 	Outputs []string                 `yaml:"outputs"`
+	// OutputsStorage 输出变量上传的对象存储, 为空时通过 termination message 返回 [optional]
+	OutputsStorage *step.S3 `yaml:"outputs_storage"`
+	// KeepPodOnFailure pauses the job executor after the job failed, so the pod can be debugged.
+	KeepPodOnFailure bool `yaml:"keep_pod_on_failure"`
+	// TraceContext is the span of the job in aslan, spans of the job executor are its children.
+	TraceContext map[string]string `yaml:"trace_context,omitempty"`
+	// Tracing is the exporter of the job executor, tracing is disabled in the job if it is nil.
+	Tracing *tracing.Config `yaml:"tracing,omitempty"`
 }
 
 type EnvVar []string
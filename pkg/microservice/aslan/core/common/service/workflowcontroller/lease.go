@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const (
+	// a running task is owned by the replica running it as long as the replica renews the lease.
+	taskLeaseDuration      = 2 * time.Minute
+	taskLeaseRenewInterval = 30 * time.Second
+)
+
+var (
+	ownerOnce sync.Once
+	owner     string
+)
+
+// taskOwner returns the owner of the tasks run by this replica, a restarted pod gets a new one.
+func taskOwner() string {
+	ownerOnce.Do(func() {
+		owner = fmt.Sprintf("%s-%s", config.PodName(), uuid.NewV4())
+	})
+	return owner
+}
+
+func leaseExpireTime() int64 {
+	return time.Now().Add(taskLeaseDuration).Unix()
+}
+
+// keepLease renews the lease of the task until ctx is done, the task stops running here if another replica
+// has taken it over, which happens when the lease could not be renewed in time.
+func (c *workflowCtl) keepLease(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(taskLeaseRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := commonrepo.NewworkflowTaskv4Coll().RenewLease(c.workflowTask.WorkflowName, c.workflowTask.TaskID, c.workflowTask.Owner, leaseExpireTime())
+			if err != nil {
+				c.logger.Warnf("renew lease of workflow task %s:%d error: %v", c.workflowTask.WorkflowName, c.workflowTask.TaskID, err)
+				continue
+			}
+			if !renewed {
+				c.logger.Errorf("workflow task %s:%d is taken over by another replica, stop running it", c.workflowTask.WorkflowName, c.workflowTask.TaskID)
+				atomic.StoreInt32(&c.leaseLost, 1)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (c *workflowCtl) lostLease() bool {
+	return atomic.LoadInt32(&c.leaseLost) == 1
+}
+
+// watchExpiredTasks takes over the running tasks whose lease has expired, e.g. the replica running them was restarted.
+func watchExpiredTasks() {
+	for {
+		takeOverExpiredTasks()
+		time.Sleep(taskLeaseRenewInterval)
+	}
+}
+
+// takeOverExpiredTasks claims the running tasks whose lease has expired, the tasks waiting for approval hold
+// no running job and are resumed on this replica, the others are cancelled since their jobs are lost.
+func takeOverExpiredTasks() {
+	tasks, err := commonrepo.NewworkflowTaskv4Coll().InCompletedTasks()
+	if err != nil {
+		log.Errorf("find [InCompletedTasks] error: %v", err)
+		return
+	}
+	now := time.Now().Unix()
+	for _, task := range tasks {
+		if task.Status != config.StatusRunning || task.LeaseExpireTime >= now {
+			continue
+		}
+		claimed, err := commonrepo.NewworkflowTaskv4Coll().ClaimTask(task.WorkflowName, task.TaskID, taskOwner(), now, leaseExpireTime())
+		if err != nil {
+			log.Errorf("claim workflow task %s:%d error: %v", task.WorkflowName, task.TaskID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if waitingForApprove(task) {
+			if err := resumeTask(task); err != nil {
+				log.Errorf("resume workflow task %s:%d error: %v", task.WorkflowName, task.TaskID, err)
+			}
+			continue
+		}
+		if err := CancelWorkflowTask(setting.DefaultTaskRevoker, task.WorkflowName, task.TaskID, log.SugaredLogger()); err != nil {
+			log.Errorf("[CancelRunningTask] error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var (
+	queueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "workflow",
+		Name:      "queue_length",
+		Help:      "Number of workflow v4 tasks in the queue by status.",
+	}, []string{"status"})
+
+	queueWaitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "workflow",
+		Name:      "queue_wait_seconds",
+		Help:      "Time workflow v4 tasks wait in the queue before they start to run.",
+		Buckets:   metrics.TaskDurationBuckets,
+	})
+
+	stageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "workflow",
+		Name:      "stage_duration_seconds",
+		Help:      "Duration of workflow v4 stages by status.",
+		Buckets:   metrics.TaskDurationBuckets,
+	}, []string{"status"})
+)
+
+// queueStatuses are always exported, so the queue length drops to 0 instead of disappearing.
+var queueStatuses = []config.Status{config.StatusWaiting, config.StatusBlocked, config.StatusQueued, config.StatusRunning}
+
+func observeQueueLength(queues []*commonmodels.WorkflowQueue) {
+	counts := make(map[config.Status]int)
+	for _, queue := range queues {
+		counts[queue.Status]++
+	}
+	for _, status := range queueStatuses {
+		queueLength.WithLabelValues(string(status)).Set(float64(counts[status]))
+	}
+}
+
+func observeQueueWait(queue *commonmodels.WorkflowQueue) {
+	queueWaitDuration.Observe(time.Since(time.Unix(queue.CreateTime, 0)).Seconds())
+}
+
+func observeStage(stage *commonmodels.StageTask) {
+	stageDuration.WithLabelValues(string(stage.Status)).Observe(float64(stage.EndTime - stage.StartTime))
+}
@@ -80,6 +80,9 @@ func CreateTask(t *commonmodels.WorkflowTask) error {
 
 func UpdateTask(t *commonmodels.WorkflowTask) error {
 	t.Status = config.StatusWaiting
+	// the task is owned again by the replica which runs it next.
+	t.Owner = ""
+	t.LeaseExpireTime = 0
 	if err := commonrepo.NewworkflowTaskv4Coll().Update(t.ID.Hex(), t); err != nil {
 		log.Errorf("create workflow task v4 error: %v", err)
 		return err
@@ -112,6 +115,7 @@ func Push(t *commonmodels.WorkflowTask) error {
 
 func InitWorkflowController() {
 	InitQueue()
+	go watchExpiredTasks()
 	go WorfklowTaskSender()
 }
 
@@ -127,7 +131,11 @@ func InitQueue() error {
 	}
 
 	for _, task := range tasks {
-		// 如果 Queue 重新初始化, 取消所有 running tasks
+		// running tasks may be run by other replicas, they are taken over by watchExpiredTasks once their lease expires.
+		if task.Status == config.StatusRunning {
+			continue
+		}
+		// 如果 Queue 重新初始化, 取消所有未开始的 tasks
 		if err := CancelWorkflowTask(setting.DefaultTaskRevoker, task.WorkflowName, task.TaskID, log); err != nil {
 			log.Errorf("[CancelRunningTask] error: %v", err)
 			continue
@@ -136,6 +144,30 @@ func InitQueue() error {
 	return nil
 }
 
+func waitingForApprove(task *commonmodels.WorkflowTask) bool {
+	if task.Status != config.StatusRunning {
+		return false
+	}
+	for _, stage := range task.Stages {
+		if stage.Status == config.StatusPassed || stage.Status == config.StatusSkipped {
+			continue
+		}
+		return isWaitingForApprove(stage)
+	}
+	return false
+}
+
+// resumeTask runs the task claimed by this replica again.
+func resumeTask(task *commonmodels.WorkflowTask) error {
+	sysSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return fmt.Errorf("get system stettings error: %v", err)
+	}
+	log.Infof("resume workflow task %s:%d waiting for approval", task.WorkflowName, task.TaskID)
+	go NewWorkflowController(task, log.SugaredLogger()).Run(context.Background(), int(sysSetting.BuildConcurrency))
+	return nil
+}
+
 // WorfklowTaskSender 监控warpdrive空闲情况, 如果有空闲, 则发现下一个waiting task给warpdrive
 // 并将task状态设置为queued
 func WorfklowTaskSender() {
@@ -147,38 +179,18 @@ func WorfklowTaskSender() {
 			log.Errorf("get system stettings error: %v", err)
 		}
 		//c.checkAgents()
-		if !hasAgentAvaiable(int(sysSetting.WorkflowConcurrency)) {
-			continue
-		}
-		t, err := NextWaitingTask()
-		if err != nil {
-			// no waiting task found
-			blockTasks, err := BlockedTaskQueue()
-			if err != nil {
-				//no blocked task found
-				continue
+		observeQueueLength(ListTasks())
+		for hasAgentAvaiable(int(sysSetting.WorkflowConcurrency)) {
+			t := pickNextTask(ListTasks(), sysSetting, lastScheduledProject)
+			if t == nil {
+				break
 			}
-			for _, blockTask := range blockTasks {
-				if hasAgentAvaiable(int(sysSetting.WorkflowConcurrency)) {
-					//判断相同的工作流是否正在运行
-					if ParallelRunningAndQueuedTasks(blockTask) {
-						continue
-					}
-					// update agent and queue
-					if err := updateQueueAndRunTask(blockTask, int(sysSetting.BuildConcurrency)); err != nil {
-						continue
-					}
-				} else {
-					break
-				}
+			// update agent and queue
+			if err := updateQueueAndRunTask(t, int(sysSetting.BuildConcurrency)); err != nil {
+				break
 			}
-			continue
-		}
-		// update agent and queue
-		if err := updateQueueAndRunTask(t, int(sysSetting.BuildConcurrency)); err != nil {
-			continue
+			lastScheduledProject = t.ProjectName
 		}
-
 	}
 }
 
@@ -206,50 +218,6 @@ func ListTasks() []*commonmodels.WorkflowQueue {
 	return queues
 }
 
-// NextWaitingTask 查询下一个等待的task
-func NextWaitingTask() (*commonmodels.WorkflowQueue, error) {
-	opt := &commonrepo.ListWorfklowQueueOption{
-		Status: config.StatusWaiting,
-	}
-
-	tasks, err := commonrepo.NewWorkflowQueueColl().List(opt)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, t := range tasks {
-		return t, nil
-	}
-
-	return nil, errors.New("no waiting task found")
-}
-
-func BlockedTaskQueue() ([]*commonmodels.WorkflowQueue, error) {
-	opt := &commonrepo.ListWorfklowQueueOption{
-		Status: config.StatusBlocked,
-	}
-
-	queues, err := commonrepo.NewWorkflowQueueColl().List(opt)
-	if err != nil || len(queues) == 0 {
-		return nil, errors.New("no blocked task found")
-	}
-
-	return queues, nil
-}
-
-func ParallelRunningAndQueuedTasks(currentTask *commonmodels.WorkflowQueue) bool {
-	for _, t := range ListTasks() {
-		// task状态为TaskQueued说明task已经被send到nsq,wd已经开始处理但是没有返回ack
-		if t.Status != config.StatusRunning && t.Status != config.StatusQueued {
-			continue
-		}
-		if t.WorkflowName == currentTask.WorkflowName {
-			return true
-		}
-	}
-	return false
-}
-
 func updateQueueAndRunTask(t *commonmodels.WorkflowQueue, jobConcurrency int) error {
 	logger := log.SugaredLogger()
 	// 更新队列状态为TaskQueued
@@ -263,6 +231,7 @@ func updateQueueAndRunTask(t *commonmodels.WorkflowQueue, jobConcurrency int) er
 		logger.Errorf("%s:%d update t status error", t.WorkflowName, t.TaskID)
 		return fmt.Errorf("%s:%d update t status error", t.WorkflowName, t.TaskID)
 	}
+	observeQueueWait(t)
 	ctx := context.Background()
 	go NewWorkflowController(workflowTask, logger).Run(ctx, jobConcurrency)
 	return nil
@@ -286,6 +255,8 @@ func ConvertTaskToQueue(task *commonmodels.WorkflowTask) *commonmodels.WorkflowQ
 		TaskRevoker:  task.TaskRevoker,
 		CreateTime:   task.CreateTime,
 		MultiRun:     task.MultiRun,
+		Priority:     task.Priority,
+		Concurrency:  task.Concurrency,
 	}
 }
 
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// the number of the recently finished tasks used to estimate the duration of a task.
+const etaSampleSize = 50
+
+// lastScheduledProject is the project of the last task sent by WorfklowTaskSender,
+// projects take turns from the next one.
+var lastScheduledProject string
+
+// pickNextTask picks the next task to run from the waiting and blocked ones in the queue:
+// tasks with higher priority run first, projects having tasks of the same priority take turns,
+// and tasks exceeding the concurrency limit of the workflow or the project keep waiting.
+func pickNextTask(queues []*commonmodels.WorkflowQueue, sysSetting *commonmodels.SystemSetting, lastProject string) *commonmodels.WorkflowQueue {
+	workflowRunning := make(map[string]int64)
+	projectRunning := make(map[string]int64)
+	pending := make([]*commonmodels.WorkflowQueue, 0)
+	for _, t := range queues {
+		switch t.Status {
+		case config.StatusRunning, config.StatusQueued:
+			workflowRunning[t.WorkflowName]++
+			projectRunning[t.ProjectName]++
+		case config.StatusWaiting, config.StatusBlocked:
+			pending = append(pending, t)
+		}
+	}
+	sortPendingTasks(pending)
+
+	candidates := make([]*commonmodels.WorkflowQueue, 0)
+	for _, t := range pending {
+		if len(candidates) > 0 && taskPriority(t.Priority) < taskPriority(candidates[0].Priority) {
+			break
+		}
+		if !t.MultiRun && workflowRunning[t.WorkflowName] > 0 {
+			continue
+		}
+		if t.MultiRun && t.Concurrency > 0 && workflowRunning[t.WorkflowName] >= t.Concurrency {
+			continue
+		}
+		if limit := projectConcurrency(sysSetting, t.ProjectName); limit > 0 && projectRunning[t.ProjectName] >= limit {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// the oldest task of each project, the project next to the last scheduled one wins.
+	projectTasks := make(map[string]*commonmodels.WorkflowQueue)
+	projects := make([]string, 0)
+	for _, t := range candidates {
+		if _, ok := projectTasks[t.ProjectName]; !ok {
+			projectTasks[t.ProjectName] = t
+			projects = append(projects, t.ProjectName)
+		}
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		if project > lastProject {
+			return projectTasks[project]
+		}
+	}
+	return projectTasks[projects[0]]
+}
+
+// sortPendingTasks sorts the tasks by priority, then by create time.
+func sortPendingTasks(tasks []*commonmodels.WorkflowQueue) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		pi, pj := taskPriority(tasks[i].Priority), taskPriority(tasks[j].Priority)
+		if pi != pj {
+			return pi > pj
+		}
+		return tasks[i].CreateTime < tasks[j].CreateTime
+	})
+}
+
+// taskPriority treats the tasks created before priorities were introduced as normal ones.
+func taskPriority(priority config.TaskPriority) config.TaskPriority {
+	if priority == 0 {
+		return config.TaskPriorityNormal
+	}
+	return priority
+}
+
+func projectConcurrency(sysSetting *commonmodels.SystemSetting, projectName string) int64 {
+	for _, project := range sysSetting.ProjectConcurrency {
+		if project.ProjectName == projectName {
+			return project.Concurrency
+		}
+	}
+	return sysSetting.DefaultProjectConcurrency
+}
+
+// QueuePosition returns the position of the task among the waiting ones counting from 1, and the estimated time it
+// starts to run, the time is 0 if it can't be estimated. The position is approximate since projects take turns.
+func QueuePosition(workflowName string, taskID int64) (int, int64) {
+	pending := PendingTasks()
+	sortPendingTasks(pending)
+	position := 0
+	for i, t := range pending {
+		if t.WorkflowName == workflowName && t.TaskID == taskID {
+			position = i + 1
+			break
+		}
+	}
+	if position == 0 {
+		return 0, 0
+	}
+
+	sysSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil || sysSetting.WorkflowConcurrency <= 0 {
+		return position, 0
+	}
+	duration := averageTaskDuration()
+	if duration == 0 {
+		return position, 0
+	}
+	rounds := (int64(position) + sysSetting.WorkflowConcurrency - 1) / sysSetting.WorkflowConcurrency
+	return position, time.Now().Unix() + rounds*duration
+}
+
+// averageTaskDuration returns the average duration in seconds of the recently finished tasks.
+func averageTaskDuration() int64 {
+	tasks, _, err := commonrepo.NewworkflowTaskv4Coll().List(&commonrepo.ListWorkflowTaskV4Option{Limit: etaSampleSize})
+	if err != nil {
+		log.Errorf("list workflow tasks error: %v", err)
+		return 0
+	}
+	var total, count int64
+	for _, task := range tasks {
+		if task.StartTime == 0 || task.EndTime <= task.StartTime {
+			continue
+		}
+		total += task.EndTime - task.StartTime
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / count
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func queueTask(project, workflow string, taskID int64, status config.Status, priority config.TaskPriority) *commonmodels.WorkflowQueue {
+	return &commonmodels.WorkflowQueue{
+		ProjectName:  project,
+		WorkflowName: workflow,
+		TaskID:       taskID,
+		Status:       status,
+		Priority:     priority,
+		CreateTime:   taskID,
+		MultiRun:     true,
+	}
+}
+
+var _ = Describe("pick next task", func() {
+	sysSetting := &commonmodels.SystemSetting{}
+
+	It("runs the task with higher priority first", func() {
+		queues := []*commonmodels.WorkflowQueue{
+			queueTask("a", "pr", 1, config.StatusWaiting, config.TaskPriorityLow),
+			queueTask("a", "hotfix", 2, config.StatusWaiting, config.TaskPriorityUrgent),
+			queueTask("b", "release", 3, config.StatusWaiting, config.TaskPriorityHigh),
+		}
+		Expect(pickNextTask(queues, sysSetting, "").WorkflowName).To(Equal("hotfix"))
+	})
+
+	It("lets projects take turns", func() {
+		queues := []*commonmodels.WorkflowQueue{
+			queueTask("a", "w1", 1, config.StatusWaiting, 0),
+			queueTask("a", "w1", 2, config.StatusWaiting, 0),
+			queueTask("b", "w2", 3, config.StatusWaiting, 0),
+		}
+		Expect(pickNextTask(queues, sysSetting, "").ProjectName).To(Equal("a"))
+		Expect(pickNextTask(queues, sysSetting, "a").ProjectName).To(Equal("b"))
+		Expect(pickNextTask(queues, sysSetting, "b").ProjectName).To(Equal("a"))
+	})
+
+	It("keeps the tasks over the concurrency limits waiting", func() {
+		limited := &commonmodels.SystemSetting{
+			ProjectConcurrency: []*commonmodels.ProjectConcurrency{{ProjectName: "a", Concurrency: 1}},
+		}
+		queues := []*commonmodels.WorkflowQueue{
+			queueTask("a", "w1", 1, config.StatusRunning, 0),
+			queueTask("a", "w1", 2, config.StatusWaiting, config.TaskPriorityUrgent),
+			queueTask("b", "w2", 3, config.StatusRunning, 0),
+			queueTask("b", "w2", 4, config.StatusWaiting, 0),
+		}
+		queues[3].Concurrency = 1
+		Expect(pickNextTask(queues, limited, "")).To(BeNil())
+
+		queues[3].Concurrency = 2
+		Expect(pickNextTask(queues, limited, "").TaskID).To(Equal(int64(4)))
+	})
+
+	It("runs one task of a workflow without multi run at a time", func() {
+		queues := []*commonmodels.WorkflowQueue{
+			queueTask("a", "w1", 1, config.StatusRunning, 0),
+			queueTask("a", "w1", 2, config.StatusBlocked, 0),
+		}
+		queues[1].MultiRun = false
+		Expect(pickNextTask(queues, sysSetting, "")).To(BeNil())
+	})
+})
@@ -26,38 +26,43 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
-type approveMap struct {
-	m map[string]*approveWithLock
-	sync.RWMutex
-}
-
-type approveWithLock struct {
-	approval *commonmodels.Approval
-	sync.RWMutex
-}
-
-var globalApproveMap approveMap
-
-func init() {
-	globalApproveMap.m = make(map[string]*approveWithLock, 0)
-}
-
 type StageCtl interface {
 	Run(ctx context.Context, concurrency int)
 }
 
-func runStage(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, concurrency int, logger *zap.SugaredLogger, ack func()) {
+func runStage(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, dag *jobcontroller.JobDAG, concurrency int, logger *zap.SugaredLogger, ack func()) {
 	stage.Status = config.StatusRunning
-	stage.StartTime = time.Now().Unix()
+	// a resumed stage keeps its original start time, the approval timeout counts from it.
+	if stage.StartTime == 0 {
+		stage.StartTime = time.Now().Unix()
+	}
 	ack()
+	ctx, span := tracing.Start(ctx, "stage "+stage.Name)
 	logger.Infof("start stage: %s,status: %s", stage.Name, stage.Status)
 	if err := waitiForApprove(ctx, stage, workflowCtx, ack); err != nil {
 		stage.Error = err.Error()
 		stage.EndTime = time.Now().Unix()
+		// release the downstream jobs waiting on the jobs of this stage.
+		if dag.Enabled() {
+			for _, job := range stage.Jobs {
+				if job.Status == "" {
+					job.Status = config.StatusNotRun
+					job.Error = err.Error()
+				}
+				dag.Finish(job, job.Status)
+			}
+		}
 		logger.Errorf("finish stage: %s,status: %s", stage.Name, stage.Status)
 		ack()
+		observeStage(stage)
+		jobcontroller.EndSpan(span, stage.Status, stage.Error)
 		return
 	}
 	defer func() {
@@ -65,31 +70,125 @@ func runStage(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *c
 		stage.EndTime = time.Now().Unix()
 		logger.Infof("finish stage: %s,status: %s", stage.Name, stage.Status)
 		ack()
+		observeStage(stage)
+		jobcontroller.EndSpan(span, stage.Status, stage.Error)
 	}()
 
-	stageCtl := NewCustomStageCtl(stage, workflowCtx, logger, ack)
+	stageCtl := NewCustomStageCtl(stage, workflowCtx, dag, logger, ack)
 
 	stageCtl.Run(ctx, concurrency)
 }
 
 func RunStages(ctx context.Context, stages []*commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, concurrency int, logger *zap.SugaredLogger, ack func()) {
+	if dag := jobcontroller.NewJobDAG(stages); dag.Enabled() {
+		runStagesInDAG(ctx, stages, workflowCtx, dag, concurrency, logger, ack)
+		return
+	}
 	for _, stage := range stages {
-		runStage(ctx, stage, workflowCtx, concurrency, logger, ack)
+		// skip the stages already done before the task was resumed.
+		if stage.Status == config.StatusPassed || stage.Status == config.StatusSkipped {
+			continue
+		}
+		runStage(ctx, stage, workflowCtx, nil, concurrency, logger, ack)
 		if statusFailed(stage.Status) {
 			return
 		}
 	}
 }
 
+// runStagesInDAG runs all the stages at the same time, jobs in them start by their dependencies instead of stage order.
+// A stage starts once any of its jobs can start, so the approval of a stage is not asked too early,
+// and a stage none of whose jobs can run ends without asking for the approval at all.
+func runStagesInDAG(ctx context.Context, stages []*commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, dag *jobcontroller.JobDAG, concurrency int, logger *zap.SugaredLogger, ack func()) {
+	var wg sync.WaitGroup
+	for _, stage := range stages {
+		if stage.Status == config.StatusPassed || stage.Status == config.StatusSkipped {
+			continue
+		}
+		wg.Add(1)
+		go func(stage *commonmodels.StageTask) {
+			defer wg.Done()
+			if err := dag.WaitStage(ctx, stage); err != nil {
+				endStageNotRun(ctx, stage, dag, err, logger, ack)
+				return
+			}
+			runStage(ctx, stage, workflowCtx, dag, concurrency, logger, ack)
+		}(stage)
+	}
+	wg.Wait()
+}
+
+// endStageNotRun ends the stage in the DAG whose jobs are all blocked by failed upstream jobs.
+func endStageNotRun(ctx context.Context, stage *commonmodels.StageTask, dag *jobcontroller.JobDAG, err error, logger *zap.SugaredLogger, ack func()) {
+	status := config.StatusNotRun
+	if ctx.Err() != nil {
+		status = config.StatusCancelled
+	}
+	for _, job := range stage.Jobs {
+		if job.Status != config.StatusPassed && job.Status != config.StatusSkipped {
+			job.Status = status
+			job.Error = err.Error()
+		}
+		dag.Finish(job, job.Status)
+	}
+	stage.Status = status
+	stage.StartTime = time.Now().Unix()
+	stage.EndTime = stage.StartTime
+	logger.Infof("stage %s not run: %v", stage.Name, err)
+	ack()
+	observeStage(stage)
+}
+
 func ApproveStage(workflowName, stageName, userName, userID, comment string, taskID int64, approve bool) error {
-	approveKey := fmt.Sprintf("%s-%d-%s", workflowName, taskID, stageName)
-	approveWithL, ok := globalApproveMap.getApproval(approveKey)
-	if !ok {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		return fmt.Errorf("workflow %s ID %d not found: %v", workflowName, taskID, err)
+	}
+	if task.Status != config.StatusRunning {
 		return fmt.Errorf("workflow %s ID %d stage %s do not need approve", workflowName, taskID, stageName)
 	}
-	return approveWithL.doApproval(userName, userID, comment, approve)
+	var stage *commonmodels.StageTask
+	for _, s := range task.Stages {
+		if s.Name == stageName {
+			stage = s
+			break
+		}
+	}
+	if stage == nil || !isWaitingForApprove(stage) {
+		return fmt.Errorf("workflow %s ID %d stage %s do not need approve", workflowName, taskID, stageName)
+	}
+	approveUser, err := findApproveUser(stage.Approval, userID)
+	if err != nil {
+		return err
+	}
+	if approveUser == nil {
+		return fmt.Errorf("user %s has no authority to approve", userName)
+	}
+	if approveUser.RejectOrApprove != "" {
+		return fmt.Errorf("%s have %s already", approveUser.UserName, approveUser.RejectOrApprove)
+	}
+	approveUser.Operator = userName
+	approveUser.Comment = comment
+	approveUser.OperationTime = time.Now().Unix()
+	approveUser.RejectOrApprove = config.Reject
+	if approve {
+		approveUser.RejectOrApprove = config.Approve
+	}
+	// the approval is written to the task in mongo directly, so it can be done from any replica,
+	// the replica running the task picks it up in waitiForApprove.
+	updated, err := commonrepo.NewworkflowTaskv4Coll().UpdateApproveUser(workflowName, taskID, stageName, approveUser)
+	if err != nil {
+		return fmt.Errorf("update approval of workflow %s ID %d stage %s error: %v", workflowName, taskID, stageName, err)
+	}
+	if !updated {
+		return fmt.Errorf("workflow %s ID %d stage %s approval has been changed, please refresh and retry", workflowName, taskID, stageName)
+	}
+	return nil
 }
 
+// maxApprovalSyncErrors is how many times in a row syncing the approval may fail, it is synced every second.
+const maxApprovalSyncErrors = 60
+
 func waitiForApprove(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func()) error {
 	if stage.Approval == nil {
 		return nil
@@ -100,16 +199,51 @@ func waitiForApprove(ctx context.Context, stage *commonmodels.StageTask, workflo
 	if stage.Approval.Timeout == 0 {
 		stage.Approval.Timeout = 60
 	}
-	approveKey := fmt.Sprintf("%s-%d-%s", workflowCtx.WorkflowName, workflowCtx.TaskID, stage.Name)
-	approveWithL := &approveWithLock{approval: stage.Approval}
-	globalApproveMap.setApproval(approveKey, approveWithL)
-	defer func() {
-		globalApproveMap.deleteApproval(approveKey)
-		ack()
-	}()
+	// the approval was already done before the task was resumed.
+	if stage.Approval.RejectOrApprove == config.Approve {
+		return nil
+	}
+	defer ack()
 
-	timeout := time.After(time.Duration(stage.Approval.Timeout) * time.Minute)
-	latestApproveCount := 0
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowCtx.WorkflowName, workflowCtx.TaskID)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return fmt.Errorf("find workflow %s ID %d error: %v", workflowCtx.WorkflowName, workflowCtx.TaskID, err)
+	}
+	rule, err := matchAutoApproveRule(stage.Approval, task)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+	if rule != nil {
+		stage.Approval.RejectOrApprove = config.Approve
+		stage.Approval.AutoApprovedBy = rule.Name
+		return nil
+	}
+	// approvals made before the approvers are resolved must not be overwritten by the ack.
+	if _, err := syncApproval(stage, workflowCtx); err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+	if err := resolveApprovers(stage.Approval); err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+	// the ack never writes the approve users, approvals may be made on other replicas at the same time.
+	if err := commonrepo.NewworkflowTaskv4Coll().AddApproveUsers(workflowCtx.WorkflowName, workflowCtx.TaskID, stage.Name, stage.Approval.ApproveUsers); err != nil {
+		stage.Status = config.StatusFailed
+		return fmt.Errorf("save approve users error: %v", err)
+	}
+	if _, err := syncApproval(stage, workflowCtx); err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+	ack()
+	go sendApproveNotifications(stage.Name, workflowCtx)
+
+	deadline := time.Unix(stage.StartTime, 0).Add(time.Duration(stage.Approval.Timeout) * time.Minute)
+	timeout := time.After(time.Until(deadline))
+	syncErrors := 0
 	for {
 		time.Sleep(1 * time.Second)
 		select {
@@ -118,10 +252,26 @@ func waitiForApprove(ctx context.Context, stage *commonmodels.StageTask, workflo
 			return fmt.Errorf("workflow was canceled")
 
 		case <-timeout:
-			stage.Status = config.StatusCancelled
-			return fmt.Errorf("workflow timeout")
+			stage.Status = config.StatusApprovalTimeout
+			return fmt.Errorf("approval timeout")
 		default:
-			approved, approveCount, err := approveWithL.isApproval()
+			cancelled, err := syncApproval(stage, workflowCtx)
+			if err != nil {
+				// mongo may be unavailable for a while, give up only when it keeps failing.
+				syncErrors++
+				log.Warnf("sync approval of workflow %s ID %d stage %s error: %v", workflowCtx.WorkflowName, workflowCtx.TaskID, stage.Name, err)
+				if syncErrors >= maxApprovalSyncErrors {
+					stage.Status = config.StatusFailed
+					return fmt.Errorf("failed to sync approval: %v", err)
+				}
+				continue
+			}
+			syncErrors = 0
+			if cancelled {
+				stage.Status = config.StatusCancelled
+				return fmt.Errorf("workflow was canceled")
+			}
+			approved, _, err := checkApproval(stage.Approval)
 			if err != nil {
 				stage.Status = config.StatusReject
 				return err
@@ -129,16 +279,84 @@ func waitiForApprove(ctx context.Context, stage *commonmodels.StageTask, workflo
 			if approved {
 				return nil
 			}
-			if approveCount > latestApproveCount {
-				ack()
-				latestApproveCount = approveCount
-			}
 		}
 	}
 }
 
+// sendApproveNotifications notifies the approvers with the task in mongo, the running task is being changed by the stages.
+func sendApproveNotifications(stageName string, workflowCtx *commonmodels.WorkflowTaskCtx) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowCtx.WorkflowName, workflowCtx.TaskID)
+	if err != nil {
+		log.Errorf("find workflow %s ID %d error: %v", workflowCtx.WorkflowName, workflowCtx.TaskID, err)
+		return
+	}
+	for _, stage := range task.Stages {
+		if stage.Name != stageName {
+			continue
+		}
+		sendNotificationsWithTimeout(log.SugaredLogger(), func(ctx context.Context) error {
+			return instantmessage.NewWeChatClient().SendWorkflowTaskApproveNotifications(ctx, task, stage)
+		})
+		return
+	}
+}
+
+// syncApproval loads the approve users of the stage from mongo, approvals may be done on other replicas.
+// It also reports whether the task has been cancelled meanwhile.
+func syncApproval(stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx) (bool, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowCtx.WorkflowName, workflowCtx.TaskID)
+	if err != nil {
+		return false, err
+	}
+	if task.Status == config.StatusCancelled {
+		return true, nil
+	}
+	for _, s := range task.Stages {
+		if s.Name != stage.Name || s.Approval == nil {
+			continue
+		}
+		stage.Approval.ApproveUsers = s.Approval.ApproveUsers
+		return false, nil
+	}
+	return false, fmt.Errorf("stage %s not found in workflow %s ID %d", stage.Name, workflowCtx.WorkflowName, workflowCtx.TaskID)
+}
+
+func checkApproval(approval *commonmodels.Approval) (bool, int, error) {
+	approveCount := 0
+	for _, user := range approval.ApproveUsers {
+		if user.RejectOrApprove == config.Reject {
+			approval.RejectOrApprove = config.Reject
+			return false, approveCount, fmt.Errorf("%s reject this task", user.UserName)
+		}
+		if user.RejectOrApprove == config.Approve {
+			approveCount++
+		}
+	}
+	if approveCount >= approval.NeededApprovers {
+		approval.RejectOrApprove = config.Approve
+		return true, approveCount, nil
+	}
+	return false, approveCount, nil
+}
+
+// isWaitingForApprove reports whether the stage is running and still waiting for the approval result.
+func isWaitingForApprove(stage *commonmodels.StageTask) bool {
+	if stage.Status != config.StatusRunning || stage.Approval == nil || !stage.Approval.Enabled {
+		return false
+	}
+	if stage.Approval.RejectOrApprove != "" {
+		return false
+	}
+	for _, job := range stage.Jobs {
+		if job.Status != "" {
+			return false
+		}
+	}
+	return true
+}
+
 func statusFailed(status config.Status) bool {
-	if status == config.StatusCancelled || status == config.StatusFailed || status == config.StatusTimeout || status == config.StatusReject {
+	if status == config.StatusCancelled || status == config.StatusFailed || status == config.StatusTimeout || status == config.StatusReject || status == config.StatusApprovalTimeout {
 		return true
 	}
 	return false
@@ -146,10 +364,11 @@ func statusFailed(status config.Status) bool {
 
 func updateStageStatus(stage *commonmodels.StageTask) {
 	statusMap := map[config.Status]int{
-		config.StatusCancelled: 4,
-		config.StatusTimeout:   3,
-		config.StatusFailed:    2,
-		config.StatusPassed:    1,
+		config.StatusCancelled: 5,
+		config.StatusTimeout:   4,
+		config.StatusFailed:    3,
+		config.StatusPassed:    2,
+		config.StatusNotRun:    1,
 		config.StatusSkipped:   0,
 	}
 
@@ -180,64 +399,3 @@ func updateStageStatus(stage *commonmodels.StageTask) {
 	}
 	stage.Status = stageStatus
 }
-
-func (c *approveMap) setApproval(key string, value *approveWithLock) {
-	c.Lock()
-	defer c.Unlock()
-	c.m[key] = value
-}
-
-func (c *approveMap) getApproval(key string) (*approveWithLock, bool) {
-	c.RLock()
-	defer c.RUnlock()
-	v, existed := c.m[key]
-	return v, existed
-}
-func (c *approveMap) deleteApproval(key string) {
-	c.Lock()
-	defer c.Unlock()
-	delete(c.m, key)
-}
-
-func (c *approveWithLock) isApproval() (bool, int, error) {
-	c.Lock()
-	defer c.Unlock()
-	approveCount := 0
-	for _, user := range c.approval.ApproveUsers {
-		if user.RejectOrApprove == config.Reject {
-			c.approval.RejectOrApprove = config.Reject
-			return false, approveCount, fmt.Errorf("%s reject this task", user.UserName)
-		}
-		if user.RejectOrApprove == config.Approve {
-			approveCount++
-		}
-	}
-	if approveCount >= c.approval.NeededApprovers {
-		c.approval.RejectOrApprove = config.Approve
-		return true, approveCount, nil
-	}
-	return false, approveCount, nil
-}
-
-func (c *approveWithLock) doApproval(userName, userID, comment string, appvove bool) error {
-	c.Lock()
-	defer c.Unlock()
-	for _, user := range c.approval.ApproveUsers {
-		if user.UserID != userID {
-			continue
-		}
-		if user.RejectOrApprove != "" {
-			return fmt.Errorf("%s have %s already", userName, user.RejectOrApprove)
-		}
-		user.Comment = comment
-		user.OperationTime = time.Now().Unix()
-		if appvove {
-			user.RejectOrApprove = config.Approve
-			return nil
-		} else {
-			user.RejectOrApprove = config.Reject
-			return nil
-		}
-	}
-	return fmt.Errorf("user %s has no authority to approve", userName)
-}
@@ -28,15 +28,17 @@ import (
 type CustomStageCtl struct {
 	stage       *commonmodels.StageTask
 	workflowCtx *commonmodels.WorkflowTaskCtx
+	dag         *jobcontroller.JobDAG
 	logger      *zap.SugaredLogger
 	ack         func()
 }
 
-func NewCustomStageCtl(stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger, ack func()) *CustomStageCtl {
+func NewCustomStageCtl(stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, dag *jobcontroller.JobDAG, logger *zap.SugaredLogger, ack func()) *CustomStageCtl {
 	return &CustomStageCtl{
 		stage:       stage,
 		logger:      logger,
 		workflowCtx: workflowCtx,
+		dag:         dag,
 		ack:         ack,
 	}
 }
@@ -51,5 +53,5 @@ func (c *CustomStageCtl) Run(ctx context.Context, concurrency int) {
 		}
 
 	}
-	jobcontroller.RunJobs(ctx, c.stage.Jobs, c.workflowCtx, workerConcurrency, c.logger, c.ack)
+	jobcontroller.RunJobs(ctx, c.stage.Jobs, c.workflowCtx, c.dag, workerConcurrency, c.logger, c.ack)
 }
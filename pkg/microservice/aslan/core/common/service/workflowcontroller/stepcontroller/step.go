@@ -60,8 +60,9 @@ func SummarizeSteps(ctx context.Context, workflowCtx *commonmodels.WorkflowTaskC
 		stepCtls = append(stepCtls, stepCtl)
 	}
 	for _, stepCtl := range stepCtls {
+		// one step failed to summarize should not stop the others.
 		if err := stepCtl.AfterRun(ctx); err != nil {
-			return nil
+			logger.Errorf("summarize step error: %v", err)
 		}
 	}
 	return nil
@@ -81,6 +82,14 @@ func instantiateStepCtl(step *commonmodels.StepTask, workflowCtx *commonmodels.W
 		stepCtl, err = NewToolInstallCtl(step, jobPath, logger)
 	case config.StepArchive:
 		stepCtl, err = NewArchiveCtl(step, logger)
+	case config.StepImageDistribute:
+		stepCtl, err = NewImageDistributeCtl(step, logger)
+	case config.StepArchiveDistribute:
+		stepCtl, err = NewArchiveDistributeCtl(step, logger)
+	case config.StepJunitReport:
+		stepCtl, err = NewJunitReportCtl(step, logger)
+	case config.StepHtmlReport:
+		stepCtl, err = NewHtmlReportCtl(step, logger)
 	default:
 		logger.Errorf("unknown step type: %s", step.StepType)
 		return stepCtl, fmt.Errorf("unknown step type: %s", step.StepType)
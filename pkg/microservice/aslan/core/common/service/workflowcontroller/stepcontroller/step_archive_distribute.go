@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type archiveDistributeCtl struct {
+	step                  *commonmodels.StepTask
+	archiveDistributeSpec *step.StepArchiveDistributeSpec
+	log                   *zap.SugaredLogger
+}
+
+func NewArchiveDistributeCtl(stepTask *commonmodels.StepTask, log *zap.SugaredLogger) (*archiveDistributeCtl, error) {
+	yamlString, err := yaml.Marshal(stepTask.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal archive distribute spec error: %v", err)
+	}
+	archiveDistributeSpec := &step.StepArchiveDistributeSpec{}
+	if err := yaml.Unmarshal(yamlString, &archiveDistributeSpec); err != nil {
+		return nil, fmt.Errorf("unmarshal archive distribute spec error: %v", err)
+	}
+	stepTask.Spec = archiveDistributeSpec
+	return &archiveDistributeCtl{archiveDistributeSpec: archiveDistributeSpec, log: log, step: stepTask}, nil
+}
+
+// PreRun fills the credentials of the target storages.
+func (s *archiveDistributeCtl) PreRun(ctx context.Context) error {
+	if len(s.archiveDistributeSpec.Targets) == 0 {
+		return fmt.Errorf("step %s: no target storage to distribute the archives to", s.step.Name)
+	}
+	for _, target := range s.archiveDistributeSpec.Targets {
+		if target.S3 != nil {
+			continue
+		}
+		modelS3, err := commonrepo.NewS3StorageColl().Find(target.ObjectStorageID)
+		if err != nil {
+			return fmt.Errorf("find object storage %s error: %v", target.ObjectStorageID, err)
+		}
+		target.S3 = modelS3toS3(modelS3)
+	}
+	s.step.Spec = s.archiveDistributeSpec
+	return nil
+}
+
+func (s *archiveDistributeCtl) AfterRun(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type imageDistributeCtl struct {
+	step                *commonmodels.StepTask
+	imageDistributeSpec *step.StepImageDistributeSpec
+	log                 *zap.SugaredLogger
+}
+
+func NewImageDistributeCtl(stepTask *commonmodels.StepTask, log *zap.SugaredLogger) (*imageDistributeCtl, error) {
+	yamlString, err := yaml.Marshal(stepTask.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal image distribute spec error: %v", err)
+	}
+	imageDistributeSpec := &step.StepImageDistributeSpec{}
+	if err := yaml.Unmarshal(yamlString, &imageDistributeSpec); err != nil {
+		return nil, fmt.Errorf("unmarshal image distribute spec error: %v", err)
+	}
+	stepTask.Spec = imageDistributeSpec
+	return &imageDistributeCtl{imageDistributeSpec: imageDistributeSpec, log: log, step: stepTask}, nil
+}
+
+// PreRun makes sure there is somewhere to distribute to, the registries are set when the job task was created.
+func (s *imageDistributeCtl) PreRun(ctx context.Context) error {
+	if len(s.imageDistributeSpec.Targets) == 0 {
+		return fmt.Errorf("step %s: no target registry to distribute the image to", s.step.Name)
+	}
+	return nil
+}
+
+func (s *imageDistributeCtl) AfterRun(ctx context.Context) error {
+	return nil
+}
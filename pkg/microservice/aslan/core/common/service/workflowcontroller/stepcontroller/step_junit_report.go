@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stepcontroller
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types/step"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	// only the first failed cases are kept in the job task.
+	maxFailedTestCases  = 50
+	maxFailureTextBytes = 1024
+)
+
+type junitReportCtl struct {
+	step            *commonmodels.StepTask
+	junitReportSpec *step.StepJunitReportSpec
+	log             *zap.SugaredLogger
+}
+
+func NewJunitReportCtl(stepTask *commonmodels.StepTask, log *zap.SugaredLogger) (*junitReportCtl, error) {
+	yamlString, err := yaml.Marshal(stepTask.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshal junit report spec error: %v", err)
+	}
+	junitReportSpec := &step.StepJunitReportSpec{}
+	if err := yaml.Unmarshal(yamlString, &junitReportSpec); err != nil {
+		return nil, fmt.Errorf("unmarshal junit report spec error: %v", err)
+	}
+	stepTask.Spec = junitReportSpec
+	return &junitReportCtl{junitReportSpec: junitReportSpec, log: log, step: stepTask}, nil
+}
+
+func (s *junitReportCtl) PreRun(ctx context.Context) error {
+	if s.junitReportSpec.S3 != nil {
+		return nil
+	}
+	modelS3, err := commonrepo.NewS3StorageColl().FindDefault()
+	if err != nil {
+		return err
+	}
+	s.junitReportSpec.S3 = modelS3toS3(modelS3)
+	s.step.Spec = s.junitReportSpec
+	return nil
+}
+
+// AfterRun downloads the merged report and saves the summary of it to the step result.
+func (s *junitReportCtl) AfterRun(ctx context.Context) error {
+	if s.junitReportSpec.S3 == nil {
+		return nil
+	}
+	objectKey := reportObjectKey(s.junitReportSpec.S3, s.junitReportSpec.S3DestDir, s.junitReportSpec.FileName)
+	client, err := newS3Client(s.junitReportSpec.S3)
+	if err != nil {
+		return err
+	}
+	tempFileName, err := util.GenerateTmpFile()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tempFileName)
+	}()
+	if err := client.DownloadWithOption(s.junitReportSpec.S3.Bucket, objectKey, tempFileName, &s3tool.DownloadOption{
+		IgnoreNotExistError: true,
+		RetryNum:            2,
+	}); err != nil {
+		return fmt.Errorf("download junit report %s error: %v", objectKey, err)
+	}
+	xmlBytes, err := ioutil.ReadFile(tempFileName)
+	if err != nil {
+		return err
+	}
+	// no report was generated.
+	if len(xmlBytes) == 0 {
+		return nil
+	}
+	testSuite := &commonmodels.TestSuite{}
+	if err := xml.Unmarshal(xmlBytes, testSuite); err != nil {
+		return fmt.Errorf("unmarshal junit report %s error: %v", objectKey, err)
+	}
+	report := TestReportFromSuite(testSuite)
+	report.JunitReport = objectKey
+	s.step.Result = report
+	return nil
+}
+
+// TestReportFromSuite summarizes the test suite, failed cases are truncated.
+func TestReportFromSuite(testSuite *commonmodels.TestSuite) *commonmodels.JobTestReport {
+	report := &commonmodels.JobTestReport{
+		Tests:       testSuite.Tests,
+		Successes:   testSuite.Successes,
+		Failures:    testSuite.Failures,
+		Errors:      testSuite.Errors,
+		Skips:       testSuite.Skips,
+		Time:        testSuite.Time,
+		FailedCases: []*commonmodels.FailedTestCase{},
+	}
+	for _, testCase := range testSuite.TestCases {
+		if len(report.FailedCases) >= maxFailedTestCases {
+			break
+		}
+		failedCase := &commonmodels.FailedTestCase{Name: testCase.Name, ClassName: testCase.ClassName}
+		switch {
+		case testCase.Failure != nil:
+			failedCase.Type = "failure"
+			failedCase.Message = testCase.Failure.Message
+			failedCase.Text = testCase.Failure.Text
+		case testCase.Error != nil:
+			failedCase.Type = "error"
+			failedCase.Message = testCase.Error.Message
+			failedCase.Text = testCase.Error.Text
+		default:
+			continue
+		}
+		if len(failedCase.Text) > maxFailureTextBytes {
+			failedCase.Text = failedCase.Text[:maxFailureTextBytes]
+		}
+		report.FailedCases = append(report.FailedCases, failedCase)
+	}
+	return report
+}
+
+func reportObjectKey(storage *step.S3, destDir, fileName string) string {
+	return strings.TrimLeft(path.Join(storage.Subfolder, destDir, fileName), "/")
+}
+
+func newS3Client(storage *step.S3) (*s3tool.Client, error) {
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %v", err)
+	}
+	return client, nil
+}
@@ -23,22 +23,32 @@ import (
 	"time"
 
 	uuid "github.com/satori/go.uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 var cancelChannelMap sync.Map
 
+// notifyTimeout is the deadline given to the senders of the notifications of a task.
+const notifyTimeout = time.Minute
+
 type workflowCtl struct {
 	workflowTask       *commonmodels.WorkflowTask
 	globalContextMutex sync.RWMutex
 	logger             *zap.SugaredLogger
 	ack                func()
+	// set to 1 once another replica has taken the task over.
+	leaseLost int32
 }
 
 func NewWorkflowController(workflowTask *commonmodels.WorkflowTask, logger *zap.SugaredLogger) *workflowCtl {
@@ -102,17 +112,39 @@ func (c *workflowCtl) Run(ctx context.Context, concurrency int) {
 	if c.workflowTask.GlobalContext == nil {
 		c.workflowTask.GlobalContext = make(map[string]string)
 	}
+	// a task with start time is resumed after restart, the running notification has been sent.
+	resumed := c.workflowTask.StartTime != 0
 	c.workflowTask.Status = config.StatusRunning
-	c.workflowTask.StartTime = time.Now().Unix()
+	c.workflowTask.Owner = taskOwner()
+	c.workflowTask.LeaseExpireTime = leaseExpireTime()
+	if c.workflowTask.StartTime == 0 {
+		c.workflowTask.StartTime = time.Now().Unix()
+	}
 	c.ack()
+
+	// continue the trace of the request which created the task.
+	ctx, span := tracing.Start(tracing.Extract(ctx, c.workflowTask.TraceContext), "workflow "+c.workflowTask.WorkflowName,
+		attribute.String("zadig.project", c.workflowTask.ProjectName),
+		attribute.Int64("zadig.task_id", c.workflowTask.TaskID),
+	)
+	c.logger = c.logger.With(tracing.Field(ctx))
 	c.logger.Infof("start workflow: %s,status: %s", c.workflowTask.WorkflowName, c.workflowTask.Status)
+	if !resumed {
+		c.sendNotifications()
+	}
 	defer func() {
 		c.workflowTask.EndTime = time.Now().Unix()
 		c.logger.Infof("finish workflow: %s,status: %s", c.workflowTask.WorkflowName, c.workflowTask.Status)
 		c.ack()
+		jobcontroller.EndSpan(span, c.workflowTask.Status, c.workflowTask.Error)
+		// the replica which has taken the task over sends the notifications.
+		if !c.lostLease() {
+			c.sendNotifications()
+		}
 	}()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	go c.keepLease(ctx, cancel)
 	cancelKey := fmt.Sprintf("%s-%d", c.workflowTask.WorkflowName, c.workflowTask.TaskID)
 	cancelChannelMap.Store(cancelKey, cancel)
 	defer cancelChannelMap.Delete(cancelKey)
@@ -126,23 +158,63 @@ func (c *workflowCtl) Run(ctx context.Context, concurrency int) {
 		DockerMountDir:    fmt.Sprintf("/tmp/%s/docker/%d", uuid.NewV4(), time.Now().Unix()),
 		ConfigMapMountDir: fmt.Sprintf("/tmp/%s/cm/%d", uuid.NewV4(), time.Now().Unix()),
 		WorkflowKeyVals:   c.workflowTask.KeyVals,
+		WorkflowParams:    c.workflowTask.Params,
+		TaskCreator:       c.workflowTask.TaskCreator,
 		GlobalContextGet:  c.getGlobalContext,
 		GlobalContextSet:  c.setGlobalContext,
 		GlobalContextEach: c.globalContextEach,
 	}
+	if c.workflowTask.WorkflowArgs != nil {
+		workflowCtx.HookPayload = c.workflowTask.WorkflowArgs.HookPayload
+	}
 
 	RunStages(ctx, c.workflowTask.Stages, workflowCtx, concurrency, c.logger, c.ack)
 	updateworkflowStatus(c.workflowTask)
 }
 
+// sendNotifications sends the notifications of the task with its current status in the background, the task is
+// copied first since the stages keep changing it.
+func (c *workflowCtl) sendNotifications() {
+	data, err := bson.Marshal(c.workflowTask)
+	if err != nil {
+		c.logger.Errorf("marshal workflow task error: %v", err)
+		return
+	}
+	task := &commonmodels.WorkflowTask{}
+	if err := bson.Unmarshal(data, task); err != nil {
+		c.logger.Errorf("unmarshal workflow task error: %v", err)
+		return
+	}
+	go sendNotificationsWithTimeout(c.logger, func(ctx context.Context) error {
+		return instantmessage.NewWeChatClient().SendWorkflowTaskNotifications(ctx, task)
+	})
+}
+
+// sendNotificationsWithTimeout calls send with a context which is done after notifyTimeout, the im, webhook and mail
+// requests made by send are given up then, so an unreachable receiver can not hold the sender forever.
+func sendNotificationsWithTimeout(logger *zap.SugaredLogger, send func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	if err := send(ctx); err != nil {
+		logger.Errorf("send workflow task notifications error: %v", err)
+		return
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		logger.Warnf("send workflow task notifications timeout after %s", notifyTimeout)
+	}
+}
+
 func updateworkflowStatus(workflow *commonmodels.WorkflowTask) {
 	statusMap := map[config.Status]int{
-		config.StatusReject:    5,
-		config.StatusCancelled: 4,
-		config.StatusTimeout:   3,
-		config.StatusFailed:    2,
-		config.StatusPassed:    1,
-		config.StatusSkipped:   0,
+		config.StatusApprovalTimeout: 7,
+		config.StatusReject:          6,
+		config.StatusCancelled:       5,
+		config.StatusTimeout:         4,
+		config.StatusFailed:          3,
+		config.StatusPassed:          2,
+		config.StatusNotRun:          1,
+		config.StatusSkipped:         0,
 	}
 
 	// 初始化workflowStatus为创建状态
@@ -180,7 +252,7 @@ func (c *workflowCtl) updateWorkflowTask() {
 		return
 	}
 	// 如果当前状态已经通过或者失败, 不处理新接受到的ACK
-	if taskInColl.Status == config.StatusPassed || taskInColl.Status == config.StatusFailed || taskInColl.Status == config.StatusTimeout || taskInColl.Status == config.StatusReject {
+	if taskInColl.Status == config.StatusPassed || taskInColl.Status == config.StatusFailed || taskInColl.Status == config.StatusTimeout || taskInColl.Status == config.StatusReject || taskInColl.Status == config.StatusApprovalTimeout {
 		c.logger.Infof("%s:%d:%s task already done", c.workflowTask.WorkflowName, c.workflowTask.TaskID, taskInColl.Status)
 		return
 	}
@@ -196,12 +268,16 @@ func (c *workflowCtl) updateWorkflowTask() {
 	if success := UpdateQueue(c.workflowTask); !success {
 		c.logger.Errorf("%s:%d update t status error", c.workflowTask.WorkflowName, c.workflowTask.TaskID)
 	}
-	// TODO update workflow task
-	if err := commonrepo.NewworkflowTaskv4Coll().Update(c.workflowTask.ID.Hex(), c.workflowTask); err != nil {
+	updated, err := commonrepo.NewworkflowTaskv4Coll().UpdateProgress(c.workflowTask)
+	if err != nil {
 		c.logger.Errorf("update workflow task v4 failed,error: %v", err)
 	}
+	if err == nil && !updated {
+		c.logger.Infof("%s:%d task is owned by another replica, ACK dropped", c.workflowTask.WorkflowName, c.workflowTask.TaskID)
+		return
+	}
 
-	if c.workflowTask.Status == config.StatusPassed || c.workflowTask.Status == config.StatusFailed || c.workflowTask.Status == config.StatusTimeout || c.workflowTask.Status == config.StatusCancelled || taskInColl.Status == config.StatusReject {
+	if c.workflowTask.Status == config.StatusPassed || c.workflowTask.Status == config.StatusFailed || c.workflowTask.Status == config.StatusTimeout || c.workflowTask.Status == config.StatusCancelled || taskInColl.Status == config.StatusReject || c.workflowTask.Status == config.StatusApprovalTimeout {
 		c.logger.Infof("%s:%d:%v task done", c.workflowTask.WorkflowName, c.workflowTask.TaskID, c.workflowTask.Status)
 		q := ConvertTaskToQueue(c.workflowTask)
 		if err := Remove(q); err != nil {
@@ -21,6 +21,7 @@ import (
 
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	cronservice "github.com/koderover/zadig/pkg/microservice/aslan/core/cron/service"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 )
 
@@ -162,3 +163,17 @@ func ListCronjob(c *gin.Context) {
 	ctx.Resp = resp
 	ctx.Err = err
 }
+
+func ListWorkflowV4Schedules(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = workflowservice.ListWorkflowV4Schedules(ctx.Logger)
+}
+
+func RunWorkflowV4Schedule(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = workflowservice.RunWorkflowV4Schedule(c.Param("workflowName"), c.Param("scheduleName"), ctx.Logger)
+}
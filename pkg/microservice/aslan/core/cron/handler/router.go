@@ -40,4 +40,10 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		cronjob.GET("", ListActiveCronjob)
 		cronjob.GET("/type/:type/name/:name", ListCronjob)
 	}
+
+	workflowV4 := router.Group("workflowv4")
+	{
+		workflowV4.GET("/schedule", ListWorkflowV4Schedules)
+		workflowV4.POST("/schedule/:workflowName/:scheduleName", RunWorkflowV4Schedule)
+	}
 }
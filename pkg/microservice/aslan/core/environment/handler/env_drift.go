@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/pkg/setting"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func DetectEnvDriftCronJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	service.DetectEnvDriftCronJob(ctx.Logger)
+}
+
+type listEnvDriftsArgs struct {
+	ProjectName string `form:"projectName"`
+	ServiceName string `form:"serviceName"`
+	Resolved    bool   `form:"resolved"`
+	PageNum     int64  `form:"pageNum"`
+	PageSize    int64  `form:"pageSize"`
+}
+
+func ListEnvDrifts(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := &listEnvDriftsArgs{}
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	ctx.Resp, ctx.Err = service.ListEnvDrifts(args.ProjectName, c.Param("name"), args.ServiceName, args.Resolved, args.PageNum, args.PageSize, ctx.Logger)
+}
+
+func GetEnvDrift(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetEnvDrift(c.Query("projectName"), c.Param("name"), c.Param("id"), ctx.Logger)
+}
+
+func DetectEnvDrift(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.DetectEnvDrift(c.Query("projectName"), c.Param("name"), ctx.Logger)
+}
+
+func ReapplyEnvDrift(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneEnv, "重新应用", "环境漂移", envName, c.Param("id"), ctx.Logger, envName)
+
+	ctx.Err = service.ReapplyEnvDrift(projectName, envName, c.Param("id"), ctx.UserName, ctx.Logger)
+}
+
+func AdoptEnvDrift(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneEnv, "采纳", "环境漂移", envName, c.Param("id"), ctx.Logger, envName)
+
+	ctx.Err = service.AdoptEnvDrift(projectName, envName, c.Param("id"), ctx.UserName, ctx.Logger)
+}
@@ -61,6 +61,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	cron := router.Group("cron")
 	{
 		cron.GET("/cleanproduct", CleanProductCronJob)
+		cron.GET("/envdrift", DetectEnvDriftCronJob)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -166,6 +167,17 @@ func (*Router) Inject(router *gin.RouterGroup) {
 
 		environments.GET("/:name/services/:serviceName/pmexec", ConnectSshPmExec)
 
+		environments.GET("/:name/snapshots", ListEnvSnapshots)
+		environments.GET("/:name/snapshots/:revision", GetEnvSnapshot)
+		environments.POST("/:name/snapshots/:revision/rollback", RollbackEnvToSnapshot)
+		environments.GET("/:name/snapshot-diff", DiffEnvSnapshots)
+
+		environments.GET("/:name/drifts", ListEnvDrifts)
+		environments.GET("/:name/drifts/:id", GetEnvDrift)
+		environments.POST("/:name/drift-detection", DetectEnvDrift)
+		environments.POST("/:name/drifts/:id/reapply", ReapplyEnvDrift)
+		environments.POST("/:name/drifts/:id/adopt", AdoptEnvDrift)
+
 		environments.POST("/:name/services/:serviceName/devmode/patch", PatchWorkload)
 		environments.POST("/:name/services/:serviceName/devmode/recover", RecoverWorkload)
 	}
@@ -0,0 +1,725 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	versionedclient "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/envsnapshot"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+	"github.com/koderover/zadig/pkg/tool/kube/serializer"
+)
+
+const (
+	envDriftModified = "modified"
+	envDriftMissing  = "missing"
+
+	envDriftResolutionReapply = "reapply"
+	envDriftResolutionAdopt   = "adopt"
+	envDriftResolutionSynced  = "synced"
+
+	envDriftMaskedValue = "******"
+)
+
+var (
+	// envDriftDetecting is set while the cron job is running, a new run is skipped until the last one ends.
+	envDriftDetecting int32
+
+	containerImagePathRegex = regexp.MustCompile(`^(.*\.(?:initContainers|containers)\[\d+\])\.image$`)
+)
+
+type EnvDriftList struct {
+	Total  int64                    `json:"total"`
+	Drifts []*commonmodels.EnvDrift `json:"drifts"`
+}
+
+// DetectEnvDriftCronJob detects the drifts of all the k8s yaml envs.
+func DetectEnvDriftCronJob(log *zap.SugaredLogger) {
+	if !atomic.CompareAndSwapInt32(&envDriftDetecting, 0, 1) {
+		log.Info("[DetectEnvDriftCronJob] the last run is not finished, skip")
+		return
+	}
+	defer atomic.StoreInt32(&envDriftDetecting, 0)
+
+	log.Info("[DetectEnvDriftCronJob] started ...")
+	defer log.Info("[DetectEnvDriftCronJob] end")
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{})
+	if err != nil {
+		log.Errorf("[Product.List] error: %v", err)
+		return
+	}
+	for _, env := range envs {
+		if !envDriftDetectable(env) {
+			continue
+		}
+		if err := detectEnvDrift(env, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to detect env drift: %v", env.EnvName, env.ProductName, err)
+		}
+	}
+}
+
+// DetectEnvDrift detects the drifts of the env now and returns the unresolved ones.
+func DetectEnvDrift(productName, envName string, log *zap.SugaredLogger) (*EnvDriftList, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s/%s: %v", productName, envName, err)
+		return nil, e.ErrDetectEnvDrift.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	if env.Source == setting.SourceFromExternal || getProjectType(productName) != setting.K8SDeployType {
+		return nil, e.ErrDetectEnvDrift.AddDesc("only the envs of k8s yaml projects are supported")
+	}
+	if !envDriftDetectable(env) {
+		return nil, e.ErrDetectEnvDrift.AddDesc(e.EnvCantUpdatedMsg)
+	}
+	if err := detectEnvDrift(env, log); err != nil {
+		log.Errorf("[%s][P:%s] failed to detect env drift: %v", envName, productName, err)
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+
+	drifts, total, err := commonrepo.NewEnvDriftColl().List(&commonrepo.ListEnvDriftOption{ProductName: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrDetectEnvDrift.AddErr(err)
+	}
+	return &EnvDriftList{Total: total, Drifts: drifts}, nil
+}
+
+func ListEnvDrifts(productName, envName, serviceName string, resolved bool, pageNum, pageSize int64, log *zap.SugaredLogger) (*EnvDriftList, error) {
+	drifts, total, err := commonrepo.NewEnvDriftColl().List(&commonrepo.ListEnvDriftOption{
+		ProductName: productName,
+		EnvName:     envName,
+		ServiceName: serviceName,
+		Resolved:    resolved,
+		PageNum:     pageNum,
+		PageSize:    pageSize,
+	})
+	if err != nil {
+		log.Errorf("failed to list drifts of env %s/%s: %v", productName, envName, err)
+		return nil, e.ErrListEnvDrifts.AddErr(err)
+	}
+	return &EnvDriftList{Total: total, Drifts: drifts}, nil
+}
+
+func GetEnvDrift(productName, envName, id string, log *zap.SugaredLogger) (*commonmodels.EnvDrift, error) {
+	drift, err := findEnvDrift(productName, envName, id)
+	if err != nil {
+		log.Errorf("failed to find drift %s of env %s/%s: %v", id, productName, envName, err)
+		return nil, e.ErrGetEnvDrift.AddErr(err)
+	}
+	return drift, nil
+}
+
+// ReapplyEnvDrift applies the service of the drift to the cluster again, which overrides the live changes
+// of all the resources of the service.
+func ReapplyEnvDrift(productName, envName, id, user string, log *zap.SugaredLogger) error {
+	drift, env, svc, err := prepareEnvDriftResolve(productName, envName, id)
+	if err != nil {
+		log.Errorf("failed to re-apply drift %s of env %s/%s: %v", id, productName, envName, err)
+		return e.ErrReapplyEnvDrift.AddErr(err)
+	}
+	renderSet, err := envRenderSet(env)
+	if err != nil {
+		return e.ErrReapplyEnvDrift.AddErr(err)
+	}
+	kubeClient, istioClient, inf, err := getEnvKubeClients(env)
+	if err != nil {
+		return e.ErrReapplyEnvDrift.AddErr(err)
+	}
+
+	if _, err := upsertService(true, env, svc, nil, renderSet, inf, kubeClient, istioClient, log); err != nil {
+		log.Errorf("[%s][P:%s] failed to re-apply service %s: %v", envName, productName, svc.ServiceName, err)
+		return e.ErrReapplyEnvDrift.AddErr(err)
+	}
+	if err := commonrepo.NewEnvDriftColl().ResolveService(productName, envName, drift.ServiceName, envDriftResolutionReapply, user); err != nil {
+		return e.ErrReapplyEnvDrift.AddErr(err)
+	}
+	return nil
+}
+
+// AdoptEnvDrift keeps the live changes of the resource by updating the env, only the changes of container images
+// can be adopted since the other fields come from the service template shared by all the envs.
+func AdoptEnvDrift(productName, envName, id, user string, log *zap.SugaredLogger) error {
+	drift, env, svc, err := prepareEnvDriftResolve(productName, envName, id)
+	if err != nil {
+		log.Errorf("failed to adopt drift %s of env %s/%s: %v", id, productName, envName, err)
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	if drift.Type == envDriftMissing {
+		return e.ErrAdoptEnvDrift.AddDesc("the resource is missing in the cluster, re-apply it instead")
+	}
+	renderSet, err := envRenderSet(env)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+
+	resources, err := renderEnvDriftResources(env, renderSet, svc)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	var expected *unstructured.Unstructured
+	for _, res := range resources {
+		if res.GetKind() == drift.Kind && res.GetName() == drift.Name {
+			expected = res
+			break
+		}
+	}
+	if expected == nil {
+		return e.ErrAdoptEnvDrift.AddDesc(fmt.Sprintf("%s %s is no longer in service %s", drift.Kind, drift.Name, drift.ServiceName))
+	}
+	live, found, err := getEnvDriftLiveObject(env, expected, kubeClient)
+	if err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	if !found {
+		return e.ErrAdoptEnvDrift.AddDesc("the resource is missing in the cluster, re-apply it instead")
+	}
+
+	images, others := adoptableImages(expected.Object, diffManifest(expected.Object, live.Object))
+	if len(others) > 0 {
+		return e.ErrAdoptEnvDrift.AddDesc(fmt.Sprintf("only container images can be adopted, change %s in the service template instead", strings.Join(others, ", ")))
+	}
+	if len(images) > 0 {
+		if err := adoptServiceImages(env, svc, images); err != nil {
+			log.Errorf("[%s][P:%s] failed to adopt images of service %s: %v", envName, productName, svc.ServiceName, err)
+			return e.ErrAdoptEnvDrift.AddErr(err)
+		}
+		if _, err := envsnapshot.Create(productName, envName, envsnapshot.SourceDriftAdopt, user, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to create env snapshot: %v", envName, productName, err)
+		}
+	}
+	if err := commonrepo.NewEnvDriftColl().Resolve([]primitive.ObjectID{drift.ID}, envDriftResolutionAdopt, user); err != nil {
+		return e.ErrAdoptEnvDrift.AddErr(err)
+	}
+	return nil
+}
+
+func findEnvDrift(productName, envName, id string) (*commonmodels.EnvDrift, error) {
+	drift, err := commonrepo.NewEnvDriftColl().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if drift.ProductName != productName || drift.EnvName != envName {
+		return nil, fmt.Errorf("drift %s is not found in env %s/%s", id, productName, envName)
+	}
+	return drift, nil
+}
+
+// prepareEnvDriftResolve returns the unresolved drift, the env and the service the drift belongs to.
+func prepareEnvDriftResolve(productName, envName, id string) (*commonmodels.EnvDrift, *commonmodels.Product, *commonmodels.ProductService, error) {
+	drift, err := findEnvDrift(productName, envName, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if drift.Resolved {
+		return nil, nil, nil, fmt.Errorf("drift %s is already resolved", id)
+	}
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find env %s/%s: %v", productName, envName, err)
+	}
+	if !envDriftDetectable(env) {
+		return nil, nil, nil, fmt.Errorf("env %s/%s can not be updated now", productName, envName)
+	}
+	svc, ok := env.GetServiceMap()[drift.ServiceName]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("service %s is not in env %s/%s", drift.ServiceName, productName, envName)
+	}
+	return drift, env, svc, nil
+}
+
+func envDriftDetectable(env *commonmodels.Product) bool {
+	if env.Source == setting.SourceFromExternal {
+		return false
+	}
+	switch env.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return false
+	}
+	return getProjectType(env.ProductName) == setting.K8SDeployType
+}
+
+// envRenderSet returns the renderset the env runs with.
+func envRenderSet(env *commonmodels.Product) (*commonmodels.RenderSet, error) {
+	opt := &commonrepo.RenderSetFindOption{Name: env.Namespace, EnvName: env.EnvName, ProductTmpl: env.ProductName}
+	if env.Render != nil && env.Render.Name != "" {
+		opt.Name = env.Render.Name
+		opt.Revision = env.Render.Revision
+	}
+	renderSet, err := commonrepo.NewRenderSetColl().Find(opt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find renderset %s: %v", opt.Name, err)
+	}
+	return renderSet, nil
+}
+
+func getEnvKubeClients(env *commonmodels.Product) (client.Client, versionedclient.Interface, informers.SharedInformerFactory, error) {
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	restConfig, err := kubeclient.GetRESTConfig(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	istioClient, err := versionedclient.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cls, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	inf, err := informer.NewInformer(env.ClusterID, env.Namespace, cls)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return kubeClient, istioClient, inf, nil
+}
+
+// detectEnvDrift compares the resources rendered from the services of the env with the live objects, records
+// the drifts found and resolves the ones which are gone.
+func detectEnvDrift(env *commonmodels.Product, log *zap.SugaredLogger) error {
+	renderSet, err := envRenderSet(env)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	services := env.GetServiceMap()
+	// the drifts of the services failed to render and of the resources failed to check are kept.
+	checked := sets.NewString()
+	kept := sets.NewString()
+	for _, svc := range services {
+		if svc.Type != setting.K8SDeployType {
+			continue
+		}
+		resources, err := renderEnvDriftResources(env, renderSet, svc)
+		if err != nil {
+			log.Warnf("[%s][P:%s] failed to render service %s: %v", env.EnvName, env.ProductName, svc.ServiceName, err)
+			continue
+		}
+		checked.Insert(svc.ServiceName)
+
+		for _, res := range resources {
+			key := envDriftKey(svc.ServiceName, res.GetKind(), res.GetName())
+			drift, err := diffEnvResource(env, svc.ServiceName, res, kubeClient)
+			if err != nil {
+				log.Warnf("[%s][P:%s] failed to check %s %s: %v", env.EnvName, env.ProductName, res.GetKind(), res.GetName(), err)
+				kept.Insert(key)
+				continue
+			}
+			if drift == nil {
+				continue
+			}
+			kept.Insert(key)
+			if err := commonrepo.NewEnvDriftColl().Upsert(drift); err != nil {
+				return fmt.Errorf("failed to save drift of %s %s: %v", drift.Kind, drift.Name, err)
+			}
+		}
+	}
+
+	drifts, _, err := commonrepo.NewEnvDriftColl().List(&commonrepo.ListEnvDriftOption{ProductName: env.ProductName, EnvName: env.EnvName})
+	if err != nil {
+		return err
+	}
+	synced := make([]primitive.ObjectID, 0)
+	for _, drift := range drifts {
+		if _, ok := services[drift.ServiceName]; ok && !checked.Has(drift.ServiceName) {
+			continue
+		}
+		if kept.Has(envDriftKey(drift.ServiceName, drift.Kind, drift.Name)) {
+			continue
+		}
+		synced = append(synced, drift.ID)
+	}
+	return commonrepo.NewEnvDriftColl().Resolve(synced, envDriftResolutionSynced, setting.SystemUser)
+}
+
+func envDriftKey(serviceName, kind, name string) string {
+	return strings.Join([]string{serviceName, kind, name}, "/")
+}
+
+// renderEnvDriftResources renders the resources of the service in the env, jobs are skipped since they are
+// recreated on every update and may be cleaned up after finished.
+func renderEnvDriftResources(env *commonmodels.Product, renderSet *commonmodels.RenderSet, svc *commonmodels.ProductService) ([]*unstructured.Unstructured, error) {
+	parsedYaml, err := renderService(env, renderSet, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*unstructured.Unstructured, 0)
+	for _, item := range releaseutil.SplitManifests(*parsedYaml) {
+		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %v", err)
+		}
+		if u.GetKind() == setting.Job || u.GetName() == "" {
+			continue
+		}
+		resources = append(resources, u)
+	}
+	return resources, nil
+}
+
+func getEnvDriftLiveObject(env *commonmodels.Product, expected *unstructured.Unstructured, kubeClient client.Client) (*unstructured.Unstructured, bool, error) {
+	namespace := env.Namespace
+	switch expected.GetKind() {
+	case setting.ClusterRole, setting.ClusterRoleBinding:
+		namespace = ""
+	}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(expected.GroupVersionKind())
+	found, err := getter.GetResourceInCache(namespace, expected.GetName(), live, kubeClient)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	return live, true, nil
+}
+
+// diffEnvResource returns the drift of the resource, or nil if the live object is the same as the rendered one.
+func diffEnvResource(env *commonmodels.Product, serviceName string, expected *unstructured.Unstructured, kubeClient client.Client) (*commonmodels.EnvDrift, error) {
+	live, found, err := getEnvDriftLiveObject(env, expected, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizeSecretData(expected)
+	normalizeWorkload(expected)
+	drift := &commonmodels.EnvDrift{
+		ProductName: env.ProductName,
+		EnvName:     env.EnvName,
+		ServiceName: serviceName,
+		Kind:        expected.GetKind(),
+		Name:        expected.GetName(),
+		Namespace:   env.Namespace,
+		Type:        envDriftMissing,
+		Diffs:       make([]*commonmodels.EnvDriftField, 0),
+	}
+	if found {
+		drift.Namespace = live.GetNamespace()
+		drift.Diffs = diffManifest(expected.Object, live.Object)
+		if len(drift.Diffs) == 0 {
+			return nil, nil
+		}
+		drift.Type = envDriftModified
+	}
+
+	// the data of secrets is not recorded.
+	if drift.Kind == setting.Secret {
+		for _, diff := range drift.Diffs {
+			diff.Expected, diff.Live = envDriftMaskedValue, envDriftMaskedValue
+		}
+		return drift, nil
+	}
+	if drift.Expected, err = manifestString(expected.Object); err != nil {
+		return nil, err
+	}
+	if found {
+		live.SetManagedFields(nil)
+		unstructured.RemoveNestedField(live.Object, "status")
+		if drift.Live, err = manifestString(live.Object); err != nil {
+			return nil, err
+		}
+	}
+	return drift, nil
+}
+
+// normalizeSecretData moves the stringData of a secret to the data since only the data is returned by kubernetes.
+func normalizeSecretData(u *unstructured.Unstructured) {
+	if u.GetKind() != setting.Secret {
+		return
+	}
+	stringData, found, err := unstructured.NestedStringMap(u.Object, "stringData")
+	if err != nil || !found {
+		return
+	}
+	data, _, err := unstructured.NestedMap(u.Object, "data")
+	if err != nil || data == nil {
+		data = make(map[string]interface{})
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	_ = unstructured.SetNestedMap(u.Object, data, "data")
+	unstructured.RemoveNestedField(u.Object, "stringData")
+}
+
+// normalizeWorkload makes the rendered workload the same as the one zadig applies. The imagePullSecrets injected by
+// applySystemImagePullSecrets are added, and the replicas are removed since workloads are scaled by zadig and by
+// the horizontal pod autoscalers without updating the service.
+func normalizeWorkload(u *unstructured.Unstructured) {
+	var podSpecPath []string
+	switch u.GetKind() {
+	case setting.Deployment, setting.StatefulSet:
+		unstructured.RemoveNestedField(u.Object, "spec", "replicas")
+		podSpecPath = []string{"spec", "template", "spec"}
+	case setting.CronJob:
+		podSpecPath = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return
+	}
+
+	secretsPath := append(podSpecPath, "imagePullSecrets")
+	secrets, _, err := unstructured.NestedSlice(u.Object, secretsPath...)
+	if err != nil {
+		return
+	}
+	for _, secret := range secrets {
+		if ref, ok := secret.(map[string]interface{}); ok && ref["name"] == setting.DefaultImagePullSecret {
+			return
+		}
+	}
+	secrets = append(secrets, map[string]interface{}{"name": setting.DefaultImagePullSecret})
+	_ = unstructured.SetNestedSlice(u.Object, secrets, secretsPath...)
+}
+
+func manifestString(obj map[string]interface{}) (string, error) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// diffManifest compares the fields in the rendered object with the ones in the live object, the fields only in
+// the live object are set by kubernetes or zadig and are ignored.
+func diffManifest(expected, live map[string]interface{}) []*commonmodels.EnvDriftField {
+	diffs := make([]*commonmodels.EnvDriftField, 0)
+	for _, key := range sortedKeys(expected) {
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			expectedMeta, _ := expected[key].(map[string]interface{})
+			liveMeta, _ := live[key].(map[string]interface{})
+			for _, field := range []string{"labels", "annotations"} {
+				diffs = diffValue("metadata."+field, expectedMeta[field], liveMeta[field], diffs)
+			}
+		default:
+			diffs = diffValue(key, expected[key], live[key], diffs)
+		}
+	}
+	return diffs
+}
+
+func diffValue(path string, expected, live interface{}, diffs []*commonmodels.EnvDriftField) []*commonmodels.EnvDriftField {
+	if live == nil && isEmptyValue(expected) {
+		return diffs
+	}
+
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(exp) {
+			diffs = diffValue(path+"."+key, exp[key], liveMap[key], diffs)
+		}
+		return diffs
+	case []interface{}:
+		liveList, ok := live.([]interface{})
+		if !ok || len(liveList) != len(exp) {
+			break
+		}
+		for i := range exp {
+			diffs = diffValue(fmt.Sprintf("%s[%d]", path, i), exp[i], liveList[i], diffs)
+		}
+		return diffs
+	default:
+		if scalarEqual(exp, live) {
+			return diffs
+		}
+	}
+
+	return append(diffs, &commonmodels.EnvDriftField{Path: path, Expected: driftValueString(expected), Live: driftValueString(live)})
+}
+
+// isEmptyValue returns true for the values kubernetes omits, such as false, 0 and empty lists.
+func isEmptyValue(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case bool:
+		return !value
+	case int64:
+		return value == 0
+	case float64:
+		return value == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+// scalarEqual compares the values as strings, or as quantities such as 1 and 1000m.
+func scalarEqual(expected, live interface{}) bool {
+	if live == nil {
+		return false
+	}
+	expectedStr, liveStr := fmt.Sprint(expected), fmt.Sprint(live)
+	if expectedStr == liveStr {
+		return true
+	}
+	expectedQuantity, err := resource.ParseQuantity(expectedStr)
+	if err != nil {
+		return false
+	}
+	liveQuantity, err := resource.ParseQuantity(liveStr)
+	if err != nil {
+		return false
+	}
+	return expectedQuantity.Cmp(liveQuantity) == 0
+}
+
+func driftValueString(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return strings.TrimSpace(string(data))
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// adoptableImages returns the live images of the containers in the diffs and the paths of the other diffs.
+func adoptableImages(expected map[string]interface{}, diffs []*commonmodels.EnvDriftField) (map[string]string, []string) {
+	images := make(map[string]string)
+	others := make([]string, 0)
+	for _, diff := range diffs {
+		match := containerImagePathRegex.FindStringSubmatch(diff.Path)
+		if match == nil {
+			others = append(others, diff.Path)
+			continue
+		}
+		container, _ := lookupPath(expected, match[1]).(map[string]interface{})
+		name, _ := container["name"].(string)
+		if name == "" || diff.Live == "" {
+			others = append(others, diff.Path)
+			continue
+		}
+		images[name] = diff.Live
+	}
+	return images, others
+}
+
+// lookupPath returns the value at a path like spec.template.spec.containers[0], or nil if it's not found.
+func lookupPath(obj interface{}, path string) interface{} {
+	for _, segment := range strings.Split(path, ".") {
+		key, indexes := segment, []int{}
+		if i := strings.Index(segment, "["); i >= 0 {
+			key = segment[:i]
+			for _, idx := range strings.Split(strings.TrimSuffix(segment[i+1:], "]"), "][") {
+				n, err := strconv.Atoi(idx)
+				if err != nil {
+					return nil
+				}
+				indexes = append(indexes, n)
+			}
+		}
+
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = m[key]
+		for _, n := range indexes {
+			l, ok := obj.([]interface{})
+			if !ok || n < 0 || n >= len(l) {
+				return nil
+			}
+			obj = l[n]
+		}
+	}
+	return obj
+}
+
+// adoptServiceImages sets the images of the containers of the service in the env.
+func adoptServiceImages(env *commonmodels.Product, svc *commonmodels.ProductService, images map[string]string) error {
+	adopted := sets.NewString()
+	for _, container := range svc.Containers {
+		if image, ok := images[container.Name]; ok {
+			container.Image = image
+			adopted.Insert(container.Name)
+		}
+	}
+	for name := range images {
+		if !adopted.Has(name) {
+			return fmt.Errorf("container %s is not in service %s", name, svc.ServiceName)
+		}
+	}
+
+	for i, group := range env.Services {
+		for _, s := range group {
+			if s.ServiceName == svc.ServiceName {
+				return commonrepo.NewProductColl().UpdateGroup(env.EnvName, env.ProductName, i, group)
+			}
+		}
+	}
+	return fmt.Errorf("service %s is not in env %s/%s", svc.ServiceName, env.ProductName, env.EnvName)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+var testExpectedDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      hostNetwork: false
+      containers:
+      - name: test
+        image: test:v1
+        resources:
+          limits:
+            cpu: "1"
+            memory: 1Gi
+`
+
+var testLiveDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+    s-product: test_product
+  resourceVersion: "100"
+spec:
+  replicas: 2
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      containers:
+      - name: test
+        image: test:v2
+        imagePullPolicy: IfNotPresent
+        resources:
+          limits:
+            cpu: 1000m
+            memory: 1024Mi
+status:
+  replicas: 2
+`
+
+var testLiveInjectedDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: test
+        image: test:v1
+        resources:
+          limits:
+            cpu: "1"
+            memory: 1Gi
+      imagePullSecrets:
+      - name: default-registry-secret
+`
+
+var _ = Describe("Testing env drift", func() {
+
+	Describe("test diffManifest", func() {
+
+		It("should ignore the fields set by kubernetes and the equal quantities", func() {
+			expected, live := map[string]interface{}{}, map[string]interface{}{}
+			Expect(yaml.Unmarshal([]byte(testExpectedDeployment), &expected)).To(Succeed())
+			Expect(yaml.Unmarshal([]byte(testLiveDeployment), &live)).To(Succeed())
+
+			diffs := diffManifest(expected, live)
+			Expect(diffs).To(HaveLen(2))
+			Expect(diffs[0].Path).To(Equal("spec.replicas"))
+			Expect(diffs[0].Expected).To(Equal("1"))
+			Expect(diffs[0].Live).To(Equal("2"))
+			Expect(diffs[1].Path).To(Equal("spec.template.spec.containers[0].image"))
+		})
+	})
+
+	Describe("test normalizeWorkload", func() {
+
+		It("should ignore the replicas and the image pull secret injected by zadig", func() {
+			expected, live := &unstructured.Unstructured{}, map[string]interface{}{}
+			Expect(yaml.Unmarshal([]byte(testExpectedDeployment), &expected.Object)).To(Succeed())
+			Expect(yaml.Unmarshal([]byte(testLiveInjectedDeployment), &live)).To(Succeed())
+
+			normalizeWorkload(expected)
+			Expect(diffManifest(expected.Object, live)).To(BeEmpty())
+		})
+
+		It("should not inject the image pull secret twice", func() {
+			expected := &unstructured.Unstructured{}
+			Expect(yaml.Unmarshal([]byte(testLiveInjectedDeployment), &expected.Object)).To(Succeed())
+
+			normalizeWorkload(expected)
+			secrets, _, _ := unstructured.NestedSlice(expected.Object, "spec", "template", "spec", "imagePullSecrets")
+			Expect(secrets).To(HaveLen(1))
+		})
+
+		It("should keep the image pull secrets of the other workloads", func() {
+			expected := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}}
+			normalizeWorkload(expected)
+			Expect(expected.Object).To(Equal(map[string]interface{}{"kind": "Service"}))
+		})
+	})
+
+	Describe("test adoptableImages", func() {
+
+		It("should return the live images by the container names", func() {
+			expected, live := map[string]interface{}{}, map[string]interface{}{}
+			Expect(yaml.Unmarshal([]byte(testExpectedDeployment), &expected)).To(Succeed())
+			Expect(yaml.Unmarshal([]byte(testLiveDeployment), &live)).To(Succeed())
+
+			images, others := adoptableImages(expected, diffManifest(expected, live))
+			Expect(images).To(Equal(map[string]string{"test": "test:v2"}))
+			Expect(others).To(Equal([]string{"spec.replicas"}))
+		})
+	})
+})
@@ -0,0 +1,556 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/envsnapshot"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	helmtool "github.com/koderover/zadig/pkg/tool/helmclient"
+)
+
+const (
+	snapshotDiffAdded    = "added"
+	snapshotDiffDeleted  = "deleted"
+	snapshotDiffModified = "modified"
+)
+
+type EnvSnapshotList struct {
+	Total     int64                       `json:"total"`
+	Snapshots []*commonmodels.EnvSnapshot `json:"snapshots"`
+}
+
+type EnvSnapshotDiff struct {
+	From       int64                    `json:"from"`
+	To         int64                    `json:"to"`
+	Services   []*ServiceSnapshotDiff   `json:"services"`
+	KVs        []*KVSnapshotDiff        `json:"kvs"`
+	Values     []*ValuesSnapshotDiff    `json:"values"`
+	EnvConfigs []*EnvConfigSnapshotDiff `json:"env_configs"`
+}
+
+type ServiceSnapshotDiff struct {
+	ServiceName  string               `json:"service_name"`
+	Status       string               `json:"status"`
+	FromRevision int64                `json:"from_revision"`
+	ToRevision   int64                `json:"to_revision"`
+	Images       []*ImageSnapshotDiff `json:"images"`
+}
+
+type ImageSnapshotDiff struct {
+	Container string `json:"container"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+type KVSnapshotDiff struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ValuesSnapshotDiff is the diff of the default values if the service name is empty, or the values of the chart.
+type ValuesSnapshotDiff struct {
+	ServiceName string `json:"service_name"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+type EnvConfigSnapshotDiff struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+func ListEnvSnapshots(productName, envName string, pageNum, pageSize int64, log *zap.SugaredLogger) (*EnvSnapshotList, error) {
+	snapshots, total, err := commonrepo.NewEnvSnapshotColl().List(&commonrepo.ListEnvSnapshotOption{
+		ProductName: productName,
+		EnvName:     envName,
+		PageNum:     pageNum,
+		PageSize:    pageSize,
+	})
+	if err != nil {
+		log.Errorf("failed to list snapshots of env %s/%s: %v", productName, envName, err)
+		return nil, e.ErrListEnvSnapshots.AddErr(err)
+	}
+	return &EnvSnapshotList{Total: total, Snapshots: snapshots}, nil
+}
+
+func GetEnvSnapshot(productName, envName string, revision int64, log *zap.SugaredLogger) (*commonmodels.EnvSnapshot, error) {
+	snapshot, err := commonrepo.NewEnvSnapshotColl().Find(productName, envName, revision)
+	if err != nil {
+		log.Errorf("failed to find snapshot %d of env %s/%s: %v", revision, productName, envName, err)
+		return nil, e.ErrGetEnvSnapshot.AddErr(err)
+	}
+	return snapshot, nil
+}
+
+// DiffEnvSnapshots returns the changes from one snapshot to another, the services, the variables and the env configs.
+func DiffEnvSnapshots(productName, envName string, from, to int64, log *zap.SugaredLogger) (*EnvSnapshotDiff, error) {
+	fromSnapshot, err := commonrepo.NewEnvSnapshotColl().Find(productName, envName, from)
+	if err != nil {
+		log.Errorf("failed to find snapshot %d of env %s/%s: %v", from, productName, envName, err)
+		return nil, e.ErrDiffEnvSnapshots.AddErr(err)
+	}
+	toSnapshot, err := commonrepo.NewEnvSnapshotColl().Find(productName, envName, to)
+	if err != nil {
+		log.Errorf("failed to find snapshot %d of env %s/%s: %v", to, productName, envName, err)
+		return nil, e.ErrDiffEnvSnapshots.AddErr(err)
+	}
+
+	resp := &EnvSnapshotDiff{
+		From:     from,
+		To:       to,
+		Services: diffSnapshotServices(fromSnapshot.GetServiceMap(), toSnapshot.GetServiceMap()),
+	}
+	fromRenderSet, toRenderSet := fromSnapshot.RenderSet, toSnapshot.RenderSet
+	if fromRenderSet == nil {
+		fromRenderSet = &commonmodels.RenderSet{}
+	}
+	if toRenderSet == nil {
+		toRenderSet = &commonmodels.RenderSet{}
+	}
+	resp.KVs = diffSnapshotKVs(fromRenderSet.GetKeyValueMap(), toRenderSet.GetKeyValueMap())
+	resp.Values = diffSnapshotValues(fromRenderSet, toRenderSet)
+	resp.EnvConfigs = diffSnapshotEnvConfigs(fromSnapshot.EnvConfigs, toSnapshot.EnvConfigs)
+	return resp, nil
+}
+
+// the diffs are in the order of the names, the maps are walked by their sorted keys.
+func diffSnapshotServices(from, to map[string]*commonmodels.ProductService) []*ServiceSnapshotDiff {
+	resp := make([]*ServiceSnapshotDiff, 0)
+	for _, name := range sets.StringKeySet(from).Union(sets.StringKeySet(to)).List() {
+		fromSvc, inFrom := from[name]
+		toSvc, inTo := to[name]
+		switch {
+		case !inTo:
+			resp = append(resp, &ServiceSnapshotDiff{ServiceName: name, Status: snapshotDiffDeleted, FromRevision: fromSvc.Revision})
+		case !inFrom:
+			resp = append(resp, &ServiceSnapshotDiff{
+				ServiceName: name,
+				Status:      snapshotDiffAdded,
+				ToRevision:  toSvc.Revision,
+				Images:      diffContainerImages(nil, toSvc.Containers),
+			})
+		default:
+			images := diffContainerImages(fromSvc.Containers, toSvc.Containers)
+			if fromSvc.Revision == toSvc.Revision && len(images) == 0 {
+				continue
+			}
+			resp = append(resp, &ServiceSnapshotDiff{
+				ServiceName:  name,
+				Status:       snapshotDiffModified,
+				FromRevision: fromSvc.Revision,
+				ToRevision:   toSvc.Revision,
+				Images:       images,
+			})
+		}
+	}
+	return resp
+}
+
+func diffContainerImages(from, to []*commonmodels.Container) []*ImageSnapshotDiff {
+	resp := make([]*ImageSnapshotDiff, 0)
+	fromMap := buildContainerMap(from)
+	toMap := buildContainerMap(to)
+	for _, name := range sets.StringKeySet(fromMap).Union(sets.StringKeySet(toMap)).List() {
+		fromImage, toImage := "", ""
+		if container, ok := fromMap[name]; ok {
+			fromImage = container.Image
+		}
+		if container, ok := toMap[name]; ok {
+			toImage = container.Image
+		}
+		if fromImage != toImage {
+			resp = append(resp, &ImageSnapshotDiff{Container: name, From: fromImage, To: toImage})
+		}
+	}
+	return resp
+}
+
+func diffSnapshotKVs(from, to map[string]string) []*KVSnapshotDiff {
+	resp := make([]*KVSnapshotDiff, 0)
+	for _, key := range sets.StringKeySet(from).Union(sets.StringKeySet(to)).List() {
+		fromValue, inFrom := from[key]
+		toValue, inTo := to[key]
+		if inFrom != inTo || fromValue != toValue {
+			resp = append(resp, &KVSnapshotDiff{Key: key, From: fromValue, To: toValue})
+		}
+	}
+	return resp
+}
+
+func diffSnapshotValues(from, to *commonmodels.RenderSet) []*ValuesSnapshotDiff {
+	resp := make([]*ValuesSnapshotDiff, 0)
+	if from.DefaultValues != to.DefaultValues {
+		resp = append(resp, &ValuesSnapshotDiff{From: from.DefaultValues, To: to.DefaultValues})
+	}
+	fromValues := make(map[string]string)
+	for _, chart := range from.ChartInfos {
+		fromValues[chart.ServiceName] = chartValues(chart)
+	}
+	toValues := make(map[string]string)
+	for _, chart := range to.ChartInfos {
+		toValues[chart.ServiceName] = chartValues(chart)
+	}
+	for _, name := range sets.StringKeySet(fromValues).Union(sets.StringKeySet(toValues)).List() {
+		if fromValues[name] != toValues[name] {
+			resp = append(resp, &ValuesSnapshotDiff{ServiceName: name, From: fromValues[name], To: toValues[name]})
+		}
+	}
+	return resp
+}
+
+// chartValues joins the values of the chart, the override yaml and the override values.
+func chartValues(chart *templatemodels.RenderChart) string {
+	return strings.Join([]string{chart.ValuesYaml, chart.GetOverrideYaml(), chart.OverrideValues}, "\n---\n")
+}
+
+func diffSnapshotEnvConfigs(from, to []*commonmodels.EnvSnapshotConfig) []*EnvConfigSnapshotDiff {
+	resp := make([]*EnvConfigSnapshotDiff, 0)
+	fromMap := make(map[string]*commonmodels.EnvSnapshotConfig)
+	for _, cfg := range from {
+		fromMap[cfg.Type+"/"+cfg.Name] = cfg
+	}
+	toMap := make(map[string]*commonmodels.EnvSnapshotConfig)
+	for _, cfg := range to {
+		toMap[cfg.Type+"/"+cfg.Name] = cfg
+	}
+	for _, key := range sets.StringKeySet(fromMap).Union(sets.StringKeySet(toMap)).List() {
+		fromCfg, inFrom := fromMap[key]
+		toCfg, inTo := toMap[key]
+		switch {
+		case !inTo:
+			resp = append(resp, &EnvConfigSnapshotDiff{Name: fromCfg.Name, Type: fromCfg.Type, Status: snapshotDiffDeleted, From: fromCfg.YamlData})
+		case !inFrom:
+			resp = append(resp, &EnvConfigSnapshotDiff{Name: toCfg.Name, Type: toCfg.Type, Status: snapshotDiffAdded, To: toCfg.YamlData})
+		case fromCfg.YamlData != toCfg.YamlData:
+			resp = append(resp, &EnvConfigSnapshotDiff{Name: toCfg.Name, Type: toCfg.Type, Status: snapshotDiffModified, From: fromCfg.YamlData, To: toCfg.YamlData})
+		}
+	}
+	return resp
+}
+
+// RollbackEnvToSnapshot rolls the env back to the snapshot, or only the service if the service name is set.
+// A service rollback restores the revision and the images (chart values for helm) of the service, the variables
+// and the env configs shared by other services are only restored by an env rollback.
+func RollbackEnvToSnapshot(productName, envName string, revision int64, serviceName, user, requestID string, log *zap.SugaredLogger) error {
+	snapshot, err := commonrepo.NewEnvSnapshotColl().Find(productName, envName, revision)
+	if err != nil {
+		log.Errorf("failed to find snapshot %d of env %s/%s: %v", revision, productName, envName, err)
+		return e.ErrRollbackEnv.AddErr(err)
+	}
+	snapshotServices := snapshot.GetServiceMap()
+	if serviceName != "" {
+		if _, ok := snapshotServices[serviceName]; !ok {
+			return e.ErrRollbackEnv.AddDesc(fmt.Sprintf("service %s is not found in snapshot %d", serviceName, revision))
+		}
+	}
+
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s/%s: %v", productName, envName, err)
+		return e.ErrRollbackEnv.AddDesc(e.EnvNotFoundErrMsg)
+	}
+	switch env.Status {
+	case setting.ProductStatusCreating, setting.ProductStatusUpdating, setting.ProductStatusDeleting:
+		return e.ErrRollbackEnv.AddDesc(e.EnvCantUpdatedMsg)
+	}
+
+	// services added after the snapshot are removed by an env rollback.
+	if serviceName == "" {
+		addedServices := make([]string, 0)
+		for _, svc := range env.GetServiceMap() {
+			if _, ok := snapshotServices[svc.ServiceName]; !ok {
+				addedServices = append(addedServices, svc.ServiceName)
+			}
+		}
+		if len(addedServices) > 0 {
+			if err := DeleteProductServices(user, requestID, envName, productName, addedServices, log); err != nil {
+				log.Errorf("failed to delete services %v of env %s/%s: %v", addedServices, productName, envName, err)
+				return e.ErrRollbackEnv.AddErr(err)
+			}
+			if env, err = commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName}); err != nil {
+				return e.ErrRollbackEnv.AddErr(err)
+			}
+		}
+	}
+	env.Services = rollbackServices(env, snapshot, serviceName)
+
+	if err := commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUpdating); err != nil {
+		log.Errorf("[%s][P:%s] Product.UpdateStatus error: %v", envName, productName, err)
+		return e.ErrRollbackEnv.AddDesc(e.UpdateEnvStatusErrMsg)
+	}
+
+	go func() {
+		var err error
+		if getProjectType(productName) == setting.HelmDeployType {
+			err = rollbackHelmEnv(env, snapshot, serviceName, user, log)
+		} else {
+			err = rollbackK8sEnv(env, snapshot, serviceName, user, log)
+		}
+		if err != nil {
+			log.Errorf("[%s][P:%s] failed to roll back env to snapshot %d: %v", envName, productName, revision, err)
+			title := fmt.Sprintf("回滚 [%s] 的 [%s] 环境失败", productName, envName)
+			commonservice.SendErrorMessage(user, title, requestID, err, log)
+			if err := commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, setting.ProductStatusFailed, err.Error()); err != nil {
+				log.Errorf("[%s][P:%s] Product.UpdateStatusAndError error: %v", envName, productName, err)
+			}
+			return
+		}
+		if err := commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, setting.ProductStatusSuccess, ""); err != nil {
+			log.Errorf("[%s][P:%s] Product.UpdateStatusAndError error: %v", envName, productName, err)
+			return
+		}
+		if _, err := envsnapshot.Create(productName, envName, envsnapshot.SourceRollback, user, log); err != nil {
+			log.Errorf("[%s][P:%s] failed to create env snapshot: %v", envName, productName, err)
+		}
+	}()
+	return nil
+}
+
+// rollbackServices returns the services of the env after the rollback.
+func rollbackServices(env *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, serviceName string) [][]*commonmodels.ProductService {
+	if serviceName == "" {
+		return snapshot.Services
+	}
+	target := snapshot.GetServiceMap()[serviceName]
+	resp := make([][]*commonmodels.ProductService, 0, len(env.Services))
+	found := false
+	for _, group := range env.Services {
+		newGroup := make([]*commonmodels.ProductService, 0, len(group))
+		for _, svc := range group {
+			if svc.ServiceName == serviceName {
+				newGroup = append(newGroup, target)
+				found = true
+				continue
+			}
+			newGroup = append(newGroup, svc)
+		}
+		resp = append(resp, newGroup)
+	}
+	if !found {
+		if len(resp) == 0 {
+			resp = append(resp, []*commonmodels.ProductService{})
+		}
+		resp[len(resp)-1] = append(resp[len(resp)-1], target)
+	}
+	return resp
+}
+
+func rollbackK8sEnv(env *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, serviceName, user string, log *zap.SugaredLogger) error {
+	if serviceName == "" && snapshot.RenderSet != nil {
+		if err := commonservice.CreateRenderSet(&commonmodels.RenderSet{
+			Name:        snapshot.RenderSet.Name,
+			EnvName:     env.EnvName,
+			ProductTmpl: env.ProductName,
+			UpdateBy:    user,
+			KVs:         snapshot.RenderSet.KVs,
+		}, log); err != nil {
+			return err
+		}
+	}
+	renderSetName := env.Namespace
+	if env.Render != nil && env.Render.Name != "" {
+		renderSetName = env.Render.Name
+	}
+	renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{Name: renderSetName, EnvName: env.EnvName, ProductTmpl: env.ProductName})
+	if err != nil {
+		return fmt.Errorf("failed to find renderset %s: %v", renderSetName, err)
+	}
+	env.Render = &commonmodels.RenderInfo{
+		Name:        renderSet.Name,
+		Revision:    renderSet.Revision,
+		ProductTmpl: renderSet.ProductTmpl,
+		Description: renderSet.Description,
+	}
+
+	kubeClient, istioClient, inf, err := getEnvKubeClients(env)
+	if err != nil {
+		return err
+	}
+
+	existedServices := make(map[string]*commonmodels.ProductService)
+	current, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: env.ProductName, EnvName: env.EnvName})
+	if err == nil {
+		existedServices = current.GetServiceMap()
+	}
+	for _, group := range env.Services {
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+		errList := &multierror.Error{}
+		for _, svc := range group {
+			if serviceName != "" && svc.ServiceName != serviceName {
+				continue
+			}
+			svc.Render = env.Render
+			wg.Add(1)
+			go func(svc *commonmodels.ProductService) {
+				defer wg.Done()
+				prevSvc := existedServices[svc.ServiceName]
+				if _, err := upsertService(prevSvc != nil, env, svc, prevSvc, renderSet, inf, kubeClient, istioClient, log); err != nil {
+					lock.Lock()
+					errList = multierror.Append(errList, err)
+					lock.Unlock()
+				}
+			}(svc)
+		}
+		wg.Wait()
+		if err := errList.ErrorOrNil(); err != nil {
+			return err
+		}
+	}
+
+	env.Status = setting.ProductStatusUpdating
+	if err := commonrepo.NewProductColl().Update(env); err != nil {
+		return fmt.Errorf("failed to update env: %v", err)
+	}
+	if serviceName == "" {
+		return rollbackEnvConfigs(env, snapshot, user, log)
+	}
+	return nil
+}
+
+func rollbackHelmEnv(env *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, serviceName, user string, log *zap.SugaredLogger) error {
+	renderSet, err := FindHelmRenderSet(env.ProductName, env.Namespace, env.EnvName, log)
+	if err != nil {
+		return err
+	}
+	if snapshot.RenderSet != nil {
+		if serviceName == "" {
+			renderSet.DefaultValues = snapshot.RenderSet.DefaultValues
+			renderSet.YamlData = snapshot.RenderSet.YamlData
+			renderSet.ChartInfos = snapshot.RenderSet.ChartInfos
+		} else {
+			renderSet.ChartInfos = rollbackChartInfo(renderSet.ChartInfos, snapshot.RenderSet.ChartInfos, serviceName)
+		}
+	}
+	if err := commonservice.CreateHelmRenderSet(&commonmodels.RenderSet{
+		Name:          renderSet.Name,
+		EnvName:       env.EnvName,
+		ProductTmpl:   env.ProductName,
+		UpdateBy:      user,
+		DefaultValues: renderSet.DefaultValues,
+		YamlData:      renderSet.YamlData,
+		ChartInfos:    renderSet.ChartInfos,
+	}, log); err != nil {
+		return err
+	}
+	if renderSet, err = FindHelmRenderSet(env.ProductName, env.Namespace, env.EnvName, log); err != nil {
+		return err
+	}
+	if env.Render == nil {
+		env.Render = &commonmodels.RenderInfo{ProductTmpl: env.ProductName}
+	}
+	env.Render.Name = renderSet.Name
+	env.Render.Revision = renderSet.Revision
+	env.ChartInfos = renderSet.ChartInfos
+	env.Status = setting.ProductStatusUpdating
+	if err := commonrepo.NewProductColl().Update(env); err != nil {
+		return fmt.Errorf("failed to update env: %v", err)
+	}
+
+	helmClient, err := helmtool.NewClientFromNamespace(env.ClusterID, env.Namespace)
+	if err != nil {
+		return err
+	}
+	var filter svcUpgradeFilter
+	if serviceName != "" {
+		filter = func(svc *commonmodels.ProductService) bool {
+			return svc.ServiceName == serviceName
+		}
+	}
+	if err := proceedHelmRelease(env.ProductName, env.EnvName, env, renderSet, helmClient, filter, log); err != nil {
+		return err
+	}
+	if serviceName == "" {
+		return rollbackEnvConfigs(env, snapshot, user, log)
+	}
+	return nil
+}
+
+func rollbackChartInfo(current, snapshot []*templatemodels.RenderChart, serviceName string) []*templatemodels.RenderChart {
+	var target *templatemodels.RenderChart
+	for _, chart := range snapshot {
+		if chart.ServiceName == serviceName {
+			target = chart
+		}
+	}
+	if target == nil {
+		return current
+	}
+	resp := make([]*templatemodels.RenderChart, 0, len(current))
+	found := false
+	for _, chart := range current {
+		if chart.ServiceName == serviceName {
+			resp = append(resp, target)
+			found = true
+			continue
+		}
+		resp = append(resp, chart)
+	}
+	if !found {
+		resp = append(resp, target)
+	}
+	return resp
+}
+
+// rollbackEnvConfigs restores the env configs changed after the snapshot, the ones deleted after it are not recreated.
+func rollbackEnvConfigs(env *commonmodels.Product, snapshot *commonmodels.EnvSnapshot, user string, log *zap.SugaredLogger) error {
+	errList := &multierror.Error{}
+	for _, cfg := range snapshot.EnvConfigs {
+		latest, err := getLatestEnvResource(cfg.Name, cfg.Type, env.EnvName, env.ProductName)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			errList = multierror.Append(errList, fmt.Errorf("failed to find env config %s/%s: %v", cfg.Type, cfg.Name, err))
+			continue
+		}
+		if latest.DeletedAt != 0 || latest.YamlData == cfg.YamlData {
+			continue
+		}
+		if err := UpdateCommonEnvCfg(&commonmodels.CreateUpdateCommonEnvCfgArgs{
+			EnvName:           env.EnvName,
+			ProductName:       env.ProductName,
+			Name:              cfg.Name,
+			YamlData:          cfg.YamlData,
+			CommonEnvCfgType:  config.CommonEnvCfgType(cfg.Type),
+			LatestEnvResource: latest,
+		}, user, true, log); err != nil {
+			log.Errorf("failed to roll back env config %s/%s of env %s/%s: %v", cfg.Type, cfg.Name, env.ProductName, env.EnvName, err)
+			errList = multierror.Append(errList, fmt.Errorf("failed to roll back env config %s/%s: %v", cfg.Type, cfg.Name, err))
+		}
+	}
+	return errList.ErrorOrNil()
+}
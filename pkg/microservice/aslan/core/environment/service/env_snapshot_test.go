@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+)
+
+func snapshotService(revision int64, images ...string) *commonmodels.ProductService {
+	svc := &commonmodels.ProductService{Revision: revision}
+	for i := 0; i+1 < len(images); i += 2 {
+		svc.Containers = append(svc.Containers, &commonmodels.Container{Name: images[i], Image: images[i+1]})
+	}
+	return svc
+}
+
+var _ = Describe("env snapshot diff", func() {
+	It("diffs the services in the order of their names", func() {
+		from := map[string]*commonmodels.ProductService{
+			"c": snapshotService(1, "c", "c:v1"),
+			"b": snapshotService(1, "b", "b:v1"),
+			"a": snapshotService(1, "a", "a:v1"),
+			"e": snapshotService(2, "e", "e:v1"),
+		}
+		to := map[string]*commonmodels.ProductService{
+			"d": snapshotService(1, "d", "d:v1"),
+			"b": snapshotService(1, "b", "b:v1"),
+			"a": snapshotService(2, "a", "a:v2"),
+			"e": snapshotService(3, "e", "e:v1"),
+		}
+		Expect(diffSnapshotServices(from, to)).To(Equal([]*ServiceSnapshotDiff{
+			{ServiceName: "a", Status: snapshotDiffModified, FromRevision: 1, ToRevision: 2, Images: []*ImageSnapshotDiff{{Container: "a", From: "a:v1", To: "a:v2"}}},
+			{ServiceName: "c", Status: snapshotDiffDeleted, FromRevision: 1},
+			{ServiceName: "d", Status: snapshotDiffAdded, ToRevision: 1, Images: []*ImageSnapshotDiff{{Container: "d", To: "d:v1"}}},
+			{ServiceName: "e", Status: snapshotDiffModified, FromRevision: 2, ToRevision: 3, Images: []*ImageSnapshotDiff{}},
+		}))
+	})
+
+	It("diffs the container images in the order of the containers", func() {
+		from := snapshotService(1, "web", "web:v1", "sidecar", "sidecar:v1", "init", "init:v1").Containers
+		to := snapshotService(1, "web", "web:v2", "sidecar", "sidecar:v1", "agent", "agent:v1").Containers
+		Expect(diffContainerImages(from, to)).To(Equal([]*ImageSnapshotDiff{
+			{Container: "agent", To: "agent:v1"},
+			{Container: "init", From: "init:v1"},
+			{Container: "web", From: "web:v1", To: "web:v2"},
+		}))
+	})
+
+	DescribeTable("diffs the variables",
+		func(from, to map[string]string, expected []*KVSnapshotDiff) {
+			Expect(diffSnapshotKVs(from, to)).To(Equal(expected))
+		},
+		Entry("unchanged", map[string]string{"a": "1"}, map[string]string{"a": "1"}, []*KVSnapshotDiff{}),
+		Entry("changed", map[string]string{"a": "1"}, map[string]string{"a": "2"}, []*KVSnapshotDiff{{Key: "a", From: "1", To: "2"}}),
+		Entry("added and deleted", map[string]string{"b": "1"}, map[string]string{"a": "1"},
+			[]*KVSnapshotDiff{{Key: "a", To: "1"}, {Key: "b", From: "1"}}),
+		Entry("added with empty value", map[string]string{}, map[string]string{"a": ""}, []*KVSnapshotDiff{{Key: "a"}}),
+		Entry("sorted by key", map[string]string{"c": "1", "a": "1", "b": "1"}, map[string]string{"c": "2", "a": "2", "b": "2"},
+			[]*KVSnapshotDiff{{Key: "a", From: "1", To: "2"}, {Key: "b", From: "1", To: "2"}, {Key: "c", From: "1", To: "2"}}),
+	)
+
+	It("diffs the default values and the values of the charts", func() {
+		from := &commonmodels.RenderSet{DefaultValues: "a: 1", ChartInfos: []*templatemodels.RenderChart{
+			{ServiceName: "b", ValuesYaml: "b: 1"},
+			{ServiceName: "a", ValuesYaml: "a: 1"},
+		}}
+		to := &commonmodels.RenderSet{DefaultValues: "a: 2", ChartInfos: []*templatemodels.RenderChart{
+			{ServiceName: "c", ValuesYaml: "c: 1"},
+			{ServiceName: "a", ValuesYaml: "a: 1"},
+		}}
+		diffs := diffSnapshotValues(from, to)
+		Expect(diffs).To(HaveLen(3))
+		Expect(diffs[0]).To(Equal(&ValuesSnapshotDiff{From: "a: 1", To: "a: 2"}))
+		Expect(diffs[1].ServiceName).To(Equal("b"))
+		Expect(diffs[1].To).To(BeEmpty())
+		Expect(diffs[2].ServiceName).To(Equal("c"))
+		Expect(diffs[2].From).To(BeEmpty())
+	})
+
+	It("diffs the env configs in the order of their types and names", func() {
+		from := []*commonmodels.EnvSnapshotConfig{
+			{Name: "b", Type: "ConfigMap", YamlData: "b1"},
+			{Name: "a", Type: "Secret", YamlData: "a1"},
+			{Name: "a", Type: "ConfigMap", YamlData: "a1"},
+		}
+		to := []*commonmodels.EnvSnapshotConfig{
+			{Name: "a", Type: "ConfigMap", YamlData: "a2"},
+			{Name: "c", Type: "Ingress", YamlData: "c1"},
+			{Name: "a", Type: "Secret", YamlData: "a1"},
+		}
+		Expect(diffSnapshotEnvConfigs(from, to)).To(Equal([]*EnvConfigSnapshotDiff{
+			{Name: "a", Type: "ConfigMap", Status: snapshotDiffModified, From: "a1", To: "a2"},
+			{Name: "b", Type: "ConfigMap", Status: snapshotDiffDeleted, From: "b1"},
+			{Name: "c", Type: "Ingress", Status: snapshotDiffAdded, To: "c1"},
+		}))
+	})
+})
@@ -58,6 +58,7 @@ import (
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/collaboration"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/envsnapshot"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/pkg/setting"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
@@ -894,6 +895,10 @@ func UpdateProductV2(envName, productName, user, requestID string, serviceNames
 				log.Errorf("[%s][P:%s] Product.UpdateErrors error: %v", envName, productName, err)
 				return
 			}
+
+			if _, err = envsnapshot.Create(productName, envName, envsnapshot.SourceEnvUpdate, user, log); err != nil {
+				log.Errorf("[%s][P:%s] failed to create env snapshot: %v", envName, productName, err)
+			}
 		}
 	}()
 	return nil
@@ -1430,6 +1435,10 @@ func UpdateHelmProduct(productName, envName, username, requestID string, overrid
 				log.Errorf("[%s][%s] Product.Update error: %v", envName, productName, err)
 				return
 			}
+
+			if _, err = envsnapshot.Create(productName, envName, envsnapshot.SourceHelmUpdate, username, log); err != nil {
+				log.Errorf("[%s][P:%s] failed to create env snapshot: %v", envName, productName, err)
+			}
 		}
 	}()
 	return nil
@@ -19,6 +19,7 @@ package handler
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -73,6 +74,48 @@ func GetWorkflowV4JobContainerLogs(c *gin.Context) {
 	ctx.Resp, ctx.Err = logservice.GetWorkflowV4JobContainerLogs(strings.ToLower(c.Param("workflowName")), c.Param("jobName"), taskID, ctx.Logger)
 }
 
+func SearchWorkflowV4JobLogs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+	args := &logservice.SearchJobLogArgs{}
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	resp, err := logservice.SearchWorkflowV4JobLog(c.Param("workflowName"), c.Param("jobName"), taskID, args, ctx.Logger)
+	if err != nil {
+		ctx.Err = e.ErrSearchJobLog.AddErr(err)
+		return
+	}
+	ctx.Resp = resp
+}
+
+func DownloadWorkflowV4TaskLogs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+	workflowName := c.Param("workflowName")
+	fileBytes, err := logservice.DownloadWorkflowV4TaskLogs(workflowName, taskID, ctx.Logger)
+	if err != nil {
+		ctx.Err = e.ErrDownloadJobLogs.AddErr(err)
+		return
+	}
+
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%d-logs.tar.gz"`, workflowName, taskID))
+	c.Data(http.StatusOK, "application/gzip", fileBytes)
+}
+
 func GetTestJobContainerLogs(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -36,6 +36,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		log.GET("/v3/workflow/:workflowName/tasks/:taskId", GetWorkflowBuildV3JobContainerLogs)
 		log.GET("/scanning/:id/task/:scan_id", GetScanningContainerLogs)
 		log.GET("/v4/workflow/:workflowName/tasks/:taskID/jobs/:jobName", GetWorkflowV4JobContainerLogs)
+		log.GET("/v4/workflow/:workflowName/tasks/:taskID/jobs/:jobName/search", SearchWorkflowV4JobLogs)
+		log.GET("/v4/workflow/:workflowName/tasks/:taskID/download", DownloadWorkflowV4TaskLogs)
 	}
 
 	sse := router.Group("sse")
@@ -49,5 +51,6 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		sse.GET("/v3/workflow/build/:workflowName/:taskId/:lines", GetWorkflowBuildV3JobContainerLogsSSE)
 		sse.GET("/scanning/:id/task/:scan_id", GetScanningContainerLogsSSE)
 		sse.GET("/v4/workflow/:workflowName/:taskID/:jobName/:lines", GetWorkflowJobContainerLogsSSE)
+		sse.GET("/v4/follow/workflow/:workflowName/tasks/:taskID/jobs/:jobName", GetWorkflowV4JobLogFollowSSE)
 	}
 }
@@ -109,6 +109,23 @@ func GetWorkflowJobContainerLogsSSE(c *gin.Context) {
 	}, ctx.Logger)
 }
 
+// GetWorkflowV4JobLogFollowSSE streams the whole log of a workflow v4 job with line numbers, and switches to the
+// archived log once the job ends.
+func GetWorkflowV4JobLogFollowSSE(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		internalhandler.JSONResponse(c, ctx)
+		return
+	}
+
+	internalhandler.Stream(c, func(ctx1 context.Context, streamChan chan interface{}) {
+		logservice.WorkflowV4JobLogFollow(ctx1, streamChan, c.Param("workflowName"), c.Param("jobName"), taskID, ctx.Logger)
+	}, ctx.Logger)
+}
+
 func GetWorkflowBuildJobContainerLogsSSE(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 
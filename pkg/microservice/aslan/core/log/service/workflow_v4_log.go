@@ -0,0 +1,446 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	"github.com/koderover/zadig/pkg/tool/kube/containerlog"
+	"github.com/koderover/zadig/pkg/tool/kube/getter"
+	"github.com/koderover/zadig/pkg/tool/kube/watcher"
+)
+
+const (
+	LogSourceLive    = "live"
+	LogSourceArchive = "archive"
+
+	// the job log is archived after the job ends, it is polled for a while before giving up.
+	archivePollInterval = 3 * time.Second
+	archiveWaitTimeout  = 2 * time.Minute
+
+	defaultSearchLimit = 500
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// JobLogMessage is a message of the job log stream, the content keeps the ANSI colors of the log.
+type JobLogMessage struct {
+	Line    int    `json:"line,omitempty"`
+	Content string `json:"content,omitempty"`
+	Source  string `json:"source"`
+	// Status is only set in the last message, once the job ended and the archived log is sent.
+	Status config.Status `json:"status,omitempty"`
+}
+
+type SearchJobLogArgs struct {
+	Keyword    string `form:"keyword"`
+	Regexp     bool   `form:"regexp"`
+	IgnoreCase bool   `form:"ignoreCase"`
+	// Context is the number of lines around the matched lines to return.
+	Context int `form:"context"`
+	Limit   int `form:"limit"`
+}
+
+type JobLogLine struct {
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+	Matched bool   `json:"matched"`
+}
+
+type SearchJobLogResp struct {
+	Lines     []*JobLogLine `json:"lines"`
+	Matched   int           `json:"matched"`
+	Source    string        `json:"source"`
+	Truncated bool          `json:"truncated"`
+}
+
+func workflowV4JobFinished(status config.Status) bool {
+	switch status {
+	case config.StatusPassed, config.StatusFailed, config.StatusTimeout, config.StatusCancelled,
+		config.StatusSkipped, config.StatusReject, config.StatusApprovalTimeout, config.StatusNotRun, config.StatusDisabled:
+		return true
+	}
+	return false
+}
+
+// workflowV4JobCluster returns the cluster and namespace where the pod of the job runs.
+func workflowV4JobCluster(job *commonmodels.JobTask) (string, string, error) {
+	var clusterID string
+	switch job.JobType {
+	case string(config.JobZadigBuild), string(config.JobFreestyle), string(config.JobBuild):
+		jobSpec := &commonmodels.JobTaskBuildSpec{}
+		if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+			return "", "", fmt.Errorf("failed to parse job spec: %v", err)
+		}
+		clusterID = jobSpec.Properties.ClusterID
+	case string(config.JobPlugin):
+		jobSpec := &commonmodels.JobTaskPluginSpec{}
+		if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+			return "", "", fmt.Errorf("failed to parse job spec: %v", err)
+		}
+		clusterID = jobSpec.Properties.ClusterID
+	default:
+		return "", "", fmt.Errorf("unsupported job type %s", job.JobType)
+	}
+
+	if clusterID == "" {
+		clusterID = setting.LocalClusterID
+	}
+	if clusterID == setting.LocalClusterID {
+		return clusterID, config.Namespace(), nil
+	}
+	return clusterID, setting.AttachedClusterNamespace, nil
+}
+
+func findWorkflowV4Job(workflowName, jobName string, taskID int64) (*commonmodels.JobTask, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find workflow %s task %d: %v", workflowName, taskID, err)
+	}
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name == jobName {
+				return job, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("job %s not found in workflow %s task %d", jobName, workflowName, taskID)
+}
+
+// jobPodLogStream opens the log stream of the pod of the job, it is the same container which is archived when the job ends.
+func jobPodLogStream(ctx context.Context, workflowName string, taskID int64, job *commonmodels.JobTask, follow bool) (io.ReadCloser, error) {
+	clusterID, namespace, err := workflowV4JobCluster(job)
+	if err != nil {
+		return nil, err
+	}
+	clientSet, err := kubeclient.GetClientset(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := getWorkflowSelector(&GetContainerOptions{PipelineName: workflowName, TaskID: taskID, SubTask: job.Name})
+	if follow {
+		podCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = watcher.WaitUntilPodRunning(podCtx, namespace, selector, clientSet)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("wait pod running error: %s", err)
+		}
+	}
+
+	pods, err := getter.ListPods(namespace, selector, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pod found with selector: %s", selector)
+	}
+	// pods of the earlier runs of the job may be left, the newest pod is the current one.
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+	if len(pods[0].Spec.Containers) == 0 {
+		return nil, fmt.Errorf("no container found in pod %s", pods[0].Name)
+	}
+	return openPodLog(ctx, namespace, pods[0], follow, clientSet)
+}
+
+func openPodLog(ctx context.Context, namespace string, pod *corev1.Pod, follow bool, clientSet kubernetes.Interface) (io.ReadCloser, error) {
+	return containerlog.GetContainerLogStream(ctx, namespace, pod.Name, pod.Spec.Containers[0].Name, follow, 0, clientSet)
+}
+
+// getWorkflowV4JobLog returns the archived log of a finished job, or the current log of the pod of a running job.
+func getWorkflowV4JobLog(workflowName string, taskID int64, job *commonmodels.JobTask, log *zap.SugaredLogger) (string, string, error) {
+	if workflowV4JobFinished(job.Status) {
+		content, err := getContainerLogFromS3(strings.ToLower(workflowName), job.Name, taskID, log)
+		return content, LogSourceArchive, err
+	}
+
+	out, err := jobPodLogStream(context.TODO(), workflowName, taskID, job, false)
+	if err != nil {
+		return "", LogSourceLive, err
+	}
+	defer out.Close()
+	buf := new(bytes.Buffer)
+	if _, err := io.Copy(buf, out); err != nil {
+		return "", LogSourceLive, err
+	}
+	return buf.String(), LogSourceLive, nil
+}
+
+func splitLogLines(content string) []string {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+// WorkflowV4JobLogFollow streams the log of a workflow v4 job with line numbers. The log of the running pod is followed
+// until the job ends, then the rest of the archived log is sent, so the stream is the same as the archived log.
+func WorkflowV4JobLogFollow(ctx context.Context, streamChan chan interface{}, workflowName, jobName string, taskID int64, log *zap.SugaredLogger) {
+	send := func(msg *JobLogMessage) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case streamChan <- msg:
+			return true
+		}
+	}
+
+	job, err := findWorkflowV4Job(workflowName, jobName, taskID)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	sent := 0
+	if !workflowV4JobFinished(job.Status) {
+		sent, err = followJobPodLog(ctx, send, workflowName, taskID, job)
+		if err != nil {
+			log.Warnf("failed to follow the log of job %s, err: %s", jobName, err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+
+	content, status, err := waitWorkflowV4JobArchive(ctx, workflowName, jobName, taskID, log)
+	if err != nil {
+		log.Errorf("failed to get the archived log of job %s, err: %s", jobName, err)
+		return
+	}
+	lines := splitLogLines(content)
+	for i := sent; i < len(lines); i++ {
+		if !send(&JobLogMessage{Line: i + 1, Content: lines[i], Source: LogSourceArchive}) {
+			return
+		}
+	}
+	send(&JobLogMessage{Source: LogSourceArchive, Status: status})
+}
+
+func followJobPodLog(ctx context.Context, send func(*JobLogMessage) bool, workflowName string, taskID int64, job *commonmodels.JobTask) (int, error) {
+	out, err := jobPodLogStream(ctx, workflowName, taskID, job, true)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	sent := 0
+	reader := bufio.NewReader(out)
+	for {
+		line, err := reader.ReadString('\n')
+		// a partial line at the end of a broken stream is left to the archive
+		if err != nil {
+			if err == io.EOF {
+				return sent, nil
+			}
+			return sent, err
+		}
+		sent++
+		if !send(&JobLogMessage{Line: sent, Content: strings.TrimRight(line, "\r\n"), Source: LogSourceLive}) {
+			return sent, ctx.Err()
+		}
+	}
+}
+
+// waitWorkflowV4JobArchive waits until the job ends and its log is archived.
+func waitWorkflowV4JobArchive(ctx context.Context, workflowName, jobName string, taskID int64, log *zap.SugaredLogger) (string, config.Status, error) {
+	ticker := time.NewTicker(archivePollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(archiveWaitTimeout)
+
+	for {
+		job, err := findWorkflowV4Job(workflowName, jobName, taskID)
+		if err != nil {
+			return "", "", err
+		}
+		if workflowV4JobFinished(job.Status) {
+			content, err := getContainerLogFromS3(strings.ToLower(workflowName), jobName, taskID, log)
+			if err != nil {
+				return "", "", err
+			}
+			if content != "" || time.Now().After(deadline) {
+				return content, job.Status, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-ticker.C:
+			// the deadline only applies once the job ended, running jobs are waited as long as the client is connected
+			if !workflowV4JobFinished(job.Status) {
+				deadline = time.Now().Add(archiveWaitTimeout)
+			}
+		}
+	}
+}
+
+// SearchWorkflowV4JobLog searches the log of a job line by line, ANSI colors are ignored when matching but kept in the result.
+func SearchWorkflowV4JobLog(workflowName, jobName string, taskID int64, args *SearchJobLogArgs, log *zap.SugaredLogger) (*SearchJobLogResp, error) {
+	match, err := jobLogMatcher(args)
+	if err != nil {
+		return nil, err
+	}
+	job, err := findWorkflowV4Job(workflowName, jobName, taskID)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	content, source, err := getWorkflowV4JobLog(workflowName, taskID, job, log)
+	if err != nil {
+		log.Errorf("failed to get the log of job %s, err: %s", jobName, err)
+		return nil, err
+	}
+
+	resp := searchLogLines(splitLogLines(content), match, args)
+	resp.Source = source
+	return resp, nil
+}
+
+func jobLogMatcher(args *SearchJobLogArgs) (func(string) bool, error) {
+	if args.Keyword == "" {
+		return nil, fmt.Errorf("keyword is required")
+	}
+	if args.Regexp {
+		expr := args.Keyword
+		if args.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp %s: %v", args.Keyword, err)
+		}
+		return re.MatchString, nil
+	}
+	if args.IgnoreCase {
+		keyword := strings.ToLower(args.Keyword)
+		return func(line string) bool { return strings.Contains(strings.ToLower(line), keyword) }, nil
+	}
+	return func(line string) bool { return strings.Contains(line, args.Keyword) }, nil
+}
+
+func searchLogLines(lines []string, match func(string) bool, args *SearchJobLogArgs) *SearchJobLogResp {
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	resp := &SearchJobLogResp{Lines: make([]*JobLogLine, 0)}
+
+	// the last line added to the result, to not add a context line twice
+	last := -1
+	for i, line := range lines {
+		if !match(ansiEscape.ReplaceAllString(line, "")) {
+			continue
+		}
+		if resp.Matched == limit {
+			resp.Truncated = true
+			break
+		}
+		resp.Matched++
+
+		start := i - args.Context
+		if start <= last {
+			start = last + 1
+		}
+		if start < 0 {
+			start = 0
+		}
+		end := i + args.Context
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		for j := start; j <= end; j++ {
+			resp.Lines = append(resp.Lines, &JobLogLine{Line: j + 1, Content: lines[j], Matched: j == i})
+		}
+		if end > last {
+			last = end
+		}
+	}
+	return resp
+}
+
+// DownloadWorkflowV4TaskLogs packs the logs of all the jobs of a task into a gzipped tarball.
+func DownloadWorkflowV4TaskLogs(workflowName string, taskID int64, log *zap.SugaredLogger) ([]byte, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		log.Errorf("failed to find workflow %s task %d, err: %s", workflowName, taskID, err)
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if _, _, err := workflowV4JobCluster(job); err != nil {
+				continue
+			}
+			content, _, err := getWorkflowV4JobLog(workflowName, taskID, job, log)
+			if err != nil {
+				log.Warnf("failed to get the log of job %s, err: %s", job.Name, err)
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    job.Name + ".log",
+				Mode:    0644,
+				Size:    int64(len(content)),
+				ModTime: time.Now(),
+			}); err != nil {
+				return nil, err
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchLogLines(t *testing.T) {
+	lines := splitLogLines("start\n\x1b[31merror\x1b[0m: build failed\nretry\nerror again\r\ndone\n")
+	assert.Len(t, lines, 5)
+
+	match, err := jobLogMatcher(&SearchJobLogArgs{Keyword: "ERROR:", IgnoreCase: true})
+	assert.NoError(t, err)
+	resp := searchLogLines(lines, match, &SearchJobLogArgs{Context: 1})
+	assert.Equal(t, 1, resp.Matched)
+	assert.Equal(t, []*JobLogLine{
+		{Line: 1, Content: "start"},
+		{Line: 2, Content: "\x1b[31merror\x1b[0m: build failed", Matched: true},
+		{Line: 3, Content: "retry"},
+	}, resp.Lines)
+
+	match, err = jobLogMatcher(&SearchJobLogArgs{Keyword: "^error", Regexp: true})
+	assert.NoError(t, err)
+	resp = searchLogLines(lines, match, &SearchJobLogArgs{Context: 1, Limit: 1})
+	assert.Equal(t, 1, resp.Matched)
+	assert.True(t, resp.Truncated)
+
+	resp = searchLogLines(lines, match, &SearchJobLogArgs{Context: 2})
+	assert.Equal(t, 2, resp.Matched)
+	assert.Len(t, resp.Lines, 5)
+
+	_, err = jobLogMatcher(&SearchJobLogArgs{Keyword: "(", Regexp: true})
+	assert.Error(t, err)
+}
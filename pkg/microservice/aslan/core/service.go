@@ -54,6 +54,7 @@ import (
 	"github.com/koderover/zadig/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 	"github.com/koderover/zadig/pkg/tool/rsa"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -129,6 +130,15 @@ func Start(ctx context.Context) {
 		Development: commonconfig.Mode() != setting.ReleaseMode,
 	})
 
+	if err := tracing.Init(&tracing.Config{
+		ServiceName: "aslan",
+		Exporter:    commonconfig.TraceExporter(),
+		Endpoint:    commonconfig.TraceOTLPEndpoint(),
+		File:        commonconfig.TraceFile(),
+	}); err != nil {
+		log.Errorf("Failed to init tracing, tracing is disabled, error: %s", err)
+	}
+
 	initDatabase()
 
 	initService()
@@ -144,6 +154,7 @@ func Start(ctx context.Context) {
 	systemservice.SetProxyConfig()
 
 	workflowservice.InitPipelineController()
+	workflowservice.InitTriggerWorkflowJob()
 	// update offical plugins
 	workflowservice.UpdateOfficalPluginRepository(log.SugaredLogger())
 	workflowcontroller.InitWorkflowController()
@@ -169,6 +180,9 @@ func Start(ctx context.Context) {
 func Stop(ctx context.Context) {
 	mongotool.Close(ctx)
 	gormtool.Close()
+	if err := tracing.Shutdown(ctx); err != nil {
+		log.Errorf("Failed to flush spans, error: %s", err)
+	}
 }
 
 func initService() {
@@ -284,12 +298,16 @@ func initDatabase() {
 		commonrepo.NewProjectClusterRelationColl(),
 		commonrepo.NewEnvResourceColl(),
 		commonrepo.NewEnvSvcDependColl(),
+		commonrepo.NewEnvSnapshotColl(),
 		commonrepo.NewBuildTemplateColl(),
 		commonrepo.NewScanningColl(),
 		commonrepo.NewWorkflowV4Coll(),
 		commonrepo.NewworkflowTaskv4Coll(),
+		commonrepo.NewApprovalDelegationColl(),
 		commonrepo.NewWorkflowQueueColl(),
 		commonrepo.NewPluginRepoColl(),
+		commonrepo.NewWebhookDeliveryColl(),
+		commonrepo.NewEnvDriftColl(),
 
 		systemrepo.NewAnnouncementColl(),
 		systemrepo.NewOperationLogColl(),
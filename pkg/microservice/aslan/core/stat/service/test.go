@@ -22,6 +22,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
 )
 
 type testDashboard struct {
@@ -51,6 +52,18 @@ func GetTestDashboard(startTime, endTime int64, log *zap.SugaredLogger) (*testDa
 	for _, test := range tests {
 		testNames.Insert(test.Name)
 	}
+	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
+	if err != nil {
+		log.Errorf("Failed to list workflow v4 err:%s", err)
+		return nil, err
+	}
+	for _, workflow := range workflows {
+		for _, stage := range workflow.Stages {
+			for _, job := range stage.Jobs {
+				testNames.Insert(jobcontroller.TestTaskStatName(workflow.Name, job.Name))
+			}
+		}
+	}
 	testTasks, err := commonrepo.NewTestTaskStatColl().GetTestTasks(startTime, endTime)
 	if err != nil {
 		log.Errorf("Failed to list TestTaskStat err:%s", err)
@@ -67,14 +67,32 @@ func InitTestStat(log *zap.SugaredLogger) error {
 			return fmt.Errorf("pipeline list err:%v", err)
 		}
 
+		// workflow v4 tasks with the test reports of the junit_report steps
+		allTasksV4, _, err := commonmongodb.NewworkflowTaskv4Coll().List(&commonmongodb.ListWorkflowTaskV4Option{
+			ProjectName: product.ProductName,
+			CreateTime:  option.CreateTime,
+		})
+		if err != nil {
+			log.Errorf("workflow v4 task list err:%v", err)
+			return fmt.Errorf("workflow v4 task list err:%v", err)
+		}
+
 		taskDateMap := make(map[string][]*taskmodels.Task)
-		if len(allTasks) > 0 {
+		taskV4DateMap := make(map[string][]*commonmodels.WorkflowTask)
+		if len(allTasks) > 0 || len(allTasksV4) > 0 {
 			//将task的时间戳转成日期，以日期为单位分组
 			for _, task := range allTasks {
 				time := time.Unix(task.CreateTime, 0)
 				date := time.Format(config.Date)
 				taskDateMap[date] = append(taskDateMap[date], task)
 			}
+			for _, task := range allTasksV4 {
+				date := time.Unix(task.CreateTime, 0).Format(config.Date)
+				taskV4DateMap[date] = append(taskV4DateMap[date], task)
+				if _, ok := taskDateMap[date]; !ok {
+					taskDateMap[date] = []*taskmodels.Task{}
+				}
+			}
 		} else {
 			time := time.Now().AddDate(0, 0, -1)
 			date := time.Format(config.Date)
@@ -178,6 +196,29 @@ func InitTestStat(log *zap.SugaredLogger) error {
 				}
 			}
 
+			for _, task := range taskV4DateMap[taskDate] {
+				for _, stage := range task.Stages {
+					for _, job := range stage.Jobs {
+						if job.TestReport == nil || job.TestReport.JunitReport == "" {
+							continue
+						}
+						switch job.Status {
+						case config.StatusPassed:
+							totalSuccess++
+						case config.StatusFailed:
+							totalFailure++
+						case config.StatusTimeout:
+							totalTimeout++
+						default:
+							continue
+						}
+						totalDuration += job.EndTime - job.StartTime
+						totalTestCount++
+						totalTestCase += job.TestReport.Tests
+					}
+				}
+			}
+
 			testStat := new(models.TestStat)
 			testStat.ProductName = product.ProductName
 			testStat.TotalSuccess = totalSuccess
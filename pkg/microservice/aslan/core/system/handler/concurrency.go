@@ -50,3 +50,34 @@ func UpdateWorkflowConcurrency(c *gin.Context) {
 
 	ctx.Err = service.UpdateWorkflowConcurrency(args.WorkflowConcurrency, args.BuildConcurrency, ctx.Logger)
 }
+
+func GetProjectConcurrency(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetProjectConcurrency()
+}
+
+func UpdateProjectConcurrency(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.ProjectConcurrencySettings)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+
+	if args.DefaultProjectConcurrency < 0 {
+		ctx.Err = errors.New("concurrency cannot be less than 0")
+		return
+	}
+	for _, project := range args.ProjectConcurrency {
+		if project.ProjectName == "" || project.Concurrency < 0 {
+			ctx.Err = errors.New("invalid project concurrency")
+			return
+		}
+	}
+
+	ctx.Err = service.UpdateProjectConcurrency(args, ctx.Logger)
+}
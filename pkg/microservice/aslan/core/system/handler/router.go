@@ -128,6 +128,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		concurrency.GET("/workflow", GetWorkflowConcurrency)
 		concurrency.POST("/workflow", UpdateWorkflowConcurrency)
+		concurrency.GET("/project", GetProjectConcurrency)
+		concurrency.POST("/project", UpdateProjectConcurrency)
 	}
 
 	// default login default login home page settings
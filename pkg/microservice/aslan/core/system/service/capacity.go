@@ -42,8 +42,10 @@ func UpdateSysCapStrategy(strategy *commonmodels.CapacityStrategy) error {
 		return err
 	}
 
-	// 更新成功后，立即按照新的配置清理数据
-	go handleWorkflowTaskRetentionCenter(strategy, false)
+	// 更新成功后，立即按照新的配置清理数据，环境快照在下次创建快照时清理
+	if strategy.Target == commonmodels.WorkflowTaskRetention {
+		go handleWorkflowTaskRetentionCenter(strategy, false)
+	}
 
 	return nil
 }
@@ -53,6 +55,9 @@ func GetCapacityStrategy(target commonmodels.CapacityTarget) (*commonmodels.Capa
 	if err != nil && target == commonmodels.WorkflowTaskRetention {
 		return commonmodels.DefaultWorkflowTaskRetention, nil // Return default setup
 	}
+	if err != nil && target == commonmodels.EnvSnapshotRetention {
+		return commonmodels.DefaultEnvSnapshotRetention, nil
+	}
 	return result, err
 }
 
@@ -397,6 +402,16 @@ func validateStrategy(strategy *commonmodels.CapacityStrategy) error {
 				"can only set one positive value at a time. days: %v, items: %v",
 				retention.MaxDays, retention.MaxItems)
 		}
+	} else if strategy.Target == commonmodels.EnvSnapshotRetention {
+		retention := strategy.Retention
+		if retention == nil {
+			return errors.New("SysCap strategy: nil retention config for EnvSnapshotRetention")
+		}
+		if retention.MaxDays < 0 || retention.MaxItems < 0 || (retention.MaxDays == 0 && retention.MaxItems == 0) {
+			return fmt.Errorf("SysCap strategy: max days or items value invalid, "+
+				"at least one positive value is required. days: %v, items: %v",
+				retention.MaxDays, retention.MaxItems)
+		}
 	} else {
 		// Note: currently doesn't support other strategies yet.
 		return fmt.Errorf("SysCap strategy target is invalid - passed in value: %v", strategy.Target)
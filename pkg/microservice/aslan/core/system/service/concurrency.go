@@ -42,6 +42,26 @@ func GetWorkflowConcurrency() (*WorkflowConcurrencySettings, error) {
 	}, nil
 }
 
+func GetProjectConcurrency() (*ProjectConcurrencySettings, error) {
+	configuration, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectConcurrencySettings{
+		DefaultProjectConcurrency: configuration.DefaultProjectConcurrency,
+		ProjectConcurrency:        configuration.ProjectConcurrency,
+	}, nil
+}
+
+// UpdateProjectConcurrency limits the running workflow v4 tasks of each project, it takes effect on the next scheduling.
+func UpdateProjectConcurrency(args *ProjectConcurrencySettings, log *zap.SugaredLogger) error {
+	if err := commonrepo.NewSystemSettingColl().UpdateProjectConcurrencySetting(args.DefaultProjectConcurrency, args.ProjectConcurrency); err != nil {
+		log.Errorf("Failed to update project concurrency settings, the error is: %s", err)
+		return err
+	}
+	return nil
+}
+
 func UpdateWorkflowConcurrency(workflowConcurrency, buildConcurrency int64, log *zap.SugaredLogger) error {
 	// check if there are running tasks
 	tasks := workflowservice.RunningPipelineTasks()
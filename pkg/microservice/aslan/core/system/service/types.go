@@ -16,6 +16,10 @@ limitations under the License.
 
 package service
 
+import (
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
 type ExternalSystemDetail struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -28,6 +32,11 @@ type WorkflowConcurrencySettings struct {
 	BuildConcurrency    int64 `json:"build_concurrency"`
 }
 
+type ProjectConcurrencySettings struct {
+	DefaultProjectConcurrency int64                              `json:"default_project_concurrency"`
+	ProjectConcurrency        []*commonmodels.ProjectConcurrency `json:"project_concurrency"`
+}
+
 type SonarIntegration struct {
 	ID            string `json:"id"`
 	ServerAddress string `json:"server_address"`
@@ -29,6 +29,9 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	webhook := router.Group("webhook")
 	{
 		webhook.POST("", ProcessWebHook)
+		webhook.GET("/deliveries", ListWebhookDeliveries)
+		webhook.GET("/deliveries/:id", GetWebhookDelivery)
+		webhook.POST("/deliveries/:id/replay", ReplayWebhookDelivery)
 	}
 
 	build := router.Group("build")
@@ -180,6 +183,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		workflowV4.POST("/webhook/:workflowName", CreateWebhookForWorkflowV4)
 		workflowV4.PUT("/webhook/:workflowName", UpdateWebhookForWorkflowV4)
 		workflowV4.DELETE("/webhook/:workflowName/trigger/:triggerName", DeleteWebhookForWorkflowV4)
+		workflowV4.POST("/webhook/:workflowName/trigger/:triggerName/sync", SyncWorkflowV4FromRepo)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -191,8 +195,14 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		taskV4.GET("", ListWorkflowTaskV4)
 		taskV4.GET("/workflow/:workflowName/task/:taskID", GetWorkflowTaskV4)
 		taskV4.DELETE("/workflow/:workflowName/task/:taskID", CancelWorkflowTaskV4)
+		taskV4.POST("/workflow/:workflowName/task/:taskID/retry", RetryWorkflowTaskV4)
+		taskV4.POST("/workflow/:workflowName/task/:taskID/job/:jobName/resume", ResumeWorkflowTaskV4Job)
+		taskV4.GET("/workflow/:workflowName/task/:taskID/job/:jobName/report/html/*path", GetWorkflowTaskV4HTMLReport)
 		taskV4.GET("/clone/workflow/:workflowName/task/:taskID", CloneWorkflowTaskV4)
 		taskV4.POST("/approve", ApproveStage)
+		taskV4.POST("/approval/delegation", CreateApprovalDelegation)
+		taskV4.GET("/approval/delegation", ListApprovalDelegations)
+		taskV4.DELETE("/approval/delegation/:id", DeleteApprovalDelegation)
 	}
 
 	// ---------------------------------------------------------------------------------------
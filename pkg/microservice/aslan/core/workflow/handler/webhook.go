@@ -17,18 +17,13 @@ limitations under the License.
 package handler
 
 import (
-	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/go-github/v35/github"
-	"github.com/hashicorp/go-multierror"
-	"github.com/xanzy/go-gitlab"
-	"go.uber.org/zap"
 
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/webhook"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
-	"github.com/koderover/zadig/pkg/tool/codehub"
-	"github.com/koderover/zadig/pkg/tool/gitee"
 )
 
 // @Router /workflow/webhook [POST]
@@ -45,53 +40,42 @@ func ProcessWebHook(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
-	if github.WebHookType(c.Request) != "" {
-		ctx.Err = processGithub(payload, c.Request, ctx.RequestID, ctx.Logger)
-	} else if gitlab.HookEventType(c.Request) != "" {
-		ctx.Err = webhook.ProcessGitlabHook(payload, c.Request, ctx.RequestID, ctx.Logger)
-	} else if codehub.HookEventType(c.Request) != "" {
-		ctx.Err = webhook.ProcessCodehubHook(payload, c.Request, ctx.RequestID, ctx.Logger)
-	} else if gitee.HookEventType(c.Request) != "" {
-		ctx.Err = webhook.ProcessGiteeHook(payload, c.Request, ctx.RequestID, ctx.Logger)
-	} else {
-		ctx.Err = webhook.ProcessGerritHook(payload, c.Request, ctx.RequestID, ctx.Logger)
-	}
+	ctx.Err = webhook.ProcessWebHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 }
 
-func processGithub(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
-	errs := &multierror.Error{}
+type listWebhookDeliveriesResp struct {
+	Deliveries []*commonmodels.WebhookDelivery `json:"deliveries"`
+	Total      int64                           `json:"total"`
+}
 
-	// trigger classic pipeline
-	_, err := webhook.ProcessGithubHook(payload, req, requestID, log)
-	if err != nil {
-		log.Errorf("error happens to trigger classic pipeline %v", err)
-		errs = multierror.Append(errs, err)
+func ListWebhookDeliveries(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := &webhook.ListWebhookDeliveriesArgs{}
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.Err = err
+		return
 	}
+	deliveries, total, err := webhook.ListWebhookDeliveries(args, ctx.Logger)
+	ctx.Resp = &listWebhookDeliveriesResp{Deliveries: deliveries, Total: total}
+	ctx.Err = err
+}
 
-	// trigger workflow
-	err = webhook.ProcessGithubWebHook(payload, req, requestID, log)
+func GetWebhookDelivery(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
 
-	if err != nil {
-		log.Errorf("error happens to trigger workflow %v", err)
-		errs = multierror.Append(errs, err)
-	}
-	//测试管理webhook
-	err = webhook.ProcessGithubWebHookForTest(payload, req, requestID, log)
-	if err != nil {
-		log.Errorf("error happens to trigger ProcessGithubWebHookForTest %v", err)
-		errs = multierror.Append(errs, err)
-	}
-	// webhooks for scanning task
-	err = webhook.ProcessGithubWebhookForScanning(payload, req, requestID, log)
-	if err != nil {
-		log.Errorf("error happens to trigger Scanning for github %v", err)
-		errs = multierror.Append(errs, err)
-	}
-	// webhooks for workflow v4
-	err = webhook.ProcessGithubWebHookForWorkflowV4(payload, req, requestID, log)
-	if err != nil {
-		log.Errorf("error happens to trigger workflowV4 for github %v", err)
-		errs = multierror.Append(errs, err)
+	ctx.Resp, ctx.Err = webhook.GetWebhookDelivery(c.Param("id"), ctx.Logger)
+}
+
+func ReplayWebhookDelivery(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	confirm, _ := strconv.ParseBool(c.Query("confirm"))
+	if confirm {
+		internalhandler.InsertOperationLog(c, ctx.UserName, "", "重放", "webhook投递", c.Param("id"), "", ctx.Logger)
 	}
-	return errs.ErrorOrNil()
+	ctx.Resp, ctx.Err = webhook.ReplayWebhookDelivery(c.Param("id"), ctx.UserName, confirm, ctx.Logger)
 }
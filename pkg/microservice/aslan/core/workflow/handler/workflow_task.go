@@ -36,6 +36,7 @@ import (
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types/dto"
 )
 
@@ -80,6 +81,7 @@ func CreateWorkflowTask(c *gin.Context) {
 		args.WorkflowTaskCreator = ctx.UserName
 	}
 
+	args.TraceContext = tracing.Inject(c.Request.Context())
 	ctx.Resp, ctx.Err = workflow.CreateWorkflowTask(args, args.WorkflowTaskCreator, ctx.Logger)
 
 	// 发送通知
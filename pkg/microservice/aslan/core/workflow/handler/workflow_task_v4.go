@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +30,7 @@ import (
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 type listWorkflowTaskV4Query struct {
@@ -69,7 +71,11 @@ func CreateWorkflowTaskV4(c *gin.Context) {
 		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
 		return
 	}
-	ctx.Resp, ctx.Err = workflow.CreateWorkflowTaskV4(ctx.UserName, args, ctx.Logger)
+	ctx.Resp, ctx.Err = workflow.CreateWorkflowTaskV4(&workflow.CreateWorkflowTaskV4Args{
+		Name:         ctx.UserName,
+		UserID:       ctx.UserID,
+		TraceContext: tracing.Inject(c.Request.Context()),
+	}, args, ctx.Logger)
 }
 
 func ListWorkflowTaskV4(c *gin.Context) {
@@ -114,6 +120,53 @@ func CancelWorkflowTaskV4(c *gin.Context) {
 	ctx.Err = workflow.CancelWorkflowTaskV4(ctx.UserName, c.Param("workflowName"), taskID, ctx.Logger)
 }
 
+func RetryWorkflowTaskV4(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+	ctx.Err = workflow.RetryWorkflowTaskV4(c.Param("workflowName"), taskID, ctx.Logger)
+}
+
+// ResumeWorkflowTaskV4Job resumes the job paused at a breakpoint, the job fails if abort=true is given.
+func ResumeWorkflowTaskV4Job(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+	abort := c.Query("abort") == "true"
+	ctx.Err = workflow.ResumeWorkflowTaskV4Job(c.Param("workflowName"), c.Param("jobName"), taskID, abort, ctx.Logger)
+}
+
+// GetWorkflowTaskV4HTMLReport serves the files of the html test report, so the report can be opened in the browser directly.
+// The report is built by users, it is sandboxed as a page of a unique origin so its scripts can not act as the logged-in user.
+func GetWorkflowTaskV4HTMLReport(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	taskID, err := strconv.ParseInt(c.Param("taskID"), 10, 64)
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid task id")
+		return
+	}
+	content, contentType, err := workflow.GetWorkflowTaskV4HTMLReport(c.Param("workflowName"), c.Param("jobName"), c.Param("path"), taskID, ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	c.Header("Content-Security-Policy", "sandbox allow-scripts allow-popups allow-forms")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, contentType, content)
+}
+
 func CloneWorkflowTaskV4(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
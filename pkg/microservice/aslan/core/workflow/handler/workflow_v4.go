@@ -200,3 +200,10 @@ func DeleteWebhookForWorkflowV4(c *gin.Context) {
 
 	ctx.Err = workflow.DeleteWebhookForWorkflowV4(c.Param("workflowName"), c.Param("triggerName"), ctx.Logger)
 }
+
+func SyncWorkflowV4FromRepo(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Err = workflow.SyncWorkflowV4FromRepoByHook(c.Param("workflowName"), c.Param("triggerName"), ctx.Logger)
+}
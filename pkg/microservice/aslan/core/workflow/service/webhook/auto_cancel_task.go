@@ -138,20 +138,23 @@ func AutoCancelTestTask(autoCancelOpt *AutoCancelOpt, task *task.Task, log *zap.
 	return nil
 }
 
-func AutoCancelWorkflowV4Task(autoCancelOpt *AutoCancelOpt, log *zap.SugaredLogger) error {
+// AutoCancelWorkflowV4Task cancels the unfinished task of the same pull request and returns the ids of the cancelled tasks.
+func AutoCancelWorkflowV4Task(autoCancelOpt *AutoCancelOpt, log *zap.SugaredLogger) ([]int64, error) {
 	if autoCancelOpt == nil || autoCancelOpt.MergeRequestID == "" || autoCancelOpt.CommitID == "" {
-		return nil
+		return nil, nil
 	}
 	if !autoCancelOpt.AutoCancel {
-		return nil
+		return nil, nil
 	}
 
 	tasks, err := commonrepo.NewworkflowTaskv4Coll().FindTodoTasksByWorkflowName(autoCancelOpt.WorkflowName)
 	if err != nil {
 		log.Errorf("find [InCompletedWorkflowV4Tasks] error: %v", err)
-		return err
+		return nil, err
 	}
 
+	var cancelled []int64
+
 	for _, task := range tasks {
 		if task.TaskCreator != setting.WebhookTaskCreator ||
 			task.WorkflowArgs.HookPayload == nil {
@@ -172,8 +175,10 @@ func AutoCancelWorkflowV4Task(autoCancelOpt *AutoCancelOpt, log *zap.SugaredLogg
 		}
 		if err = workflowcontroller.CancelWorkflowTask(task.TaskCreator, task.WorkflowName, task.TaskID, log); err != nil {
 			log.Errorf("CancelRunningWorkflowV4Task failed,task.TaskCreator:%s, task.WorkflowName:%s, task.TaskID:%d, error: %v", task.TaskCreator, task.WorkflowName, task.TaskID, err)
+		} else {
+			cancelled = append(cancelled, task.TaskID)
 		}
 		break
 	}
-	return nil
+	return cancelled, nil
 }
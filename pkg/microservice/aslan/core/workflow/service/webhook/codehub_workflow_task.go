@@ -17,6 +17,7 @@ limitations under the License.
 package webhook
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -33,6 +34,7 @@ import (
 )
 
 type codehubMergeEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *codehub.MergeEvent
@@ -40,15 +42,20 @@ type codehubMergeEventMatcher struct {
 
 func (cmem *codehubMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := cmem.event
-	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.ObjectAttributes.Target.PathWithNamespace {
-		if EventConfigured(hookRepo, config.HookEventPr) && (hookRepo.Branch == ev.ObjectAttributes.TargetBranch) {
-			if ev.ObjectAttributes.State == "opened" {
-				hookRepo.Committer = ev.User.Username
-				return true, nil
-			}
-		}
+	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) != ev.ObjectAttributes.Target.PathWithNamespace {
+		return cmem.skip(skipReasonRepo, ev.ObjectAttributes.Target.PathWithNamespace), nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventPr) {
+		return cmem.skip(skipReasonEvent, config.HookEventPr), nil
+	}
+	if hookRepo.Branch != ev.ObjectAttributes.TargetBranch {
+		return cmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
+	}
+	if ev.ObjectAttributes.State != "opened" {
+		return cmem.skip(skipReasonMRState, ev.ObjectAttributes.State), nil
 	}
-	return false, nil
+	hookRepo.Committer = ev.User.Username
+	return true, nil
 }
 
 func (cmem *codehubMergeEventMatcher) UpdateTaskArgs(
@@ -72,6 +79,7 @@ func (cmem *codehubMergeEventMatcher) UpdateTaskArgs(
 }
 
 type codehubPushEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *codehub.PushEvent
@@ -79,14 +87,17 @@ type codehubPushEventMatcher struct {
 
 func (cpem *codehubPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := cpem.event
-	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Project.PathWithNamespace {
-		if hookRepo.Branch == getBranchFromRef(ev.Ref) && EventConfigured(hookRepo, config.HookEventPush) {
-			hookRepo.Committer = ev.UserUsername
-			return true, nil
-		}
+	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) != ev.Project.PathWithNamespace {
+		return cpem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
-
-	return false, nil
+	if !EventConfigured(hookRepo, config.HookEventPush) {
+		return cpem.skip(skipReasonEvent, config.HookEventPush), nil
+	}
+	if hookRepo.Branch != getBranchFromRef(ev.Ref) {
+		return cpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
+	}
+	hookRepo.Committer = ev.UserUsername
+	return true, nil
 }
 
 func (cpem *codehubPushEventMatcher) UpdateTaskArgs(
@@ -162,15 +173,18 @@ func TriggerWorkflowByCodehubEvent(event interface{}, baseURI, requestID string,
 			}
 
 			if !matches {
+				recordHookSkipped(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo, matcher.SkipReason())
 				log.Debugf("event not matches %v", item.MainRepo)
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo)
 
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
 			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
 			var prod *commonmodels.Product
 			if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
+				match.SkipReason = fmt.Sprintf("environment %s is not found", namespace)
 				log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
 				continue
 			}
@@ -206,9 +220,11 @@ func TriggerWorkflowByCodehubEvent(event interface{}, baseURI, requestID string,
 			args.Committer = item.MainRepo.Committer
 			// 3. create task with args
 			if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+				recordHookTask(match, 0, err)
 				log.Errorf("failed to create workflow task when receive push event %v due to %v ", event, err)
 				mErr = multierror.Append(mErr, err)
 			} else {
+				recordHookTask(match, resp.TaskID, nil)
 				log.Infof("succeed to create task %v", resp)
 			}
 		}
@@ -0,0 +1,470 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v35/github"
+	"github.com/hashicorp/go-multierror"
+	"github.com/xanzy/go-gitlab"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	gitservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/codehub"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/gitee"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	DeliveryTypeWorkflow   = "workflow"
+	DeliveryTypeWorkflowV4 = "workflow_v4"
+	DeliveryTypeTest       = "test"
+	DeliveryTypeScanning   = "scanning"
+
+	SignatureVerified      = "verified"
+	SignatureFailed        = "failed"
+	SignatureNotConfigured = "not_configured"
+	SignatureNotSupported  = "not_supported"
+)
+
+// headers carrying the hook secret in plain text, they are not saved with the delivery.
+var tokenHeaders = map[string]string{
+	setting.SourceFromGitlab:  "X-Gitlab-Token",
+	setting.SourceFromGitee:   "X-Gitee-Token",
+	setting.SourceFromCodeHub: "X-Codehub-Token",
+}
+
+type deliveryRecorder struct {
+	mu       sync.Mutex
+	delivery *commonmodels.WebhookDelivery
+}
+
+// deliveryRecorders holds the deliveries being processed by request id, which is passed to all the triggers.
+var deliveryRecorders sync.Map
+
+func getDeliveryRecorder(requestID string) *deliveryRecorder {
+	if r, ok := deliveryRecorders.Load(requestID); ok {
+		return r.(*deliveryRecorder)
+	}
+	return nil
+}
+
+// recordHookSkipped records why a hook did not match the delivery, hooks on other repos are not recorded.
+func recordHookSkipped(requestID, typ, name, projectName string, hookRepo *commonmodels.MainHookRepo, reason string) {
+	r := getDeliveryRecorder(requestID)
+	if r == nil || hookRepo == nil || !hookOnDeliveryRepo(r.delivery, hookRepo) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delivery.Matches = append(r.delivery.Matches, &commonmodels.WebhookDeliveryMatch{
+		Type:        typ,
+		Name:        name,
+		ProjectName: projectName,
+		HookName:    hookRepo.Name,
+		SkipReason:  reason,
+	})
+}
+
+// recordHookMatched records a hook which matched the delivery, the result of the triggered task is set by recordHookTask.
+func recordHookMatched(requestID, typ, name, projectName string, hookRepo *commonmodels.MainHookRepo) *commonmodels.WebhookDeliveryMatch {
+	match := &commonmodels.WebhookDeliveryMatch{
+		Type:        typ,
+		Name:        name,
+		ProjectName: projectName,
+		Matched:     true,
+	}
+	if hookRepo != nil {
+		match.HookName = hookRepo.Name
+	}
+
+	r := getDeliveryRecorder(requestID)
+	if r == nil {
+		return match
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delivery.Matches = append(r.delivery.Matches, match)
+	return match
+}
+
+func recordHookTask(match *commonmodels.WebhookDeliveryMatch, taskID int64, err error) {
+	match.TaskID = taskID
+	if err != nil {
+		match.Error = err.Error()
+	}
+}
+
+func hookOnDeliveryRepo(delivery *commonmodels.WebhookDelivery, hookRepo *commonmodels.MainHookRepo) bool {
+	if delivery.Repo == "" {
+		return false
+	}
+	if delivery.Provider == setting.SourceFromGerrit {
+		return hookRepo.RepoName == delivery.Repo
+	}
+	return checkRepoNamespaceMatch(hookRepo, delivery.Repo) || hookRepo.RepoOwner+"/"+hookRepo.RepoName == delivery.Repo
+}
+
+const (
+	skipReasonRepo       = "repo %s does not match"
+	skipReasonEvent      = "event %s is not enabled"
+	skipReasonBranch     = "branch %s does not match %s"
+	skipReasonYamlBranch = "branch %s does not match the branches in the yaml trigger"
+	skipReasonFiles      = "changed files do not match the file rules"
+	skipReasonYamlFiles  = "changed files do not match the folders in the yaml trigger"
+	skipReasonMRState    = "merge request is %s"
+)
+
+// hookSkip is embedded in the event matchers to keep why the last Match did not match.
+type hookSkip struct {
+	reason string
+}
+
+// skip sets the skip reason and returns false, so a matcher can `return m.skip(...), nil`.
+func (s *hookSkip) skip(format string, args ...interface{}) bool {
+	s.reason = fmt.Sprintf(format, args...)
+	return false
+}
+
+func (s *hookSkip) SkipReason() string {
+	return s.reason
+}
+
+// matchSkipReason returns why a matcher did not match a hook, the match error is the reason if there is one.
+func matchSkipReason(matcher interface{ SkipReason() string }, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return matcher.SkipReason()
+}
+
+func webhookProvider(req *http.Request) string {
+	switch {
+	case github.WebHookType(req) != "":
+		return setting.SourceFromGithub
+	case gitlab.HookEventType(req) != "":
+		return setting.SourceFromGitlab
+	case codehub.HookEventType(req) != "":
+		return setting.SourceFromCodeHub
+	case gitee.HookEventType(req) != "":
+		return setting.SourceFromGitee
+	default:
+		return setting.SourceFromGerrit
+	}
+}
+
+func newWebhookDelivery(provider string, payload []byte, req *http.Request, requestID string) *commonmodels.WebhookDelivery {
+	delivery := &commonmodels.WebhookDelivery{
+		Provider:   provider,
+		RequestID:  requestID,
+		URI:        req.RequestURI,
+		Signature:  verifyDeliverySignature(provider, payload, req),
+		Headers:    make(map[string]string),
+		Payload:    string(payload),
+		Matches:    make([]*commonmodels.WebhookDeliveryMatch, 0),
+		CreateTime: time.Now().Unix(),
+	}
+	for key := range req.Header {
+		if key == tokenHeaders[provider] {
+			continue
+		}
+		delivery.Headers[key] = req.Header.Get(key)
+	}
+
+	body := make(map[string]interface{})
+	_ = json.Unmarshal(payload, &body)
+	describeDelivery(delivery, body, req)
+	return delivery
+}
+
+// describeDelivery sets the event, repo and ref of the delivery from the raw payload.
+func describeDelivery(delivery *commonmodels.WebhookDelivery, body map[string]interface{}, req *http.Request) {
+	switch delivery.Provider {
+	case setting.SourceFromGithub:
+		delivery.Event = github.WebHookType(req)
+		delivery.DeliveryID = github.DeliveryID(req)
+		delivery.Repo = payloadString(body, "repository", "full_name")
+		switch delivery.Event {
+		case "push":
+			delivery.Ref = payloadString(body, "ref")
+			delivery.HookEvent, delivery.Branch = pushHookEvent(delivery.Ref)
+		case "pull_request":
+			delivery.Ref = payloadString(body, "pull_request", "head", "ref")
+			delivery.HookEvent = config.HookEventPr
+			delivery.Branch = payloadString(body, "pull_request", "base", "ref")
+		case "create":
+			if payloadString(body, "ref_type") == "tag" {
+				delivery.Ref = payloadString(body, "ref")
+				delivery.HookEvent = config.HookEventTag
+			}
+		}
+	case setting.SourceFromGitlab, setting.SourceFromCodeHub:
+		if delivery.Provider == setting.SourceFromGitlab {
+			delivery.Event = string(gitlab.HookEventType(req))
+		} else {
+			delivery.Event = string(codehub.HookEventType(req))
+		}
+		delivery.Repo = payloadString(body, "project", "path_with_namespace")
+		switch payloadString(body, "object_kind") {
+		case "push", "tag_push":
+			delivery.Ref = payloadString(body, "ref")
+			delivery.HookEvent, delivery.Branch = pushHookEvent(delivery.Ref)
+		case "merge_request":
+			delivery.Repo = payloadString(body, "object_attributes", "target", "path_with_namespace")
+			delivery.Ref = payloadString(body, "object_attributes", "source_branch")
+			delivery.HookEvent = config.HookEventPr
+			delivery.Branch = payloadString(body, "object_attributes", "target_branch")
+		}
+	case setting.SourceFromGitee:
+		delivery.Event = string(gitee.HookEventType(req))
+		delivery.Repo = payloadString(body, "repository", "full_name")
+		if pr := payloadString(body, "pull_request", "base", "ref"); pr != "" {
+			delivery.Ref = payloadString(body, "pull_request", "head", "ref")
+			delivery.HookEvent = config.HookEventPr
+			delivery.Branch = pr
+		} else {
+			delivery.Ref = payloadString(body, "ref")
+			delivery.HookEvent, delivery.Branch = pushHookEvent(delivery.Ref)
+		}
+	case setting.SourceFromGerrit:
+		delivery.Event = payloadString(body, "type")
+		delivery.Repo = payloadString(body, "project", "name")
+		if delivery.Repo == "" {
+			delivery.Repo = payloadString(body, "change", "project")
+		}
+		delivery.Ref = payloadString(body, "refName")
+		delivery.HookEvent = config.HookEventType(delivery.Event)
+		delivery.Branch = payloadString(body, "change", "branch")
+	}
+}
+
+func pushHookEvent(ref string) (config.HookEventType, string) {
+	if strings.HasPrefix(ref, "refs/tags/") {
+		return config.HookEventTag, ""
+	}
+	return config.HookEventPush, getBranchFromRef(ref)
+}
+
+func payloadString(body map[string]interface{}, path ...string) string {
+	var cur interface{} = body
+	for _, key := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		cur = m[key]
+	}
+	s, _ := cur.(string)
+	return s
+}
+
+func verifyDeliverySignature(provider string, payload []byte, req *http.Request) string {
+	secret := gitservice.GetHookSecret()
+	if secret == "" {
+		return SignatureNotConfigured
+	}
+
+	switch provider {
+	case setting.SourceFromGithub:
+		if req.Header.Get(signatureHeader) == "" {
+			return SignatureNotConfigured
+		}
+		if err := validateSecret(payload, []byte(secret), req); err != nil {
+			return SignatureFailed
+		}
+		return SignatureVerified
+	case setting.SourceFromGitlab, setting.SourceFromGitee, setting.SourceFromCodeHub:
+		token := req.Header.Get(tokenHeaders[provider])
+		if token == "" {
+			return SignatureNotConfigured
+		}
+		if token != secret {
+			return SignatureFailed
+		}
+		return SignatureVerified
+	default:
+		return SignatureNotSupported
+	}
+}
+
+// ProcessWebHook triggers the workflows, tests and scannings matching the webhook, and saves the delivery with the result.
+func ProcessWebHook(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
+	_, err := processWebHook(payload, req, requestID, "", "", log)
+	return err
+}
+
+func processWebHook(payload []byte, req *http.Request, requestID, replayOf, replayer string, log *zap.SugaredLogger) (*commonmodels.WebhookDelivery, error) {
+	start := time.Now()
+	provider := webhookProvider(req)
+	delivery := newWebhookDelivery(provider, payload, req, requestID)
+	delivery.ReplayOf = replayOf
+	delivery.Replayer = replayer
+
+	deliveryRecorders.Store(requestID, &deliveryRecorder{delivery: delivery})
+	defer deliveryRecorders.Delete(requestID)
+
+	var err error
+	switch provider {
+	case setting.SourceFromGithub:
+		err = processGithubHooks(payload, req, requestID, log)
+	case setting.SourceFromGitlab:
+		err = ProcessGitlabHook(payload, req, requestID, log)
+	case setting.SourceFromCodeHub:
+		err = ProcessCodehubHook(payload, req, requestID, log)
+	case setting.SourceFromGitee:
+		err = ProcessGiteeHook(payload, req, requestID, log)
+	default:
+		err = ProcessGerritHook(payload, req, requestID, log)
+	}
+	ObserveWebhook(provider, time.Since(start), err)
+
+	delivery.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	if createErr := commonrepo.NewWebhookDeliveryColl().Create(delivery); createErr != nil {
+		log.Errorf("failed to save webhook delivery of request %s, err: %s", requestID, createErr)
+	}
+	return delivery, err
+}
+
+func processGithubHooks(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
+	errs := &multierror.Error{}
+
+	// trigger classic pipeline
+	_, err := ProcessGithubHook(payload, req, requestID, log)
+	if err != nil {
+		log.Errorf("error happens to trigger classic pipeline %v", err)
+		errs = multierror.Append(errs, err)
+	}
+
+	// trigger workflow
+	err = ProcessGithubWebHook(payload, req, requestID, log)
+
+	if err != nil {
+		log.Errorf("error happens to trigger workflow %v", err)
+		errs = multierror.Append(errs, err)
+	}
+	//测试管理webhook
+	err = ProcessGithubWebHookForTest(payload, req, requestID, log)
+	if err != nil {
+		log.Errorf("error happens to trigger ProcessGithubWebHookForTest %v", err)
+		errs = multierror.Append(errs, err)
+	}
+	// webhooks for scanning task
+	err = ProcessGithubWebhookForScanning(payload, req, requestID, log)
+	if err != nil {
+		log.Errorf("error happens to trigger Scanning for github %v", err)
+		errs = multierror.Append(errs, err)
+	}
+	// webhooks for workflow v4
+	err = ProcessGithubWebHookForWorkflowV4(payload, req, requestID, log)
+	if err != nil {
+		log.Errorf("error happens to trigger workflowV4 for github %v", err)
+		errs = multierror.Append(errs, err)
+	}
+	return errs.ErrorOrNil()
+}
+
+type ListWebhookDeliveriesArgs struct {
+	Provider    string `form:"provider"`
+	Repo        string `form:"repo"`
+	Event       string `form:"event"`
+	Name        string `form:"name"`
+	MatchedOnly bool   `form:"matchedOnly"`
+	PageNum     int64  `form:"pageNum"`
+	PageSize    int64  `form:"pageSize"`
+}
+
+func ListWebhookDeliveries(args *ListWebhookDeliveriesArgs, log *zap.SugaredLogger) ([]*commonmodels.WebhookDelivery, int64, error) {
+	deliveries, total, err := commonrepo.NewWebhookDeliveryColl().List(&commonrepo.ListWebhookDeliveryOption{
+		Provider:    args.Provider,
+		Repo:        args.Repo,
+		Event:       args.Event,
+		Name:        args.Name,
+		MatchedOnly: args.MatchedOnly,
+		PageNum:     args.PageNum,
+		PageSize:    args.PageSize,
+	})
+	if err != nil {
+		log.Errorf("failed to list webhook deliveries, err: %s", err)
+		return nil, 0, e.ErrListWebhookDelivery.AddErr(err)
+	}
+	return deliveries, total, nil
+}
+
+func GetWebhookDelivery(id string, log *zap.SugaredLogger) (*commonmodels.WebhookDelivery, error) {
+	delivery, err := commonrepo.NewWebhookDeliveryColl().Find(id)
+	if err != nil {
+		log.Errorf("failed to find webhook delivery %s, err: %s", id, err)
+		return nil, e.ErrGetWebhookDelivery.AddErr(err)
+	}
+	return delivery, nil
+}
+
+// ReplayWebhookDelivery processes the saved delivery again against the current hooks, the replay is saved as a new delivery.
+// The matched hooks create tasks again, so the replay has to be confirmed.
+func ReplayWebhookDelivery(id, username string, confirm bool, log *zap.SugaredLogger) (*commonmodels.WebhookDelivery, error) {
+	if !confirm {
+		return nil, e.ErrReplayWebhookDelivery.AddDesc("replaying the delivery triggers the matched workflows, tests and scannings again, replay it with confirm=true")
+	}
+
+	delivery, err := commonrepo.NewWebhookDeliveryColl().Find(id)
+	if err != nil {
+		log.Errorf("failed to find webhook delivery %s, err: %s", id, err)
+		if err == mongo.ErrNoDocuments {
+			return nil, e.ErrReplayWebhookDelivery.AddDesc("delivery not found")
+		}
+		return nil, e.ErrReplayWebhookDelivery.AddErr(err)
+	}
+	if delivery.Signature == SignatureFailed {
+		return nil, e.ErrReplayWebhookDelivery.AddDesc("the signature of the delivery failed to verify")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URI, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return nil, e.ErrReplayWebhookDelivery.AddErr(err)
+	}
+	req.RequestURI = delivery.URI
+	for key, value := range delivery.Headers {
+		req.Header.Set(key, value)
+	}
+	// tokens are not saved, the current secret is used for the deliveries which were verified
+	if header, ok := tokenHeaders[delivery.Provider]; ok && delivery.Signature == SignatureVerified {
+		req.Header.Set(header, gitservice.GetHookSecret())
+	}
+
+	replay, err := processWebHook([]byte(delivery.Payload), req, util.UUID(), delivery.ID.Hex(), username, log)
+	if err != nil {
+		log.Warnf("replay webhook delivery %s finished with error: %s", id, err)
+	}
+	return replay, nil
+}
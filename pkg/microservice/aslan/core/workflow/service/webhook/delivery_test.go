@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	gitservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+const testDeliveryPayload = `{"ref":"refs/heads/main"}`
+
+func deliveryRequest(headers map[string]string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/api/workflow/webhook", strings.NewReader(testDeliveryPayload))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
+func githubSignature(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var _ = Describe("webhook delivery", func() {
+	DescribeTable("verifyDeliverySignature",
+		func(provider string, headers func() map[string]string, expected string) {
+			Expect(verifyDeliverySignature(provider, []byte(testDeliveryPayload), deliveryRequest(headers()))).To(Equal(expected))
+		},
+		Entry("github signed with the hook secret", setting.SourceFromGithub, func() map[string]string {
+			return map[string]string{signatureHeader: githubSignature(testDeliveryPayload, gitservice.GetHookSecret())}
+		}, SignatureVerified),
+		Entry("github signed with another secret", setting.SourceFromGithub, func() map[string]string {
+			return map[string]string{signatureHeader: githubSignature(testDeliveryPayload, "other")}
+		}, SignatureFailed),
+		Entry("github without signature", setting.SourceFromGithub, func() map[string]string {
+			return nil
+		}, SignatureNotConfigured),
+		Entry("gitlab with the hook secret", setting.SourceFromGitlab, func() map[string]string {
+			return map[string]string{"X-Gitlab-Token": gitservice.GetHookSecret()}
+		}, SignatureVerified),
+		Entry("gitee with another token", setting.SourceFromGitee, func() map[string]string {
+			return map[string]string{"X-Gitee-Token": "other"}
+		}, SignatureFailed),
+		Entry("codehub without token", setting.SourceFromCodeHub, func() map[string]string {
+			return nil
+		}, SignatureNotConfigured),
+		Entry("gerrit", setting.SourceFromGerrit, func() map[string]string {
+			return nil
+		}, SignatureNotSupported),
+	)
+
+	DescribeTable("payloadString",
+		func(path []string, expected string) {
+			body := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(`{"ref":"refs/heads/main","number":1,"repository":{"full_name":"koderover/zadig","owner":null}}`), &body)).To(Succeed())
+			Expect(payloadString(body, path...)).To(Equal(expected))
+		},
+		Entry("top level string", []string{"ref"}, "refs/heads/main"),
+		Entry("nested string", []string{"repository", "full_name"}, "koderover/zadig"),
+		Entry("missing key", []string{"repository", "name"}, ""),
+		Entry("not a string", []string{"number"}, ""),
+		Entry("path through a string", []string{"ref", "name"}, ""),
+		Entry("path through null", []string{"repository", "owner", "login"}, ""),
+		Entry("object", []string{"repository"}, ""),
+	)
+
+	DescribeTable("describeDelivery",
+		func(provider string, headers map[string]string, payload string, expected *commonmodels.WebhookDelivery) {
+			body := map[string]interface{}{}
+			Expect(json.Unmarshal([]byte(payload), &body)).To(Succeed())
+			delivery := &commonmodels.WebhookDelivery{Provider: provider}
+			describeDelivery(delivery, body, deliveryRequest(headers))
+			Expect(delivery).To(Equal(expected))
+		},
+		Entry("github push", setting.SourceFromGithub,
+			map[string]string{"X-GitHub-Event": "push", "X-GitHub-Delivery": "d1"},
+			`{"ref":"refs/heads/main","repository":{"full_name":"koderover/zadig"}}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGithub, Event: "push", DeliveryID: "d1", Repo: "koderover/zadig",
+				Ref: "refs/heads/main", HookEvent: config.HookEventPush, Branch: "main"}),
+		Entry("github tag push", setting.SourceFromGithub,
+			map[string]string{"X-GitHub-Event": "push"},
+			`{"ref":"refs/tags/v1.0.0","repository":{"full_name":"koderover/zadig"}}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGithub, Event: "push", Repo: "koderover/zadig",
+				Ref: "refs/tags/v1.0.0", HookEvent: config.HookEventTag}),
+		Entry("github pull request", setting.SourceFromGithub,
+			map[string]string{"X-GitHub-Event": "pull_request"},
+			`{"pull_request":{"head":{"ref":"feature"},"base":{"ref":"main"}},"repository":{"full_name":"koderover/zadig"}}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGithub, Event: "pull_request", Repo: "koderover/zadig",
+				Ref: "feature", HookEvent: config.HookEventPr, Branch: "main"}),
+		Entry("gitlab merge request", setting.SourceFromGitlab,
+			map[string]string{"X-Gitlab-Event": "Merge Request Hook"},
+			`{"object_kind":"merge_request","project":{"path_with_namespace":"fork/zadig"},"object_attributes":{"target":{"path_with_namespace":"koderover/zadig"},"source_branch":"feature","target_branch":"main"}}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGitlab, Event: "Merge Request Hook", Repo: "koderover/zadig",
+				Ref: "feature", HookEvent: config.HookEventPr, Branch: "main"}),
+		Entry("gitee push", setting.SourceFromGitee,
+			map[string]string{"X-Gitee-Event": "Push Hook"},
+			`{"ref":"refs/heads/dev","repository":{"full_name":"koderover/zadig"}}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGitee, Event: "Push Hook", Repo: "koderover/zadig",
+				Ref: "refs/heads/dev", HookEvent: config.HookEventPush, Branch: "dev"}),
+		Entry("gerrit change merged", setting.SourceFromGerrit, nil,
+			`{"type":"change-merged","change":{"project":"zadig","branch":"main"},"refName":"refs/heads/main"}`,
+			&commonmodels.WebhookDelivery{Provider: setting.SourceFromGerrit, Event: "change-merged", Repo: "zadig",
+				Ref: "refs/heads/main", HookEvent: config.HookEventType("change-merged"), Branch: "main"}),
+	)
+})
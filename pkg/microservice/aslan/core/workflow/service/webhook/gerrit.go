@@ -87,7 +87,7 @@ func ProcessGerritHook(payload []byte, req *http.Request, requestID string, log
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := TriggerWorkflowV4ByGerritEvent(gerritTypeEventObj, payload, req.RequestURI, baseURI, req.Header.Get("X-Forwarded-Host"), requestID, log); err != nil {
+		if err := TriggerWorkflowV4ByGerritEvent(req.Context(), gerritTypeEventObj, payload, req.RequestURI, baseURI, req.Header.Get("X-Forwarded-Host"), requestID, log); err != nil {
 			errorList = multierror.Append(errorList, err)
 		}
 	}()
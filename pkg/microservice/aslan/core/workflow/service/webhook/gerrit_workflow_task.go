@@ -157,10 +157,12 @@ type SubmitterInfo struct {
 
 type gerritEventMatcher interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	UpdateTaskArgs(*commonmodels.Product, *commonmodels.WorkflowTaskArgs, *commonmodels.MainHookRepo, string) *commonmodels.WorkflowTaskArgs
 }
 
 type gerritChangeMergedEventMatcher struct {
+	hookSkip
 	Log      *zap.SugaredLogger
 	Item     *commonmodels.WorkflowHook
 	Workflow *commonmodels.Workflow
@@ -173,17 +175,21 @@ func (gruem *gerritChangeMergedEventMatcher) Match(hookRepo *commonmodels.MainHo
 		return false, fmt.Errorf("event doesn't match")
 	}
 
-	if event.Project.Name == gruem.Item.MainRepo.RepoName && strings.Contains(event.RefName, gruem.Item.MainRepo.Branch) {
-		existEventNames := make([]string, 0)
-		for _, eventName := range gruem.Item.MainRepo.Events {
-			existEventNames = append(existEventNames, string(eventName))
-		}
-		if sets.NewString(existEventNames...).Has(event.Type) {
-			hookRepo.Committer = event.Submitter.Username
-			return true, nil
-		}
+	if event.Project.Name != gruem.Item.MainRepo.RepoName {
+		return gruem.skip(skipReasonRepo, event.Project.Name), nil
+	}
+	if !strings.Contains(event.RefName, gruem.Item.MainRepo.Branch) {
+		return gruem.skip(skipReasonBranch, event.RefName, gruem.Item.MainRepo.Branch), nil
+	}
+	existEventNames := make([]string, 0)
+	for _, eventName := range gruem.Item.MainRepo.Events {
+		existEventNames = append(existEventNames, string(eventName))
+	}
+	if !sets.NewString(existEventNames...).Has(event.Type) {
+		return gruem.skip(skipReasonEvent, event.Type), nil
 	}
-	return false, nil
+	hookRepo.Committer = event.Submitter.Username
+	return true, nil
 }
 
 func (gruem *gerritChangeMergedEventMatcher) UpdateTaskArgs(
@@ -206,6 +212,7 @@ func (gruem *gerritChangeMergedEventMatcher) UpdateTaskArgs(
 }
 
 type gerritPatchsetCreatedEventMatcher struct {
+	hookSkip
 	Log      *zap.SugaredLogger
 	Item     *commonmodels.WorkflowHook
 	Workflow *commonmodels.Workflow
@@ -218,17 +225,21 @@ func (gpcem *gerritPatchsetCreatedEventMatcher) Match(hookRepo *commonmodels.Mai
 		return false, fmt.Errorf("event doesn't match")
 	}
 
-	if event.Project.Name == gpcem.Item.MainRepo.RepoName && strings.Contains(event.RefName, gpcem.Item.MainRepo.Branch) {
-		existEventNames := make([]string, 0)
-		for _, eventName := range gpcem.Item.MainRepo.Events {
-			existEventNames = append(existEventNames, string(eventName))
-		}
-		if sets.NewString(existEventNames...).Has(event.Type) {
-			hookRepo.Committer = event.Uploader.Username
-			return true, nil
-		}
+	if event.Project.Name != gpcem.Item.MainRepo.RepoName {
+		return gpcem.skip(skipReasonRepo, event.Project.Name), nil
+	}
+	if !strings.Contains(event.RefName, gpcem.Item.MainRepo.Branch) {
+		return gpcem.skip(skipReasonBranch, event.RefName, gpcem.Item.MainRepo.Branch), nil
+	}
+	existEventNames := make([]string, 0)
+	for _, eventName := range gpcem.Item.MainRepo.Events {
+		existEventNames = append(existEventNames, string(eventName))
+	}
+	if !sets.NewString(existEventNames...).Has(event.Type) {
+		return gpcem.skip(skipReasonEvent, event.Type), nil
 	}
-	return false, nil
+	hookRepo.Committer = event.Uploader.Username
+	return true, nil
 }
 
 func (gpcem *gerritPatchsetCreatedEventMatcher) UpdateTaskArgs(product *commonmodels.Product, args *commonmodels.WorkflowTaskArgs, hookRepo *commonmodels.MainHookRepo, requestID string) *commonmodels.WorkflowTaskArgs {
@@ -323,11 +334,13 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 					if isMatch, err := matcher.Match(item.MainRepo); err != nil {
 						errorList = multierror.Append(errorList, err)
 					} else if isMatch {
+						match := recordHookMatched(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo)
 						log.Infof("TriggerWorkflowByGerritEvent event match hook %v %v of %s", event, item.MainRepo, workflow.Name)
 						namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
 						opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
 						var prod *commonmodels.Product
 						if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
+							match.SkipReason = fmt.Sprintf("environment %s is not found", namespace)
 							log.Warnf("TriggerWorkflowByGerritEvent can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
 							continue
 						}
@@ -344,6 +357,7 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 								// 同一个pr下的不同patch set，如果两个patch set更新的内容完全相同，并且前一个patch set触发的任务执行成功，则新的patch set不再触发任务。
 								if checkLatestTaskStaus(workflow.Name, mergeRequestID, commitID, detail, log) {
 									log.Infof("last patchset has already triggered task, workflowName:%s, mergeRequestID:%s, PatchSetID:%s", workflow.Name, mergeRequestID, commitID)
+									match.SkipReason = "the patchset has already triggered a task"
 									continue
 								}
 							}
@@ -387,11 +401,15 @@ func TriggerWorkflowByGerritEvent(event *gerritTypeEvent, body []byte, uri, base
 						workflowArgs.RepoName = item.MainRepo.RepoName
 						workflowArgs.Committer = item.MainRepo.Committer
 						if resp, err := workflowservice.CreateWorkflowTask(workflowArgs, setting.WebhookTaskCreator, log); err != nil {
+							recordHookTask(match, 0, err)
 							log.Errorf("TriggerWorkflowByGerritEvent failed to create workflow task when receive push event %v due to %v ", event, err)
 							errorList = multierror.Append(errorList, err)
 						} else {
+							recordHookTask(match, resp.TaskID, nil)
 							log.Infof("TriggerWorkflowByGerritEvent succeed to create task %v", resp)
 						}
+					} else {
+						recordHookSkipped(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo, matcher.SkipReason())
 					}
 				}
 			}
@@ -17,6 +17,7 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -35,15 +36,18 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	"github.com/koderover/zadig/pkg/tool/gerrit"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
 type gerritEventMatcherForWorkflowV4 interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository
 }
 
 type gerritChangeMergedEventMatcherForWorkflowV4 struct {
+	hookSkip
 	Log      *zap.SugaredLogger
 	Item     *commonmodels.WorkflowV4Hook
 	Workflow *commonmodels.WorkflowV4
@@ -56,17 +60,21 @@ func (gruem *gerritChangeMergedEventMatcherForWorkflowV4) Match(hookRepo *common
 		return false, fmt.Errorf("event doesn't match")
 	}
 
-	if event.Project.Name == gruem.Item.MainRepo.RepoName && strings.Contains(event.RefName, gruem.Item.MainRepo.Branch) {
-		existEventNames := make([]string, 0)
-		for _, eventName := range gruem.Item.MainRepo.Events {
-			existEventNames = append(existEventNames, string(eventName))
-		}
-		if sets.NewString(existEventNames...).Has(event.Type) {
-			hookRepo.Committer = event.Submitter.Username
-			return true, nil
-		}
+	if event.Project.Name != gruem.Item.MainRepo.RepoName {
+		return gruem.skip(skipReasonRepo, event.Project.Name), nil
+	}
+	if !strings.Contains(event.RefName, gruem.Item.MainRepo.Branch) {
+		return gruem.skip(skipReasonBranch, event.RefName, gruem.Item.MainRepo.Branch), nil
 	}
-	return false, nil
+	existEventNames := make([]string, 0)
+	for _, eventName := range gruem.Item.MainRepo.Events {
+		existEventNames = append(existEventNames, string(eventName))
+	}
+	if !sets.NewString(existEventNames...).Has(event.Type) {
+		return gruem.skip(skipReasonEvent, event.Type), nil
+	}
+	hookRepo.Committer = event.Submitter.Username
+	return true, nil
 }
 
 func (gruem *gerritChangeMergedEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -81,6 +89,7 @@ func (gruem *gerritChangeMergedEventMatcherForWorkflowV4) GetHookRepo(hookRepo *
 }
 
 type gerritPatchsetCreatedEventMatcherForWorkflowV4 struct {
+	hookSkip
 	Log      *zap.SugaredLogger
 	Item     *commonmodels.WorkflowV4Hook
 	Workflow *commonmodels.WorkflowV4
@@ -93,17 +102,21 @@ func (gpcem *gerritPatchsetCreatedEventMatcherForWorkflowV4) Match(hookRepo *com
 		return false, fmt.Errorf("event doesn't match")
 	}
 
-	if event.Project.Name == gpcem.Item.MainRepo.RepoName && strings.Contains(event.RefName, gpcem.Item.MainRepo.Branch) {
-		existEventNames := make([]string, 0)
-		for _, eventName := range gpcem.Item.MainRepo.Events {
-			existEventNames = append(existEventNames, string(eventName))
-		}
-		if sets.NewString(existEventNames...).Has(event.Type) {
-			hookRepo.Committer = event.Uploader.Username
-			return true, nil
-		}
+	if event.Project.Name != gpcem.Item.MainRepo.RepoName {
+		return gpcem.skip(skipReasonRepo, event.Project.Name), nil
+	}
+	if !strings.Contains(event.RefName, gpcem.Item.MainRepo.Branch) {
+		return gpcem.skip(skipReasonBranch, event.RefName, gpcem.Item.MainRepo.Branch), nil
+	}
+	existEventNames := make([]string, 0)
+	for _, eventName := range gpcem.Item.MainRepo.Events {
+		existEventNames = append(existEventNames, string(eventName))
 	}
-	return false, nil
+	if !sets.NewString(existEventNames...).Has(event.Type) {
+		return gpcem.skip(skipReasonEvent, event.Type), nil
+	}
+	hookRepo.Committer = event.Uploader.Username
+	return true, nil
 }
 
 func (gpcem *gerritPatchsetCreatedEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -147,7 +160,7 @@ func createGerritEventMatcherForWorkflowV4(event *gerritTypeEvent, body []byte,
 	return nil
 }
 
-func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, baseURI, domain, requestID string, log *zap.SugaredLogger) error {
+func TriggerWorkflowV4ByGerritEvent(ctx context.Context, event *gerritTypeEvent, body []byte, uri, baseURI, domain, requestID string, log *zap.SugaredLogger) error {
 	log.Infof("gerrit webhook request url:%s\n", uri)
 
 	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
@@ -158,6 +171,11 @@ func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, ba
 	}
 	var errorList = &multierror.Error{}
 	var hookPayload *commonmodels.HookPayload
+	// a merged change works like a push, and a created patchset like a pull request.
+	eventType := config.HookEventPush
+	if event.Type == patchsetCreatedEventType {
+		eventType = config.HookEventPr
+	}
 	var notification *commonmodels.Notification
 	for _, workflow := range workflows {
 		if workflow.HookCtls == nil {
@@ -197,8 +215,10 @@ func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, ba
 				errorList = multierror.Append(errorList, err)
 			}
 			if !isMatch {
+				recordHookSkipped(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo, matchSkipReason(matcher, err))
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo)
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			eventRepo := matcher.GetHookRepo(item.MainRepo)
 
@@ -208,6 +228,7 @@ func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, ba
 					// for different patch sets under the same pr, if the updated contents of the two patch sets are exactly the same, and the task triggered by the previous patch set is executed successfully, the new patch set will no longer trigger the task.
 					if checkLatestTaskStaus(workflow.Name, mergeRequestID, commitID, detail, log) {
 						log.Infof("last patchset has already triggered task, workflowName:%s, mergeRequestID:%s, PatchSetID:%s", workflow.Name, mergeRequestID, commitID)
+						match.SkipReason = "the patchset has already triggered a task"
 						continue
 					}
 				}
@@ -222,7 +243,8 @@ func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, ba
 					AutoCancel:     item.AutoCancel,
 					WorkflowName:   workflow.Name,
 				}
-				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				cancelled, err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				match.CancelledTasks = cancelled
 				if err != nil {
 					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
 					errorList = multierror.Append(errorList, err)
@@ -251,24 +273,28 @@ func TriggerWorkflowV4ByGerritEvent(event *gerritTypeEvent, body []byte, uri, ba
 			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
 				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				errorList = multierror.Append(errorList, fmt.Errorf(errMsg))
 				continue
 			}
 			if err := job.MergeWebhookRepo(item.WorkflowArg, eventRepo); err != nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				errorList = multierror.Append(errorList, fmt.Errorf(errMsg))
 				continue
 			}
 			if notification != nil {
 				workflow.NotificationID = notification.ID.Hex()
 			}
-			workflow.HookPayload = hookPayload
-			if resp, err := workflowservice.CreateWorkflowTaskV4(setting.WebhookTaskCreator, workflow, log); err != nil {
+			workflow.HookPayload = workflowV4HookPayload(hookPayload, eventType, eventRepo, matcher)
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{Name: setting.WebhookTaskCreator, TraceContext: tracing.Inject(ctx), Priority: hookPriority(item.WorkflowArg)}, workflow, log); err != nil {
 				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, err)
 				errorList = multierror.Append(errorList, fmt.Errorf(errMsg))
 			} else {
+				recordHookTask(match, resp.TaskID, nil)
 				log.Infof("succeed to create task %v", resp)
 			}
 
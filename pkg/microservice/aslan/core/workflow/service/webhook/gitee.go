@@ -104,7 +104,7 @@ func ProcessGiteeHook(payload []byte, req *http.Request, requestID string, log *
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err = TriggerWorkflowV4ByGiteeEvent(event, baseURI, requestID, log); err != nil {
+			if err = TriggerWorkflowV4ByGiteeEvent(req.Context(), event, baseURI, requestID, log); err != nil {
 				errorList = multierror.Append(errorList, err)
 			}
 		}()
@@ -138,7 +138,7 @@ func ProcessGiteeHook(payload []byte, req *http.Request, requestID string, log *
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err = TriggerWorkflowV4ByGiteeEvent(event, baseURI, requestID, log); err != nil {
+			if err = TriggerWorkflowV4ByGiteeEvent(req.Context(), event, baseURI, requestID, log); err != nil {
 				errorList = multierror.Append(errorList, err)
 			}
 		}()
@@ -164,7 +164,7 @@ func ProcessGiteeHook(payload []byte, req *http.Request, requestID string, log *
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err = TriggerWorkflowV4ByGiteeEvent(event, baseURI, requestID, log); err != nil {
+			if err = TriggerWorkflowV4ByGiteeEvent(req.Context(), event, baseURI, requestID, log); err != nil {
 				errorList = multierror.Append(errorList, err)
 			}
 		}()
@@ -33,6 +33,7 @@ import (
 )
 
 type giteePushEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *gitee.PushEvent
@@ -42,17 +43,17 @@ func (gpem *giteePushEventMatcherForTesting) Match(hookRepo *commonmodels.MainHo
 	ev := gpem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Repository.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPush) {
-			return false, nil
+			return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 		}
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 
 		if isRegular {
 			matched, err := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref))
 			if err != nil || !matched {
-				return false, nil
+				return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = getBranchFromRef(ev.Ref)
@@ -63,10 +64,13 @@ func (gpem *giteePushEventMatcherForTesting) Match(hookRepo *commonmodels.MainHo
 			changedFiles = append(changedFiles, commit.Modified...)
 		}
 
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gpem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gpem.skip(skipReasonRepo, ev.Repository.FullName), nil
 }
 
 func (gpem *giteePushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -80,33 +84,34 @@ func (gpem *giteePushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.T
 }
 
 type giteeTagEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *gitee.TagPushEvent
 }
 
-func (gtem giteeTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *giteeTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Project.PathWithNamespace {
 		if !EventConfigured(hookRepo, config.HookEventTag) {
-			return false, nil
+			return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 		}
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.Project.DefaultBranch {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 		}
 
 		if isRegular {
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.Project.DefaultBranch)
 			if err != nil || !matched {
-				return false, nil
+				return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = ev.Project.DefaultBranch
 		return true, nil
 	}
 
-	return false, nil
+	return gtem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 }
 
 func (gtem giteeTagEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -120,6 +125,7 @@ func (gtem giteeTagEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.Tes
 }
 
 type giteeMergeEventMatcherForTesting struct {
+	hookSkip
 	diffFunc giteePullRequestDiffFunc
 	log      *zap.SugaredLogger
 	testing  *commonmodels.Testing
@@ -131,18 +137,18 @@ func (gmem *giteeMergeEventMatcherForTesting) Match(hookRepo *commonmodels.MainH
 	// TODO: match codehost
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.PullRequest.Base.Repo.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPr) {
-			return false, nil
+			return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.PullRequest.Base.Ref {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 
 		if isRegular {
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.PullRequest.Base.Ref)
 			if err != nil || !matched {
-				return false, nil
+				return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = ev.PullRequest.Base.Ref
@@ -155,11 +161,14 @@ func (gmem *giteeMergeEventMatcherForTesting) Match(hookRepo *commonmodels.MainH
 			}
 			gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-			return MatchChanges(hookRepo, changedFiles), nil
+			if !MatchChanges(hookRepo, changedFiles) {
+				return gmem.skip(skipReasonFiles), nil
+			}
+			return true, nil
 		}
-
+		return gmem.skip(skipReasonMRState, ev.PullRequest.State), nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonRepo, ev.PullRequest.Base.Repo.FullName), nil
 }
 
 func (gmem *giteeMergeEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -230,6 +239,7 @@ func TriggerTestByGiteeEvent(event interface{}, baseURI, requestID string, log *
 				if matches, err := matcher.Match(item.MainRepo); err != nil {
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo)
 					log.Infof("event match hook %v of %s", item.MainRepo, testing.Name)
 					var mergeRequestID, commitID string
 					if ev, isPr := event.(*gitee.PullRequestEvent); isPr {
@@ -275,12 +285,15 @@ func TriggerTestByGiteeEvent(event interface{}, baseURI, requestID string, log *
 
 					// 3. create task with args
 					if resp, err := testingservice.CreateTestTask(args, log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create testing task when receive event %v due to %s ", event, err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp.TaskID, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo, matcher.SkipReason())
 					log.Debugf("event not matches %v", item.MainRepo)
 				}
 			}
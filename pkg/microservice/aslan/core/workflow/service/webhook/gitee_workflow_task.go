@@ -39,6 +39,7 @@ import (
 type giteePullRequestDiffFunc func(event *gitee.PullRequestEvent, id int) ([]string, error)
 
 type giteePushEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *gitee.PushEvent
@@ -48,18 +49,18 @@ func (gpem *giteePushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (b
 	ev := gpem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Repository.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPush) {
-			return false, nil
+			return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 		if isRegular {
 			// Do not use regexp.MustCompile to avoid panic
 			matched, err := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref))
 			if err != nil || !matched {
-				return false, nil
+				return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = getBranchFromRef(ev.Ref)
@@ -70,10 +71,13 @@ func (gpem *giteePushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (b
 			changedFiles = append(changedFiles, commit.Removed...)
 			changedFiles = append(changedFiles, commit.Modified...)
 		}
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gpem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gpem.skip(skipReasonRepo, ev.Repository.FullName), nil
 }
 
 func (gpem *giteePushEventMatcher) UpdateTaskArgs(
@@ -95,6 +99,7 @@ func (gpem *giteePushEventMatcher) UpdateTaskArgs(
 }
 
 type giteeMergeEventMatcher struct {
+	hookSkip
 	diffFunc giteePullRequestDiffFunc
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
@@ -105,17 +110,17 @@ func (gmem *giteeMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 	ev := gmem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.PullRequest.Base.Repo.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPr) {
-			return false, nil
+			return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.PullRequest.Base.Ref {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 		if isRegular {
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.PullRequest.Base.Ref)
 			if err != nil || !matched {
-				return false, nil
+				return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = ev.PullRequest.Base.Ref
@@ -129,10 +134,14 @@ func (gmem *giteeMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 			}
 			gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-			return MatchChanges(hookRepo, changedFiles), nil
+			if !MatchChanges(hookRepo, changedFiles) {
+				return gmem.skip(skipReasonFiles), nil
+			}
+			return true, nil
 		}
+		return gmem.skip(skipReasonMRState, ev.PullRequest.State), nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonRepo, ev.PullRequest.Base.Repo.FullName), nil
 }
 
 func (gmem *giteeMergeEventMatcher) UpdateTaskArgs(
@@ -155,27 +164,28 @@ func (gmem *giteeMergeEventMatcher) UpdateTaskArgs(
 }
 
 type giteeTagEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *gitee.TagPushEvent
 }
 
-func (gtem giteeTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *giteeTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Repository.FullName {
 		if !EventConfigured(hookRepo, config.HookEventTag) {
-			return false, nil
+			return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.Repository.DefaultBranch {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Repository.DefaultBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			// Do not use regexp.MustCompile to avoid panic
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.Repository.DefaultBranch)
 			if err != nil || !matched {
-				return false, nil
+				return gtem.skip(skipReasonBranch, ev.Repository.DefaultBranch, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Tag = getTagFromRef(ev.Ref)
@@ -184,7 +194,7 @@ func (gtem giteeTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (boo
 		return true, nil
 	}
 
-	return false, nil
+	return gtem.skip(skipReasonRepo, ev.Repository.FullName), nil
 }
 
 func (gtem giteeTagEventMatcher) UpdateTaskArgs(product *commonmodels.Product, args *commonmodels.WorkflowTaskArgs, hookRepo *commonmodels.MainHookRepo, requestID string) *commonmodels.WorkflowTaskArgs {
@@ -261,11 +271,13 @@ func TriggerWorkflowByGiteeEvent(event interface{}, baseURI, requestID string, l
 				if matches, err := matcher.Match(item.MainRepo); err != nil {
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo)
 					log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 					namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
 					opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
 					var prod *commonmodels.Product
 					if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
+						match.SkipReason = fmt.Sprintf("environment %s is not found", namespace)
 						log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
 						continue
 					}
@@ -325,12 +337,15 @@ func TriggerWorkflowByGiteeEvent(event interface{}, baseURI, requestID string, l
 
 					// 3. create task with args
 					if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create workflow task when receive push event due to %v ", err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp.TaskID, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo, matcher.SkipReason())
 					log.Debugf("event not matches %v", item.MainRepo)
 				}
 			}
@@ -17,6 +17,7 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -32,36 +33,41 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/gitee"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
 type giteeEventMatcherForWorkflowV4 interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository
 }
 
 type giteePushEventMatcherForWorkflowV4 struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *gitee.PushEvent
+	// files changed by the event, set once the event matched.
+	changedFiles []string
 }
 
 func (gpem *giteePushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Repository.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPush) {
-			return false, nil
+			return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 		if isRegular {
 			// Do not use regexp.MustCompile to avoid panic
 			matched, err := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref))
 			if err != nil || !matched {
-				return false, nil
+				return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = getBranchFromRef(ev.Ref)
@@ -72,10 +78,18 @@ func (gpem *giteePushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.Mai
 			changedFiles = append(changedFiles, commit.Removed...)
 			changedFiles = append(changedFiles, commit.Modified...)
 		}
-		return MatchChanges(hookRepo, changedFiles), nil
+		gpem.changedFiles = changedFiles
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gpem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gpem.skip(skipReasonRepo, ev.Repository.FullName), nil
+}
+
+func (gpem *giteePushEventMatcherForWorkflowV4) GetChangedFiles() []string {
+	return gpem.changedFiles
 }
 
 func (gpem *giteePushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -90,27 +104,30 @@ func (gpem *giteePushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmode
 }
 
 type giteeMergeEventMatcherForWorkflowV4 struct {
+	hookSkip
 	diffFunc giteePullRequestDiffFunc
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *gitee.PullRequestEvent
+	// files changed by the event, set once the event matched.
+	changedFiles []string
 }
 
 func (gmem *giteeMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gmem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.PullRequest.Base.Repo.FullName {
 		if !EventConfigured(hookRepo, config.HookEventPr) {
-			return false, nil
+			return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.PullRequest.Base.Ref {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 		if isRegular {
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.PullRequest.Base.Ref)
 			if err != nil || !matched {
-				return false, nil
+				return gmem.skip(skipReasonBranch, ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Branch = ev.PullRequest.Base.Ref
@@ -123,11 +140,20 @@ func (gmem *giteeMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.Ma
 				return false, err
 			}
 			gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
+			gmem.changedFiles = changedFiles
 
-			return MatchChanges(hookRepo, changedFiles), nil
+			if !MatchChanges(hookRepo, changedFiles) {
+				return gmem.skip(skipReasonFiles), nil
+			}
+			return true, nil
 		}
+		return gmem.skip(skipReasonMRState, ev.PullRequest.State), nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonRepo, ev.PullRequest.Base.Repo.FullName), nil
+}
+
+func (gmem *giteeMergeEventMatcherForWorkflowV4) GetChangedFiles() []string {
+	return gmem.changedFiles
 }
 
 func (gmem *giteeMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -143,27 +169,28 @@ func (gmem *giteeMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmod
 }
 
 type giteeTagEventMatcherForWorkflowV4 struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *gitee.TagPushEvent
 }
 
-func (gtem giteeTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *giteeTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Repository.FullName {
 		if !EventConfigured(hookRepo, config.HookEventTag) {
-			return false, nil
+			return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 		}
 
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.Repository.DefaultBranch {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Repository.DefaultBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			// Do not use regexp.MustCompile to avoid panic
 			matched, err := regexp.MatchString(hookRepo.Branch, ev.Repository.DefaultBranch)
 			if err != nil || !matched {
-				return false, nil
+				return gtem.skip(skipReasonBranch, ev.Repository.DefaultBranch, hookRepo.Branch), nil
 			}
 		}
 		hookRepo.Tag = getTagFromRef(ev.Ref)
@@ -172,7 +199,7 @@ func (gtem giteeTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainH
 		return true, nil
 	}
 
-	return false, nil
+	return gtem.skip(skipReasonRepo, ev.Repository.FullName), nil
 }
 
 func (gtem *giteeTagEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -215,7 +242,7 @@ func createGiteeEventMatcherForWorkflowV4(
 	return nil
 }
 
-func TriggerWorkflowV4ByGiteeEvent(event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
+func TriggerWorkflowV4ByGiteeEvent(ctx context.Context, event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
 	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
 	if err != nil {
 		errMsg := fmt.Sprintf("list workflow v4 error: %v", err)
@@ -229,6 +256,15 @@ func TriggerWorkflowV4ByGiteeEvent(event interface{}, baseURI, requestID string,
 	}
 	var hookPayload *commonmodels.HookPayload
 	var notification *commonmodels.Notification
+	var eventType config.HookEventType
+	switch event.(type) {
+	case *gitee.PushEvent:
+		eventType = config.HookEventPush
+	case *gitee.PullRequestEvent:
+		eventType = config.HookEventPr
+	case *gitee.TagPushEvent:
+		eventType = config.HookEventTag
+	}
 
 	for _, workflow := range workflows {
 		if workflow.HookCtls == nil {
@@ -250,8 +286,10 @@ func TriggerWorkflowV4ByGiteeEvent(event interface{}, baseURI, requestID string,
 				mErr = multierror.Append(mErr, err)
 			}
 			if !matches {
+				recordHookSkipped(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo, matchSkipReason(matcher, err))
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo)
 
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			eventRepo := matcher.GetHookRepo(item.MainRepo)
@@ -267,7 +305,8 @@ func TriggerWorkflowV4ByGiteeEvent(event interface{}, baseURI, requestID string,
 					AutoCancel:     item.AutoCancel,
 					WorkflowName:   workflow.Name,
 				}
-				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				cancelled, err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				match.CancelledTasks = cancelled
 				if err != nil {
 					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
 					mErr = multierror.Append(mErr, err)
@@ -296,24 +335,28 @@ func TriggerWorkflowV4ByGiteeEvent(event interface{}, baseURI, requestID string,
 			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
 				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
 			if err := job.MergeWebhookRepo(workflow, eventRepo); err != nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
 			if notification != nil {
 				workflow.NotificationID = notification.ID.Hex()
 			}
-			workflow.HookPayload = hookPayload
-			if resp, err := workflowservice.CreateWorkflowTaskV4(setting.WebhookTaskCreator, workflow, log); err != nil {
+			workflow.HookPayload = workflowV4HookPayload(hookPayload, eventType, eventRepo, matcher)
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{Name: setting.WebhookTaskCreator, TraceContext: tracing.Inject(ctx), Priority: hookPriority(item.WorkflowArg)}, workflow, log); err != nil {
 				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, err)
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 			} else {
+				recordHookTask(match, resp.TaskID, nil)
 				log.Infof("succeed to create task %v", resp)
 			}
 		}
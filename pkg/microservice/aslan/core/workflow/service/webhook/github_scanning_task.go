@@ -31,6 +31,7 @@ import (
 
 type gitEventMatcherForScanning interface {
 	Match(repository *types.ScanningHook) (bool, error)
+	SkipReason() string
 }
 
 func TriggerScanningByGithubEvent(event interface{}, requestID string, log *zap.SugaredLogger) error {
@@ -59,6 +60,7 @@ func TriggerScanningByGithubEvent(event interface{}, requestID string, log *zap.
 				if matches, err := matcher.Match(item); err != nil {
 					mErr = multierror.Append(err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeScanning, scanning.Name, scanning.ProjectName, ConvertScanningHookToMainHookRepo(item))
 					log.Infof("event match hook %v of %s", item, scanning.Name)
 					var mergeRequestID string
 					if ev, isPr := event.(*github.PullRequestEvent); isPr {
@@ -86,12 +88,15 @@ func TriggerScanningByGithubEvent(event interface{}, requestID string, log *zap.
 					triggerRepoInfo = append(triggerRepoInfo, repoInfo)
 
 					if resp, err := scanningservice.CreateScanningTask(scanning.ID.Hex(), triggerRepoInfo, "", "webhook", log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create testing task when receive event %v due to %v ", event, err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeScanning, scanning.Name, scanning.ProjectName, ConvertScanningHookToMainHookRepo(item), matcher.SkipReason())
 					log.Debugf("event not matches %v", item)
 				}
 			}
@@ -101,25 +106,26 @@ func TriggerScanningByGithubEvent(event interface{}, requestID string, log *zap.
 }
 
 type githubPushEventMatcherForScanning struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
 	event    *github.PushEvent
 }
 
-func (gpem githubPushEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
+func (gpem *githubPushEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
 	ev := gpem.event
 	if hookRepo == nil {
-		return false, nil
+		return gpem.skip("no hook is configured"), nil
 	}
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == *ev.Repo.FullName {
 		matchRepo := ConvertScanningHookToMainHookRepo(hookRepo)
 
 		if !EventConfigured(matchRepo, config.HookEventPush) {
-			return false, nil
+			return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 		}
 
 		if hookRepo.Branch != getBranchFromRef(*ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 		}
 
 		hookRepo.Branch = getBranchFromRef(*ev.Ref)
@@ -130,29 +136,33 @@ func (gpem githubPushEventMatcherForScanning) Match(hookRepo *types.ScanningHook
 			changedFiles = append(changedFiles, commit.Modified...)
 		}
 
-		return MatchChanges(matchRepo, changedFiles), nil
+		if !MatchChanges(matchRepo, changedFiles) {
+			return gpem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gpem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 }
 
 type githubMergeEventMatcherForScanning struct {
+	hookSkip
 	diffFunc githubPullRequestDiffFunc
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
 	event    *github.PullRequestEvent
 }
 
-func (gmem githubMergeEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
+func (gmem *githubMergeEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
 	ev := gmem.event
 	if hookRepo == nil {
-		return false, nil
+		return gmem.skip("no hook is configured"), nil
 	}
 	// TODO: match codehost
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == *ev.PullRequest.Base.Repo.FullName {
 		matchRepo := ConvertScanningHookToMainHookRepo(hookRepo)
 		if !EventConfigured(matchRepo, config.HookEventPr) {
-			return false, nil
+			return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 		}
 
 		hookRepo.Branch = *ev.PullRequest.Base.Ref
@@ -166,25 +176,29 @@ func (gmem githubMergeEventMatcherForScanning) Match(hookRepo *types.ScanningHoo
 			}
 			gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-			return MatchChanges(matchRepo, changedFiles), nil
+			if !MatchChanges(matchRepo, changedFiles) {
+				return gmem.skip(skipReasonFiles), nil
+			}
+			return true, nil
 		}
-
+		return gmem.skip(skipReasonMRState, *ev.PullRequest.State), nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonRepo, *ev.PullRequest.Base.Repo.FullName), nil
 }
 
 type githubTagEventMatcherForScanning struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
 	event    *github.CreateEvent
 }
 
-func (gtem githubTagEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
+func (gtem *githubTagEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
 	ev := gtem.event
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == *ev.Repo.FullName {
 		hookInfo := ConvertScanningHookToMainHookRepo(hookRepo)
 		if !EventConfigured(hookInfo, config.HookEventTag) {
-			return false, nil
+			return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 		}
 
 		hookRepo.Branch = *ev.Repo.DefaultBranch
@@ -192,7 +206,7 @@ func (gtem githubTagEventMatcherForScanning) Match(hookRepo *types.ScanningHook)
 		return true, nil
 	}
 
-	return false, nil
+	return gtem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 }
 
 func createGithubEventMatcherForScanning(
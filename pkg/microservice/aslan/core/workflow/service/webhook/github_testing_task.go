@@ -55,6 +55,7 @@ func TriggerTestByGithubEvent(event interface{}, requestID string, log *zap.Suga
 				if matches, err := matcher.Match(item.MainRepo); err != nil {
 					mErr = multierror.Append(err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo)
 					log.Infof("event match hook %v of %s", item.MainRepo, testing.Name)
 					var mergeRequestID, commitID string
 					if ev, isPr := event.(*github.PullRequestEvent); isPr {
@@ -69,12 +70,15 @@ func TriggerTestByGithubEvent(event interface{}, requestID string, log *zap.Suga
 					args.RepoOwner = item.MainRepo.RepoOwner
 					args.RepoName = item.MainRepo.RepoName
 					if resp, err := testingservice.CreateTestTask(args, log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create testing task when receive event %v due to %v ", event, err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp.TaskID, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo, matcher.SkipReason())
 					log.Debugf("event not matches %v", item.MainRepo)
 				}
 			}
@@ -84,12 +88,14 @@ func TriggerTestByGithubEvent(event interface{}, requestID string, log *zap.Suga
 }
 
 type githubPushEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *github.PushEvent
 }
 
 type githubMergeEventMatcherForTesting struct {
+	hookSkip
 	diffFunc githubPullRequestDiffFunc
 	log      *zap.SugaredLogger
 	testing  *commonmodels.Testing
@@ -99,19 +105,19 @@ type githubMergeEventMatcherForTesting struct {
 func (gpem *githubPushEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != getBranchFromRef(*ev.Ref) {
-		return false, nil
+		return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(*ev.Ref)); !matched {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = getBranchFromRef(*ev.Ref)
@@ -122,7 +128,10 @@ func (gpem *githubPushEventMatcherForTesting) Match(hookRepo *commonmodels.MainH
 		changedFiles = append(changedFiles, commit.Modified...)
 	}
 
-	return MatchChanges(hookRepo, changedFiles), nil
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
+	}
+	return true, nil
 }
 
 func (gpem *githubPushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -136,27 +145,28 @@ func (gpem *githubPushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.
 }
 
 type githubTagEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *github.CreateEvent
 }
 
-func (gtem githubTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *githubTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.Repo.DefaultBranch {
-		return false, nil
+		return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.Repo.DefaultBranch); !matched {
-			return false, nil
+			return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = *ev.Repo.DefaultBranch
@@ -207,21 +217,21 @@ func (gmem *githubMergeEventMatcherForTesting) Match(hookRepo *commonmodels.Main
 	// TODO: match codehost
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.PullRequest.Base.Repo.FullName) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, *ev.PullRequest.Base.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.PullRequest.Base.Ref {
-		return false, nil
+		return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.PullRequest.Base.Ref); !matched {
-			return false, nil
+			return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = *ev.PullRequest.Base.Ref
@@ -235,9 +245,12 @@ func (gmem *githubMergeEventMatcherForTesting) Match(hookRepo *commonmodels.Main
 		}
 		gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonMRState, *ev.PullRequest.State), nil
 }
 
 func (gmem *githubMergeEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -44,10 +44,12 @@ type githubPullRequestDiffFunc func(event *github.PullRequestEvent, id int) ([]s
 
 type gitEventMatcher interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	UpdateTaskArgs(*commonmodels.Product, *commonmodels.WorkflowTaskArgs, *commonmodels.MainHookRepo, string) *commonmodels.WorkflowTaskArgs
 }
 
 type githubPushEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *github.PushEvent
@@ -57,21 +59,21 @@ func (gpem *githubPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 	ev := gpem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != getBranchFromRef(*ev.Ref) {
-		return false, nil
+		return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 	}
 	if isRegular {
 		// Do not use regexp.MustCompile to avoid panic
 		if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(*ev.Ref)); !matched {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = getBranchFromRef(*ev.Ref)
@@ -82,7 +84,10 @@ func (gpem *githubPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 		changedFiles = append(changedFiles, commit.Removed...)
 		changedFiles = append(changedFiles, commit.Modified...)
 	}
-	return MatchChanges(hookRepo, changedFiles), nil
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
+	}
+	return true, nil
 }
 
 func getBranchFromRef(ref string) string {
@@ -122,6 +127,7 @@ func (gpem *githubPushEventMatcher) UpdateTaskArgs(
 }
 
 type githubMergeEventMatcher struct {
+	hookSkip
 	diffFunc githubPullRequestDiffFunc
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
@@ -132,20 +138,20 @@ func (gmem *githubMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo)
 	ev := gmem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.PullRequest.Base.Repo.FullName) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, *ev.PullRequest.Base.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.PullRequest.Base.Ref {
-		return false, nil
+		return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 	}
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.PullRequest.Base.Ref); !matched {
-			return false, nil
+			return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = *ev.PullRequest.Base.Ref
@@ -159,10 +165,13 @@ func (gmem *githubMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo)
 		}
 		gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gmem.skip(skipReasonMRState, *ev.PullRequest.State), nil
 }
 
 func (gmem *githubMergeEventMatcher) UpdateTaskArgs(
@@ -245,30 +254,31 @@ func (waf *workflowArgsFactory) Update(product *commonmodels.Product, args *comm
 }
 
 type githubTagEventMatcher struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.Workflow
 	event    *github.CreateEvent
 }
 
-func (gtem githubTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *githubTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.Repo.DefaultBranch {
-		return false, nil
+		return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 	}
 	if isRegular {
 		// Do not use regexp.MustCompile to avoid panic
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.Repo.DefaultBranch); !matched {
-			return false, nil
+			return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Tag = getTagFromRef(*ev.Ref)
@@ -353,11 +363,13 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 				if matches, err := matcher.Match(item.MainRepo); err != nil {
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo)
 					log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 					namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
 					opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
 					var prod *commonmodels.Product
 					if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
+						match.SkipReason = fmt.Sprintf("environment %s is not found", namespace)
 						log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
 						continue
 					}
@@ -407,12 +419,15 @@ func TriggerWorkflowByGithubEvent(event interface{}, baseURI, deliveryID, reques
 
 					// 3. create task with args
 					if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create workflow task when receive push event due to %v ", err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp.TaskID, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo, matcher.SkipReason())
 					log.Debugf("event not matches %v", item.MainRepo)
 				}
 			}
@@ -440,3 +455,27 @@ func findChangedFilesOfPullRequest(event *github.PullRequestEvent, codehostID in
 	}
 	return changeFiles, nil
 }
+
+// findChangedFilesOfPush returns the files changed by the push, the push event holds 20 commits at most while
+// the comparison holds all the changed files.
+func findChangedFilesOfPush(event *github.PushEvent, codehostID int) ([]string, error) {
+	if strings.Trim(event.GetBefore(), "0") == "" {
+		return nil, fmt.Errorf("no commit before the push")
+	}
+	detail, err := systemconfig.New().GetCodeHost(codehostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find codehost %d: %v", codehostID, err)
+	}
+	githubCli := git.NewClient(detail.AccessToken, config.ProxyHTTPSAddr(), detail.EnableProxy)
+	repo := event.GetRepo()
+	commitComparison, _, err := githubCli.Repositories.CompareCommits(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), event.GetBefore(), event.GetAfter())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes from github, err: %v", err)
+	}
+
+	changeFiles := make([]string, 0)
+	for _, commitFile := range commitComparison.Files {
+		changeFiles = append(changeFiles, commitFile.GetFilename())
+	}
+	return changeFiles, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -32,39 +33,50 @@ import (
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
 type gitEventMatcherForWorkflowV4 interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository
 }
 
+// changedFilesGetter is implemented by the matchers which know the files changed by the event,
+// the files can be used by the job conditions of workflow v4.
+type changedFilesGetter interface {
+	GetChangedFiles() []string
+}
+
 type githubPushEventMatcheForWorkflowV4 struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *github.PushEvent
+	// files changed by the event, set once the event matched.
+	changedFiles []string
 }
 
 func (gpem *githubPushEventMatcheForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != getBranchFromRef(*ev.Ref) {
-		return false, nil
+		return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 	}
 	if isRegular {
 		// Do not use regexp.MustCompile to avoid panic
 		if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(*ev.Ref)); !matched {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(*ev.Ref), hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = getBranchFromRef(*ev.Ref)
@@ -75,7 +87,15 @@ func (gpem *githubPushEventMatcheForWorkflowV4) Match(hookRepo *commonmodels.Mai
 		changedFiles = append(changedFiles, commit.Removed...)
 		changedFiles = append(changedFiles, commit.Modified...)
 	}
-	return MatchChanges(hookRepo, changedFiles), nil
+	gpem.changedFiles = changedFiles
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
+	}
+	return true, nil
+}
+
+func (gpem *githubPushEventMatcheForWorkflowV4) GetChangedFiles() []string {
+	return gpem.changedFiles
 }
 
 func (gpem *githubPushEventMatcheForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -92,30 +112,33 @@ func (gpem *githubPushEventMatcheForWorkflowV4) GetHookRepo(hookRepo *commonmode
 }
 
 type githubMergeEventMatcherForWorkflowV4 struct {
+	hookSkip
 	diffFunc githubPullRequestDiffFunc
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *github.PullRequestEvent
+	// files changed by the event, set once the event matched.
+	changedFiles []string
 }
 
 func (gmem *githubMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gmem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.PullRequest.Base.Repo.FullName) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, *ev.PullRequest.Base.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.PullRequest.Base.Ref {
-		return false, nil
+		return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 	}
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.PullRequest.Base.Ref); !matched {
-			return false, nil
+			return gmem.skip(skipReasonBranch, *ev.PullRequest.Base.Ref, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = *ev.PullRequest.Base.Ref
@@ -128,11 +151,19 @@ func (gmem *githubMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.M
 			return false, err
 		}
 		gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
+		gmem.changedFiles = changedFiles
 
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gmem.skip(skipReasonMRState, *ev.PullRequest.State), nil
+}
+
+func (gmem *githubMergeEventMatcherForWorkflowV4) GetChangedFiles() []string {
+	return gmem.changedFiles
 }
 
 func (gmem *githubMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -150,30 +181,31 @@ func (gmem *githubMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmo
 }
 
 type githubTagEventMatcherForWorkflowV4 struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	workflow *commonmodels.WorkflowV4
 	event    *github.CreateEvent
 }
 
-func (gtem githubTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *githubTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, *ev.Repo.FullName) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, *ev.Repo.FullName), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != *ev.Repo.DefaultBranch {
-		return false, nil
+		return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 	}
 	if isRegular {
 		// Do not use regexp.MustCompile to avoid panic
 		if matched, _ := regexp.MatchString(hookRepo.Branch, *ev.Repo.DefaultBranch); !matched {
-			return false, nil
+			return gtem.skip(skipReasonBranch, *ev.Repo.DefaultBranch, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Tag = getTagFromRef(*ev.Ref)
@@ -224,7 +256,7 @@ func createGithubEventMatcherForWorkflowV4(
 	return nil
 }
 
-func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requestID string, log *zap.SugaredLogger) error {
+func TriggerWorkflowV4ByGithubEvent(ctx context.Context, event interface{}, baseURI, deliveryID, requestID string, log *zap.SugaredLogger) error {
 	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
 	if err != nil {
 		errMsg := fmt.Sprintf("list workflow v4 error: %v", err)
@@ -237,6 +269,15 @@ func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requ
 		return findChangedFilesOfPullRequest(pullRequestEvent, codehostId)
 	}
 	hookPayload := &commonmodels.HookPayload{}
+	var eventType config.HookEventType
+	switch event.(type) {
+	case *github.PushEvent:
+		eventType = config.HookEventPush
+	case *github.PullRequestEvent:
+		eventType = config.HookEventPr
+	case *github.CreateEvent:
+		eventType = config.HookEventTag
+	}
 
 	for _, workflow := range workflows {
 		if workflow.HookCtls == nil {
@@ -246,6 +287,16 @@ func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requ
 			if !item.Enabled {
 				continue
 			}
+			if pushEvent, ok := event.(*github.PushEvent); ok && githubPushSyncsYaml(pushEvent, item) {
+				changedFiles, err := findChangedFilesOfPush(pushEvent, item.MainRepo.CodehostID)
+				if err != nil {
+					log.Warnf("failed to compare the commits of push %s, the files in the commits of the event are used: %v", pushEvent.GetAfter(), err)
+					changedFiles = githubPushedFiles(pushEvent)
+				}
+				if workflow, err = syncWorkflowV4FromPush(workflow, item, changedFiles, pushEvent.GetAfter(), log); err != nil {
+					mErr = multierror.Append(mErr, err)
+				}
+			}
 			matcher := createGithubEventMatcherForWorkflowV4(event, diffSrv, workflow, log)
 			if matcher == nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
@@ -258,8 +309,10 @@ func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requ
 				mErr = multierror.Append(mErr, err)
 			}
 			if !matches {
+				recordHookSkipped(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo, matchSkipReason(matcher, err))
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo)
 
 			var mergeRequestID, commitID string
 			if ev, isPr := event.(*github.PullRequestEvent); isPr {
@@ -273,7 +326,8 @@ func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requ
 					AutoCancel:     item.AutoCancel,
 					WorkflowName:   workflow.Name,
 				}
-				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				cancelled, err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				match.CancelledTasks = cancelled
 				if err != nil {
 					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
 					mErr = multierror.Append(mErr, err)
@@ -293,27 +347,37 @@ func TriggerWorkflowV4ByGithubEvent(event interface{}, baseURI, deliveryID, requ
 			}
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			eventRepo := matcher.GetHookRepo(item.MainRepo)
-			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
+			taskWorkflow, err := workflowV4ForTask(workflow, item, githubEventYamlRef(event), log)
+			if err != nil {
+				recordHookTask(match, 0, err)
+				mErr = multierror.Append(mErr, err)
+				continue
+			}
+			if err := job.MergeArgs(taskWorkflow, item.WorkflowArg); err != nil {
 				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
-			if err := job.MergeWebhookRepo(workflow, eventRepo); err != nil {
+			if err := job.MergeWebhookRepo(taskWorkflow, eventRepo); err != nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
-			workflow.HookPayload = hookPayload
-			if resp, err := workflowservice.CreateWorkflowTaskV4(setting.WebhookTaskCreator, workflow, log); err != nil {
+			taskWorkflow.HookPayload = workflowV4HookPayload(hookPayload, eventType, eventRepo, matcher)
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{Name: setting.WebhookTaskCreator, TraceContext: tracing.Inject(ctx), Priority: hookPriority(item.WorkflowArg)}, taskWorkflow, log); err != nil {
 				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, err)
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 			} else {
-				if workflow.HookPayload.IsPr {
+				recordHookTask(match, resp.TaskID, nil)
+				if taskWorkflow.HookPayload.IsPr {
 					// Updating the comment in the git repository, this will not cause the function to return error if this function call fails
-					if err := scmnotify.NewService().CreateGitCheckForWorkflowV4(workflow, resp.TaskID, log); err != nil {
+					if err := scmnotify.NewService().CreateGitCheckForWorkflowV4(taskWorkflow, resp.TaskID, log); err != nil {
 						log.Warnf("Failed to create github check status for custom workflow %s, taskID: %d the error is: %s", workflow.Name, resp.TaskID, err)
 					}
 				}
@@ -57,6 +57,7 @@ func TriggerScanningByGitlabEvent(event interface{}, baseURI, requestID string,
 				if matches, err := matcher.Match(item); err != nil {
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeScanning, scanning.Name, scanning.ProjectName, ConvertScanningHookToMainHookRepo(item))
 					log.Infof("event match hook %v of %s", item, scanning.Name)
 					var mergeRequestID int
 					if ev, isPr := event.(*gitlab.MergeEvent); isPr {
@@ -91,12 +92,15 @@ func TriggerScanningByGitlabEvent(event interface{}, baseURI, requestID string,
 					}
 
 					if resp, err := scanningservice.CreateScanningTask(scanning.ID.Hex(), triggerRepoInfo, notificationID, "webhook", log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create testing task when receive event %v due to %v ", event, err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeScanning, scanning.Name, scanning.ProjectName, ConvertScanningHookToMainHookRepo(item), matcher.SkipReason())
 					log.Debugf("event not matches %v", item)
 				}
 			}
@@ -135,6 +139,7 @@ func createGitlabEventMatcherForScanning(
 }
 
 type gitlabPushEventMatcherForScanning struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
 	event    *gitlab.PushEvent
@@ -143,16 +148,16 @@ type gitlabPushEventMatcherForScanning struct {
 func (gpem *gitlabPushEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
 	ev := gpem.event
 	if hookRepo == nil {
-		return false, nil
+		return gpem.skip("no hook is configured"), nil
 	}
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Project.PathWithNamespace {
 		matchRepo := ConvertScanningHookToMainHookRepo(hookRepo)
 
 		if !EventConfigured(matchRepo, config.HookEventPush) {
-			return false, nil
+			return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 		}
 		if hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 
 		hookRepo.Branch = getBranchFromRef(ev.Ref)
@@ -163,13 +168,17 @@ func (gpem *gitlabPushEventMatcherForScanning) Match(hookRepo *types.ScanningHoo
 			changedFiles = append(changedFiles, commit.Modified...)
 		}
 
-		return MatchChanges(matchRepo, changedFiles), nil
+		if !MatchChanges(matchRepo, changedFiles) {
+			return gpem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
 
-	return false, nil
+	return gpem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 }
 
 type gitlabMergeEventMatcherForScanning struct {
+	hookSkip
 	diffFunc gitlabMergeRequestDiffFunc
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
@@ -179,13 +188,13 @@ type gitlabMergeEventMatcherForScanning struct {
 func (gmem *gitlabMergeEventMatcherForScanning) Match(hookRepo *types.ScanningHook) (bool, error) {
 	ev := gmem.event
 	if hookRepo == nil {
-		return false, nil
+		return gmem.skip("no hook is configured"), nil
 	}
 	// TODO: match codehost
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.ObjectAttributes.Target.PathWithNamespace {
 		matchRepo := ConvertScanningHookToMainHookRepo(hookRepo)
 		if !EventConfigured(matchRepo, config.HookEventPr) {
-			return false, nil
+			return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 		}
 
 		hookRepo.Branch = ev.ObjectAttributes.TargetBranch
@@ -199,14 +208,18 @@ func (gmem *gitlabMergeEventMatcherForScanning) Match(hookRepo *types.ScanningHo
 			}
 			gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-			return MatchChanges(matchRepo, changedFiles), nil
+			if !MatchChanges(matchRepo, changedFiles) {
+				return gmem.skip(skipReasonFiles), nil
+			}
+			return true, nil
 		}
-
+		return gmem.skip(skipReasonMRState, ev.ObjectAttributes.State), nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonRepo, ev.ObjectAttributes.Target.PathWithNamespace), nil
 }
 
 type gitlabTagEventMatcherForScanning struct {
+	hookSkip
 	log      *zap.SugaredLogger
 	scanning *commonmodels.Scanning
 	event    *gitlab.TagEvent
@@ -217,12 +230,12 @@ func (gtem *gitlabTagEventMatcherForScanning) Match(hookRepo *types.ScanningHook
 	if (hookRepo.RepoOwner + "/" + hookRepo.RepoName) == ev.Project.PathWithNamespace {
 		hookInfo := ConvertScanningHookToMainHookRepo(hookRepo)
 		if !EventConfigured(hookInfo, config.HookEventTag) {
-			return false, nil
+			return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 		}
 
 		hookRepo.Branch = ev.Project.DefaultBranch
 		return true, nil
 	}
 
-	return false, nil
+	return gtem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 }
@@ -34,6 +34,7 @@ import (
 
 type gitEventMatcherForTesting interface {
 	Match(*commonmodels.MainHookRepo) (bool, error)
+	SkipReason() string
 	UpdateTaskArgs(*commonmodels.TestTaskArgs, string) *commonmodels.TestTaskArgs
 }
 
@@ -52,6 +53,7 @@ func (waf *testArgsFactory) Update(args *commonmodels.TestTaskArgs) *commonmodel
 }
 
 type gitlabPushEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *gitlab.PushEvent
@@ -60,19 +62,19 @@ type gitlabPushEventMatcherForTesting struct {
 func (gpem *gitlabPushEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-		return false, nil
+		return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref)); !matched {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = getBranchFromRef(ev.Ref)
@@ -82,7 +84,10 @@ func (gpem *gitlabPushEventMatcherForTesting) Match(hookRepo *commonmodels.MainH
 		changedFiles = append(changedFiles, commit.Removed...)
 		changedFiles = append(changedFiles, commit.Modified...)
 	}
-	return MatchChanges(hookRepo, changedFiles), nil
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
+	}
+	return true, nil
 }
 
 func (gpem *gitlabPushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
@@ -96,29 +101,30 @@ func (gpem *gitlabPushEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.
 }
 
 type gitlabTagEventMatcherForTesting struct {
+	hookSkip
 	log     *zap.SugaredLogger
 	testing *commonmodels.Testing
 	event   *gitlab.TagEvent
 }
 
-func (gtem gitlabTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *gitlabTagEventMatcherForTesting) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != ev.Project.DefaultBranch {
-		return false, nil
+		return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, ev.Project.DefaultBranch); !matched {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = ev.Project.DefaultBranch
@@ -168,6 +174,7 @@ func TriggerTestByGitlabEvent(event interface{}, baseURI, requestID string, log
 				if matches, err := matcher.Match(item.MainRepo); err != nil {
 					mErr = multierror.Append(mErr, err)
 				} else if matches {
+					match := recordHookMatched(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo)
 					log.Infof("event match hook %v of %s", item.MainRepo, testing.Name)
 					var mergeRequestID, commitID string
 					if ev, isPr := event.(*gitlab.MergeEvent); isPr {
@@ -210,12 +217,15 @@ func TriggerTestByGitlabEvent(event interface{}, baseURI, requestID string, log
 
 					// 3. create task with args
 					if resp, err := testingservice.CreateTestTask(args, log); err != nil {
+						recordHookTask(match, 0, err)
 						log.Errorf("failed to create testing task when receive event %v due to %v ", event, err)
 						mErr = multierror.Append(mErr, err)
 					} else {
+						recordHookTask(match, resp.TaskID, nil)
 						log.Infof("succeed to create task %v", resp)
 					}
 				} else {
+					recordHookSkipped(requestID, DeliveryTypeTest, testing.Name, testing.ProductName, item.MainRepo, matcher.SkipReason())
 					log.Debugf("event not matches %v", item.MainRepo)
 				}
 			}
@@ -254,6 +264,7 @@ func createGitlabEventMatcherForTesting(
 }
 
 type gitlabMergeEventMatcherForTesting struct {
+	hookSkip
 	diffFunc gitlabMergeRequestDiffFunc
 	log      *zap.SugaredLogger
 	testing  *commonmodels.Testing
@@ -264,21 +275,21 @@ func (gmem *gitlabMergeEventMatcherForTesting) Match(hookRepo *commonmodels.Main
 	ev := gmem.event
 	// TODO: match codehost
 	if !checkRepoNamespaceMatch(hookRepo, ev.ObjectAttributes.Target.PathWithNamespace) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, ev.ObjectAttributes.Target.PathWithNamespace), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 
 	isRegular := hookRepo.IsRegular
 	if !isRegular && hookRepo.Branch != ev.ObjectAttributes.TargetBranch {
-		return false, nil
+		return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 	}
 
 	if isRegular {
 		if matched, _ := regexp.MatchString(hookRepo.Branch, ev.ObjectAttributes.TargetBranch); !matched {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 		}
 	}
 	hookRepo.Branch = ev.ObjectAttributes.TargetBranch
@@ -292,9 +303,12 @@ func (gmem *gitlabMergeEventMatcherForTesting) Match(hookRepo *commonmodels.Main
 		}
 		gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
 
-		return MatchChanges(hookRepo, changedFiles), nil
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
+		}
+		return true, nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonMRState, ev.ObjectAttributes.State), nil
 }
 
 func (gmem *gitlabMergeEventMatcherForTesting) UpdateTaskArgs(args *commonmodels.TestTaskArgs, requestID string) *commonmodels.TestTaskArgs {
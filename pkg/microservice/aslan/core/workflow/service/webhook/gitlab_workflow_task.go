@@ -49,6 +49,7 @@ import (
 type gitlabMergeRequestDiffFunc func(event *gitlab.MergeEvent, id int) ([]string, error)
 
 type gitlabMergeEventMatcher struct {
+	hookSkip
 	diffFunc           gitlabMergeRequestDiffFunc
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.Workflow
@@ -62,10 +63,10 @@ func (gmem *gitlabMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo)
 	ev := gmem.event
 	// TODO: match codehost
 	if !checkRepoNamespaceMatch(hookRepo, ev.ObjectAttributes.Target.PathWithNamespace) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, ev.ObjectAttributes.Target.PathWithNamespace), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 	if gmem.isYaml {
 		refFlag := false
@@ -76,16 +77,16 @@ func (gmem *gitlabMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo)
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gmem.skip(skipReasonYamlBranch, getBranchFromRef(ev.ObjectAttributes.TargetBranch)), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.ObjectAttributes.TargetBranch {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, ev.ObjectAttributes.TargetBranch); !matched {
-				return false, nil
+				return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 			}
 		}
 	}
@@ -102,11 +103,17 @@ func (gmem *gitlabMergeEventMatcher) Match(hookRepo *commonmodels.MainHookRepo)
 		if gmem.isYaml {
 			serviceChangeds := ServicesMatchChangesFiles(gmem.trigger.Rules.MatchFolders, changedFiles)
 			gmem.yamlServiceChanged = serviceChangeds
-			return len(serviceChangeds) != 0, nil
+			if len(serviceChangeds) == 0 {
+				return gmem.skip(skipReasonYamlFiles), nil
+			}
+			return true, nil
+		}
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
 		}
-		return MatchChanges(hookRepo, changedFiles), nil
+		return true, nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonMRState, ev.ObjectAttributes.State), nil
 }
 
 func (gmem *gitlabMergeEventMatcher) UpdateTaskArgs(
@@ -177,6 +184,7 @@ func createGitlabEventMatcher(
 }
 
 type gitlabPushEventMatcher struct {
+	hookSkip
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.Workflow
 	event              *gitlab.PushEvent
@@ -188,10 +196,10 @@ type gitlabPushEventMatcher struct {
 func (gpem *gitlabPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 	if gpem.isYaml {
 		refFlag := false
@@ -202,16 +210,16 @@ func (gpem *gitlabPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gpem.skip(skipReasonYamlBranch, getBranchFromRef(ev.Ref)), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref)); !matched {
-				return false, nil
+				return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 			}
 		}
 	}
@@ -244,9 +252,15 @@ func (gpem *gitlabPushEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (
 	if gpem.isYaml {
 		serviceChangeds := ServicesMatchChangesFiles(gpem.trigger.Rules.MatchFolders, changedFiles)
 		gpem.yamlServiceChanged = serviceChangeds
-		return len(serviceChangeds) != 0, nil
+		if len(serviceChangeds) == 0 {
+			return gpem.skip(skipReasonYamlFiles), nil
+		}
+		return true, nil
 	}
-	return MatchChanges(hookRepo, changedFiles), nil
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
+	}
+	return true, nil
 }
 
 func (gpem *gitlabPushEventMatcher) UpdateTaskArgs(
@@ -282,6 +296,7 @@ func (gpem *gitlabPushEventMatcher) UpdateTaskArgs(
 }
 
 type gitlabTagEventMatcher struct {
+	hookSkip
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.Workflow
 	event              *gitlab.TagEvent
@@ -290,15 +305,15 @@ type gitlabTagEventMatcher struct {
 	yamlServiceChanged []BuildServices
 }
 
-func (gtem gitlabTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *gitlabTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 	if gtem.isYaml {
 		refFlag := false
@@ -309,16 +324,16 @@ func (gtem gitlabTagEventMatcher) Match(hookRepo *commonmodels.MainHookRepo) (bo
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gtem.skip(skipReasonYamlBranch, ev.Project.DefaultBranch), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.Project.DefaultBranch {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, ev.Project.DefaultBranch); !matched {
-				return false, nil
+				return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 			}
 		}
 	}
@@ -592,14 +607,17 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 			}
 
 			if !matches {
+				recordHookSkipped(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo, matcher.SkipReason())
 				log.Debugf("event not matches %v", item.MainRepo)
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflow, workflow.Name, workflow.ProductTmplName, item.MainRepo)
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			namespace := strings.Split(item.WorkflowArgs.Namespace, ",")[0]
 			opt := &commonrepo.ProductFindOptions{Name: workflow.ProductTmplName, EnvName: namespace}
 			var prod *commonmodels.Product
 			if prod, err = commonrepo.NewProductColl().Find(opt); err != nil {
+				match.SkipReason = fmt.Sprintf("environment %s is not found", namespace)
 				log.Warnf("can't find environment %s-%s", item.WorkflowArgs.Namespace, workflow.ProductTmplName)
 				continue
 			}
@@ -651,6 +669,7 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 			// 3. create task with args
 			if item.WorkflowArgs.BaseNamespace == "" {
 				if resp, err := workflowservice.CreateWorkflowTask(args, setting.WebhookTaskCreator, log); err != nil {
+					recordHookTask(match, 0, err)
 					log.Errorf("failed to create workflow task when receive push event %v due to %v ", event, err)
 					mErr = multierror.Append(mErr, err)
 					// 单独创建一条通知，展示任务创建失败的错误信息
@@ -661,13 +680,16 @@ func TriggerWorkflowByGitlabEvent(event interface{}, baseURI, requestID string,
 						log.Errorf("SendErrWebhookComment failed, product:%s, workflow:%s, err:%v", workflow.ProductTmplName, workflow.Name, err2)
 					}
 				} else {
+					recordHookTask(match, resp.TaskID, nil)
 					log.Infof("succeed to create task %v", resp)
 				}
 			} else if item.WorkflowArgs.BaseNamespace != "" && isMergeRequest {
 				if err = CreateEnvAndTaskByPR(args, prID, requestID, log); err != nil {
+					recordHookTask(match, 0, err)
 					log.Infof("CreateRandomEnv err:%v", err)
 				}
 			} else {
+				match.SkipReason = "it is not a pull request event"
 				log.Warnf("It's not a PR event,BaseNamespace:%s", item.WorkflowArgs.BaseNamespace)
 			}
 		}
@@ -17,6 +17,7 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -34,10 +35,12 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 )
 
 type gitlabMergeEventMatcherForWorkflowV4 struct {
+	hookSkip
 	diffFunc           gitlabMergeRequestDiffFunc
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.WorkflowV4
@@ -45,16 +48,17 @@ type gitlabMergeEventMatcherForWorkflowV4 struct {
 	trigger            *TriggerYaml
 	isYaml             bool
 	yamlServiceChanged []BuildServices
+	changedFiles       []string
 }
 
 func (gmem *gitlabMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gmem.event
 	// TODO: match codehost
 	if !checkRepoNamespaceMatch(hookRepo, ev.ObjectAttributes.Target.PathWithNamespace) {
-		return false, nil
+		return gmem.skip(skipReasonRepo, ev.ObjectAttributes.Target.PathWithNamespace), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPr) {
-		return false, nil
+		return gmem.skip(skipReasonEvent, config.HookEventPr), nil
 	}
 	if gmem.isYaml {
 		refFlag := false
@@ -65,16 +69,16 @@ func (gmem *gitlabMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.M
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gmem.skip(skipReasonYamlBranch, getBranchFromRef(ev.ObjectAttributes.TargetBranch)), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.ObjectAttributes.TargetBranch {
-			return false, nil
+			return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, ev.ObjectAttributes.TargetBranch); !matched {
-				return false, nil
+				return gmem.skip(skipReasonBranch, ev.ObjectAttributes.TargetBranch, hookRepo.Branch), nil
 			}
 		}
 	}
@@ -88,14 +92,25 @@ func (gmem *gitlabMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.M
 			return false, err
 		}
 		gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
+		gmem.changedFiles = changedFiles
 		if gmem.isYaml {
 			serviceChangeds := ServicesMatchChangesFiles(gmem.trigger.Rules.MatchFolders, changedFiles)
 			gmem.yamlServiceChanged = serviceChangeds
-			return len(serviceChangeds) != 0, nil
+			if len(serviceChangeds) == 0 {
+				return gmem.skip(skipReasonYamlFiles), nil
+			}
+			return true, nil
+		}
+		if !MatchChanges(hookRepo, changedFiles) {
+			return gmem.skip(skipReasonFiles), nil
 		}
-		return MatchChanges(hookRepo, changedFiles), nil
+		return true, nil
 	}
-	return false, nil
+	return gmem.skip(skipReasonMRState, ev.ObjectAttributes.State), nil
+}
+
+func (gmem *gitlabMergeEventMatcherForWorkflowV4) GetChangedFiles() []string {
+	return gmem.changedFiles
 }
 
 func (gmem *gitlabMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -139,21 +154,23 @@ func createGitlabEventMatcherForWorkflowV4(
 }
 
 type gitlabPushEventMatcherForWorkflowV4 struct {
+	hookSkip
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.WorkflowV4
 	event              *gitlab.PushEvent
 	trigger            *TriggerYaml
 	isYaml             bool
 	yamlServiceChanged []BuildServices
+	changedFiles       []string
 }
 
 func (gpem *gitlabPushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gpem.event
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gpem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 	if !EventConfigured(hookRepo, config.HookEventPush) {
-		return false, nil
+		return gpem.skip(skipReasonEvent, config.HookEventPush), nil
 	}
 	if gpem.isYaml {
 		refFlag := false
@@ -164,16 +181,16 @@ func (gpem *gitlabPushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.Ma
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gpem.skip(skipReasonYamlBranch, getBranchFromRef(ev.Ref)), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != getBranchFromRef(ev.Ref) {
-			return false, nil
+			return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, getBranchFromRef(ev.Ref)); !matched {
-				return false, nil
+				return gpem.skip(skipReasonBranch, getBranchFromRef(ev.Ref), hookRepo.Branch), nil
 			}
 		}
 	}
@@ -203,12 +220,23 @@ func (gpem *gitlabPushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.Ma
 		changedFiles = append(changedFiles, diff.NewPath)
 		changedFiles = append(changedFiles, diff.OldPath)
 	}
+	gpem.changedFiles = changedFiles
 	if gpem.isYaml {
 		serviceChangeds := ServicesMatchChangesFiles(gpem.trigger.Rules.MatchFolders, changedFiles)
 		gpem.yamlServiceChanged = serviceChangeds
-		return len(serviceChangeds) != 0, nil
+		if len(serviceChangeds) == 0 {
+			return gpem.skip(skipReasonYamlFiles), nil
+		}
+		return true, nil
+	}
+	if !MatchChanges(hookRepo, changedFiles) {
+		return gpem.skip(skipReasonFiles), nil
 	}
-	return MatchChanges(hookRepo, changedFiles), nil
+	return true, nil
+}
+
+func (gpem *gitlabPushEventMatcherForWorkflowV4) GetChangedFiles() []string {
+	return gpem.changedFiles
 }
 
 func (gpem *gitlabPushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
@@ -223,6 +251,7 @@ func (gpem *gitlabPushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmod
 }
 
 type gitlabTagEventMatcherForWorkflowV4 struct {
+	hookSkip
 	log                *zap.SugaredLogger
 	workflow           *commonmodels.WorkflowV4
 	event              *gitlab.TagEvent
@@ -231,15 +260,15 @@ type gitlabTagEventMatcherForWorkflowV4 struct {
 	yamlServiceChanged []BuildServices
 }
 
-func (gtem gitlabTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+func (gtem *gitlabTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
 	ev := gtem.event
 
 	if !checkRepoNamespaceMatch(hookRepo, ev.Project.PathWithNamespace) {
-		return false, nil
+		return gtem.skip(skipReasonRepo, ev.Project.PathWithNamespace), nil
 	}
 
 	if !EventConfigured(hookRepo, config.HookEventTag) {
-		return false, nil
+		return gtem.skip(skipReasonEvent, config.HookEventTag), nil
 	}
 	if gtem.isYaml {
 		refFlag := false
@@ -250,16 +279,16 @@ func (gtem gitlabTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.Main
 			}
 		}
 		if !refFlag {
-			return false, nil
+			return gtem.skip(skipReasonYamlBranch, ev.Project.DefaultBranch), nil
 		}
 	} else {
 		isRegular := hookRepo.IsRegular
 		if !isRegular && hookRepo.Branch != ev.Project.DefaultBranch {
-			return false, nil
+			return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 		}
 		if isRegular {
 			if matched, _ := regexp.MatchString(hookRepo.Branch, ev.Project.DefaultBranch); !matched {
-				return false, nil
+				return gtem.skip(skipReasonBranch, ev.Project.DefaultBranch, hookRepo.Branch), nil
 			}
 		}
 	}
@@ -282,7 +311,7 @@ func (gpem *gitlabTagEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmode
 	}
 }
 
-func TriggerWorkflowV4ByGitlabEvent(event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
+func TriggerWorkflowV4ByGitlabEvent(ctx context.Context, event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
 	// TODO: cache workflow
 	// 1. find configured workflow
 	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
@@ -310,26 +339,35 @@ func TriggerWorkflowV4ByGitlabEvent(event interface{}, baseURI, requestID string
 			var pushEvent *gitlab.PushEvent
 			var mergeEvent *gitlab.MergeEvent
 			var tagEvent *gitlab.TagEvent
+			var eventType config.HookEventType
 			switch evt := event.(type) {
 			case *gitlab.PushEvent:
+				eventType = config.HookEventPush
 				pushEvent = evt
 				if !checkRepoNamespaceMatch(item.MainRepo, pushEvent.Project.PathWithNamespace) {
 					log.Debugf("event not matches repo: %v", item.MainRepo)
 					continue
 				}
 			case *gitlab.MergeEvent:
+				eventType = config.HookEventPr
 				mergeEvent = evt
 				if !checkRepoNamespaceMatch(item.MainRepo, mergeEvent.ObjectAttributes.Target.PathWithNamespace) {
 					log.Debugf("event not matches repo: %v", item.MainRepo)
 					continue
 				}
 			case *gitlab.TagEvent:
+				eventType = config.HookEventTag
 				tagEvent = evt
 				if !checkRepoNamespaceMatch(item.MainRepo, tagEvent.Project.PathWithNamespace) {
 					log.Debugf("event not matches repo: %v", item.MainRepo)
 					continue
 				}
 			}
+			if item.SyncYaml && pushEvent != nil && getBranchFromRef(pushEvent.Ref) == pushEvent.Project.DefaultBranch {
+				if workflow, err = syncWorkflowV4FromPush(workflow, item, gitlabPushedFiles(pushEvent), pushEvent.After, log); err != nil {
+					mErr = multierror.Append(mErr, err)
+				}
+			}
 			matcher := createGitlabEventMatcherForWorkflowV4(event, diffSrv, workflow, log)
 			if matcher == nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
@@ -342,8 +380,10 @@ func TriggerWorkflowV4ByGitlabEvent(event interface{}, baseURI, requestID string
 				mErr = multierror.Append(mErr, err)
 			}
 			if !matches {
+				recordHookSkipped(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo, matchSkipReason(matcher, err))
 				continue
 			}
+			match := recordHookMatched(requestID, DeliveryTypeWorkflowV4, workflow.Name, workflow.Project, item.MainRepo)
 			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
 			eventRepo := matcher.GetHookRepo(item.MainRepo)
 			var mergeRequestID, commitID string
@@ -359,7 +399,8 @@ func TriggerWorkflowV4ByGitlabEvent(event interface{}, baseURI, requestID string
 					AutoCancel:     item.AutoCancel,
 					WorkflowName:   workflow.Name,
 				}
-				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				cancelled, err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				match.CancelledTasks = cancelled
 				if err != nil {
 					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
 					mErr = multierror.Append(mErr, err)
@@ -381,27 +422,37 @@ func TriggerWorkflowV4ByGitlabEvent(event interface{}, baseURI, requestID string
 					)
 				}
 			}
-			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
+			taskWorkflow, err := workflowV4ForTask(workflow, item, gitlabEventYamlRef(event), log)
+			if err != nil {
+				recordHookTask(match, 0, err)
+				mErr = multierror.Append(mErr, err)
+				continue
+			}
+			if err := job.MergeArgs(taskWorkflow, item.WorkflowArg); err != nil {
 				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
-			if err := job.MergeWebhookRepo(workflow, eventRepo); err != nil {
+			if err := job.MergeWebhookRepo(taskWorkflow, eventRepo); err != nil {
 				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, fmt.Errorf(errMsg))
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 				continue
 			}
 			if notification != nil {
-				workflow.NotificationID = notification.ID.Hex()
+				taskWorkflow.NotificationID = notification.ID.Hex()
 			}
-			workflow.HookPayload = hookPayload
-			if resp, err := workflowservice.CreateWorkflowTaskV4(setting.WebhookTaskCreator, workflow, log); err != nil {
+			taskWorkflow.HookPayload = workflowV4HookPayload(hookPayload, eventType, eventRepo, matcher)
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{Name: setting.WebhookTaskCreator, TraceContext: tracing.Inject(ctx), Priority: hookPriority(item.WorkflowArg)}, taskWorkflow, log); err != nil {
 				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
 				log.Error(errMsg)
+				recordHookTask(match, 0, err)
 				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
 			} else {
+				recordHookTask(match, resp.TaskID, nil)
 				log.Infof("succeed to create task %v", resp)
 			}
 		}
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var processDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "webhook",
+	Name:      "process_duration_seconds",
+	Help:      "Latency of processing the webhook events by provider and result.",
+	Buckets:   metrics.DurationBuckets,
+}, []string{"provider", "result"})
+
+// ObserveWebhook records how long it took to process a webhook event of the provider.
+func ObserveWebhook(provider string, duration time.Duration, err error) {
+	processDuration.WithLabelValues(provider, metrics.Result(err)).Observe(duration.Seconds())
+}
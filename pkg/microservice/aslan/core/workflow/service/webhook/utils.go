@@ -32,6 +32,7 @@ import (
 	"go.uber.org/zap"
 	"helm.sh/helm/v3/pkg/releaseutil"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
@@ -769,3 +770,37 @@ func subElem(parent, sub string) bool {
 	}
 	return false
 }
+
+// workflowV4HookPayload returns the hook payload of a workflow v4 task, which carries the event info used by job conditions.
+// the payload of pull request events is shared by all the matched workflows, so it is copied.
+func workflowV4HookPayload(payload *commonmodels.HookPayload, eventType config.HookEventType, eventRepo *types.Repository, matcher interface{}) *commonmodels.HookPayload {
+	resp := &commonmodels.HookPayload{}
+	if payload != nil {
+		*resp = *payload
+	}
+	resp.EventType = string(eventType)
+	if resp.Repo == "" {
+		resp.Owner = eventRepo.RepoOwner
+		resp.Repo = eventRepo.RepoName
+		resp.CodehostID = eventRepo.CodehostID
+	}
+	if resp.Branch == "" {
+		resp.Branch = eventRepo.Branch
+	}
+	if resp.CommitID == "" {
+		resp.CommitID = eventRepo.CommitID
+	}
+	if getter, ok := matcher.(changedFilesGetter); ok {
+		resp.ChangedFiles = sets.NewString(getter.GetChangedFiles()...).Delete("").List()
+	}
+	return resp
+}
+
+// hookPriority returns the priority set in the config of a workflow v4 hook, e.g. hooks of pull requests may run
+// with a lower priority, 0 means the priority of the workflow.
+func hookPriority(workflowArg *commonmodels.WorkflowV4) config.TaskPriority {
+	if workflowArg == nil {
+		return 0
+	}
+	return workflowArg.Priority
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"github.com/google/go-github/v35/github"
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+)
+
+// syncWorkflowV4FromPush syncs the workflow if the yaml file of it is changed by the push to the default branch.
+// The synced workflow is returned, or the original one if it is not synced.
+func syncWorkflowV4FromPush(workflow *commonmodels.WorkflowV4, hook *commonmodels.WorkflowV4Hook, changedFiles []string, commitID string, log *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
+	if !workflowV4YamlChanged(workflow.Name, hook, changedFiles) {
+		return workflow, nil
+	}
+	yamlPath := workflowservice.WorkflowV4YamlPath(workflow.Name, hook)
+	synced, err := workflowservice.SyncWorkflowV4FromRepo(workflow, hook, commitID, log)
+	if err != nil {
+		log.Errorf("failed to sync workflow %s from %s at %s: %v", workflow.Name, yamlPath, commitID, err)
+		return workflow, err
+	}
+	return synced, nil
+}
+
+// workflowV4YamlChanged tells whether the yaml file of the workflow is in the changed files.
+func workflowV4YamlChanged(workflowName string, hook *commonmodels.WorkflowV4Hook, changedFiles []string) bool {
+	yamlPath := workflowservice.WorkflowV4YamlPath(workflowName, hook)
+	for _, file := range changedFiles {
+		if file == yamlPath {
+			return true
+		}
+	}
+	return false
+}
+
+// workflowV4ForTask returns the workflow to run, which is loaded from the main repo at the ref of the event if the hook is defined so.
+func workflowV4ForTask(workflow *commonmodels.WorkflowV4, hook *commonmodels.WorkflowV4Hook, ref string, log *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
+	if !hook.IsYaml {
+		return workflow, nil
+	}
+	loaded, err := workflowservice.LoadWorkflowV4FromRepo(workflow, hook, ref, log)
+	if err != nil {
+		log.Errorf("failed to load workflow %s from repo at %s: %v", workflow.Name, ref, err)
+		return nil, err
+	}
+	return loaded, nil
+}
+
+func gitlabPushedFiles(ev *gitlab.PushEvent) []string {
+	var files []string
+	for _, commit := range ev.Commits {
+		files = append(files, commit.Added...)
+		files = append(files, commit.Modified...)
+	}
+	return files
+}
+
+// githubPushSyncsYaml tells whether the push is to the default branch of the main repo of the hook, the workflow
+// yaml is synced from it only.
+func githubPushSyncsYaml(ev *github.PushEvent, hook *commonmodels.WorkflowV4Hook) bool {
+	if !hook.SyncYaml || hook.MainRepo == nil {
+		return false
+	}
+	return checkRepoNamespaceMatch(hook.MainRepo, ev.GetRepo().GetFullName()) &&
+		getBranchFromRef(ev.GetRef()) == ev.GetRepo().GetDefaultBranch()
+}
+
+// githubPushedFiles returns the files in the commits of the push event, which holds 20 commits at most.
+func githubPushedFiles(ev *github.PushEvent) []string {
+	var files []string
+	for _, commit := range ev.Commits {
+		files = append(files, commit.Added...)
+		files = append(files, commit.Modified...)
+	}
+	return files
+}
+
+// gitlabEventYamlRef returns the ref the workflow yaml is loaded at. The yaml of merge requests is loaded from
+// the target branch, since the source branch may come from a fork and anyone opening one could define the jobs.
+func gitlabEventYamlRef(event interface{}) string {
+	switch ev := event.(type) {
+	case *gitlab.PushEvent:
+		return ev.After
+	case *gitlab.MergeEvent:
+		return ev.ObjectAttributes.TargetBranch
+	case *gitlab.TagEvent:
+		return ev.CheckoutSHA
+	}
+	return ""
+}
+
+// githubEventYamlRef returns the ref the workflow yaml is loaded at. The yaml of pull requests is loaded from
+// the base branch, since the head may come from a fork and anyone opening one could define the jobs.
+func githubEventYamlRef(event interface{}) string {
+	switch ev := event.(type) {
+	case *github.PushEvent:
+		return ev.GetAfter()
+	case *github.PullRequestEvent:
+		return ev.GetPullRequest().GetBase().GetRef()
+	case *github.CreateEvent:
+		return ev.GetRef()
+	}
+	return ""
+}
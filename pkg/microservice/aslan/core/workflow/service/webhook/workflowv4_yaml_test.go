@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package webhook
+
+import (
+	"github.com/google/go-github/v35/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func yamlHook(syncYaml bool, yamlPath string) *commonmodels.WorkflowV4Hook {
+	return &commonmodels.WorkflowV4Hook{
+		MainRepo: &commonmodels.MainHookRepo{RepoOwner: "koderover", RepoName: "zadig"},
+		SyncYaml: syncYaml,
+		YamlPath: yamlPath,
+	}
+}
+
+func githubPush(fullName, ref, defaultBranch string) *github.PushEvent {
+	return &github.PushEvent{
+		Ref: github.String(ref),
+		Repo: &github.PushEventRepository{
+			FullName:      github.String(fullName),
+			DefaultBranch: github.String(defaultBranch),
+		},
+	}
+}
+
+var _ = Describe("workflow v4 yaml", func() {
+	DescribeTable("syncs the yaml from the push",
+		func(ev *github.PushEvent, hook *commonmodels.WorkflowV4Hook, expected bool) {
+			Expect(githubPushSyncsYaml(ev, hook)).To(Equal(expected))
+		},
+		Entry("default branch of the main repo", githubPush("koderover/zadig", "refs/heads/main", "main"), yamlHook(true, ""), true),
+		Entry("sync is disabled", githubPush("koderover/zadig", "refs/heads/main", "main"), yamlHook(false, ""), false),
+		Entry("other branch", githubPush("koderover/zadig", "refs/heads/dev", "main"), yamlHook(true, ""), false),
+		Entry("other repo", githubPush("someone/zadig", "refs/heads/main", "main"), yamlHook(true, ""), false),
+		Entry("hook without main repo", githubPush("koderover/zadig", "refs/heads/main", "main"), &commonmodels.WorkflowV4Hook{SyncYaml: true}, false),
+	)
+
+	DescribeTable("finds the yaml in the changed files",
+		func(hook *commonmodels.WorkflowV4Hook, files []string, expected bool) {
+			Expect(workflowV4YamlChanged("deploy", hook, files)).To(Equal(expected))
+		},
+		Entry("default path", yamlHook(true, ""), []string{"main.go", ".zadig/workflows/deploy.yaml"}, true),
+		Entry("yaml of other workflow", yamlHook(true, ""), []string{".zadig/workflows/build.yaml"}, false),
+		Entry("custom path", yamlHook(true, "ci/deploy.yaml"), []string{"ci/deploy.yaml"}, true),
+		Entry("default path with custom path", yamlHook(true, "ci/deploy.yaml"), []string{".zadig/workflows/deploy.yaml"}, false),
+		Entry("no changed files", yamlHook(true, ""), nil, false),
+	)
+
+	It("collects the added and modified files of the commits", func() {
+		ev := &github.PushEvent{Commits: []*github.HeadCommit{
+			{Added: []string{"a.go"}, Modified: []string{"b.go"}, Removed: []string{"c.go"}},
+			{Modified: []string{".zadig/workflows/deploy.yaml"}},
+		}}
+		Expect(githubPushedFiles(ev)).To(Equal([]string{"a.go", "b.go", ".zadig/workflows/deploy.yaml"}))
+	})
+})
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// CreateApprovalDelegation lets the delegate approve on behalf of the current user in the time range.
+func CreateApprovalDelegation(userName, userID string, args *commonmodels.ApprovalDelegation, logger *zap.SugaredLogger) error {
+	args.DelegatorID = userID
+	args.DelegatorName = userName
+	args.CreatedBy = userName
+	if args.DelegateID == "" || args.DelegateID == userID {
+		return e.ErrCreateApprovalDelegation.AddDesc("invalid delegate")
+	}
+	if args.StartTime == 0 {
+		args.StartTime = time.Now().Unix()
+	}
+	if args.EndTime <= args.StartTime || args.EndTime <= time.Now().Unix() {
+		return e.ErrCreateApprovalDelegation.AddDesc("end time should be later than start time and now")
+	}
+	if err := commonrepo.NewApprovalDelegationColl().Create(args); err != nil {
+		logger.Errorf("create approval delegation error: %v", err)
+		return e.ErrCreateApprovalDelegation.AddErr(err)
+	}
+	return nil
+}
+
+// ListApprovalDelegations lists the delegations given or received by the user.
+func ListApprovalDelegations(userID string, logger *zap.SugaredLogger) ([]*commonmodels.ApprovalDelegation, error) {
+	given, err := commonrepo.NewApprovalDelegationColl().List(&commonrepo.ListApprovalDelegationOption{DelegatorIDs: []string{userID}})
+	if err != nil {
+		logger.Errorf("list approval delegations of %s error: %v", userID, err)
+		return nil, e.ErrListApprovalDelegations.AddErr(err)
+	}
+	received, err := commonrepo.NewApprovalDelegationColl().List(&commonrepo.ListApprovalDelegationOption{DelegateID: userID})
+	if err != nil {
+		logger.Errorf("list approval delegations to %s error: %v", userID, err)
+		return nil, e.ErrListApprovalDelegations.AddErr(err)
+	}
+	return append(given, received...), nil
+}
+
+func DeleteApprovalDelegation(id, userID string, logger *zap.SugaredLogger) error {
+	delegation, err := commonrepo.NewApprovalDelegationColl().Find(id)
+	if err != nil {
+		logger.Errorf("find approval delegation %s error: %v", id, err)
+		return e.ErrDeleteApprovalDelegation.AddErr(err)
+	}
+	if delegation.DelegatorID != userID {
+		return e.ErrDeleteApprovalDelegation.AddErr(fmt.Errorf("only the delegator can delete the delegation"))
+	}
+	if err := commonrepo.NewApprovalDelegationColl().Delete(id); err != nil {
+		logger.Errorf("delete approval delegation %s error: %v", id, err)
+		return e.ErrDeleteApprovalDelegation.AddErr(err)
+	}
+	return nil
+}
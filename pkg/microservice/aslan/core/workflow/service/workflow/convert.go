@@ -47,6 +47,7 @@ func ConvertQueueToTask(queueTask *commonmodels.Queue) *task.Task {
 		SubTasks:                queueTask.SubTasks,
 		Stages:                  queueTask.Stages,
 		ReqID:                   queueTask.ReqID,
+		TraceContext:            queueTask.TraceContext,
 		AgentHost:               queueTask.AgentHost,
 		DockerHost:              queueTask.DockerHost,
 		TeamName:                queueTask.TeamName,
@@ -94,6 +95,7 @@ func ConvertTaskToQueue(task *task.Task) *commonmodels.Queue {
 		SubTasks:                task.SubTasks,
 		Stages:                  task.Stages,
 		ReqID:                   task.ReqID,
+		TraceContext:            task.TraceContext,
 		AgentHost:               task.AgentHost,
 		DockerHost:              task.DockerHost,
 		TeamName:                task.TeamName,
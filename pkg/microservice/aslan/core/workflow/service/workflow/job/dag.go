@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"strings"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// DAGEnabled reports whether the jobs in the workflow run as a DAG, which is true once any job declares depends_on.
+func DAGEnabled(workflow *commonmodels.WorkflowV4) bool {
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if len(job.DependsOn) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// JobUpstreams returns the names of the jobs every job depends on.
+// A job without depends_on depends on all the jobs in the previous stage, just like running stage by stage.
+func JobUpstreams(workflow *commonmodels.WorkflowV4) map[string][]string {
+	resp := make(map[string][]string)
+	var lastStageJobs []string
+	for _, stage := range workflow.Stages {
+		stageJobs := []string{}
+		for _, job := range stage.Jobs {
+			stageJobs = append(stageJobs, job.Name)
+			if len(job.DependsOn) > 0 {
+				resp[job.Name] = job.DependsOn
+			} else {
+				resp[job.Name] = lastStageJobs
+			}
+		}
+		lastStageJobs = stageJobs
+	}
+	return resp
+}
+
+// CheckJobDependencies makes sure every depends_on quotes an existing job and there is no circular dependency.
+func CheckJobDependencies(workflow *commonmodels.WorkflowV4) error {
+	if !DAGEnabled(workflow) {
+		return nil
+	}
+	upstreams := JobUpstreams(workflow)
+	for jobName, deps := range upstreams {
+		for _, dep := range deps {
+			if dep == jobName {
+				return fmt.Errorf("job %s can not depend on itself", jobName)
+			}
+			if _, ok := upstreams[dep]; !ok {
+				return fmt.Errorf("job %s depends on job %s which does not exist", jobName, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(jobName string) error
+	visit = func(jobName string) error {
+		switch state[jobName] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependency found in jobs: %s -> %s", strings.Join(path, " -> "), jobName)
+		}
+		state[jobName] = visiting
+		path = append(path, jobName)
+		for _, dep := range upstreams[jobName] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[jobName] = visited
+		return nil
+	}
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if err := visit(job.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func newDAGWorkflow(stages ...[]*commonmodels.Job) *commonmodels.WorkflowV4 {
+	workflow := &commonmodels.WorkflowV4{}
+	for _, jobs := range stages {
+		workflow.Stages = append(workflow.Stages, &commonmodels.WorkflowStage{Jobs: jobs})
+	}
+	return workflow
+}
+
+var _ = Describe("Testing job dag", func() {
+
+	Context("JobUpstreams", func() {
+		It("should depend on the previous stage without depends_on", func() {
+			workflow := newDAGWorkflow(
+				[]*commonmodels.Job{{Name: "build-a"}, {Name: "build-b"}},
+				[]*commonmodels.Job{{Name: "deploy-a", DependsOn: []string{"build-a"}}, {Name: "deploy-all"}},
+			)
+			upstreams := JobUpstreams(workflow)
+			Expect(upstreams["build-a"]).To(BeEmpty())
+			Expect(upstreams["deploy-a"]).To(Equal([]string{"build-a"}))
+			Expect(upstreams["deploy-all"]).To(Equal([]string{"build-a", "build-b"}))
+		})
+	})
+
+	Context("CheckJobDependencies", func() {
+		It("should be passed for workflow without depends_on", func() {
+			workflow := newDAGWorkflow([]*commonmodels.Job{{Name: "a"}}, []*commonmodels.Job{{Name: "b"}})
+			Expect(CheckJobDependencies(workflow)).ShouldNot(HaveOccurred())
+		})
+		It("should be passed for valid dependencies", func() {
+			workflow := newDAGWorkflow(
+				[]*commonmodels.Job{{Name: "a"}, {Name: "b", DependsOn: []string{"a"}}},
+				[]*commonmodels.Job{{Name: "c", DependsOn: []string{"a"}}},
+			)
+			Expect(CheckJobDependencies(workflow)).ShouldNot(HaveOccurred())
+		})
+		It("should raise error for unknown job", func() {
+			workflow := newDAGWorkflow([]*commonmodels.Job{{Name: "a", DependsOn: []string{"x"}}})
+			Expect(CheckJobDependencies(workflow)).Should(HaveOccurred())
+		})
+		It("should raise error for depending on itself", func() {
+			workflow := newDAGWorkflow([]*commonmodels.Job{{Name: "a", DependsOn: []string{"a"}}})
+			Expect(CheckJobDependencies(workflow)).Should(HaveOccurred())
+		})
+		It("should raise error for cycles", func() {
+			workflow := newDAGWorkflow([]*commonmodels.Job{
+				{Name: "a", DependsOn: []string{"c"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"b"}},
+			})
+			Expect(CheckJobDependencies(workflow)).Should(HaveOccurred())
+		})
+		It("should raise error for cycles through the previous stage", func() {
+			workflow := newDAGWorkflow(
+				[]*commonmodels.Job{{Name: "a", DependsOn: []string{"b"}}},
+				[]*commonmodels.Job{{Name: "b"}},
+			)
+			Expect(CheckJobDependencies(workflow)).Should(HaveOccurred())
+		})
+	})
+})
@@ -42,6 +42,10 @@ func InitJobCtl(job *commonmodels.Job, workflow *commonmodels.WorkflowV4) (JobCt
 	switch job.JobType {
 	case config.JobZadigBuild:
 		resp = &BuildJob{job: job, workflow: workflow}
+	case config.JobZadigTest:
+		resp = &TestingJob{job: job, workflow: workflow}
+	case config.JobZadigScanning:
+		resp = &ScanningJob{job: job, workflow: workflow}
 	case config.JobZadigDeploy:
 		resp = &DeployJob{job: job, workflow: workflow}
 	case config.JobPlugin:
@@ -50,6 +54,10 @@ func InitJobCtl(job *commonmodels.Job, workflow *commonmodels.WorkflowV4) (JobCt
 		resp = &FreeStyleJob{job: job, workflow: workflow}
 	case config.JobCustomDeploy:
 		resp = &CustomDeployJob{job: job, workflow: workflow}
+	case config.JobCanaryDeploy:
+		resp = &CanaryDeployJob{job: job, workflow: workflow}
+	case config.JobTriggerWorkflow:
+		resp = &TriggerWorkflowJob{job: job, workflow: workflow}
 	default:
 		return resp, fmt.Errorf("job type not found %s", job.JobType)
 	}
@@ -77,7 +85,15 @@ func ToJobs(job *commonmodels.Job, workflow *commonmodels.WorkflowV4, taskID int
 	if err != nil {
 		return []*commonmodels.JobTask{}, err
 	}
-	return jobCtl.ToJobs(taskID)
+	jobs, err := jobCtl.ToJobs(taskID)
+	if err != nil {
+		return jobs, err
+	}
+	for _, jobTask := range jobs {
+		jobTask.OriginName = job.Name
+		jobTask.Condition = job.Condition
+	}
+	return jobs, nil
 }
 
 func MergeWebhookRepo(workflow *commonmodels.WorkflowV4, repo *types.Repository) error {
@@ -89,6 +105,12 @@ func MergeWebhookRepo(workflow *commonmodels.WorkflowV4, repo *types.Repository)
 					return err
 				}
 			}
+			if job.JobType == config.JobZadigTest {
+				jobCtl := &TestingJob{job: job, workflow: workflow}
+				if err := jobCtl.MergeWebhookRepo(repo); err != nil {
+					return err
+				}
+			}
 			if job.JobType == config.JobFreestyle {
 				jobCtl := &FreeStyleJob{job: job, workflow: workflow}
 				if err := jobCtl.MergeWebhookRepo(repo); err != nil {
@@ -112,6 +134,14 @@ func GetRepos(workflow *commonmodels.WorkflowV4) ([]*types.Repository, error) {
 				}
 				resp = append(resp, buildRepos...)
 			}
+			if job.JobType == config.JobZadigTest {
+				jobCtl := &TestingJob{job: job, workflow: workflow}
+				testingRepos, err := jobCtl.GetRepos()
+				if err != nil {
+					return resp, err
+				}
+				resp = append(resp, testingRepos...)
+			}
 			if job.JobType == config.JobFreestyle {
 				jobCtl := &FreeStyleJob{job: job, workflow: workflow}
 				freeStyleRepos, err := jobCtl.GetRepos()
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+type CanaryDeployJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.CanaryDeployJobSpec
+}
+
+func (j *CanaryDeployJob) Instantiate() error {
+	j.spec = &commonmodels.CanaryDeployJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *CanaryDeployJob) SetPreset() error {
+	j.spec = &commonmodels.CanaryDeployJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *CanaryDeployJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.CanaryDeployJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+		j.job.Spec = j.spec
+		argsSpec := &commonmodels.CanaryDeployJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+		j.spec.Targets = argsSpec.Targets
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *CanaryDeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+
+	j.spec = &commonmodels.CanaryDeployJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	for _, target := range j.spec.Targets {
+		t := strings.Split(target.Target, "/")
+		if len(t) != 2 {
+			log.Errorf("target string: %s wrong format", target.Target)
+			continue
+		}
+		workloadName := t[0]
+		containerName := t[1]
+		jobTaskSpec := &commonmodels.JobTaskCanaryDeploySpec{
+			Namespace:      j.spec.Namespace,
+			ClusterID:      j.spec.ClusterID,
+			Timeout:        j.spec.Timeout,
+			WorkloadName:   workloadName,
+			ContainerName:  containerName,
+			K8sServiceName: target.K8sServiceName,
+			Image:          target.Image,
+			Strategy:       j.spec.Strategy,
+			TrafficRouter:  j.spec.TrafficRouter,
+			Steps:          j.spec.Steps,
+			HealthCheck:    j.spec.HealthCheck,
+		}
+		jobTask := &commonmodels.JobTask{
+			Name:    jobNameFormat(j.job.Name + "-" + workloadName + "-" + containerName),
+			JobType: string(config.JobCanaryDeploy),
+			Spec:    jobTaskSpec,
+		}
+		resp = append(resp, jobTask)
+	}
+	j.job.Spec = j.spec
+	return resp, nil
+}
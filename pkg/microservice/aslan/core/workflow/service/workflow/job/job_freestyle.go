@@ -18,6 +18,9 @@ package job
 
 import (
 	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
 
 	configbase "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
@@ -66,6 +69,30 @@ func (j *FreeStyleJob) Instantiate() error {
 				return err
 			}
 			step.Spec = stepSpec
+		case config.StepImageDistribute:
+			stepSpec := &steptypes.StepImageDistributeSpec{}
+			if err := commonmodels.IToiYaml(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			step.Spec = stepSpec
+		case config.StepArchiveDistribute:
+			stepSpec := &steptypes.StepArchiveDistributeSpec{}
+			if err := commonmodels.IToiYaml(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			step.Spec = stepSpec
+		case config.StepJunitReport:
+			stepSpec := &steptypes.StepJunitReportSpec{}
+			if err := commonmodels.IToiYaml(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			step.Spec = stepSpec
+		case config.StepHtmlReport:
+			stepSpec := &steptypes.StepHtmlReportSpec{}
+			if err := commonmodels.IToiYaml(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			step.Spec = stepSpec
 		default:
 			return fmt.Errorf("freestyle job step type %s not supported", step.StepType)
 		}
@@ -176,12 +203,25 @@ func (j *FreeStyleJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 		Properties: *j.spec.Properties,
 		Steps:      stepsToStepTasks(j.spec.Steps),
 	}
+	if err := setReportDest(jobTaskSpec.Steps, j.workflow.Name, j.job.Name, taskID); err != nil {
+		return resp, err
+	}
+	distributeOutputs, err := setDistributeOutputs(jobTaskSpec.Steps)
+	if err != nil {
+		return resp, err
+	}
 	jobTask := &commonmodels.JobTask{
-		Name:    j.job.Name,
-		JobType: string(config.JobFreestyle),
-		Spec:    jobTaskSpec,
-		Timeout: j.spec.Properties.Timeout,
+		Name:         j.job.Name,
+		JobType:      string(config.JobFreestyle),
+		Spec:         jobTaskSpec,
+		Timeout:      j.spec.Properties.Timeout,
+		Retry:        j.spec.Properties.Retry,
+		RetryBackoff: j.spec.Properties.RetryBackoff,
+	}
+	for _, output := range j.spec.Outputs {
+		jobTask.Outputs = append(jobTask.Outputs, &commonmodels.Output{Name: output.Name, Description: output.Description})
 	}
+	jobTask.Outputs = append(jobTask.Outputs, distributeOutputs...)
 	registries, err := commonservice.ListRegistryNamespaces("", true, logger)
 	if err != nil {
 		return resp, err
@@ -203,9 +243,11 @@ func stepsToStepTasks(step []*commonmodels.Step) []*commonmodels.StepTask {
 	resp := []*commonmodels.StepTask{}
 	for _, step := range step {
 		stepTask := &commonmodels.StepTask{
-			Name:     step.Name,
-			StepType: step.StepType,
-			Spec:     step.Spec,
+			Name:             step.Name,
+			StepType:         step.StepType,
+			Spec:             step.Spec,
+			BreakpointBefore: step.BreakpointBefore,
+			BreakpointAfter:  step.BreakpointAfter,
 		}
 		if stepTask.StepType == config.StepDockerBuild {
 			stepTaskSpec := &steptypes.StepDockerBuildSpec{}
@@ -229,11 +271,98 @@ func stepsToStepTasks(step []*commonmodels.Step) []*commonmodels.StepTask {
 			}
 			stepTask.Spec = stepTaskSpec
 		}
+		if stepTask.StepType == config.StepImageDistribute {
+			stepTaskSpec := &steptypes.StepImageDistributeSpec{}
+			if err := commonmodels.IToi(stepTask.Spec, stepTaskSpec); err != nil {
+				continue
+			}
+			stepTaskSpec.SourceRegistry = getDockerRegistry(stepTaskSpec.SourceRegistry, logger)
+			for _, target := range stepTaskSpec.Targets {
+				target.DockerRegistry = getDockerRegistry(target.DockerRegistry, logger)
+			}
+			stepTask.Spec = stepTaskSpec
+		}
 		resp = append(resp, stepTask)
 	}
 	return resp
 }
 
+// getDockerRegistry returns the registry with the credentials by the id, nil if the registry is not set.
+func getDockerRegistry(registry *steptypes.DockerRegistry, logger *zap.SugaredLogger) *steptypes.DockerRegistry {
+	if registry == nil || registry.DockerRegistryID == "" {
+		return registry
+	}
+	reg, _, err := commonservice.FindRegistryById(registry.DockerRegistryID, true, logger)
+	if err != nil {
+		logger.Errorf("FindRegistryById error: %v", err)
+		return registry
+	}
+	return &steptypes.DockerRegistry{
+		DockerRegistryID: registry.DockerRegistryID,
+		Host:             reg.RegAddr,
+		UserName:         reg.AccessKey,
+		Password:         reg.SecretKey,
+		Namespace:        reg.Namespace,
+	}
+}
+
+// setDistributeOutputs sets the names of the outputs the distribute steps record the results to,
+// like <step>_digest and <step>_images, and returns them as the outputs of the job.
+func setDistributeOutputs(steps []*commonmodels.StepTask) ([]*commonmodels.Output, error) {
+	resp := []*commonmodels.Output{}
+	for _, step := range steps {
+		switch step.StepType {
+		case config.StepImageDistribute:
+			stepSpec := &steptypes.StepImageDistributeSpec{}
+			if err := commonmodels.IToi(step.Spec, stepSpec); err != nil {
+				return resp, err
+			}
+			stepSpec.DigestOutput = step.Name + "_digest"
+			stepSpec.ImagesOutput = step.Name + "_images"
+			step.Spec = stepSpec
+			resp = append(resp,
+				&commonmodels.Output{Name: stepSpec.DigestOutput, Description: "digest of the distributed image"},
+				&commonmodels.Output{Name: stepSpec.ImagesOutput, Description: "distributed images separated by comma"},
+			)
+		case config.StepArchiveDistribute:
+			stepSpec := &steptypes.StepArchiveDistributeSpec{}
+			if err := commonmodels.IToi(step.Spec, stepSpec); err != nil {
+				return resp, err
+			}
+			stepSpec.URLsOutput = step.Name + "_urls"
+			step.Spec = stepSpec
+			resp = append(resp, &commonmodels.Output{Name: stepSpec.URLsOutput, Description: "urls of the distributed archives separated by comma"})
+		}
+	}
+	return resp, nil
+}
+
+// setReportDest sets where the test reports are uploaded to, the same place as the test reports of the old pipelines.
+func setReportDest(steps []*commonmodels.StepTask, workflowName, jobName string, taskID int64) error {
+	destDir := fmt.Sprintf("%s/%d/test", workflowName, taskID)
+	for _, step := range steps {
+		switch step.StepType {
+		case config.StepJunitReport:
+			stepSpec := &steptypes.StepJunitReportSpec{}
+			if err := commonmodels.IToi(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			stepSpec.S3DestDir = destDir
+			stepSpec.FileName = fmt.Sprintf("%s-junit.xml", strings.ToLower(jobName))
+			step.Spec = stepSpec
+		case config.StepHtmlReport:
+			stepSpec := &steptypes.StepHtmlReportSpec{}
+			if err := commonmodels.IToi(step.Spec, stepSpec); err != nil {
+				return err
+			}
+			stepSpec.S3DestDir = destDir
+			stepSpec.FileName = fmt.Sprintf("%s-html", strings.ToLower(jobName))
+			step.Spec = stepSpec
+		}
+	}
+	return nil
+}
+
 func getfreestyleJobVariables(steps []*commonmodels.StepTask, taskID int64, project, workflowName string) []*commonmodels.KeyVal {
 	ret := []*commonmodels.KeyVal{}
 	repos := []*types.Repository{}
@@ -80,10 +80,12 @@ func (j *PluginJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 		Plugin:     j.spec.Plugin,
 	}
 	jobTask := &commonmodels.JobTask{
-		Name:    j.job.Name,
-		JobType: string(config.JobPlugin),
-		Spec:    jobTaskSpec,
-		Outputs: j.spec.Plugin.Outputs,
+		Name:         j.job.Name,
+		JobType:      string(config.JobPlugin),
+		Spec:         jobTaskSpec,
+		Outputs:      j.spec.Plugin.Outputs,
+		Retry:        j.spec.Properties.Retry,
+		RetryBackoff: j.spec.Properties.RetryBackoff,
 	}
 	registries, err := commonservice.ListRegistryNamespaces("", true, logger)
 	if err != nil {
@@ -0,0 +1,250 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+	jobtypes "github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+const defaultScanningTimeout = 3600
+
+type ScanningJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.ZadigScanningJobSpec
+}
+
+func (j *ScanningJob) Instantiate() error {
+	j.spec = &commonmodels.ZadigScanningJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *ScanningJob) SetPreset() error {
+	j.spec = &commonmodels.ZadigScanningJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+
+	for _, scanning := range j.spec.Scannings {
+		scanningInfo, err := commonrepo.NewScanningColl().Find(scanning.ProjectName, scanning.Name)
+		if err != nil {
+			log.Errorf("find scanning: %s error: %v", scanning.Name, err)
+			continue
+		}
+		scanning.Repos = mergeRepos(scanningInfo.Repos, scanning.Repos)
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *ScanningJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.ZadigScanningJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+		j.job.Spec = j.spec
+		argsSpec := &commonmodels.ZadigScanningJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+		for _, scanning := range j.spec.Scannings {
+			for _, argsScanning := range argsSpec.Scannings {
+				if scanning.Name == argsScanning.Name && scanning.ProjectName == argsScanning.ProjectName {
+					scanning.Repos = mergeRepos(scanning.Repos, argsScanning.Repos)
+					break
+				}
+			}
+		}
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *ScanningJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	logger := log.SugaredLogger()
+	resp := []*commonmodels.JobTask{}
+
+	j.spec = &commonmodels.ZadigScanningJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	j.job.Spec = j.spec
+
+	buildRepos, err := j.getBuildRepos()
+	if err != nil {
+		return resp, err
+	}
+	registries, err := commonservice.ListRegistryNamespaces("", true, logger)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, scanning := range j.spec.Scannings {
+		scanningInfo, err := commonrepo.NewScanningColl().Find(scanning.ProjectName, scanning.Name)
+		if err != nil {
+			return resp, fmt.Errorf("find scanning: %s error: %v", scanning.Name, err)
+		}
+		if scanningInfo.AdvancedSetting == nil {
+			scanningInfo.AdvancedSetting = &types.ScanningAdvancedSetting{}
+		}
+		basicImage, err := commonrepo.NewBasicImageColl().Find(scanningInfo.ImageID)
+		if err != nil {
+			return resp, err
+		}
+		repos := renderRepos(scanning.Repos, scanningInfo.Repos)
+		// scan the same code as the build job.
+		repos = mergeRepos(repos, buildRepos)
+
+		timeout := scanningInfo.AdvancedSetting.Timeout
+		if timeout <= 0 {
+			timeout = defaultScanningTimeout
+		}
+		jobTaskSpec := &commonmodels.JobTaskScanningSpec{ScanningName: scanningInfo.Name}
+		jobTask := &commonmodels.JobTask{
+			Name:    jobNameFormat(scanning.Name + "-" + j.job.Name),
+			JobType: string(config.JobZadigScanning),
+			Spec:    jobTaskSpec,
+			Timeout: timeout,
+		}
+		jobTaskSpec.Properties = commonmodels.JobProperties{
+			Timeout:         timeout,
+			ResourceRequest: scanningInfo.AdvancedSetting.ResReq,
+			ResReqSpec:      scanningInfo.AdvancedSetting.ResReqSpec,
+			ClusterID:       scanningInfo.AdvancedSetting.ClusterID,
+			BuildOS:         basicImage.Value,
+			ImageFrom:       basicImage.ImageFrom,
+			Registries:      registries,
+		}
+		jobTaskSpec.Properties.Envs = getScanningJobVariables(repos, taskID, j.workflow.Project, j.workflow.Name)
+
+		// init git clone step
+		gitStep := &commonmodels.StepTask{
+			Name:     scanning.Name + "-git",
+			JobName:  jobTask.Name,
+			StepType: config.StepGit,
+			Spec:     step.StepGitSpec{Repos: repos},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, gitStep)
+
+		// init shell step
+		scripts := strings.Split(replaceWrapLine(scanningInfo.Script), "\n")
+		if scanningInfo.ScannerType == types.ScanningTypeSonar {
+			sonarInfo, err := commonrepo.NewSonarIntegrationColl().GetByID(context.TODO(), scanningInfo.SonarID)
+			if err != nil {
+				return resp, fmt.Errorf("failed to get sonar integration of scanning: %s, error: %v", scanning.Name, err)
+			}
+			jobTaskSpec.SonarID = scanningInfo.SonarID
+			jobTaskSpec.Properties.Envs = append(jobTaskSpec.Properties.Envs,
+				&commonmodels.KeyVal{Key: "SONAR_URL", Value: sonarInfo.ServerAddress, IsCredential: false},
+				&commonmodels.KeyVal{Key: "SONAR_TOKEN", Value: sonarInfo.Token, IsCredential: true},
+			)
+			scripts = sonarScannerScripts(scanningInfo.Parameter, repos)
+			jobTask.Outputs = append(jobTask.Outputs,
+				&commonmodels.Output{Name: jobcontroller.SonarCETaskIDOutput, Description: "id of the sonar analysis"},
+				&commonmodels.Output{Name: jobcontroller.SonarQualityGateOutput, Description: "status of the sonar quality gate"},
+			)
+		}
+		shellStep := &commonmodels.StepTask{
+			Name:     scanning.Name + "-shell",
+			JobName:  jobTask.Name,
+			StepType: config.StepShell,
+			Spec: &step.StepShellSpec{
+				Scripts: scripts,
+			},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, shellStep)
+		resp = append(resp, jobTask)
+	}
+	j.job.Spec = j.spec
+	return resp, nil
+}
+
+// getBuildRepos returns the repos of the zadig-build job the scanning job refers to.
+func (j *ScanningJob) getBuildRepos() ([]*types.Repository, error) {
+	resp := []*types.Repository{}
+	if j.spec.JobName == "" {
+		return resp, nil
+	}
+	for _, stage := range j.workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name != j.spec.JobName || job.JobType != config.JobZadigBuild {
+				continue
+			}
+			buildSpec := &commonmodels.ZadigBuildJobSpec{}
+			if err := commonmodels.IToi(job.Spec, buildSpec); err != nil {
+				return resp, err
+			}
+			for _, build := range buildSpec.ServiceAndBuilds {
+				resp = append(resp, build.Repos...)
+			}
+			return resp, nil
+		}
+	}
+	return resp, fmt.Errorf("build job %s not found", j.spec.JobName)
+}
+
+// sonarScannerScripts runs sonar-scanner in the first repo like the old scanning tasks,
+// and saves the id of the analysis so the quality gate can be checked once the job finished.
+func sonarScannerScripts(parameter string, repos []*types.Repository) []string {
+	if len(repos) == 0 {
+		return []string{"echo 'no repository to scan' && exit 1"}
+	}
+	repo := repos[0]
+	workDir := repo.RepoName
+	if repo.CheckoutPath != "" {
+		workDir = repo.CheckoutPath
+	}
+	// renders the scanned repository branch information to the user configuration
+	parameter = strings.ReplaceAll(parameter, "$BRANCH", repo.Branch)
+	return []string{
+		"set -e",
+		fmt.Sprintf("cd %s", workDir),
+		fmt.Sprintf("cat > sonar-project.properties <<'EOF'\n%s\nEOF", parameter),
+		`sonar-scanner -Dsonar.login="$SONAR_TOKEN" -Dsonar.host.url="$SONAR_URL"`,
+		fmt.Sprintf(`sed -n 's/^ceTaskId=//p' .scannerwork/report-task.txt > %s`, jobtypes.JobOutputDir+jobcontroller.SonarCETaskIDOutput),
+	}
+}
+
+func getScanningJobVariables(repos []*types.Repository, taskID int64, project, workflowName string) []*commonmodels.KeyVal {
+	ret := make([]*commonmodels.KeyVal, 0)
+	ret = append(ret, getReposVariables(repos)...)
+
+	ret = append(ret, &commonmodels.KeyVal{Key: "TASK_ID", Value: fmt.Sprintf("%d", taskID), IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "PROJECT", Value: project, IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "WORKFLOW", Value: workflowName, IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "CI", Value: "true", IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "ZADIG", Value: "true", IsCredential: false})
+	return ret
+}
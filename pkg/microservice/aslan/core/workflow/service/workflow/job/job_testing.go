@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type TestingJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.ZadigTestJobSpec
+}
+
+func (j *TestingJob) Instantiate() error {
+	j.spec = &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TestingJob) SetPreset() error {
+	j.spec = &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+
+	for _, testing := range j.spec.TestModules {
+		testingInfo, err := commonrepo.NewTestingColl().Find(testing.Name, testing.ProjectName)
+		if err != nil {
+			log.Errorf("find testing: %s error: %v", testing.Name, err)
+			continue
+		}
+		testing.Repos = mergeRepos(testingInfo.Repos, testing.Repos)
+		if testingInfo.PreTest != nil {
+			testing.KeyVals = renderKeyVals(testing.KeyVals, testingInfo.PreTest.Envs)
+		}
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TestingJob) GetRepos() ([]*types.Repository, error) {
+	resp := []*types.Repository{}
+	j.spec = &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+
+	for _, testing := range j.spec.TestModules {
+		testingInfo, err := commonrepo.NewTestingColl().Find(testing.Name, testing.ProjectName)
+		if err != nil {
+			log.Errorf("find testing: %s error: %v", testing.Name, err)
+			continue
+		}
+		resp = append(resp, mergeRepos(testingInfo.Repos, testing.Repos)...)
+	}
+	return resp, nil
+}
+
+func (j *TestingJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.ZadigTestJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+		j.job.Spec = j.spec
+		argsSpec := &commonmodels.ZadigTestJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+		for _, testing := range j.spec.TestModules {
+			for _, argsTesting := range argsSpec.TestModules {
+				if testing.Name == argsTesting.Name && testing.ProjectName == argsTesting.ProjectName {
+					testing.Repos = mergeRepos(testing.Repos, argsTesting.Repos)
+					testing.KeyVals = renderKeyVals(argsTesting.KeyVals, testing.KeyVals)
+					break
+				}
+			}
+		}
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *TestingJob) MergeWebhookRepo(webhookRepo *types.Repository) error {
+	j.spec = &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	for _, testing := range j.spec.TestModules {
+		testing.Repos = mergeRepos(testing.Repos, []*types.Repository{webhookRepo})
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TestingJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	logger := log.SugaredLogger()
+	resp := []*commonmodels.JobTask{}
+
+	j.spec = &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	j.job.Spec = j.spec
+
+	registries, err := commonservice.ListRegistryNamespaces("", true, logger)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, testing := range j.spec.TestModules {
+		testingInfo, err := commonrepo.NewTestingColl().Find(testing.Name, testing.ProjectName)
+		if err != nil {
+			return resp, fmt.Errorf("find testing: %s error: %v", testing.Name, err)
+		}
+		if testingInfo.PreTest == nil {
+			testingInfo.PreTest = &commonmodels.PreTest{}
+		}
+		basicImage, err := commonrepo.NewBasicImageColl().Find(testingInfo.PreTest.ImageID)
+		if err != nil {
+			return resp, err
+		}
+		jobTaskSpec := &commonmodels.JobTaskTestingSpec{
+			TestName:  testingInfo.Name,
+			Threshold: testingInfo.Threshold,
+		}
+		jobTask := &commonmodels.JobTask{
+			Name:    jobNameFormat(testing.Name + "-" + j.job.Name),
+			JobType: string(config.JobZadigTest),
+			Spec:    jobTaskSpec,
+			Timeout: int64(testingInfo.Timeout),
+		}
+		jobTaskSpec.Properties = commonmodels.JobProperties{
+			Timeout:         int64(testingInfo.Timeout),
+			ResourceRequest: testingInfo.PreTest.ResReq,
+			ResReqSpec:      testingInfo.PreTest.ResReqSpec,
+			CustomEnvs:      renderKeyVals(testing.KeyVals, testingInfo.PreTest.Envs),
+			ClusterID:       testingInfo.PreTest.ClusterID,
+			BuildOS:         basicImage.Value,
+			ImageFrom:       testingInfo.PreTest.ImageFrom,
+			Registries:      registries,
+		}
+		clusterInfo, err := commonrepo.NewK8SClusterColl().Get(testingInfo.PreTest.ClusterID)
+		if err != nil {
+			return resp, err
+		}
+
+		if clusterInfo.Cache.MediumType == "" {
+			jobTaskSpec.Properties.CacheEnable = false
+		} else {
+			jobTaskSpec.Properties.Cache = clusterInfo.Cache
+			jobTaskSpec.Properties.CacheEnable = testingInfo.CacheEnable
+			jobTaskSpec.Properties.CacheDirType = testingInfo.CacheDirType
+			jobTaskSpec.Properties.CacheUserDir = testingInfo.CacheUserDir
+		}
+		repos := renderRepos(testing.Repos, testingInfo.Repos)
+		jobTaskSpec.Properties.Envs = append(jobTaskSpec.Properties.CustomEnvs, getTestingJobVariables(repos, taskID, j.workflow.Project, j.workflow.Name, testing.Name)...)
+
+		if jobTaskSpec.Properties.CacheEnable && jobTaskSpec.Properties.Cache.MediumType == types.NFSMedium {
+			jobTaskSpec.Properties.CacheUserDir = renderEnv(jobTaskSpec.Properties.CacheUserDir, jobTaskSpec.Properties.Envs)
+			jobTaskSpec.Properties.Cache.NFSProperties.Subpath = renderEnv(jobTaskSpec.Properties.Cache.NFSProperties.Subpath, jobTaskSpec.Properties.Envs)
+		}
+
+		// init tools install step
+		tools := []*step.Tool{}
+		for _, tool := range testingInfo.PreTest.Installs {
+			tools = append(tools, &step.Tool{
+				Name:    tool.Name,
+				Version: tool.Version,
+			})
+		}
+		toolInstallStep := &commonmodels.StepTask{
+			Name:     fmt.Sprintf("%s-%s", testing.Name, "tool-install"),
+			JobName:  jobTask.Name,
+			StepType: config.StepTools,
+			Spec:     step.StepToolInstallSpec{Installs: tools},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, toolInstallStep)
+		// init git clone step
+		gitStep := &commonmodels.StepTask{
+			Name:     testing.Name + "-git",
+			JobName:  jobTask.Name,
+			StepType: config.StepGit,
+			Spec:     step.StepGitSpec{Repos: repos},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, gitStep)
+		// init shell step
+		shellStep := &commonmodels.StepTask{
+			Name:     testing.Name + "-shell",
+			JobName:  jobTask.Name,
+			StepType: config.StepShell,
+			Spec: &step.StepShellSpec{
+				Scripts: strings.Split(replaceWrapLine(testingInfo.Scripts), "\n"),
+			},
+		}
+		jobTaskSpec.Steps = append(jobTaskSpec.Steps, shellStep)
+		// init junit report step
+		if testingInfo.TestType == setting.FunctionTest && testingInfo.TestResultPath != "" {
+			junitStep := &commonmodels.StepTask{
+				Name:     testing.Name + "-junit-report",
+				JobName:  jobTask.Name,
+				StepType: config.StepJunitReport,
+				Spec:     &step.StepJunitReportSpec{ReportDir: testingInfo.TestResultPath},
+			}
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, junitStep)
+		}
+		// init html report step
+		if testingInfo.TestType == setting.FunctionTest && testingInfo.TestReportPath != "" {
+			htmlStep := &commonmodels.StepTask{
+				Name:     testing.Name + "-html-report",
+				JobName:  jobTask.Name,
+				StepType: config.StepHtmlReport,
+				Spec: &step.StepHtmlReportSpec{
+					ReportDir:  path.Dir(testingInfo.TestReportPath),
+					ReportFile: path.Base(testingInfo.TestReportPath),
+				},
+			}
+			jobTaskSpec.Steps = append(jobTaskSpec.Steps, htmlStep)
+		}
+		if err := setReportDest(jobTaskSpec.Steps, j.workflow.Name, jobTask.Name, taskID); err != nil {
+			return resp, err
+		}
+		resp = append(resp, jobTask)
+	}
+	j.job.Spec = j.spec
+	return resp, nil
+}
+
+func getTestingJobVariables(repos []*types.Repository, taskID int64, project, workflowName, testingName string) []*commonmodels.KeyVal {
+	ret := make([]*commonmodels.KeyVal, 0)
+	ret = append(ret, getReposVariables(repos)...)
+
+	ret = append(ret, &commonmodels.KeyVal{Key: "TASK_ID", Value: fmt.Sprintf("%d", taskID), IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "PROJECT", Value: project, IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "WORKFLOW", Value: workflowName, IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "TESTING", Value: testingName, IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "CI", Value: "true", IsCredential: false})
+	ret = append(ret, &commonmodels.KeyVal{Key: "ZADIG", Value: "true", IsCredential: false})
+	testURL := fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d", configbase.SystemAddress(), project, workflowName, taskID)
+	ret = append(ret, &commonmodels.KeyVal{Key: "TEST_URL", Value: testURL, IsCredential: false})
+	return ret
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package job
+
+import (
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+type TriggerWorkflowJob struct {
+	job      *commonmodels.Job
+	workflow *commonmodels.WorkflowV4
+	spec     *commonmodels.TriggerWorkflowJobSpec
+}
+
+func (j *TriggerWorkflowJob) Instantiate() error {
+	j.spec = &commonmodels.TriggerWorkflowJobSpec{}
+	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TriggerWorkflowJob) SetPreset() error {
+	j.spec = &commonmodels.TriggerWorkflowJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.job.Spec = j.spec
+	return nil
+}
+
+func (j *TriggerWorkflowJob) MergeArgs(args *commonmodels.Job) error {
+	if j.job.Name == args.Name && j.job.JobType == args.JobType {
+		j.spec = &commonmodels.TriggerWorkflowJobSpec{}
+		if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+			return err
+		}
+		j.job.Spec = j.spec
+		argsSpec := &commonmodels.TriggerWorkflowJobSpec{}
+		if err := commonmodels.IToi(args.Spec, argsSpec); err != nil {
+			return err
+		}
+		j.spec.Params = argsSpec.Params
+		j.spec.KeyVals = argsSpec.KeyVals
+		j.job.Spec = j.spec
+	}
+	return nil
+}
+
+func (j *TriggerWorkflowJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
+	resp := []*commonmodels.JobTask{}
+
+	j.spec = &commonmodels.TriggerWorkflowJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return resp, err
+	}
+	projectName := j.spec.ProjectName
+	if projectName == "" {
+		projectName = j.workflow.Project
+	}
+	jobTask := &commonmodels.JobTask{
+		Name:    j.job.Name,
+		JobType: string(config.JobTriggerWorkflow),
+		Spec: &commonmodels.JobTaskTriggerWorkflowSpec{
+			WorkflowName: j.spec.WorkflowName,
+			ProjectName:  projectName,
+			Params:       j.spec.Params,
+			KeyVals:      j.spec.KeyVals,
+			Wait:         j.spec.Wait,
+			Timeout:      j.spec.Timeout,
+		},
+	}
+	resp = append(resp, jobTask)
+	j.job.Spec = j.spec
+	return resp, nil
+}
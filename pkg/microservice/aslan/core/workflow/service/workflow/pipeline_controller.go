@@ -17,11 +17,13 @@ limitations under the License.
 package workflow
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	configbase "github.com/koderover/zadig/pkg/config"
@@ -35,6 +37,7 @@ import (
 	krkubeclient "github.com/koderover/zadig/pkg/tool/kube/client"
 	"github.com/koderover/zadig/pkg/tool/kube/getter"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 func SubScribeNSQ() error {
@@ -491,6 +494,14 @@ func updateAgentAndQueue(t *task.Task) error {
 		return err
 	}
 
+	// warpdrive continues the trace from the span of sending the task.
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), t.TraceContext), "send pipeline task",
+		attribute.String("zadig.pipeline", t.PipelineName),
+		attribute.Int64("zadig.task_id", t.TaskID),
+	)
+	defer span.End()
+	t.TraceContext = tracing.Inject(ctx)
+
 	b, err := json.Marshal(t)
 	if err != nil {
 		log.Errorf("marshal PipelineTaskV2 error: %v", err)
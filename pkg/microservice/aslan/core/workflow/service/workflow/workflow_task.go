@@ -822,6 +822,7 @@ func CreateWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator string,
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
 		TriggerBy:        triggerBy,
+		TraceContext:     args.TraceContext,
 	}
 
 	if len(task.Stages) <= 0 {
@@ -1960,6 +1961,7 @@ func CreateArtifactWorkflowTask(args *commonmodels.WorkflowTaskArgs, taskCreator
 		ResetImage:       workflow.ResetImage,
 		ResetImagePolicy: workflow.ResetImagePolicy,
 		TriggerBy:        triggerBy,
+		TraceContext:     args.TraceContext,
 	}
 
 	if len(task.Stages) <= 0 {
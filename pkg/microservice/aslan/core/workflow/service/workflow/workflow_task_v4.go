@@ -17,6 +17,7 @@ limitations under the License.
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -26,15 +27,40 @@ import (
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
 	jobctl "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	policyservice "github.com/koderover/zadig/pkg/microservice/policy/core/service"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types"
 	stepspec "github.com/koderover/zadig/pkg/types/step"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
+const (
+	// max levels of the workflows triggered by each other.
+	maxTriggerWorkflowDepth = 5
+	// the verb of the role rules to run workflows.
+	runWorkflowVerb = "run_workflow"
+)
+
+type CreateWorkflowTaskV4Args struct {
+	Name   string
+	UserID string
+	// set when the task is triggered by a trigger-workflow job.
+	ParentTask *commonmodels.WorkflowTaskLink
+	// set when the task is created by a schedule of the workflow.
+	ScheduleName string
+	// trace context of the request which creates the task.
+	TraceContext map[string]string
+	// set by the webhooks whose config overrides the priority of the workflow, the priority and the concurrency
+	// of the workflow in the request are never used, otherwise any user could run tasks ahead of the queue.
+	Priority config.TaskPriority
+}
+
 type CreateTaskV4Resp struct {
 	ProjectName  string `json:"project_name"`
 	WorkflowName string `json:"workflow_name"`
@@ -55,6 +81,12 @@ type WorkflowTaskPreview struct {
 	ProjectName  string                `bson:"project_name"              json:"project_name"`
 	Error        string                `bson:"error,omitempty"           json:"error,omitempty"`
 	IsRestart    bool                  `bson:"is_restart"                json:"is_restart"`
+	// the task triggered this one by a trigger-workflow job.
+	ParentTask *commonmodels.WorkflowTaskLink `bson:"parent_task,omitempty"     json:"parent_task,omitempty"`
+	Priority   config.TaskPriority            `bson:"priority"                  json:"priority"`
+	// only set when the task is waiting in the queue, eta is the estimated unix time the task starts, 0 if unknown.
+	QueuePosition int   `bson:"queue_position,omitempty"  json:"queue_position,omitempty"`
+	ETA           int64 `bson:"eta,omitempty"             json:"eta,omitempty"`
 }
 
 type StageTaskPreview struct {
@@ -68,13 +100,22 @@ type StageTaskPreview struct {
 }
 
 type JobTaskPreview struct {
-	Name      string        `bson:"name"           json:"name"`
-	JobType   string        `bson:"type"           json:"type"`
-	Status    config.Status `bson:"status"         json:"status"`
-	StartTime int64         `bson:"start_time"     json:"start_time,omitempty"`
-	EndTime   int64         `bson:"end_time"       json:"end_time,omitempty"`
-	Error     string        `bson:"error"          json:"error"`
-	Spec      interface{}   `bson:"spec"           json:"spec"`
+	Name      string                     `bson:"name"           json:"name"`
+	JobType   string                     `bson:"type"           json:"type"`
+	Status    config.Status              `bson:"status"         json:"status"`
+	StartTime int64                      `bson:"start_time"     json:"start_time,omitempty"`
+	EndTime   int64                      `bson:"end_time"       json:"end_time,omitempty"`
+	Error     string                     `bson:"error"          json:"error"`
+	Retry     int64                      `bson:"retry"          json:"retry"`
+	Attempts  []*commonmodels.JobAttempt `bson:"attempts"       json:"attempts"`
+	// the attempt which finally passed, 0 means the job has not passed.
+	SucceededAttempt int                         `bson:"succeeded_attempt"  json:"succeeded_attempt"`
+	Condition        string                      `bson:"if"                 json:"if"`
+	SkipReason       string                      `bson:"skip_reason"        json:"skip_reason"`
+	TestReport       *commonmodels.JobTestReport `bson:"test_report"        json:"test_report,omitempty"`
+	Spec             interface{}                 `bson:"spec"               json:"spec"`
+	// where the job is paused, attach the job pod with the pod terminal to debug it.
+	Breakpoint *commonmodels.JobBreakpoint `bson:"breakpoint"         json:"breakpoint,omitempty"`
 }
 
 type ZadigBuildJobSpec struct {
@@ -85,6 +126,17 @@ type ZadigBuildJobSpec struct {
 	Envs          []*commonmodels.KeyVal `bson:"envs"            json:"envs"`
 }
 
+type ZadigTestingJobSpec struct {
+	TestName string                 `bson:"test_name"       json:"test_name"`
+	Repos    []*types.Repository    `bson:"repos"           json:"repos"`
+	Envs     []*commonmodels.KeyVal `bson:"envs"            json:"envs"`
+}
+
+type ZadigScanningJobSpec struct {
+	ScanningName string              `bson:"scanning_name"   json:"scanning_name"`
+	Repos        []*types.Repository `bson:"repos"           json:"repos"`
+}
+
 type ZadigDeployJobSpec struct {
 	Env                string             `bson:"env"                          json:"env"`
 	SkipCheckRunStatus bool               `bson:"skip_check_run_status"        json:"skip_check_run_status"`
@@ -125,7 +177,7 @@ func GetWorkflowv4Preset(encryptedKey, workflowName string, log *zap.SugaredLogg
 	return workflow, nil
 }
 
-func CreateWorkflowTaskV4(user string, workflow *commonmodels.WorkflowV4, log *zap.SugaredLogger) (*CreateTaskV4Resp, error) {
+func CreateWorkflowTaskV4(args *CreateWorkflowTaskV4Args, workflow *commonmodels.WorkflowV4, log *zap.SugaredLogger) (*CreateTaskV4Resp, error) {
 	resp := &CreateTaskV4Resp{
 		ProjectName:  workflow.Project,
 		WorkflowName: workflow.Name,
@@ -152,20 +204,42 @@ func CreateWorkflowTaskV4(user string, workflow *commonmodels.WorkflowV4, log *z
 		log.Errorf("RemoveFixedValueMarks error: %v", err)
 		return resp, e.ErrCreateTask.AddDesc(err.Error())
 	}
-	if err := jobctl.RenderGlobalVariables(workflow, nextTaskID, user); err != nil {
+	if err := jobctl.RenderGlobalVariables(workflow, nextTaskID, args.Name); err != nil {
 		log.Errorf("RenderGlobalVariables error: %v", err)
 		return resp, e.ErrCreateTask.AddDesc(err.Error())
 	}
 
 	workflowTask.TaskID = nextTaskID
-	workflowTask.TaskCreator = user
-	workflowTask.TaskRevoker = user
+	workflowTask.TaskCreator = args.Name
+	workflowTask.TaskCreatorID = args.UserID
+	workflowTask.TaskRevoker = args.Name
 	workflowTask.CreateTime = time.Now().Unix()
 	workflowTask.WorkflowName = workflow.Name
 	workflowTask.ProjectName = workflow.Project
 	workflowTask.Params = workflow.Params
 	workflowTask.KeyVals = workflow.KeyVals
 	workflowTask.MultiRun = workflow.MultiRun
+	workflowTask.ParentTask = args.ParentTask
+	workflowTask.ScheduleName = args.ScheduleName
+	workflowTask.TraceContext = args.TraceContext
+	storedWorkflow, err := commonrepo.NewWorkflowV4Coll().Find(workflow.Name)
+	if err != nil {
+		log.Errorf("cannot find workflow %s, the error is: %v", workflow.Name, err)
+		return resp, e.ErrFindWorkflow.AddDesc(err.Error())
+	}
+	workflowTask.Priority = args.Priority
+	if workflowTask.Priority == 0 {
+		workflowTask.Priority = storedWorkflow.Priority
+	}
+	if workflowTask.Priority == 0 {
+		workflowTask.Priority = config.TaskPriorityNormal
+	}
+	workflowTask.Concurrency = storedWorkflow.Concurrency
+
+	var jobUpstreams map[string][]string
+	if jobctl.DAGEnabled(workflow) {
+		jobUpstreams = jobctl.JobUpstreams(workflow)
+	}
 
 	for _, stage := range workflow.Stages {
 		stageTask := &commonmodels.StageTask{
@@ -184,6 +258,18 @@ func CreateWorkflowTaskV4(user string, workflow *commonmodels.WorkflowV4, log *z
 					return resp, e.ErrCreateTask.AddDesc(err.Error())
 				}
 			}
+			if job.JobType == config.JobZadigTest {
+				if err := setZadigTestRepos(job, log); err != nil {
+					log.Errorf("zadig test job set test info error: %v", err)
+					return resp, e.ErrCreateTask.AddDesc(err.Error())
+				}
+			}
+			if job.JobType == config.JobZadigScanning {
+				if err := setZadigScanningRepos(job, log); err != nil {
+					log.Errorf("zadig scanning job set scanning info error: %v", err)
+					return resp, e.ErrCreateTask.AddDesc(err.Error())
+				}
+			}
 			if job.JobType == config.JobFreestyle {
 				if err := setFreeStyleRepos(job, log); err != nil {
 					log.Errorf("freestyle job set build info error: %v", err)
@@ -196,6 +282,9 @@ func CreateWorkflowTaskV4(user string, workflow *commonmodels.WorkflowV4, log *z
 				log.Errorf("cannot create workflow %s, the error is: %v", workflow.Name, err)
 				return resp, e.ErrCreateTask.AddDesc(err.Error())
 			}
+			for _, jobTask := range jobs {
+				jobTask.DependsOn = jobUpstreams[job.Name]
+			}
 			stageTask.Jobs = append(stageTask.Jobs, jobs...)
 		}
 		if len(stageTask.Jobs) > 0 {
@@ -225,6 +314,117 @@ func CreateWorkflowTaskV4(user string, workflow *commonmodels.WorkflowV4, log *z
 	return resp, nil
 }
 
+// InitTriggerWorkflowJob lets the trigger-workflow jobs create and cancel workflow tasks.
+func InitTriggerWorkflowJob() {
+	jobcontroller.CreateWorkflowTask = createTriggeredWorkflowTask
+	jobcontroller.CancelWorkflowTask = workflowcontroller.CancelWorkflowTask
+}
+
+// createTriggeredWorkflowTask creates a task of the workflow in the spec with the params and keyvals in it.
+// The task is created on behalf of the creator of the parent task, who must be allowed to run the workflow
+// if it is in another project.
+func createTriggeredWorkflowTask(ctx context.Context, spec *commonmodels.JobTaskTriggerWorkflowSpec, parent *commonmodels.WorkflowTaskLink, creator string, logger *zap.SugaredLogger) (int64, error) {
+	if parent == nil {
+		return 0, fmt.Errorf("workflow %s can only be triggered by a workflow task", spec.WorkflowName)
+	}
+	projectName := spec.ProjectName
+	if projectName == "" {
+		projectName = parent.ProjectName
+	}
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(spec.WorkflowName)
+	if err != nil {
+		return 0, fmt.Errorf("workflow %s not found: %v", spec.WorkflowName, err)
+	}
+	if workflow.Project != projectName {
+		return 0, fmt.Errorf("workflow %s not found in project %s", spec.WorkflowName, projectName)
+	}
+	parentTask, err := commonrepo.NewworkflowTaskv4Coll().Find(parent.WorkflowName, parent.TaskID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find workflow %s task %d: %v", parent.WorkflowName, parent.TaskID, err)
+	}
+	if workflow.Project != parent.ProjectName {
+		if parentTask.TaskCreatorID == "" {
+			return 0, fmt.Errorf("task %d of workflow %s is not created by a user, it can not trigger workflows in project %s", parent.TaskID, parent.WorkflowName, workflow.Project)
+		}
+		allowed, err := canRunWorkflow(parentTask.TaskCreatorID, workflow.Project, workflow.Name, logger)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check the permission of %s: %v", creator, err)
+		}
+		if !allowed {
+			return 0, fmt.Errorf("%s is not allowed to run workflow %s in project %s", creator, workflow.Name, workflow.Project)
+		}
+	}
+	if err := checkTriggerLoop(workflow.Name, parent); err != nil {
+		return 0, err
+	}
+
+	overrideWorkflowV4Params(workflow, spec.Params, spec.KeyVals)
+	resp, err := CreateWorkflowTaskV4(&CreateWorkflowTaskV4Args{
+		Name:         creator,
+		UserID:       parentTask.TaskCreatorID,
+		ParentTask:   parent,
+		TraceContext: tracing.Inject(ctx),
+	}, workflow, logger)
+	if err != nil {
+		return 0, err
+	}
+	return resp.TaskID, nil
+}
+
+// canRunWorkflow tells whether the user can run the workflow in the project, by the roles of the user,
+// or by the policies on the workflow.
+func canRunWorkflow(uid, projectName, workflowName string, logger *zap.SugaredLogger) (bool, error) {
+	rules, err := policyservice.GetUserRules(uid, logger)
+	if err != nil {
+		return false, err
+	}
+	if rules.IsSystemAdmin || sets.NewString(rules.ProjectAdminList...).Has(projectName) ||
+		sets.NewString(rules.ProjectVerbMap[projectName]...).Has(runWorkflowVerb) {
+		return true, nil
+	}
+	projectRules, err := policyservice.GetUserRulesByProject(uid, projectName, logger)
+	if err != nil {
+		return false, err
+	}
+	return sets.NewString(projectRules.WorkflowVerbsMap[workflowName]...).Has(runWorkflowVerb), nil
+}
+
+// overrideWorkflowV4Params sets the values of the params and keyvals of the workflow by name.
+func overrideWorkflowV4Params(workflow *commonmodels.WorkflowV4, params []*commonmodels.Param, keyVals []*commonmodels.KeyVal) {
+	for _, param := range params {
+		for _, workflowParam := range workflow.Params {
+			if workflowParam.Name == param.Name {
+				workflowParam.Value = param.Value
+			}
+		}
+	}
+	for _, kv := range keyVals {
+		for _, workflowKV := range workflow.KeyVals {
+			if workflowKV.Key == kv.Key {
+				workflowKV.Value = kv.Value
+			}
+		}
+	}
+}
+
+// checkTriggerLoop refuses to trigger a workflow which is already in the chain of the parent tasks.
+func checkTriggerLoop(workflowName string, parent *commonmodels.WorkflowTaskLink) error {
+	for depth := 0; parent != nil; depth++ {
+		if parent.WorkflowName == workflowName {
+			return fmt.Errorf("workflow %s is triggered in a loop", workflowName)
+		}
+		if depth >= maxTriggerWorkflowDepth {
+			return fmt.Errorf("workflows can be triggered at most %d levels deep", maxTriggerWorkflowDepth)
+		}
+		task, err := commonrepo.NewworkflowTaskv4Coll().Find(parent.WorkflowName, parent.TaskID)
+		if err != nil {
+			return fmt.Errorf("failed to find workflow %s task %d: %v", parent.WorkflowName, parent.TaskID, err)
+		}
+		parent = task.ParentTask
+	}
+	return nil
+}
+
 func CloneWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
 	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
 	if err != nil {
@@ -246,6 +446,64 @@ func UpdateWorkflowTaskV4(id string, workflowTask *commonmodels.WorkflowTask, lo
 	return nil
 }
 
+// RetryWorkflowTaskV4 reruns a finished task from the first failed job, passed stages and jobs are kept
+// together with their outputs in the global context.
+func RetryWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLogger) error {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		logger.Errorf("find workflowTaskV4 error: %s", err)
+		return e.ErrRestartTask.AddErr(err)
+	}
+	switch task.Status {
+	case config.StatusFailed, config.StatusTimeout, config.StatusCancelled, config.StatusReject, config.StatusApprovalTimeout:
+	case config.StatusPassed:
+		return e.ErrRestartTask.AddDesc(e.RestartPassedTaskErrMsg)
+	default:
+		return e.ErrRestartTask.AddDesc(fmt.Sprintf("cannot retry task in status: %s", task.Status))
+	}
+
+	for _, stage := range task.Stages {
+		if stage.Status == config.StatusPassed || stage.Status == config.StatusSkipped {
+			continue
+		}
+		stage.Status = ""
+		stage.StartTime = 0
+		stage.EndTime = 0
+		stage.Error = ""
+		// an approved stage does not need to be approved again.
+		if stage.Approval != nil && stage.Approval.RejectOrApprove != config.Approve {
+			stage.Approval.RejectOrApprove = ""
+			stage.Approval.AutoApprovedBy = ""
+			for _, user := range stage.Approval.ApproveUsers {
+				user.RejectOrApprove = ""
+				user.Comment = ""
+				user.OperationTime = 0
+				user.Operator = ""
+			}
+		}
+		for _, job := range stage.Jobs {
+			if job.Status == config.StatusPassed || job.Status == config.StatusSkipped {
+				continue
+			}
+			job.Status = ""
+			job.StartTime = 0
+			job.EndTime = 0
+			job.Error = ""
+			job.Attempts = nil
+		}
+	}
+	task.IsRestart = true
+	task.StartTime = 0
+	task.EndTime = 0
+	task.Error = ""
+
+	if err := workflowcontroller.UpdateTask(task); err != nil {
+		logger.Errorf("retry workflow task error: %v", err)
+		return e.ErrRestartTask.AddErr(err)
+	}
+	return nil
+}
+
 func ListWorkflowTaskV4(workflowName string, pageNum, pageSize int64, logger *zap.SugaredLogger) ([]*commonmodels.WorkflowTask, int64, error) {
 	resp, total, err := commonrepo.NewworkflowTaskv4Coll().List(&commonrepo.ListWorkflowTaskV4Option{WorkflowName: workflowName, Limit: int(pageSize), Skip: int((pageNum - 1) * pageSize)})
 	if err != nil {
@@ -263,6 +521,31 @@ func CancelWorkflowTaskV4(userName, workflowName string, taskID int64, logger *z
 	return nil
 }
 
+// ResumeWorkflowTaskV4Job resumes the job paused at a breakpoint, or fails the job if abort is true.
+func ResumeWorkflowTaskV4Job(workflowName, jobName string, taskID int64, abort bool, logger *zap.SugaredLogger) error {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		logger.Errorf("find workflowTaskV4 error: %s", err)
+		return e.ErrResumeJobBreakpoint.AddErr(err)
+	}
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name != jobName {
+				continue
+			}
+			if job.Status != config.StatusPaused || job.Breakpoint == nil {
+				return e.ErrResumeJobBreakpoint.AddDesc(fmt.Sprintf("job %s is not paused", jobName))
+			}
+			if err := jobcontroller.ResumeBreakpoint(job.Breakpoint, abort); err != nil {
+				logger.Errorf("failed to resume job %s of workflow %s task %d: %s", jobName, workflowName, taskID, err)
+				return e.ErrResumeJobBreakpoint.AddErr(err)
+			}
+			return nil
+		}
+	}
+	return e.ErrResumeJobBreakpoint.AddDesc(fmt.Sprintf("job %s not found", jobName))
+}
+
 func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLogger) (*WorkflowTaskPreview, error) {
 	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
 	if err != nil {
@@ -282,6 +565,11 @@ func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLog
 		EndTime:      task.EndTime,
 		Error:        task.Error,
 		IsRestart:    task.IsRestart,
+		ParentTask:   task.ParentTask,
+		Priority:     task.Priority,
+	}
+	if task.Status == config.StatusWaiting || task.Status == config.StatusBlocked {
+		resp.QueuePosition, resp.ETA = workflowcontroller.QueuePosition(task.WorkflowName, task.TaskID)
 	}
 	for _, stage := range task.Stages {
 		resp.Stages = append(resp.Stages, &StageTaskPreview{
@@ -314,12 +602,23 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask) []*JobTaskPreview {
 	resp := []*JobTaskPreview{}
 	for _, job := range jobs {
 		jobPreview := &JobTaskPreview{
-			Name:      job.Name,
-			Status:    job.Status,
-			StartTime: job.StartTime,
-			EndTime:   job.EndTime,
-			Error:     job.Error,
-			JobType:   job.JobType,
+			Name:       job.Name,
+			Status:     job.Status,
+			StartTime:  job.StartTime,
+			EndTime:    job.EndTime,
+			Error:      job.Error,
+			JobType:    job.JobType,
+			Retry:      job.Retry,
+			Attempts:   job.Attempts,
+			Condition:  job.Condition,
+			SkipReason: job.SkipReason,
+			TestReport: job.TestReport,
+			Breakpoint: job.Breakpoint,
+		}
+		for _, attempt := range job.Attempts {
+			if attempt.Status == config.StatusPassed {
+				jobPreview.SucceededAttempt = attempt.Attempt
+			}
 		}
 		switch job.JobType {
 		case string(config.FreestyleType):
@@ -354,6 +653,39 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask) []*JobTaskPreview {
 				}
 			}
 			jobPreview.Spec = spec
+		case string(config.JobZadigTest):
+			spec := ZadigTestingJobSpec{}
+			taskJobSpec := &commonmodels.JobTaskTestingSpec{}
+			if err := commonmodels.IToi(job.Spec, taskJobSpec); err != nil {
+				continue
+			}
+			spec.TestName = taskJobSpec.TestName
+			spec.Envs = taskJobSpec.Properties.CustomEnvs
+			for _, step := range taskJobSpec.Steps {
+				if step.StepType == config.StepGit {
+					stepSpec := &stepspec.StepGitSpec{}
+					commonmodels.IToi(step.Spec, &stepSpec)
+					spec.Repos = stepSpec.Repos
+					continue
+				}
+			}
+			jobPreview.Spec = spec
+		case string(config.JobZadigScanning):
+			spec := ZadigScanningJobSpec{}
+			taskJobSpec := &commonmodels.JobTaskScanningSpec{}
+			if err := commonmodels.IToi(job.Spec, taskJobSpec); err != nil {
+				continue
+			}
+			spec.ScanningName = taskJobSpec.ScanningName
+			for _, step := range taskJobSpec.Steps {
+				if step.StepType == config.StepGit {
+					stepSpec := &stepspec.StepGitSpec{}
+					commonmodels.IToi(step.Spec, &stepSpec)
+					spec.Repos = stepSpec.Repos
+					continue
+				}
+			}
+			jobPreview.Spec = spec
 		case string(config.JobZadigDeploy):
 			spec := ZadigDeployJobSpec{}
 			taskJobSpec := &commonmodels.JobTaskDeploySpec{}
@@ -431,6 +763,34 @@ func setZadigBuildRepos(job *commonmodels.Job, logger *zap.SugaredLogger) error
 	return nil
 }
 
+func setZadigTestRepos(job *commonmodels.Job, logger *zap.SugaredLogger) error {
+	spec := &commonmodels.ZadigTestJobSpec{}
+	if err := commonmodels.IToi(job.Spec, spec); err != nil {
+		return err
+	}
+	for _, testing := range spec.TestModules {
+		if err := setManunalBuilds(testing.Repos, testing.Repos, logger); err != nil {
+			return err
+		}
+	}
+	job.Spec = spec
+	return nil
+}
+
+func setZadigScanningRepos(job *commonmodels.Job, logger *zap.SugaredLogger) error {
+	spec := &commonmodels.ZadigScanningJobSpec{}
+	if err := commonmodels.IToi(job.Spec, spec); err != nil {
+		return err
+	}
+	for _, scanning := range spec.Scannings {
+		if err := setManunalBuilds(scanning.Repos, scanning.Repos, logger); err != nil {
+			return err
+		}
+	}
+	job.Spec = spec
+	return nil
+}
+
 func setFreeStyleRepos(job *commonmodels.Job, logger *zap.SugaredLogger) error {
 	spec := &commonmodels.FreestyleJobSpec{}
 	if err := commonmodels.IToi(job.Spec, spec); err != nil {
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+// GetWorkflowTaskV4HTMLReport returns a file of the html test report of the job,
+// filePath is relative to the report directory and the entry file is returned if it is empty.
+func GetWorkflowTaskV4HTMLReport(workflowName, jobName, filePath string, taskID int64, logger *zap.SugaredLogger) ([]byte, string, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		logger.Errorf("find workflowTaskV4 error: %s", err)
+		return nil, "", e.ErrGetTestReport.AddErr(err)
+	}
+	entry := ""
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.Name == jobName && job.TestReport != nil {
+				entry = job.TestReport.HtmlReport
+			}
+		}
+	}
+	if entry == "" {
+		return nil, "", e.ErrGetTestReport.AddDesc(fmt.Sprintf("job %s has no html test report", jobName))
+	}
+
+	objectKey := entry
+	if filePath = strings.TrimLeft(filePath, "/"); filePath != "" {
+		reportDir := path.Dir(entry)
+		objectKey = path.Join(reportDir, filePath)
+		// do not read the files out of the report directory.
+		if !strings.HasPrefix(objectKey, reportDir+"/") {
+			return nil, "", e.ErrGetTestReport.AddDesc("invalid file path")
+		}
+	}
+
+	store, err := s3.FindDefaultS3()
+	if err != nil {
+		logger.Errorf("find default s3 error: %s", err)
+		return nil, "", e.ErrGetTestReport.AddErr(err)
+	}
+	forcedPathStyle := true
+	if store.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(store.Endpoint, store.Ak, store.Sk, store.Insecure, forcedPathStyle)
+	if err != nil {
+		logger.Errorf("create s3 client error: %s", err)
+		return nil, "", e.ErrGetTestReport.AddErr(err)
+	}
+	object, err := client.GetFile(store.Bucket, objectKey, &s3tool.DownloadOption{RetryNum: 2})
+	if err != nil {
+		logger.Errorf("get html test report %s error: %s", objectKey, err)
+		return nil, "", e.ErrGetTestReport.AddErr(err)
+	}
+	defer object.Body.Close()
+	content, err := ioutil.ReadAll(object.Body)
+	if err != nil {
+		logger.Errorf("read html test report %s error: %s", objectKey, err)
+		return nil, "", e.ErrGetTestReport.AddErr(err)
+	}
+	contentType := "text/html"
+	if object.ContentType != nil && *object.ContentType != "" {
+		contentType = *object.ContentType
+	}
+	return content, contentType, nil
+}
@@ -19,6 +19,7 @@ package workflow
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -30,17 +31,20 @@ import (
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/webhook"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
 	jobctl "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/util/condition"
 )
 
 const (
-	JobNameRegx  = "^[a-z][a-z0-9-]{0,31}$"
-	WorkflowRegx = "^[a-z0-9-]{1,32}$"
+	JobNameRegx    = "^[a-z][a-z0-9-]{0,31}$"
+	WorkflowRegx   = "^[a-z0-9-]{1,32}$"
+	OutputNameRegx = "^[a-zA-Z_][a-zA-Z0-9_-]{0,63}$"
 )
 
 func CreateWorkflowV4(user string, workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) error {
@@ -277,6 +281,28 @@ func ensureWorkflowV4Resp(encryptedKey string, workflow *commonmodels.WorkflowV4
 				}
 				job.Spec = spec
 			}
+			if job.JobType == config.JobZadigTest {
+				spec := &commonmodels.ZadigTestJobSpec{}
+				if err := commonmodels.IToi(job.Spec, spec); err != nil {
+					logger.Errorf(err.Error())
+					return e.ErrFindWorkflow.AddErr(err)
+				}
+				for _, testing := range spec.TestModules {
+					testingInfo, err := commonrepo.NewTestingColl().Find(testing.Name, testing.ProjectName)
+					if err != nil {
+						logger.Errorf("testing job: %s, testing: %s not found", job.Name, testing.Name)
+						continue
+					}
+					if testingInfo.PreTest != nil {
+						testing.KeyVals = commonservice.MergeBuildEnvs(testingInfo.PreTest.Envs, testing.KeyVals)
+					}
+					if err := commonservice.EncryptKeyVals(encryptedKey, testing.KeyVals, logger); err != nil {
+						logger.Errorf(err.Error())
+						return e.ErrFindWorkflow.AddErr(err)
+					}
+				}
+				job.Spec = spec
+			}
 			if job.JobType == config.JobFreestyle {
 				spec := &commonmodels.FreestyleJobSpec{}
 				if err := commonmodels.IToi(job.Spec, spec); err != nil {
@@ -322,6 +348,20 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 		logger.Errorf(err.Error())
 		return e.ErrUpsertWorkflow.AddErr(err)
 	}
+	if workflow.Priority < 0 || workflow.Priority > config.TaskPriorityUrgent {
+		err := fmt.Errorf("invalid priority %d", workflow.Priority)
+		logger.Errorf(err.Error())
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+	if workflow.Concurrency < 0 {
+		err := fmt.Errorf("concurrency cannot be less than 0")
+		logger.Errorf(err.Error())
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+	if err := lintWorkflowV4Schedules(workflow.Schedules); err != nil {
+		logger.Errorf("invalid schedules of workflow %s: %v", workflow.Name, err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
 
 	project := &template.Product{}
 	// for deploy center workflow, it doesn't belongs to any project, so we use a specical project name to distinguish it.
@@ -356,6 +396,10 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 			logger.Errorf("duplicated stage name: %s", stage.Name)
 			return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("duplicated job name: %s", stage.Name))
 		}
+		if err := lintApproval(stage.Approval); err != nil {
+			logger.Errorf("invalid approval of stage %s: %v", stage.Name, err)
+			return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("invalid approval of stage %s: %v", stage.Name, err))
+		}
 		stageBuildJobNameMap := make(map[string]string)
 		for _, job := range stage.Jobs {
 			if !stage.Parallel {
@@ -373,6 +417,86 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 				logger.Errorf("duplicated job name: %s", job.Name)
 				return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("duplicated job name: %s", job.Name))
 			}
+			if job.Condition != "" {
+				if err := condition.Validate(job.Condition); err != nil {
+					logger.Errorf("invalid condition of job %s: %v", job.Name, err)
+					return e.ErrUpsertWorkflow.AddDesc(fmt.Sprintf("invalid condition of job %s: %v", job.Name, err))
+				}
+			}
+
+			if job.JobType == config.JobZadigTest {
+				spec := &commonmodels.ZadigTestJobSpec{}
+				if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+					logger.Errorf("decode job spec error: %v", err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+				for _, testing := range spec.TestModules {
+					if _, err := commonrepo.NewTestingColl().Find(testing.Name, testing.ProjectName); err != nil {
+						errMsg := fmt.Sprintf("testing %s in job %s not found", testing.Name, job.Name)
+						logger.Error(errMsg)
+						return e.ErrUpsertWorkflow.AddDesc(errMsg)
+					}
+				}
+			}
+
+			if job.JobType == config.JobZadigScanning {
+				spec := &commonmodels.ZadigScanningJobSpec{}
+				if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+					logger.Errorf("decode job spec error: %v", err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+				for _, scanning := range spec.Scannings {
+					if _, err := commonrepo.NewScanningColl().Find(scanning.ProjectName, scanning.Name); err != nil {
+						errMsg := fmt.Sprintf("scanning %s in job %s not found", scanning.Name, job.Name)
+						logger.Error(errMsg)
+						return e.ErrUpsertWorkflow.AddDesc(errMsg)
+					}
+				}
+				if spec.JobName != "" {
+					jobType, ok := buildJobNameMap[spec.JobName]
+					if !ok || jobType != string(config.JobZadigBuild) {
+						errMsg := fmt.Sprintf("can not quote job %s in job %s", spec.JobName, job.Name)
+						logger.Error(errMsg)
+						return e.ErrUpsertWorkflow.AddDesc(errMsg)
+					}
+				}
+			}
+
+			if job.JobType == config.JobFreestyle {
+				spec := &commonmodels.FreestyleJobSpec{}
+				if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+					logger.Errorf("decode job spec error: %v", err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+				if err := lintJobOutputs(job.Name, spec.Outputs); err != nil {
+					logger.Error(err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+			}
+
+			if job.JobType == config.JobCanaryDeploy {
+				spec := &commonmodels.CanaryDeployJobSpec{}
+				if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+					logger.Errorf("decode job spec error: %v", err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+				if err := lintCanaryDeployJob(job.Name, spec); err != nil {
+					logger.Error(err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+			}
+
+			if job.JobType == config.JobTriggerWorkflow {
+				spec := &commonmodels.TriggerWorkflowJobSpec{}
+				if err := commonmodels.IToiYaml(job.Spec, spec); err != nil {
+					logger.Errorf("decode job spec error: %v", err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+				if err := lintTriggerWorkflowJob(workflow, job.Name, spec); err != nil {
+					logger.Error(err)
+					return e.ErrUpsertWorkflow.AddErr(err)
+				}
+			}
 
 			if job.JobType == config.JobZadigDeploy {
 				spec := &commonmodels.ZadigDeployJobSpec{}
@@ -395,6 +519,31 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 			buildJobNameMap[k] = v
 		}
 	}
+	if err := jobctl.CheckJobDependencies(workflow); err != nil {
+		logger.Error(err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+	for _, notifyCtl := range workflow.NotifyCtls {
+		if err := instantmessage.ValidateNotifyCtlV4(notifyCtl); err != nil {
+			logger.Error(err)
+			return e.ErrUpsertWorkflow.AddErr(err)
+		}
+	}
+	return nil
+}
+
+func lintApproval(approval *commonmodels.Approval) error {
+	if approval == nil || !approval.Enabled {
+		return nil
+	}
+	for _, rule := range approval.AutoApproveRules {
+		if rule.BranchRegex == "" && len(rule.Creators) == 0 && len(rule.CreatorGroups) == 0 {
+			return fmt.Errorf("auto approve rule %s has no condition", rule.Name)
+		}
+		if _, err := regexp.Compile(rule.BranchRegex); err != nil {
+			return fmt.Errorf("branch regex of auto approve rule %s is invalid: %v", rule.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -415,6 +564,10 @@ func CreateWebhookForWorkflowV4(workflowName string, input *commonmodels.Workflo
 		logger.Errorf(err.Error())
 		return e.ErrCreateWebhook.AddErr(err)
 	}
+	if err := lintWorkflowV4YamlHook(workflow, input, logger); err != nil {
+		logger.Errorf(err.Error())
+		return e.ErrCreateWebhook.AddErr(err)
+	}
 	err = commonservice.ProcessWebhook([]*models.WorkflowV4Hook{input}, nil, webhook.WorkflowV4Prefix+workflowName, logger)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to create webhook for workflow %s, the error is: %v", workflowName, err)
@@ -455,6 +608,10 @@ func UpdateWebhookForWorkflowV4(workflowName string, input *commonmodels.Workflo
 		logger.Errorf(err.Error())
 		return e.ErrUpdateWebhook.AddErr(err)
 	}
+	if err := lintWorkflowV4YamlHook(workflow, input, logger); err != nil {
+		logger.Errorf(err.Error())
+		return e.ErrUpdateWebhook.AddErr(err)
+	}
 	err = commonservice.ProcessWebhook([]*models.WorkflowV4Hook{input}, []*models.WorkflowV4Hook{existHook}, webhook.WorkflowV4Prefix+workflowName, logger)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to update webhook for workflow %s, the error is: %v", workflowName, err)
@@ -545,3 +702,65 @@ func DeleteWebhookForWorkflowV4(workflowName, triggerName string, logger *zap.Su
 	}
 	return nil
 }
+
+// lintJobOutputs checks the outputs, jobexecutor reads them from the files named after them.
+func lintJobOutputs(jobName string, outputs []*commonmodels.Output) error {
+	reg := regexp.MustCompile(OutputNameRegx)
+	outputNames := make(map[string]bool)
+	for _, output := range outputs {
+		if !reg.MatchString(output.Name) {
+			return fmt.Errorf("output name %s in job %s should match %s", output.Name, jobName, OutputNameRegx)
+		}
+		if outputNames[output.Name] {
+			return fmt.Errorf("duplicated output %s in job %s", output.Name, jobName)
+		}
+		outputNames[output.Name] = true
+	}
+	return nil
+}
+
+func lintCanaryDeployJob(jobName string, spec *commonmodels.CanaryDeployJobSpec) error {
+	if spec.Strategy != config.DeployStrategyCanary && spec.Strategy != config.DeployStrategyBlueGreen {
+		return fmt.Errorf("invalid deploy strategy %s in job %s", spec.Strategy, jobName)
+	}
+	if spec.TrafficRouter != config.TrafficRouterService && spec.TrafficRouter != config.TrafficRouterIstio {
+		return fmt.Errorf("invalid traffic router %s in job %s", spec.TrafficRouter, jobName)
+	}
+	lastWeight := 0
+	for _, step := range spec.Steps {
+		if step.Weight <= lastWeight || step.Weight > 100 {
+			return fmt.Errorf("traffic weights in job %s should increase within 1-100", jobName)
+		}
+		lastWeight = step.Weight
+	}
+	for _, target := range spec.Targets {
+		if len(strings.Split(target.Target, "/")) != 2 || target.K8sServiceName == "" {
+			return fmt.Errorf("invalid target %s in job %s", target.Target, jobName)
+		}
+	}
+	return nil
+}
+
+func lintTriggerWorkflowJob(workflow *commonmodels.WorkflowV4, jobName string, spec *commonmodels.TriggerWorkflowJobSpec) error {
+	if spec.WorkflowName == "" {
+		return fmt.Errorf("no workflow to trigger in job %s", jobName)
+	}
+	if spec.WorkflowName == workflow.Name {
+		return fmt.Errorf("workflow can not trigger itself in job %s", jobName)
+	}
+	if spec.Timeout < 0 {
+		return fmt.Errorf("invalid timeout in job %s", jobName)
+	}
+	target, err := commonrepo.NewWorkflowV4Coll().Find(spec.WorkflowName)
+	if err != nil {
+		return fmt.Errorf("workflow %s in job %s not found", spec.WorkflowName, jobName)
+	}
+	projectName := spec.ProjectName
+	if projectName == "" {
+		projectName = workflow.Project
+	}
+	if target.Project != projectName {
+		return fmt.Errorf("workflow %s in job %s not found in project %s", spec.WorkflowName, jobName, projectName)
+	}
+	return nil
+}
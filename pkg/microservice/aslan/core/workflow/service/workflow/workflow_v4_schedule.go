@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type WorkflowV4ScheduleResp struct {
+	WorkflowName string `json:"workflow_name"`
+	ProjectName  string `json:"project_name"`
+	Name         string `json:"name"`
+	Cron         string `json:"cron"`
+	Timezone     string `json:"timezone"`
+}
+
+// RunWorkflowV4ScheduleResp is the result of a run of the schedule, no task is created if the run is skipped.
+type RunWorkflowV4ScheduleResp struct {
+	ProjectName  string `json:"project_name"`
+	WorkflowName string `json:"workflow_name"`
+	TaskID       int64  `json:"task_id,omitempty"`
+	Skipped      bool   `json:"skipped"`
+	SkipReason   string `json:"skip_reason,omitempty"`
+}
+
+// ListWorkflowV4Schedules returns the enabled schedules of all the workflows, the cron service reconciles its jobs with them.
+func ListWorkflowV4Schedules(logger *zap.SugaredLogger) ([]*WorkflowV4ScheduleResp, error) {
+	resp := []*WorkflowV4ScheduleResp{}
+	workflows, err := commonrepo.NewWorkflowV4Coll().ListWithScheduleEnabled()
+	if err != nil {
+		logger.Errorf("list workflows with schedules error: %v", err)
+		return resp, e.ErrListWorkflow.AddErr(err)
+	}
+	for _, workflow := range workflows {
+		for _, schedule := range workflow.Schedules {
+			if !schedule.Enabled {
+				continue
+			}
+			resp = append(resp, &WorkflowV4ScheduleResp{
+				WorkflowName: workflow.Name,
+				ProjectName:  workflow.Project,
+				Name:         schedule.Name,
+				Cron:         schedule.Cron,
+				Timezone:     schedule.Timezone,
+			})
+		}
+	}
+	return resp, nil
+}
+
+// RunWorkflowV4Schedule creates a task of the workflow with the params and keyvals of the schedule,
+// the run is skipped if the task of the last run is still unfinished unless the schedule queues its runs.
+func RunWorkflowV4Schedule(workflowName, scheduleName string, logger *zap.SugaredLogger) (*RunWorkflowV4ScheduleResp, error) {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
+	if err != nil {
+		logger.Errorf("find workflow %s error: %v", workflowName, err)
+		return nil, e.ErrFindWorkflow.AddErr(err)
+	}
+	var schedule *commonmodels.WorkflowV4Schedule
+	for _, s := range workflow.Schedules {
+		if s.Name == scheduleName {
+			schedule = s
+		}
+	}
+	if schedule == nil || !schedule.Enabled {
+		return nil, e.ErrFindWorkflow.AddDesc(fmt.Sprintf("schedule %s of workflow %s not found or disabled", scheduleName, workflowName))
+	}
+
+	if schedule.ConcurrencyPolicy != config.ScheduleQueue {
+		tasks, err := commonrepo.NewworkflowTaskv4Coll().FindTodoTasksByWorkflowName(workflowName)
+		if err != nil {
+			logger.Errorf("find running tasks of workflow %s error: %v", workflowName, err)
+			return nil, e.ErrCreateTask.AddErr(err)
+		}
+		if reason := scheduleSkipReason(scheduleName, tasks); reason != "" {
+			logger.Infof("skip schedule %s of workflow %s, %s", scheduleName, workflowName, reason)
+			return &RunWorkflowV4ScheduleResp{ProjectName: workflow.Project, WorkflowName: workflowName, Skipped: true, SkipReason: reason}, nil
+		}
+	}
+
+	overrideWorkflowV4Params(workflow, schedule.Params, schedule.KeyVals)
+	task, err := CreateWorkflowTaskV4(&CreateWorkflowTaskV4Args{
+		Name:         setting.CronTaskCreator,
+		ScheduleName: scheduleName,
+	}, workflow, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &RunWorkflowV4ScheduleResp{ProjectName: task.ProjectName, WorkflowName: task.WorkflowName, TaskID: task.TaskID}, nil
+}
+
+// scheduleSkipReason returns why the run of the schedule is skipped, empty if none of the unfinished tasks is of the schedule.
+func scheduleSkipReason(scheduleName string, todoTasks []*commonmodels.WorkflowTask) string {
+	for _, task := range todoTasks {
+		if task.ScheduleName == scheduleName {
+			return fmt.Sprintf("task %d of the last run is still %s", task.TaskID, task.Status)
+		}
+	}
+	return ""
+}
+
+func lintWorkflowV4Schedules(schedules []*commonmodels.WorkflowV4Schedule) error {
+	names := make(map[string]bool)
+	for _, schedule := range schedules {
+		if schedule.Name == "" || names[schedule.Name] {
+			return fmt.Errorf("schedule name should be unique and not empty")
+		}
+		names[schedule.Name] = true
+		// the time zone is set by the timezone field.
+		if strings.HasPrefix(schedule.Cron, "TZ=") || strings.HasPrefix(schedule.Cron, "CRON_TZ=") {
+			return fmt.Errorf("set the time zone of schedule %s by timezone instead of the cron", schedule.Name)
+		}
+		if _, err := cron.ParseStandard(schedule.Cron); err != nil {
+			return fmt.Errorf("invalid cron %s of schedule %s: %v", schedule.Cron, schedule.Name, err)
+		}
+		// an empty time zone is the time zone of the cron service.
+		if schedule.Timezone != "" {
+			if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+				return fmt.Errorf("invalid timezone %s of schedule %s: %v", schedule.Timezone, schedule.Name, err)
+			}
+		}
+		switch schedule.ConcurrencyPolicy {
+		case "", config.ScheduleSkip, config.ScheduleQueue:
+		default:
+			return fmt.Errorf("invalid concurrency policy %s of schedule %s", schedule.ConcurrencyPolicy, schedule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func workflowV4Schedule(name, cron, timezone string, policy config.ScheduleConcurrencyPolicy) *commonmodels.WorkflowV4Schedule {
+	return &commonmodels.WorkflowV4Schedule{Name: name, Cron: cron, Timezone: timezone, ConcurrencyPolicy: policy}
+}
+
+var _ = Describe("Testing workflow v4 schedules", func() {
+	DescribeTable("lintWorkflowV4Schedules",
+		func(schedules []*commonmodels.WorkflowV4Schedule, valid bool) {
+			err := lintWorkflowV4Schedules(schedules)
+			if valid {
+				Expect(err).ShouldNot(HaveOccurred())
+			} else {
+				Expect(err).Should(HaveOccurred())
+			}
+		},
+		Entry("no schedule", nil, true),
+		Entry("nightly and weekly", []*commonmodels.WorkflowV4Schedule{
+			workflowV4Schedule("nightly", "0 2 * * *", "Asia/Shanghai", config.ScheduleSkip),
+			workflowV4Schedule("weekly", "@weekly", "", config.ScheduleQueue),
+		}, true),
+		Entry("default concurrency policy", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "0 2 * * *", "UTC", "")}, true),
+		Entry("empty name", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("", "0 2 * * *", "", "")}, false),
+		Entry("duplicated names", []*commonmodels.WorkflowV4Schedule{
+			workflowV4Schedule("nightly", "0 2 * * *", "", ""),
+			workflowV4Schedule("nightly", "0 3 * * *", "", ""),
+		}, false),
+		Entry("time zone in the cron", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "CRON_TZ=UTC 0 2 * * *", "", "")}, false),
+		Entry("invalid cron", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "0 25 * * *", "", "")}, false),
+		Entry("cron with seconds", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "0 0 2 * * *", "", "")}, false),
+		Entry("invalid time zone", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "0 2 * * *", "Mars/Olympus", "")}, false),
+		Entry("invalid concurrency policy", []*commonmodels.WorkflowV4Schedule{workflowV4Schedule("nightly", "0 2 * * *", "", "replace")}, false),
+	)
+
+	DescribeTable("scheduleSkipReason",
+		func(tasks []*commonmodels.WorkflowTask, expected string) {
+			Expect(scheduleSkipReason("nightly", tasks)).To(Equal(expected))
+		},
+		Entry("no unfinished task", nil, ""),
+		Entry("unfinished task of another schedule", []*commonmodels.WorkflowTask{{TaskID: 3, ScheduleName: "weekly", Status: config.StatusRunning}}, ""),
+		Entry("unfinished manual task", []*commonmodels.WorkflowTask{{TaskID: 3, Status: config.StatusRunning}}, ""),
+		Entry("unfinished task of the schedule", []*commonmodels.WorkflowTask{
+			{TaskID: 3, Status: config.StatusRunning},
+			{TaskID: 2, ScheduleName: "nightly", Status: config.StatusQueued},
+		}, "task 2 of the last run is still queued"),
+	)
+})
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+var _ = Describe("Testing workflow v4 lint", func() {
+	DescribeTable("lintJobOutputs",
+		func(names []string, valid bool) {
+			outputs := make([]*commonmodels.Output, 0, len(names))
+			for _, name := range names {
+				outputs = append(outputs, &commonmodels.Output{Name: name})
+			}
+			err := lintJobOutputs("build", outputs)
+			if valid {
+				Expect(err).ShouldNot(HaveOccurred())
+			} else {
+				Expect(err).Should(HaveOccurred())
+			}
+		},
+		Entry("plain names", []string{"IMAGE", "_tag", "version2"}, true),
+		Entry("names with hyphen", []string{"image-tag", "build_id-1"}, true),
+		Entry("leading digit", []string{"1image"}, false),
+		Entry("leading hyphen", []string{"-image"}, false),
+		Entry("path separator", []string{"image/tag"}, false),
+		Entry("dot", []string{"image.tag"}, false),
+		Entry("empty name", []string{""}, false),
+		Entry("duplicated names", []string{"image", "image"}, false),
+	)
+})
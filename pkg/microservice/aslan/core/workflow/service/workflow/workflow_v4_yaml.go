@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/fs"
+	jobctl "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// WorkflowV4YamlDir is where the workflows are defined in the repository, one yaml file for each workflow.
+const WorkflowV4YamlDir = ".zadig/workflows"
+
+// WorkflowV4YamlPath returns the path of the yaml file the workflow is defined in.
+func WorkflowV4YamlPath(workflowName string, hook *commonmodels.WorkflowV4Hook) string {
+	if hook.YamlPath != "" {
+		return hook.YamlPath
+	}
+	return path.Join(WorkflowV4YamlDir, workflowName+".yaml")
+}
+
+// LoadWorkflowV4FromRepo loads the workflow from the yaml file in the main repo of the hook at the ref, which is a branch, tag or commit.
+// The workflow is validated, and the fields not defined by the yaml are kept from the stored workflow. The hooks, the
+// notifications and the schedules are configured on the server only, so they are always kept from the stored workflow.
+func LoadWorkflowV4FromRepo(workflow *commonmodels.WorkflowV4, hook *commonmodels.WorkflowV4Hook, ref string, logger *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
+	if hook.MainRepo == nil {
+		return nil, e.ErrLoadWorkflowYaml.AddDesc("main repo of the webhook is not set")
+	}
+	yamlPath := WorkflowV4YamlPath(workflow.Name, hook)
+	getter, err := fs.GetTreeGetter(hook.MainRepo.CodehostID)
+	if err != nil {
+		logger.Errorf("Failed to get tree getter for codehost %d, error: %s", hook.MainRepo.CodehostID, err)
+		return nil, e.ErrLoadWorkflowYaml.AddErr(err)
+	}
+	content, err := getter.GetFileContent(hook.MainRepo.GetRepoNamespace(), hook.MainRepo.RepoName, yamlPath, ref)
+	if err != nil {
+		logger.Errorf("Failed to get %s of %s/%s at %s, error: %s", yamlPath, hook.MainRepo.GetRepoNamespace(), hook.MainRepo.RepoName, ref, err)
+		return nil, e.ErrLoadWorkflowYaml.AddErr(err)
+	}
+
+	loaded := new(commonmodels.WorkflowV4)
+	if err := yaml.Unmarshal(content, loaded); err != nil {
+		logger.Errorf("Failed to unmarshal %s, error: %s", yamlPath, err)
+		return nil, e.ErrLoadWorkflowYaml.AddDesc(fmt.Sprintf("invalid workflow yaml %s: %v", yamlPath, err))
+	}
+	if loaded.Name != "" && loaded.Name != workflow.Name {
+		return nil, e.ErrLoadWorkflowYaml.AddDesc(fmt.Sprintf("workflow name %s in %s does not match %s", loaded.Name, yamlPath, workflow.Name))
+	}
+	if loaded.Project != "" && loaded.Project != workflow.Project {
+		return nil, e.ErrLoadWorkflowYaml.AddDesc(fmt.Sprintf("project %s in %s does not match %s", loaded.Project, yamlPath, workflow.Project))
+	}
+	loaded.Name = workflow.Name
+	loaded.Project = workflow.Project
+	if err := LintWorkflowV4(loaded, logger); err != nil {
+		return nil, e.ErrLoadWorkflowYaml.AddDesc(fmt.Sprintf("invalid workflow yaml %s: %v", yamlPath, err))
+	}
+
+	loaded.ID = workflow.ID
+	loaded.HookCtls = workflow.HookCtls
+	loaded.NotificationID = workflow.NotificationID
+	loaded.NotifyCtls = workflow.NotifyCtls
+	loaded.Schedules = workflow.Schedules
+	loaded.CreatedBy = workflow.CreatedBy
+	loaded.CreateTime = workflow.CreateTime
+	loaded.UpdatedBy = workflow.UpdatedBy
+	loaded.UpdateTime = workflow.UpdateTime
+	for _, stage := range loaded.Stages {
+		for _, job := range stage.Jobs {
+			if err := jobctl.Instantiate(job, loaded); err != nil {
+				logger.Errorf("Failed to instantiate workflow v4, error: %v", err)
+				return nil, e.ErrLoadWorkflowYaml.AddErr(err)
+			}
+		}
+	}
+	return loaded, nil
+}
+
+// SyncWorkflowV4FromRepo replaces the stored workflow with the one loaded from the main repo of the hook at the ref.
+func SyncWorkflowV4FromRepo(workflow *commonmodels.WorkflowV4, hook *commonmodels.WorkflowV4Hook, ref string, logger *zap.SugaredLogger) (*commonmodels.WorkflowV4, error) {
+	loaded, err := LoadWorkflowV4FromRepo(workflow, hook, ref, logger)
+	if err != nil {
+		return nil, err
+	}
+	loaded.UpdatedBy = setting.WebhookTaskCreator
+	loaded.UpdateTime = time.Now().Unix()
+	if err := commonrepo.NewWorkflowV4Coll().Update(workflow.ID.Hex(), loaded); err != nil {
+		logger.Errorf("Failed to update workflow %s, error: %s", workflow.Name, err)
+		return nil, e.ErrSyncWorkflowYaml.AddErr(err)
+	}
+	logger.Infof("workflow %s is synced from %s at %s", workflow.Name, WorkflowV4YamlPath(workflow.Name, hook), ref)
+	return loaded, nil
+}
+
+// SyncWorkflowV4FromRepoByHook syncs the workflow from the branch of the main repo of the hook.
+func SyncWorkflowV4FromRepoByHook(workflowName, hookName string, logger *zap.SugaredLogger) error {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(workflowName)
+	if err != nil {
+		logger.Errorf("Failed to find WorkflowV4: %s, the error is: %v", workflowName, err)
+		return e.ErrSyncWorkflowYaml.AddErr(err)
+	}
+	for _, hook := range workflow.HookCtls {
+		if hook.Name != hookName {
+			continue
+		}
+		if hook.MainRepo == nil || hook.MainRepo.IsRegular {
+			return e.ErrSyncWorkflowYaml.AddDesc("the branch of the webhook should be a specific one")
+		}
+		_, err := SyncWorkflowV4FromRepo(workflow, hook, hook.MainRepo.Branch, logger)
+		return err
+	}
+	return e.ErrSyncWorkflowYaml.AddDesc(fmt.Sprintf("webhook %s does not exist", hookName))
+}
+
+// lintWorkflowV4YamlHook makes sure the workflow can be loaded from the main repo of the hook.
+func lintWorkflowV4YamlHook(workflow *commonmodels.WorkflowV4, hook *commonmodels.WorkflowV4Hook, logger *zap.SugaredLogger) error {
+	if !hook.IsYaml && !hook.SyncYaml {
+		return nil
+	}
+	if hook.MainRepo == nil {
+		return fmt.Errorf("main repo of the webhook is not set")
+	}
+	ch, err := systemconfig.New().GetCodeHost(hook.MainRepo.CodehostID)
+	if err != nil {
+		return fmt.Errorf("failed to get codehost %d: %v", hook.MainRepo.CodehostID, err)
+	}
+	if ch.Type != setting.SourceFromGitlab && ch.Type != setting.SourceFromGithub {
+		return fmt.Errorf("workflow yaml is only supported in gitlab and github repositories")
+	}
+	if hook.MainRepo.IsRegular {
+		return nil
+	}
+	if _, err := LoadWorkflowV4FromRepo(workflow, hook, hook.MainRepo.Branch, logger); err != nil {
+		return err
+	}
+	return nil
+}
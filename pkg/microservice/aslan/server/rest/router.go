@@ -50,6 +50,7 @@ import (
 	featuresHandler "github.com/koderover/zadig/pkg/microservice/systemconfig/core/features/handler"
 	jiraHandler "github.com/koderover/zadig/pkg/microservice/systemconfig/core/jira/handler"
 	userHandler "github.com/koderover/zadig/pkg/microservice/user/core/handler"
+	"github.com/koderover/zadig/pkg/tool/metrics"
 
 	// Note: have to load docs for swagger to work. See https://blog.csdn.net/weixin_43249914/article/details/103035711
 	_ "github.com/koderover/zadig/pkg/microservice/aslan/server/rest/doc"
@@ -89,6 +90,8 @@ func (s *engine) injectRouterGroup(router *gin.RouterGroup) {
 
 	router.GET("/api/kodespace/downloadUrl", commonhandler.GetToolDownloadURL)
 
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// inject aslan related APIs
 	for name, r := range map[string]injector{
 		"/api/project":       new(projecthandler.Router),
@@ -52,9 +52,11 @@ func (s *engine) injectMiddlewares() {
 	if s.mode == gin.TestMode {
 		return
 	}
+	g.Use(ginmiddleware.Metrics())
 	g.Use(ginmiddleware.OperationLogStatus())
 	g.Use(ginmiddleware.Response())
 	g.Use(ginmiddleware.RequestID())
+	g.Use(ginmiddleware.Tracing())
 	g.Use(ginmiddleware.RequestLog(log.NewFileLogger(config.RequestLogFile())))
 	g.Use(ginmiddleware.GetCollaborationNew())
 	g.Use(gin.Recovery())
@@ -67,6 +67,17 @@ func (c *Client) TriggerCleanProducts(log *zap.SugaredLogger) error {
 	return err
 }
 
+// TriggerDetectEnvDrift triggers the drift detection of the k8s yaml envs
+func (c *Client) TriggerDetectEnvDrift(log *zap.SugaredLogger) error {
+	url := fmt.Sprintf("%s/environment/cron/envdrift", c.APIBase)
+	log.Info("start detect env drift..")
+	err := c.sendRequest(url)
+	if err != nil {
+		log.Errorf("trigger detect env drift error :%v", err)
+	}
+	return err
+}
+
 // TriggerCleanCIResources trigger clean CollaborationInstance Resources
 func (c *Client) TriggerCleanCIResources(log *zap.SugaredLogger) error {
 	url := fmt.Sprintf("%s/collaboration/collaborations/cron/clean", c.APIBase)
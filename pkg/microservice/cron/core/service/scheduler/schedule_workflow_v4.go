@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"reflect"
+	"time"
+
+	newgoCron "github.com/go-co-op/gocron"
+
+	"github.com/koderover/zadig/pkg/shared/client/aslan"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const workflowV4ScheduleTagPrefix = "workflow-v4-schedule-"
+
+// UpsertWorkflowV4Schedules reconciles the cron jobs with the enabled schedules of the workflows,
+// only the added, changed and removed schedules are touched.
+func (c *CronV3Client) UpsertWorkflowV4Schedules() {
+	schedules, err := c.AslanCli.ListWorkflowV4Schedules()
+	if err != nil {
+		log.Errorf("failed to list workflow v4 schedules: %s", err)
+		return
+	}
+
+	current := make(map[string]bool)
+	for _, schedule := range schedules {
+		key := schedule.WorkflowName + "/" + schedule.Name
+		current[key] = true
+		if last, ok := c.lastWorkflowV4Schedules[key]; ok && reflect.DeepEqual(last, schedule) {
+			continue
+		}
+		c.removeWorkflowV4Schedule(key)
+		if err := c.addWorkflowV4Schedule(key, schedule); err != nil {
+			log.Errorf("failed to add schedule %s, cron: %s, timezone: %s, err: %s", key, schedule.Cron, schedule.Timezone, err)
+			continue
+		}
+		log.Infof("schedule %s is added, cron: %s, timezone: %s", key, schedule.Cron, schedule.Timezone)
+	}
+
+	for key := range c.lastWorkflowV4Schedules {
+		if !current[key] {
+			c.removeWorkflowV4Schedule(key)
+			log.Infof("schedule %s is removed", key)
+		}
+	}
+}
+
+func (c *CronV3Client) addWorkflowV4Schedule(key string, schedule *aslan.WorkflowV4Schedule) error {
+	scheduler, err := c.workflowV4Scheduler(schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	workflowName, scheduleName := schedule.WorkflowName, schedule.Name
+	_, err = scheduler.Cron(schedule.Cron).Tag(workflowV4ScheduleTagPrefix + key).Do(func() {
+		log.Infof("trigger schedule %s of workflow %s", scheduleName, workflowName)
+		resp, err := c.AslanCli.RunWorkflowV4Schedule(workflowName, scheduleName)
+		if err != nil {
+			log.Errorf("failed to run schedule %s of workflow %s: %s", scheduleName, workflowName, err)
+			return
+		}
+		if resp.Skipped {
+			log.Infof("skipped schedule %s of workflow %s: %s", scheduleName, workflowName, resp.SkipReason)
+			return
+		}
+		log.Infof("created task %d of workflow %s by schedule %s", resp.TaskID, workflowName, scheduleName)
+	})
+	if err != nil {
+		return err
+	}
+	c.lastWorkflowV4Schedules[key] = schedule
+	return nil
+}
+
+func (c *CronV3Client) removeWorkflowV4Schedule(key string) {
+	last, ok := c.lastWorkflowV4Schedules[key]
+	if !ok {
+		return
+	}
+	if scheduler, ok := c.workflowV4Schedulers[last.Timezone]; ok {
+		_ = scheduler.RemoveByTag(workflowV4ScheduleTagPrefix + key)
+	}
+	delete(c.lastWorkflowV4Schedules, key)
+}
+
+// workflowV4Scheduler returns the scheduler of the time zone, cron expressions are always parsed in the location of the scheduler.
+func (c *CronV3Client) workflowV4Scheduler(timezone string) (*newgoCron.Scheduler, error) {
+	if scheduler, ok := c.workflowV4Schedulers[timezone]; ok {
+		return scheduler, nil
+	}
+	// an empty time zone is the local time zone of the cron service, time.LoadLocation treats it as UTC.
+	location := time.Local
+	if timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	scheduler := newgoCron.NewScheduler(location)
+	scheduler.StartAsync()
+	c.workflowV4Schedulers[timezone] = scheduler
+	return scheduler, nil
+}
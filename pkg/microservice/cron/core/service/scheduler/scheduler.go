@@ -58,12 +58,17 @@ type CronClient struct {
 type CronV3Client struct {
 	Scheduler *newgoCron.Scheduler
 	AslanCli  *aslan.Client
+	// schedulers of the workflow v4 schedules by time zone.
+	workflowV4Schedulers    map[string]*newgoCron.Scheduler
+	lastWorkflowV4Schedules map[string]*aslan.WorkflowV4Schedule
 }
 
 func NewCronV3() *CronV3Client {
 	return &CronV3Client{
-		Scheduler: newgoCron.NewScheduler(time.Local),
-		AslanCli:  aslan.New(configbase.AslanServiceAddress()),
+		Scheduler:               newgoCron.NewScheduler(time.Local),
+		AslanCli:                aslan.New(configbase.AslanServiceAddress()),
+		workflowV4Schedulers:    make(map[string]*newgoCron.Scheduler),
+		lastWorkflowV4Schedules: make(map[string]*aslan.WorkflowV4Schedule),
 	}
 }
 
@@ -101,6 +106,8 @@ func (c *CronV3Client) Start() {
 		}
 	})
 
+	c.Scheduler.Every(30).Seconds().SingletonMode().Do(c.UpsertWorkflowV4Schedules)
+
 	c.Scheduler.StartAsync()
 }
 
@@ -133,6 +140,8 @@ const (
 	InitHelmEnvSyncValuesScheduler = "InitHelmEnvSyncValuesScheduler"
 
 	EnvResourceSyncScheduler = "EnvResourceSyncScheduler"
+
+	EnvDriftScheduler = "EnvDriftScheduler"
 )
 
 // NewCronClient ...
@@ -208,6 +217,8 @@ func (c *CronClient) Init() {
 	c.InitHelmEnvSyncValuesScheduler()
 	// sync env resources from git at regular intervals
 	c.InitEnvResourceSyncScheduler()
+	// detect the drift between the envs and the clusters at regular intervals
+	c.InitEnvDriftScheduler()
 }
 
 func (c *CronClient) InitCleanJobScheduler() {
@@ -324,3 +335,11 @@ func (c *CronClient) InitEnvResourceSyncScheduler() {
 
 	c.Schedulers[EnvResourceSyncScheduler].Start()
 }
+
+func (c *CronClient) InitEnvDriftScheduler() {
+	c.Schedulers[EnvDriftScheduler] = gocron.NewScheduler()
+
+	c.Schedulers[EnvDriftScheduler].Every(10).Minutes().Do(c.AslanCli.TriggerDetectEnvDrift, c.log)
+
+	c.Schedulers[EnvDriftScheduler].Start()
+}
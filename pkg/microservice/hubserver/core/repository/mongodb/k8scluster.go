@@ -27,6 +27,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/hubserver/config"
 	"github.com/koderover/zadig/pkg/microservice/hubserver/core/repository/models"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
 )
 
 type K8sClusterColl struct {
@@ -62,7 +63,7 @@ func (c *K8sClusterColl) FindConnectedClusters() ([]*models.K8SCluster, error) {
 		return nil, err
 	}
 
-	return resp, err
+	return resp, openClusters(resp...)
 }
 
 func (c *K8sClusterColl) UpdateStatus(cluster *models.K8SCluster) error {
@@ -109,5 +110,20 @@ func (c *K8sClusterColl) Get(id string) (*models.K8SCluster, error) {
 	res := &models.K8SCluster{}
 
 	err = c.FindOne(context.TODO(), query).Decode(res)
-	return res, err
+	if err != nil {
+		return res, err
+	}
+	return res, openClusters(res)
+}
+
+// openClusters replaces the references to the kube configs with the plaintext.
+func openClusters(clusters ...*models.K8SCluster) error {
+	for _, cluster := range clusters {
+		plaintext, err := secretstore.Open(cluster.KubeConfig)
+		if err != nil {
+			return err
+		}
+		cluster.KubeConfig = plaintext
+	}
+	return nil
 }
@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var (
+	tunnelSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "hubserver",
+		Name:      "tunnel_sessions",
+		Help:      "Number of clusters connected with a tunnel session.",
+	})
+
+	tunnelConnectsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "hubserver",
+		Name:      "tunnel_connects_total",
+		Help:      "Number of tunnel connections of the clusters, reconnect is true if the cluster has connected before.",
+	}, []string{"cluster", "reconnect"})
+)
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -81,6 +82,8 @@ func Authorize(req *http.Request) (clientKey string, authed bool, err error) {
 	input.Cluster.ClusterID = cluster.ID.Hex()
 	input.Cluster.Joined = time.Now()
 
+	_, reconnect := clusters.Load(cluster.ID.Hex())
+	tunnelConnectsTotal.WithLabelValues(cluster.Name, strconv.FormatBool(reconnect)).Inc()
 	clusters.Store(cluster.ID.Hex(), input.Cluster)
 
 	cluster.Status = "normal"
@@ -258,12 +261,14 @@ func Sync(server *remotedialer.Server, stopCh <-chan struct{}) {
 					return
 				}
 
+				var sessions int
 				for _, cluster := range clusterInfos {
 					if cluster.Type == setting.KubeConfigClusterType {
 						continue
 					}
 					statusChanged := false
 					if _, ok := clusters.Load(cluster.ID.Hex()); ok && server.HasSession(cluster.ID.Hex()) {
+						sessions++
 						if cluster.Status != config.Normal {
 							log.Infof(
 								"cluster %s connected changed %s => %s",
@@ -290,6 +295,7 @@ func Sync(server *remotedialer.Server, stopCh <-chan struct{}) {
 						}
 					}
 				}
+				tunnelSessions.Set(float64(sessions))
 			}()
 		case <-stopCh:
 			return
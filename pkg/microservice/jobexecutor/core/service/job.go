@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -29,7 +30,12 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/config"
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/meta"
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/step"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/types/job"
+	typesstep "github.com/koderover/zadig/pkg/types/step"
 	"gopkg.in/yaml.v3"
 )
 
@@ -122,11 +128,30 @@ func (j *Job) getUserEnvs() []string {
 	return envs
 }
 
-func (j *Job) Run(ctx context.Context) error {
+func (j *Job) Run(ctx context.Context) (err error) {
+	if err := tracing.Init(j.Ctx.Tracing); err != nil {
+		log.Warnf("Failed to init tracing: %s", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracing.Shutdown(flushCtx); err != nil {
+			log.Warnf("Failed to flush spans: %s", err)
+		}
+	}()
+	ctx, span := tracing.Start(tracing.Extract(ctx, j.Ctx.TraceContext), "run steps")
+	defer func() { tracing.End(span, err) }()
+
 	if err := os.MkdirAll(job.JobOutputDir, os.ModePerm); err != nil {
 		return err
 	}
 	if err := step.RunSteps(ctx, j.Ctx.Steps, j.ActiveWorkspace, j.Ctx.Paths, j.getUserEnvs(), j.Ctx.SecretEnvs); err != nil {
+		if j.Ctx.KeepPodOnFailure {
+			fmt.Printf("Failed to run: %s.\n", err)
+			if pauseErr := step.Pause(ctx, job.BreakpointFailure, ""); pauseErr != nil {
+				log.Warnf("Failed to keep the pod after the job failed: %s", pauseErr)
+			}
+		}
 		return err
 	}
 	return nil
@@ -151,7 +176,16 @@ func (j *Job) collectJobResult(ctx context.Context) error {
 		return err
 	}
 
-	if len(jsonOutput) > MaxContainerTerminationMessageLength {
+	if j.Ctx.OutputsStorage != nil {
+		if err := uploadOutputs(j.Ctx.OutputsStorage, jsonOutput); err != nil {
+			return fmt.Errorf("failed to upload outputs: %v", err)
+		}
+		// aslan gets the outputs from the object storage, the termination message is only a fallback,
+		// it tells aslan the outputs are only in the object storage if they are too large for it.
+		if len(jsonOutput) > MaxContainerTerminationMessageLength {
+			jsonOutput = []byte(job.JobOutputsInStorage)
+		}
+	} else if len(jsonOutput) > MaxContainerTerminationMessageLength {
 		return fmt.Errorf("termination message is above max allowed size 4096, caused by large task result")
 	}
 
@@ -166,3 +200,27 @@ func (j *Job) collectJobResult(ctx context.Context) error {
 	}
 	return f.Sync()
 }
+
+func uploadOutputs(storage *typesstep.S3, outputs []byte) error {
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 client: %v", err)
+	}
+
+	f, err := ioutil.TempFile("", "outputs")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Write(outputs); err != nil {
+		return err
+	}
+
+	objectKey := strings.TrimLeft(path.Join(storage.Subfolder, job.JobOutputsFile), "/")
+	return client.Upload(storage.Bucket, f.Name(), objectKey)
+}
@@ -16,6 +16,11 @@ limitations under the License.
 
 package meta
 
+import (
+	"github.com/koderover/zadig/pkg/tool/tracing"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
 type JobContext struct {
 	Name string `yaml:"name"`
 	// Workspace 容器工作目录 [必填]
@@ -33,12 +38,23 @@ type JobContext struct {
 
 	Steps   []*Step  `yaml:"steps"`
 	Outputs []string `yaml:"outputs"`
+	// OutputsStorage 输出变量上传的对象存储, 为空时通过 termination message 返回 [optional]
+	OutputsStorage *step.S3 `yaml:"outputs_storage"`
+	// KeepPodOnFailure pauses the job executor after the job failed, so the pod can be debugged.
+	KeepPodOnFailure bool `yaml:"keep_pod_on_failure"`
+	// TraceContext is the span of the job in aslan, spans of the steps are its children.
+	TraceContext map[string]string `yaml:"trace_context,omitempty"`
+	// Tracing is the exporter of the spans, tracing is disabled if it is nil.
+	Tracing *tracing.Config `yaml:"tracing,omitempty"`
 }
 
 type Step struct {
 	Name     string      `yaml:"name"`
 	StepType string      `yaml:"type"`
 	Spec     interface{} `yaml:"spec"`
+	// pause the job executor before or after running the step.
+	BreakpointBefore bool `yaml:"breakpoint_before"`
+	BreakpointAfter  bool `yaml:"breakpoint_after"`
 }
 
 type EnvVar []string
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types/job"
+)
+
+// Pause blocks at a breakpoint until the job is resumed or aborted in the workflow task, or the breakpoint times out.
+// The job pod can be attached with the pod terminal while it's paused.
+func Pause(ctx context.Context, position, stepName string) error {
+	content, err := json.Marshal(&job.Breakpoint{
+		Position: position,
+		StepName: stepName,
+		PausedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(job.BreakpointFile), os.ModePerm); err != nil {
+		return err
+	}
+	// files left by an earlier breakpoint must not resume this one.
+	cleanBreakpointFiles()
+	if err := ioutil.WriteFile(job.BreakpointFile, content, 0644); err != nil {
+		return err
+	}
+	defer cleanBreakpointFiles()
+
+	if stepName == "" {
+		fmt.Printf("Paused after the job failed, resume or abort the job to go on.\n")
+	} else {
+		fmt.Printf("Paused %s step %s, resume or abort the job to go on.\n", position, stepName)
+	}
+
+	timeout := time.After(job.BreakpointTimeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			fmt.Printf("Breakpoint timed out after %s, resumed.\n", job.BreakpointTimeout)
+			return nil
+		case <-ticker.C:
+			if fileExists(job.BreakpointAbortFile) {
+				fmt.Printf("Aborted at the breakpoint.\n")
+				return fmt.Errorf("job aborted at breakpoint %s step %s", position, stepName)
+			}
+			if fileExists(job.BreakpointResumeFile) {
+				fmt.Printf("Resumed.\n")
+				return nil
+			}
+		}
+	}
+}
+
+func cleanBreakpointFiles() {
+	for _, file := range []string{job.BreakpointFile, job.BreakpointResumeFile, job.BreakpointAbortFile} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove %s: %s", file, err)
+		}
+	}
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
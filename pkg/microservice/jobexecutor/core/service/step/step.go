@@ -24,10 +24,14 @@ import (
 	"os"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/config"
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/cmd"
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/meta"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
+	"github.com/koderover/zadig/pkg/types/job"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -36,13 +40,36 @@ type Step interface {
 }
 
 func RunSteps(ctx context.Context, steps []*meta.Step, workspace, paths string, envs, secretEnvs []string) error {
+	var stepErr error
 	for _, stepInfo := range steps {
-		if err := runStep(ctx, stepInfo, workspace, paths, envs, secretEnvs); err != nil {
-			return err
+		// test reports are still collected after a step failed, failed tests are what they report.
+		if stepErr != nil && !isReportStep(stepInfo.StepType) {
+			continue
+		}
+		if stepInfo.BreakpointBefore {
+			if err := Pause(ctx, job.BreakpointBefore, stepInfo.Name); err != nil {
+				return err
+			}
+		}
+		stepCtx, span := tracing.Start(ctx, "step "+stepInfo.Name, attribute.String("zadig.step_type", stepInfo.StepType))
+		err := runStep(stepCtx, stepInfo, workspace, paths, envs, secretEnvs)
+		tracing.End(span, err)
+		if err != nil && stepErr == nil {
+			stepErr = err
+		}
+		if stepInfo.BreakpointAfter {
+			if err := Pause(ctx, job.BreakpointAfter, stepInfo.Name); err != nil {
+				return err
+			}
 		}
 	}
-	return nil
+	return stepErr
 }
+
+func isReportStep(stepType string) bool {
+	return stepType == "junit_report" || stepType == "html_report"
+}
+
 func runStep(ctx context.Context, step *meta.Step, workspace, paths string, envs, secretEnvs []string) error {
 	var stepInstance Step
 	var err error
@@ -73,6 +100,26 @@ func runStep(ctx context.Context, step *meta.Step, workspace, paths string, envs
 		if err != nil {
 			return err
 		}
+	case "image_distribute":
+		stepInstance, err = NewImageDistributeStep(step.Spec, workspace, envs, secretEnvs)
+		if err != nil {
+			return err
+		}
+	case "archive_distribute":
+		stepInstance, err = NewArchiveDistributeStep(step.Spec, workspace, envs, secretEnvs)
+		if err != nil {
+			return err
+		}
+	case "junit_report":
+		stepInstance, err = NewJunitReportStep(step.Spec, workspace, envs, secretEnvs)
+		if err != nil {
+			return err
+		}
+	case "html_report":
+		stepInstance, err = NewHtmlReportStep(step.Spec, workspace, envs, secretEnvs)
+		if err != nil {
+			return err
+		}
 	default:
 		err := fmt.Errorf("step type: %s does not match any known type", step.StepType)
 		log.Error(err)
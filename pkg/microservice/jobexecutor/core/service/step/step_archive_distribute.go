@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/kodo"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type ArchiveDistributeStep struct {
+	spec       *step.StepArchiveDistributeSpec
+	envs       []string
+	secretEnvs []string
+	workspace  string
+}
+
+func NewArchiveDistributeStep(spec interface{}, workspace string, envs, secretEnvs []string) (*ArchiveDistributeStep, error) {
+	archiveDistributeStep := &ArchiveDistributeStep{workspace: workspace, envs: envs, secretEnvs: secretEnvs}
+	yamlBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return archiveDistributeStep, fmt.Errorf("marshal spec %+v failed", spec)
+	}
+	if err := yaml.Unmarshal(yamlBytes, &archiveDistributeStep.spec); err != nil {
+		return archiveDistributeStep, fmt.Errorf("unmarshal spec %s to archive distribute spec failed", yamlBytes)
+	}
+	return archiveDistributeStep, nil
+}
+
+// Run uploads the files with their md5 to every target storage, just like the distribute plugin of the old pipelines.
+func (s *ArchiveDistributeStep) Run(ctx context.Context) error {
+	start := time.Now()
+	log.Info("Start distribute archives.")
+	defer func() {
+		log.Infof("Distribute archives ended. Duration: %.2f seconds.", time.Since(start).Seconds())
+	}()
+
+	envmaps := envsToMap(s.envs, s.secretEnvs)
+	urls := []string{}
+	for _, upload := range s.spec.UploadDetail {
+		if upload.FilePath == "" {
+			continue
+		}
+		localFile := filepath.Join(s.workspace, replaceEnvWithValue(upload.FilePath, envmaps))
+		info, err := os.Stat(localFile)
+		if err != nil {
+			return fmt.Errorf("failed to distribute file %s: %s", localFile, err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("failed to distribute %s: only files can be distributed", localFile)
+		}
+		md5File, err := writeMd5File(localFile)
+		if err != nil {
+			return fmt.Errorf("failed to generate md5 of file %s: %s", localFile, err)
+		}
+
+		for _, target := range s.spec.Targets {
+			if target.S3 == nil {
+				continue
+			}
+			key := strings.TrimLeft(path.Join(target.S3.Subfolder, replaceEnvWithValue(target.DestinationPath, envmaps), info.Name()), "/")
+			if err := uploadToStorage(target.S3, localFile, key); err != nil {
+				_ = os.Remove(md5File)
+				return fmt.Errorf("failed to upload file %s to %s: %s", localFile, target.S3.Bucket, err)
+			}
+			if err := uploadToStorage(target.S3, md5File, key+".md5"); err != nil {
+				_ = os.Remove(md5File)
+				return fmt.Errorf("failed to upload md5 of file %s to %s: %s", localFile, target.S3.Bucket, err)
+			}
+			objectURL := storageObjectURL(target.S3, key)
+			log.Infof("File %s is distributed to %s.", localFile, objectURL)
+			urls = append(urls, objectURL)
+		}
+		_ = os.Remove(md5File)
+	}
+
+	if s.spec.URLsOutput != "" {
+		return writeJobOutput(s.spec.URLsOutput, strings.Join(urls, ","))
+	}
+	return nil
+}
+
+func writeMd5File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	md5File, err := ioutil.TempFile("", "md5")
+	if err != nil {
+		return "", err
+	}
+	defer md5File.Close()
+	if _, err := md5File.WriteString(fmt.Sprintf("%x", h.Sum(nil))); err != nil {
+		return "", err
+	}
+	return md5File.Name(), nil
+}
+
+// isKodo reports whether the storage is qiniu kodo, which is not compatible with the s3 api on uploading.
+func isKodo(storage *step.S3) bool {
+	return strings.Contains(storage.Endpoint, "qiniucs.com")
+}
+
+func uploadToStorage(storage *step.S3, file, key string) error {
+	if isKodo(storage) {
+		client, err := kodo.NewUploadClient(storage.Ak, storage.Sk, storage.Bucket)
+		if err != nil {
+			return err
+		}
+		_, _, err = client.UploadFile(key, file)
+		return err
+	}
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return fmt.Errorf("failed to create s3 client: %s", err)
+	}
+	return client.Upload(storage.Bucket, file, key)
+}
+
+func storageObjectURL(storage *step.S3, key string) string {
+	protocol := "https"
+	if storage.Insecure {
+		protocol = "http"
+	}
+	endpoint := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(storage.Endpoint, "http://"), "https://"), "/")
+	// the endpoint of kodo is the domain of the bucket.
+	if isKodo(storage) {
+		return fmt.Sprintf("%s://%s/%s", protocol, endpoint, key)
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", protocol, endpoint, storage.Bucket, key)
+}
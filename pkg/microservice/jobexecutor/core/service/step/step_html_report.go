@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type HtmlReportStep struct {
+	spec       *step.StepHtmlReportSpec
+	envs       []string
+	secretEnvs []string
+	workspace  string
+}
+
+func NewHtmlReportStep(spec interface{}, workspace string, envs, secretEnvs []string) (*HtmlReportStep, error) {
+	htmlReportStep := &HtmlReportStep{workspace: workspace, envs: envs, secretEnvs: secretEnvs}
+	yamlBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return htmlReportStep, fmt.Errorf("marshal spec %+v failed", spec)
+	}
+	if err := yaml.Unmarshal(yamlBytes, &htmlReportStep.spec); err != nil {
+		return htmlReportStep, fmt.Errorf("unmarshal spec %s to html report spec failed", yamlBytes)
+	}
+	return htmlReportStep, nil
+}
+
+func (s *HtmlReportStep) Run(ctx context.Context) error {
+	start := time.Now()
+	log.Info("Start archive html test report.")
+	defer func() {
+		log.Infof("Archive html test report ended. Duration: %.2f seconds", time.Since(start).Seconds())
+	}()
+
+	envmaps := envsToMap(s.envs, s.secretEnvs)
+	reportDir := filepath.Join(s.workspace, replaceEnvWithValue(s.spec.ReportDir, envmaps))
+	reportFile := filepath.Join(reportDir, replaceEnvWithValue(s.spec.ReportFile, envmaps))
+	if _, err := os.Stat(reportFile); err != nil {
+		// the tests may fail before the report is generated, do not cover the real error.
+		log.Warnf("Html test report %s not found.", reportFile)
+		return nil
+	}
+	// the whole directory is uploaded, so the styles and scripts quoted by the entry file are kept.
+	return uploadReportDir(s.spec.S3, reportDir, path.Join(s.spec.S3DestDir, s.spec.FileName))
+}
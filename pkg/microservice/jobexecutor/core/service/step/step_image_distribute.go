@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type ImageDistributeStep struct {
+	spec       *step.StepImageDistributeSpec
+	envs       []string
+	secretEnvs []string
+	workspace  string
+}
+
+func NewImageDistributeStep(spec interface{}, workspace string, envs, secretEnvs []string) (*ImageDistributeStep, error) {
+	imageDistributeStep := &ImageDistributeStep{workspace: workspace, envs: envs, secretEnvs: secretEnvs}
+	yamlBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return imageDistributeStep, fmt.Errorf("marshal spec %+v failed", spec)
+	}
+	if err := yaml.Unmarshal(yamlBytes, &imageDistributeStep.spec); err != nil {
+		return imageDistributeStep, fmt.Errorf("unmarshal spec %s to image distribute spec failed", yamlBytes)
+	}
+	return imageDistributeStep, nil
+}
+
+// Run pulls the source image, then retags and pushes it to every target registry.
+func (s *ImageDistributeStep) Run(ctx context.Context) error {
+	start := time.Now()
+	log.Info("Start distribute image.")
+	defer func() {
+		log.Infof("Distribute image ended. Duration: %.2f seconds.", time.Since(start).Seconds())
+	}()
+
+	sourceImage := replaceEnvWithValue(s.spec.SourceImage, envsToMap(s.envs, s.secretEnvs))
+	if sourceImage == "" {
+		return fmt.Errorf("source image is empty")
+	}
+	if err := s.login(s.spec.SourceRegistry); err != nil {
+		return err
+	}
+	if err := s.runDockerCmd(dockerPull(sourceImage)); err != nil {
+		return fmt.Errorf("failed to pull image %s: %s", sourceImage, err)
+	}
+
+	targetImages := []string{}
+	for _, target := range s.spec.Targets {
+		if target.DockerRegistry == nil {
+			continue
+		}
+		targetImage, err := distributeImageName(sourceImage, target.DockerRegistry, target.Tag)
+		if err != nil {
+			return err
+		}
+		if err := s.login(target.DockerRegistry); err != nil {
+			return err
+		}
+		if err := s.runDockerCmd(dockerTag(sourceImage, targetImage)); err != nil {
+			return fmt.Errorf("failed to tag image %s: %s", targetImage, err)
+		}
+		if err := s.runDockerCmd(dockerPush(targetImage)); err != nil {
+			return fmt.Errorf("failed to push image %s: %s", targetImage, err)
+		}
+		log.Infof("Image %s is distributed to %s.", sourceImage, targetImage)
+		targetImages = append(targetImages, targetImage)
+	}
+
+	if s.spec.DigestOutput != "" {
+		digest, err := s.imageDigest(sourceImage)
+		if err != nil {
+			log.Warnf("Failed to get the digest of image %s: %s", sourceImage, err)
+		}
+		if err := writeJobOutput(s.spec.DigestOutput, digest); err != nil {
+			return err
+		}
+	}
+	if s.spec.ImagesOutput != "" {
+		if err := writeJobOutput(s.spec.ImagesOutput, strings.Join(targetImages, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ImageDistributeStep) login(registry *step.DockerRegistry) error {
+	if registry == nil || registry.UserName == "" {
+		return nil
+	}
+	fmt.Printf("Logining Docker Registry: %s.\n", registry.Host)
+	cmd := dockerLogin(registry.UserName, registry.Password, registry.Host)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.Env = s.envs
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to login docker registry %s: %s %s", registry.Host, err, out.String())
+	}
+	return nil
+}
+
+func (s *ImageDistributeStep) runDockerCmd(cmd *exec.Cmd) error {
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir = s.workspace
+	cmd.Env = s.envs
+	log.Info(strings.Join(cmd.Args, " "))
+	return cmd.Run()
+}
+
+// distributeImageName returns the name of the source image in the target registry,
+// e.g. harbor.a.com/ns/app:v1 is distributed to harbor.b.com/prod/app:v1.
+// A digest can not be pushed to, so an image pinned only by digest such as app@sha256:abc needs the target tag.
+func distributeImageName(sourceImage string, registry *step.DockerRegistry, tag string) (string, error) {
+	name, digest := sourceImage, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	sourceTag := ""
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name, sourceTag = name[:i], name[i+1:]
+	}
+	if tag == "" {
+		tag = sourceTag
+	}
+	if tag == "" {
+		if digest != "" {
+			return "", fmt.Errorf("image %s is pinned by digest, the target tag is required", sourceImage)
+		}
+		tag = "latest"
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(registry.Host, "http://"), "https://")
+	host = strings.TrimSuffix(host, "/")
+	if registry.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s:%s", host, registry.Namespace, name, tag), nil
+	}
+	return fmt.Sprintf("%s/%s:%s", host, name, tag), nil
+}
+
+// imageDigest returns the sha256 digest of the image pulled from or pushed to a registry.
+func (s *ImageDistributeStep) imageDigest(image string) (string, error) {
+	cmd := exec.Command(dockerExe, "inspect", "--format", "{{index .RepoDigests 0}}", image)
+	cmd.Env = s.envs
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	repoDigest := strings.TrimSpace(string(out))
+	if i := strings.LastIndex(repoDigest, "@"); i >= 0 {
+		return repoDigest[i+1:], nil
+	}
+	return repoDigest, nil
+}
+
+func dockerPull(fullImage string) *exec.Cmd {
+	return exec.Command(dockerExe, "pull", fullImage)
+}
+
+func dockerTag(sourceImage, targetImage string) *exec.Cmd {
+	return exec.Command(dockerExe, "tag", sourceImage, targetImage)
+}
+
+// writeJobOutput saves the output to where jobexecutor collects the job outputs from.
+func writeJobOutput(name, value string) error {
+	if err := os.MkdirAll(job.JobOutputDir, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(job.JobOutputDir, name), []byte(value), 0644)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+var _ = Describe("distributeImageName", func() {
+	DescribeTable("renames the source image to the target registry",
+		func(sourceImage string, registry *step.DockerRegistry, tag, expected string) {
+			targetImage, err := distributeImageName(sourceImage, registry, tag)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targetImage).To(Equal(expected))
+		},
+		Entry("keeps the tag", "harbor.a.com/dev/app:v1", &step.DockerRegistry{Host: "https://harbor.b.com", Namespace: "prod"}, "", "harbor.b.com/prod/app:v1"),
+		Entry("uses the target tag", "harbor.a.com/dev/app:v1", &step.DockerRegistry{Host: "harbor.b.com", Namespace: "prod"}, "release", "harbor.b.com/prod/app:release"),
+		Entry("defaults to latest", "app", &step.DockerRegistry{Host: "harbor.b.com/"}, "", "harbor.b.com/app:latest"),
+		Entry("keeps the port of the source registry out", "localhost:5000/app:v2", &step.DockerRegistry{Host: "http://harbor.b.com", Namespace: "ns"}, "", "harbor.b.com/ns/app:v2"),
+		Entry("strips the digest", "harbor.a.com/dev/app:v1@sha256:abc", &step.DockerRegistry{Host: "harbor.b.com", Namespace: "prod"}, "", "harbor.b.com/prod/app:v1"),
+		Entry("uses the target tag of a digest", "localhost:5000/app@sha256:abc", &step.DockerRegistry{Host: "harbor.b.com", Namespace: "prod"}, "release", "harbor.b.com/prod/app:release"),
+	)
+
+	It("requires the target tag of an image pinned by digest", func() {
+		_, err := distributeImageName("harbor.a.com/dev/app@sha256:abc", &step.DockerRegistry{Host: "harbor.b.com"}, "")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/meta"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+type JunitReportStep struct {
+	spec       *step.StepJunitReportSpec
+	envs       []string
+	secretEnvs []string
+	workspace  string
+}
+
+func NewJunitReportStep(spec interface{}, workspace string, envs, secretEnvs []string) (*JunitReportStep, error) {
+	junitReportStep := &JunitReportStep{workspace: workspace, envs: envs, secretEnvs: secretEnvs}
+	yamlBytes, err := yaml.Marshal(spec)
+	if err != nil {
+		return junitReportStep, fmt.Errorf("marshal spec %+v failed", spec)
+	}
+	if err := yaml.Unmarshal(yamlBytes, &junitReportStep.spec); err != nil {
+		return junitReportStep, fmt.Errorf("unmarshal spec %s to junit report spec failed", yamlBytes)
+	}
+	return junitReportStep, nil
+}
+
+func (s *JunitReportStep) Run(ctx context.Context) error {
+	start := time.Now()
+	log.Info("Start merge junit test reports.")
+	defer func() {
+		log.Infof("Merge junit test reports ended. Duration: %.2f seconds", time.Since(start).Seconds())
+	}()
+
+	reportDir := replaceEnvWithValue(s.spec.ReportDir, envsToMap(s.envs, s.secretEnvs))
+	reportDir = filepath.Join(s.workspace, reportDir)
+	summary, err := mergeJunitReports(reportDir)
+	if err != nil {
+		// the tests may fail before any report is generated, do not cover the real error.
+		log.Warnf("Failed to merge junit test reports in %s: %s", reportDir, err)
+		return nil
+	}
+	log.Infof("Total tests: %d, successes: %d, failures: %d, errors: %d, skips: %d.",
+		summary.Tests, summary.Successes, summary.Failures, summary.Errors, summary.Skips)
+
+	buf := bytes.NewBufferString(xml.Header)
+	encoder := xml.NewEncoder(buf)
+	encoder.Indent("  ", "    ")
+	if err := encoder.EncodeElement(summary, xml.StartElement{Name: xml.Name{Local: "testsuite"}}); err != nil {
+		return fmt.Errorf("failed to marshal merged junit test report: %s", err)
+	}
+
+	mergedFile := filepath.Join(os.TempDir(), s.spec.FileName)
+	if err := ioutil.WriteFile(mergedFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write merged junit test report: %s", err)
+	}
+	return uploadReport(s.spec.S3, mergedFile, s.spec.S3DestDir, s.spec.FileName)
+}
+
+// mergeJunitReports merges all the junit xml files in the directory into one test suite.
+func mergeJunitReports(reportDir string) (*meta.TestSuite, error) {
+	summary := &meta.TestSuite{TestCases: []meta.TestCase{}}
+	files, err := ioutil.ReadDir(reportDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	found := false
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".xml" {
+			continue
+		}
+		filePath := filepath.Join(reportDir, file.Name())
+		xmlBytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Warnf("Read file [%s], error: %v", filePath, err)
+			continue
+		}
+
+		suites := []*meta.TestSuite{}
+		if strings.Contains(strings.ToLower(string(xmlBytes)), "<testsuites") {
+			results := &meta.TestSuites{}
+			if err := xml.Unmarshal(xmlBytes, results); err != nil {
+				log.Warnf("Unmarshal xml file [%s], error: %v", filePath, err)
+				continue
+			}
+			suites = append(suites, results.TestSuites...)
+		} else {
+			result := &meta.TestSuite{}
+			if err := xml.Unmarshal(xmlBytes, result); err != nil {
+				log.Warnf("Unmarshal xml file [%s], error: %v", filePath, err)
+				continue
+			}
+			suites = append(suites, result)
+		}
+		found = true
+		for _, suite := range suites {
+			summary.Time += suite.Time
+			summary.TestCases = append(summary.TestCases, suite.TestCases...)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no junit xml file found")
+	}
+
+	// count by the test cases, the attributes of the test suites differ from tools.
+	for _, tc := range summary.TestCases {
+		summary.Tests++
+		switch {
+		case tc.Failure != nil:
+			summary.Failures++
+		case tc.Error != nil:
+			summary.Errors++
+		case tc.Skipped != nil:
+			summary.Skips++
+		default:
+			summary.Successes++
+		}
+	}
+	return summary, nil
+}
+
+func uploadReport(storage *step.S3, file, destDir, fileName string) error {
+	client, err := newReportS3Client(storage)
+	if err != nil {
+		return err
+	}
+	objectKey := strings.TrimLeft(path.Join(storage.Subfolder, destDir, fileName), "/")
+	if err := client.Upload(storage.Bucket, file, objectKey); err != nil {
+		return fmt.Errorf("failed to upload report %s: %s", file, err)
+	}
+	log.Infof("Upload report %s to %s.", file, objectKey)
+	return nil
+}
+
+func uploadReportDir(storage *step.S3, dir, destDir string) error {
+	client, err := newReportS3Client(storage)
+	if err != nil {
+		return err
+	}
+	objectKey := strings.TrimLeft(path.Join(storage.Subfolder, destDir), "/")
+	if err := client.UploadDir(storage.Bucket, dir, objectKey); err != nil {
+		return fmt.Errorf("failed to upload report directory %s: %s", dir, err)
+	}
+	log.Infof("Upload report directory %s to %s.", dir, objectKey)
+	return nil
+}
+
+func newReportS3Client(storage *step.S3) (*s3.Client, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("no object storage to upload the report")
+	}
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client to upload report, err: %s", err)
+	}
+	return client, nil
+}
+
+func envsToMap(envs, secretEnvs []string) map[string]string {
+	envmaps := make(map[string]string)
+	for _, env := range append(envs, secretEnvs...) {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		envmaps[kv[0]] = kv[1]
+	}
+	return envmaps
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const testSuiteXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="a" tests="3" time="1.5">
+  <testcase name="passed" classname="a" time="0.5"></testcase>
+  <testcase name="failed" classname="a" time="0.5"><failure message="expected 1" type="assert">stack</failure></testcase>
+  <testcase name="skipped" classname="a" time="0.5"><skipped></skipped></testcase>
+</testsuite>`
+
+const testSuitesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="b" time="2">
+    <testcase name="errored" classname="b" time="1"><error message="panic" type="runtime"></error></testcase>
+    <testcase name="passed" classname="b" time="1"></testcase>
+  </testsuite>
+</testsuites>`
+
+var _ = Describe("mergeJunitReports", func() {
+	var reportDir string
+
+	BeforeEach(func() {
+		var err error
+		reportDir, err = ioutil.TempDir("", "junit")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(reportDir)
+	})
+
+	It("merges testsuite and testsuites files counting by test cases", func() {
+		Expect(ioutil.WriteFile(filepath.Join(reportDir, "a.xml"), []byte(testSuiteXML), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(reportDir, "b.xml"), []byte(testSuitesXML), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(reportDir, "c.txt"), []byte("not a report"), 0644)).To(Succeed())
+
+		summary, err := mergeJunitReports(reportDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(summary.Tests).To(Equal(5))
+		Expect(summary.Successes).To(Equal(2))
+		Expect(summary.Failures).To(Equal(1))
+		Expect(summary.Errors).To(Equal(1))
+		Expect(summary.Skips).To(Equal(1))
+		Expect(summary.Time).To(Equal(3.5))
+	})
+
+	It("returns an error if there is no report", func() {
+		_, err := mergeJunitReports(reportDir)
+		Expect(err).To(HaveOccurred())
+	})
+})
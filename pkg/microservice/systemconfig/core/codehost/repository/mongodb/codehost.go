@@ -18,9 +18,11 @@ package mongodb
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/config"
@@ -28,6 +30,7 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	"github.com/koderover/zadig/pkg/tool/secretstore"
 )
 
 type CodehostColl struct {
@@ -56,9 +59,72 @@ func (c *CodehostColl) EnsureIndex(ctx context.Context) error {
 	return nil
 }
 
+// secretFields returns the credentials of the code host, they are saved in the secret store.
+func secretFields(host *models.CodeHost) map[string]*string {
+	return map[string]*string{
+		"password":             &host.Password,
+		"client_secret":        &host.ClientSecret,
+		"ssh_key":              &host.SSHKey,
+		"private_access_token": &host.PrivateAccessToken,
+		"access_token":         &host.AccessToken,
+		"refresh_token":        &host.RefreshToken,
+	}
+}
+
+// sealCodeHost returns a copy of the code host whose credentials are replaced with the references.
+// The secrets are named after the object id of the code host, the id field is reused once a code host is deleted.
+func sealCodeHost(host *models.CodeHost) (*models.CodeHost, error) {
+	if host.ObjectID.IsZero() {
+		return nil, fmt.Errorf("code host %d has no object id", host.ID)
+	}
+	sealed := *host
+	for field, value := range secretFields(&sealed) {
+		ref, err := secretstore.Seal(secretstore.Name(models.CodeHost{}.TableName(), host.ObjectID.Hex(), field), *value)
+		if err != nil {
+			return nil, err
+		}
+		*value = ref
+	}
+	return &sealed, nil
+}
+
+// openCodeHosts replaces the references to the credentials with the plaintext.
+func openCodeHosts(hosts ...*models.CodeHost) error {
+	for _, host := range hosts {
+		for _, value := range secretFields(host) {
+			plaintext, err := secretstore.Open(*value)
+			if err != nil {
+				return err
+			}
+			*value = plaintext
+		}
+	}
+	return nil
+}
+
+// deleteReplacedSecrets deletes the secrets of the stored code host which are replaced by the change.
+func deleteReplacedSecrets(stored *models.CodeHost, change bson.M) {
+	for field, value := range secretFields(stored) {
+		newValue, ok := change[field]
+		if !ok || newValue == *value {
+			continue
+		}
+		if err := secretstore.Delete(*value); err != nil {
+			log.Warnf("failed to delete %s of code host %d, err: %s", field, stored.ID, err)
+		}
+	}
+}
+
 func (c *CodehostColl) AddCodeHost(iCodeHost *models.CodeHost) (*models.CodeHost, error) {
+	if iCodeHost.ObjectID.IsZero() {
+		iCodeHost.ObjectID = primitive.NewObjectID()
+	}
+	sealed, err := sealCodeHost(iCodeHost)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := c.Collection.InsertOne(context.TODO(), iCodeHost)
+	_, err = c.Collection.InsertOne(context.TODO(), sealed)
 	if err != nil {
 		log.Error("repository AddCodeHost err : %v", err)
 		return nil, err
@@ -86,7 +152,7 @@ func (c *CodehostColl) GetCodeHostByAlias(alias string) (*models.CodeHost, error
 	if err := c.Collection.FindOne(context.TODO(), query).Decode(codehost); err != nil {
 		return nil, err
 	}
-	return codehost, nil
+	return codehost, openCodeHosts(codehost)
 }
 
 func (c *CodehostColl) GetCodeHostByID(ID int, ignoreDelete bool) (*models.CodeHost, error) {
@@ -98,7 +164,7 @@ func (c *CodehostColl) GetCodeHostByID(ID int, ignoreDelete bool) (*models.CodeH
 	if err := c.Collection.FindOne(context.TODO(), query).Decode(codehost); err != nil {
 		return nil, err
 	}
-	return codehost, nil
+	return codehost, openCodeHosts(codehost)
 }
 
 func (c *CodehostColl) List(args *ListArgs) ([]*models.CodeHost, error) {
@@ -126,7 +192,7 @@ func (c *CodehostColl) List(args *ListArgs) ([]*models.CodeHost, error) {
 	if err != nil {
 		return nil, err
 	}
-	return codeHosts, nil
+	return codeHosts, openCodeHosts(codeHosts...)
 }
 
 func (c *CodehostColl) CodeHostList() ([]*models.CodeHost, error) {
@@ -139,7 +205,7 @@ func (c *CodehostColl) CodeHostList() ([]*models.CodeHost, error) {
 	if err != nil {
 		return nil, err
 	}
-	return codeHosts, nil
+	return codeHosts, openCodeHosts(codeHosts...)
 }
 
 func (c *CodehostColl) DeleteCodeHostByID(ID int) error {
@@ -155,46 +221,81 @@ func (c *CodehostColl) DeleteCodeHostByID(ID int) error {
 	return nil
 }
 
+// getStored returns the code host as it is saved, its credentials are not opened.
+func (c *CodehostColl) getStored(ID int) (*models.CodeHost, error) {
+	stored := new(models.CodeHost)
+	if err := c.Collection.FindOne(context.TODO(), bson.M{"id": ID, "deleted_at": 0}).Decode(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
 func (c *CodehostColl) UpdateCodeHost(host *models.CodeHost) (*models.CodeHost, error) {
+	stored, err := c.getStored(host.ID)
+	if err != nil {
+		return nil, err
+	}
+	host.ObjectID = stored.ObjectID
+	sealed, err := sealCodeHost(host)
+	if err != nil {
+		return nil, err
+	}
+
 	query := bson.M{"id": host.ID, "deleted_at": 0}
 	modifyValue := bson.M{
 		"type":           host.Type,
 		"address":        host.Address,
 		"namespace":      host.Namespace,
 		"application_id": host.ApplicationId,
-		"client_secret":  host.ClientSecret,
+		"client_secret":  sealed.ClientSecret,
 		"region":         host.Region,
 		"username":       host.Username,
-		"password":       host.Password,
+		"password":       sealed.Password,
 		"enable_proxy":   host.EnableProxy,
 		"alias":          host.Alias,
 		"updated_at":     time.Now().Unix(),
 	}
 	if host.Type == setting.SourceFromGerrit {
-		modifyValue["access_token"] = host.AccessToken
+		modifyValue["access_token"] = sealed.AccessToken
 	} else if host.Type == setting.SourceFromGitee || host.Type == setting.SourceFromGitlab {
-		modifyValue["access_token"] = host.AccessToken
-		modifyValue["refresh_token"] = host.RefreshToken
+		modifyValue["access_token"] = sealed.AccessToken
+		modifyValue["refresh_token"] = sealed.RefreshToken
 		modifyValue["updated_at"] = host.UpdatedAt
 	} else if host.Type == setting.SourceFromOther {
 		modifyValue["auth_type"] = host.AuthType
-		modifyValue["ssh_key"] = host.SSHKey
-		modifyValue["private_access_token"] = host.PrivateAccessToken
+		modifyValue["ssh_key"] = sealed.SSHKey
+		modifyValue["private_access_token"] = sealed.PrivateAccessToken
 	}
 
 	change := bson.M{"$set": modifyValue}
-	_, err := c.Collection.UpdateOne(context.TODO(), query, change)
-	return host, err
+	if _, err = c.Collection.UpdateOne(context.TODO(), query, change); err != nil {
+		return nil, err
+	}
+	deleteReplacedSecrets(stored, modifyValue)
+	return host, nil
 }
 
 func (c *CodehostColl) UpdateCodeHostByToken(host *models.CodeHost) (*models.CodeHost, error) {
+	stored, err := c.getStored(host.ID)
+	if err != nil {
+		return nil, err
+	}
+	host.ObjectID = stored.ObjectID
+	sealed, err := sealCodeHost(host)
+	if err != nil {
+		return nil, err
+	}
+
 	query := bson.M{"id": host.ID, "deleted_at": 0}
-	change := bson.M{"$set": bson.M{
+	modifyValue := bson.M{
 		"is_ready":      "2",
-		"access_token":  host.AccessToken,
+		"access_token":  sealed.AccessToken,
 		"updated_at":    time.Now().Unix(),
-		"refresh_token": host.RefreshToken,
-	}}
-	_, err := c.Collection.UpdateOne(context.TODO(), query, change)
-	return host, err
+		"refresh_token": sealed.RefreshToken,
+	}
+	if _, err = c.Collection.UpdateOne(context.TODO(), query, bson.M{"$set": modifyValue}); err != nil {
+		return nil, err
+	}
+	deleteReplacedSecrets(stored, modifyValue)
+	return host, nil
 }
@@ -62,7 +62,14 @@ func CreateCodeHost(codehost *models.CodeHost, _ *zap.SugaredLogger) (*models.Co
 	if err != nil {
 		return nil, err
 	}
-	codehost.ID = len(list) + 1
+	// deleted code hosts are kept in the list, the ids are never reused
+	maxID := 0
+	for _, host := range list {
+		if host.ID > maxID {
+			maxID = host.ID
+		}
+	}
+	codehost.ID = maxID + 1
 	return mongodb.NewCodehostColl().AddCodeHost(codehost)
 }
 
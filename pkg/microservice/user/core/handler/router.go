@@ -50,6 +50,14 @@ func (*Router) Inject(router *gin.RouterGroup) {
 
 		users.GET("/user/count", user.CountSystemUsers)
 
+		users.POST("/user-groups", user.CreateUserGroup)
+
+		users.GET("/user-groups", user.ListUserGroups)
+
+		users.PUT("/user-groups/:groupId", user.UpdateUserGroup)
+
+		users.DELETE("/user-groups/:groupId", user.DeleteUserGroup)
+
 		router.GET("login", login.Login)
 
 		router.GET("login-enabled", login.ThirdPartyLoginEnabled)
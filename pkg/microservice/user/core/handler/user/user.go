@@ -74,6 +74,8 @@ func ListUsers(c *gin.Context) {
 	}
 	if len(args.UIDs) > 0 {
 		ctx.Resp, ctx.Err = user.SearchUsersByUIDs(args.UIDs, ctx.Logger)
+	} else if len(args.GroupIDs) > 0 {
+		ctx.Resp, ctx.Err = user.SearchUsersByGroupIDs(args.GroupIDs, ctx.Logger)
 	} else if len(args.Account) > 0 {
 		if len(args.IdentityType) == 0 {
 			args.IdentityType = config.SystemIdentityType
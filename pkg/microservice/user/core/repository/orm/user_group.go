@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orm
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+// CreateUserGroup create a user group
+func CreateUserGroup(group *models.UserGroup, db *gorm.DB) error {
+	return db.Create(group).Error
+}
+
+// GetUserGroup Get a user group based on groupID
+func GetUserGroup(groupID string, db *gorm.DB) (*models.UserGroup, error) {
+	var group models.UserGroup
+	err := db.Where("group_id = ?", groupID).First(&group).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &group, nil
+}
+
+// ListUserGroups gets all the user groups
+func ListUserGroups(db *gorm.DB) ([]models.UserGroup, error) {
+	var groups []models.UserGroup
+	err := db.Order("group_name ASC").Find(&groups).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// UpdateUserGroup update user group info
+func UpdateUserGroup(groupID string, group *models.UserGroup, db *gorm.DB) error {
+	return db.Model(&models.UserGroup{}).Where("group_id = ?", groupID).Updates(group).Error
+}
+
+// DeleteUserGroup Delete a user group based on groupID
+func DeleteUserGroup(groupID string, db *gorm.DB) error {
+	var group models.UserGroup
+	return db.Where("group_id = ?", groupID).Delete(&group).Error
+}
+
+// CreateGroupBindings adds users to user groups
+func CreateGroupBindings(bindings []*models.GroupBinding, db *gorm.DB) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	return db.Create(&bindings).Error
+}
+
+// ListGroupBindings gets the bindings of the user groups
+func ListGroupBindings(groupIDs []string, db *gorm.DB) ([]models.GroupBinding, error) {
+	var bindings []models.GroupBinding
+	err := db.Find(&bindings, "group_id in ?", groupIDs).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// DeleteGroupBindingsByGroupID removes all the users from the user group
+func DeleteGroupBindingsByGroupID(groupID string, db *gorm.DB) error {
+	var binding models.GroupBinding
+	return db.Where("group_id = ?", groupID).Delete(&binding).Error
+}
+
+// DeleteGroupBindingsByUID removes the user from all the user groups
+func DeleteGroupBindingsByUID(uid string, db *gorm.DB) error {
+	var binding models.GroupBinding
+	return db.Where("uid = ?", uid).Delete(&binding).Error
+}
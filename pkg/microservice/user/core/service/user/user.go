@@ -63,6 +63,7 @@ type QueryArgs struct {
 	Account      string   `json:"account,omitempty"`
 	IdentityType string   `json:"identity_type,omitempty"`
 	UIDs         []string `json:"uids,omitempty"`
+	GroupIDs     []string `json:"group_ids,omitempty"`
 	PerPage      int      `json:"per_page,omitempty"`
 	Page         int      `json:"page,omitempty"`
 }
@@ -328,6 +329,12 @@ func DeleteUserByUID(uid string, logger *zap.SugaredLogger) error {
 		logger.Errorf("DeleteUserByUID DeleteUserLoginByUid:%s error, error msg:%s", uid, err.Error())
 		return err
 	}
+	err = orm.DeleteGroupBindingsByUID(uid, tx)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserByUID DeleteGroupBindingsByUID:%s error, error msg:%s", uid, err.Error())
+		return err
+	}
 	return tx.Commit().Error
 }
 
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+type UserGroup struct {
+	GroupID     string   `json:"group_id"`
+	GroupName   string   `json:"group_name"`
+	Description string   `json:"description"`
+	UIDs        []string `json:"uids"`
+}
+
+func CreateUserGroup(args *UserGroup, logger *zap.SugaredLogger) (*UserGroup, error) {
+	if args.GroupName == "" {
+		return nil, e.ErrCreateUserGroup.AddDesc("用户组名称不能为空")
+	}
+	groupID, _ := uuid.NewUUID()
+	group := &models.UserGroup{
+		GroupID:     groupID.String(),
+		GroupName:   args.GroupName,
+		Description: args.Description,
+	}
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := orm.CreateUserGroup(group, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("CreateUserGroup CreateUserGroup :%v error, error msg:%s", group, err.Error())
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, e.ErrCreateUserGroup.AddErr(err).AddDesc("存在相同用户组名称")
+		}
+		return nil, e.ErrCreateUserGroup.AddErr(err)
+	}
+	if err := orm.CreateGroupBindings(groupBindings(group.GroupID, args.UIDs), tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("CreateUserGroup CreateGroupBindings :%s error, error msg:%s", group.GroupID, err.Error())
+		return nil, e.ErrCreateUserGroup.AddErr(err)
+	}
+	args.GroupID = group.GroupID
+	return args, tx.Commit().Error
+}
+
+// UpdateUserGroup updates the group info and replaces its members with the given users.
+func UpdateUserGroup(groupID string, args *UserGroup, logger *zap.SugaredLogger) error {
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	err := orm.UpdateUserGroup(groupID, &models.UserGroup{GroupName: args.GroupName, Description: args.Description}, tx)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("UpdateUserGroup UpdateUserGroup :%s error, error msg:%s", groupID, err.Error())
+		return e.ErrUpdateUserGroup.AddErr(err)
+	}
+	if err := orm.DeleteGroupBindingsByGroupID(groupID, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("UpdateUserGroup DeleteGroupBindingsByGroupID :%s error, error msg:%s", groupID, err.Error())
+		return e.ErrUpdateUserGroup.AddErr(err)
+	}
+	if err := orm.CreateGroupBindings(groupBindings(groupID, args.UIDs), tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("UpdateUserGroup CreateGroupBindings :%s error, error msg:%s", groupID, err.Error())
+		return e.ErrUpdateUserGroup.AddErr(err)
+	}
+	return tx.Commit().Error
+}
+
+func ListUserGroups(logger *zap.SugaredLogger) ([]*UserGroup, error) {
+	groups, err := orm.ListUserGroups(core.DB)
+	if err != nil {
+		logger.Errorf("ListUserGroups ListUserGroups error, error msg:%s", err.Error())
+		return nil, e.ErrListUserGroups.AddErr(err)
+	}
+	groupIDs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	bindings, err := orm.ListGroupBindings(groupIDs, core.DB)
+	if err != nil {
+		logger.Errorf("ListUserGroups ListGroupBindings error, error msg:%s", err.Error())
+		return nil, e.ErrListUserGroups.AddErr(err)
+	}
+	members := make(map[string][]string)
+	for _, binding := range bindings {
+		members[binding.GroupID] = append(members[binding.GroupID], binding.UID)
+	}
+	resp := make([]*UserGroup, 0, len(groups))
+	for _, group := range groups {
+		resp = append(resp, &UserGroup{
+			GroupID:     group.GroupID,
+			GroupName:   group.GroupName,
+			Description: group.Description,
+			UIDs:        members[group.GroupID],
+		})
+	}
+	return resp, nil
+}
+
+func DeleteUserGroup(groupID string, logger *zap.SugaredLogger) error {
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := orm.DeleteUserGroup(groupID, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserGroup DeleteUserGroup :%s error, error msg:%s", groupID, err.Error())
+		return e.ErrDeleteUserGroup.AddErr(err)
+	}
+	if err := orm.DeleteGroupBindingsByGroupID(groupID, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserGroup DeleteGroupBindingsByGroupID :%s error, error msg:%s", groupID, err.Error())
+		return e.ErrDeleteUserGroup.AddErr(err)
+	}
+	return tx.Commit().Error
+}
+
+// SearchUsersByGroupIDs gets the members of the user groups, users in several groups are returned once.
+func SearchUsersByGroupIDs(groupIDs []string, logger *zap.SugaredLogger) (*types.UsersResp, error) {
+	bindings, err := orm.ListGroupBindings(groupIDs, core.DB)
+	if err != nil {
+		logger.Errorf("SearchUsersByGroupIDs ListGroupBindings By groupIDs:%s error, error msg:%s", groupIDs, err.Error())
+		return nil, err
+	}
+	uids := make([]string, 0, len(bindings))
+	seen := make(map[string]bool)
+	for _, binding := range bindings {
+		if seen[binding.UID] {
+			continue
+		}
+		seen[binding.UID] = true
+		uids = append(uids, binding.UID)
+	}
+	if len(uids) == 0 {
+		return &types.UsersResp{Users: []types.UserInfo{}}, nil
+	}
+	return SearchUsersByUIDs(uids, logger)
+}
+
+func groupBindings(groupID string, uids []string) []*models.GroupBinding {
+	bindings := make([]*models.GroupBinding, 0, len(uids))
+	seen := make(map[string]bool)
+	for _, uid := range uids {
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		bindings = append(bindings, &models.GroupBinding{GroupID: groupID, UID: uid})
+	}
+	return bindings
+}
@@ -25,6 +25,8 @@ import (
 
 	"github.com/nsqio/go-nsq"
 	uuid "github.com/satori/go.uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -35,6 +37,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/types/task"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 	"github.com/koderover/zadig/pkg/util/rand"
 )
 
@@ -80,7 +83,20 @@ func (h *ExecHandler) HandleMessage(message *nsq.Message) error {
 	xl.Infof("Receiving pipeline task %s message", taskName)
 
 	xl = Logger(pipelineTask)
-	ctx, cancel = context.WithCancel(context.Background())
+	traceCtx, span := tracing.StartServer(tracing.Extract(context.Background(), pipelineTask.TraceContext), "run pipeline task",
+		attribute.String("zadig.pipeline", pipelineTask.PipelineName),
+		attribute.Int64("zadig.task_id", pipelineTask.TaskID),
+	)
+	// pipelineTask is reset when the task is done, keep it for the status of the span.
+	t := pipelineTask
+	defer func() {
+		span.SetAttributes(attribute.String("zadig.status", string(t.Status)))
+		if t.Status == config.StatusFailed || t.Status == config.StatusTimeout {
+			span.SetStatus(codes.Error, t.Error)
+		}
+		span.End()
+	}()
+	ctx, cancel = context.WithCancel(traceCtx)
 
 	go func(ctx context.Context, taskName string) {
 		for {
@@ -597,7 +613,7 @@ func (h *ExecHandler) executeTask(taskCtx context.Context, plugin plugins.TaskPl
 func Logger(pipelineTask *task.Task) *zap.SugaredLogger {
 	l := log.Logger()
 	if pipelineTask != nil {
-		l.With(zap.String(setting.RequestID, pipelineTask.ReqID))
+		l = l.With(zap.String(setting.RequestID, pipelineTask.ReqID), tracing.Field(tracing.Extract(context.Background(), pipelineTask.TraceContext)))
 	}
 
 	return l.Sugar()
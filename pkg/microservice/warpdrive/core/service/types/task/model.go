@@ -84,6 +84,8 @@ type Task struct {
 	IsRestart        bool                         `bson:"is_restart"                  json:"is_restart"`
 	StorageEndpoint  string                       `bson:"storage_endpoint"            json:"storage_endpoint"`
 	ArtifactInfo     *ArtifactInfo                `bson:"artifact_info"               json:"artifact_info"`
+	// TraceContext is the span of aslan sending the task.
+	TraceContext map[string]string `bson:"trace_context,omitempty" json:"trace_context,omitempty"`
 }
 
 type RenderInfo struct {
@@ -26,6 +26,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/warpdrive/core/service/taskcontroller"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/metrics"
+	"github.com/koderover/zadig/pkg/tool/tracing"
 )
 
 func Serve(ctx context.Context) error {
@@ -36,6 +38,20 @@ func Serve(ctx context.Context) error {
 		Development: commonconfig.Mode() != setting.ReleaseMode,
 	})
 
+	if err := tracing.Init(&tracing.Config{
+		ServiceName: "warpdrive",
+		Exporter:    commonconfig.TraceExporter(),
+		Endpoint:    commonconfig.TraceOTLPEndpoint(),
+		File:        commonconfig.TraceFile(),
+	}); err != nil {
+		log.Errorf("Failed to init tracing, tracing is disabled, error: %s", err)
+	}
+	defer func() {
+		if err := tracing.Shutdown(context.TODO()); err != nil {
+			log.Errorf("Failed to flush spans, error: %s", err)
+		}
+	}()
+
 	log.Info("Warpdrive service start ... ")
 
 	controller := taskcontroller.NewController()
@@ -45,6 +61,7 @@ func Serve(ctx context.Context) error {
 	}
 
 	http.HandleFunc("/ping", ping)
+	http.Handle("/metrics", metrics.Handler())
 	server := &http.Server{Addr: ":25001", Handler: nil}
 
 	stopChan := make(chan struct{})
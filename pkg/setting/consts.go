@@ -36,6 +36,19 @@ const (
 	ENVMysqlHost               = "MYSQL_HOST"
 	ENVMysqlUserDb             = "MYSQL_USER_DB"
 
+	// secret store
+	ENVSecretStoreBackend       = "SECRET_STORE_BACKEND"
+	ENVSecretStoreMasterKeyFile = "SECRET_STORE_MASTER_KEY_FILE"
+	ENVSecretStoreMasterKeys    = "SECRET_STORE_MASTER_KEYS"
+	ENVVaultAddress             = "VAULT_ADDR"
+	ENVVaultToken               = "VAULT_TOKEN"
+	ENVVaultMountPath           = "VAULT_MOUNT_PATH"
+
+	// tracing
+	ENVTraceExporter     = "TRACE_EXPORTER"
+	ENVTraceOTLPEndpoint = "TRACE_OTLP_ENDPOINT"
+	ENVTraceFile         = "TRACE_FILE"
+
 	// Aslan
 	ENVPodName              = "BE_POD_NAME"
 	ENVNamespace            = "BE_POD_NAMESPACE"
@@ -222,6 +235,7 @@ const (
 const (
 	ProductName = "zadig"
 	RequestID   = "requestID"
+	TraceID     = "traceID"
 
 	ProtocolHTTP  string = "http"
 	ProtocolHTTPS string = "https"
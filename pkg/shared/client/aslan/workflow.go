@@ -42,3 +42,39 @@ func (c *Client) GetWorkflowConcurrencySetting() (*workflowConcurrencySettingRes
 	}
 	return resp, nil
 }
+
+type WorkflowV4Schedule struct {
+	WorkflowName string `json:"workflow_name"`
+	ProjectName  string `json:"project_name"`
+	Name         string `json:"name"`
+	Cron         string `json:"cron"`
+	Timezone     string `json:"timezone"`
+}
+
+func (c *Client) ListWorkflowV4Schedules() ([]*WorkflowV4Schedule, error) {
+	url := "/cron/workflowv4/schedule"
+
+	resp := make([]*WorkflowV4Schedule, 0)
+	_, err := c.Get(url, httpclient.SetResult(&resp))
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type RunWorkflowV4ScheduleResp struct {
+	TaskID     int64  `json:"task_id"`
+	Skipped    bool   `json:"skipped"`
+	SkipReason string `json:"skip_reason"`
+}
+
+func (c *Client) RunWorkflowV4Schedule(workflowName, scheduleName string) (*RunWorkflowV4ScheduleResp, error) {
+	url := fmt.Sprintf("/cron/workflowv4/schedule/%s/%s", workflowName, scheduleName)
+
+	resp := &RunWorkflowV4ScheduleResp{}
+	_, err := c.Post(url, httpclient.SetResult(resp))
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
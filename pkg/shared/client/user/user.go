@@ -34,7 +34,8 @@ type usersResp struct {
 }
 
 type SearchArgs struct {
-	UIDs []string `json:"uids"`
+	UIDs     []string `json:"uids"`
+	GroupIDs []string `json:"group_ids,omitempty"`
 }
 
 func (c *Client) ListUsers(args *SearchArgs) ([]*User, error) {
@@ -46,6 +46,14 @@ var (
 	ErrFindUser = NewHTTPError(6002, "获取用户信息失败")
 	// ErrCallBackUser ...
 	ErrCallBackUser = NewHTTPError(6003, "dex回调用户失败")
+	// ErrCreateUserGroup ...
+	ErrCreateUserGroup = NewHTTPError(6004, "创建用户组失败")
+	// ErrUpdateUserGroup ...
+	ErrUpdateUserGroup = NewHTTPError(6005, "更新用户组失败")
+	// ErrListUserGroups ...
+	ErrListUserGroups = NewHTTPError(6006, "列出用户组失败")
+	// ErrDeleteUserGroup ...
+	ErrDeleteUserGroup = NewHTTPError(6007, "删除用户组失败")
 	//-----------------------------------------------------------------------------------------------
 	// Team APIs Range: 6020 - 6039
 	//-----------------------------------------------------------------------------------------------
@@ -288,6 +296,14 @@ var (
 
 	// ErrApproveTask ...
 	ErrApproveTask = NewHTTPError(6169, "批准工作流任务失败")
+	// ErrCreateApprovalDelegation ...
+	ErrCreateApprovalDelegation = NewHTTPError(6170, "创建审批委托失败")
+	// ErrListApprovalDelegations ...
+	ErrListApprovalDelegations = NewHTTPError(6171, "列出审批委托失败")
+	// ErrDeleteApprovalDelegation ...
+	ErrDeleteApprovalDelegation = NewHTTPError(6172, "删除审批委托失败")
+	// ErrResumeJobBreakpoint ...
+	ErrResumeJobBreakpoint = NewHTTPError(6173, "恢复暂停的工作流任务失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Keystore APIs Range: 6180 - 6189
@@ -486,6 +502,10 @@ var (
 	ErrFindWorkflow = NewHTTPError(6542, "查询workflow失败")
 	// ErrDeleteWorkflow ...
 	ErrDeleteWorkflow = NewHTTPError(6543, "删除workflow失败")
+	// ErrLoadWorkflowYaml ...
+	ErrLoadWorkflowYaml = NewHTTPError(6544, "从代码库加载workflow失败")
+	// ErrSyncWorkflowYaml ...
+	ErrSyncWorkflowYaml = NewHTTPError(6545, "从代码库同步workflow失败")
 
 	//-----------------------------------------------------------------------------------------------
 	// Directory APIs Range: 6550 - 6560
@@ -722,4 +742,34 @@ var (
 	ErrCreateWebhook = NewHTTPError(6882, "创建webhook失败")
 	ErrUpdateWebhook = NewHTTPError(6883, "更新webhook失败")
 	ErrDeleteWebhook = NewHTTPError(6884, "删除webhook失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env snapshot releated Error Range: 6890 - 6899
+	//-----------------------------------------------------------------------------------------------
+	ErrListEnvSnapshots = NewHTTPError(6890, "获取环境快照列表失败")
+	ErrGetEnvSnapshot   = NewHTTPError(6891, "获取环境快照失败")
+	ErrDiffEnvSnapshots = NewHTTPError(6892, "对比环境快照失败")
+	ErrRollbackEnv      = NewHTTPError(6893, "回滚环境失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// webhook delivery releated Error Range: 6900 - 6909
+	//-----------------------------------------------------------------------------------------------
+	ErrListWebhookDelivery   = NewHTTPError(6900, "获取webhook投递记录失败")
+	ErrGetWebhookDelivery    = NewHTTPError(6901, "获取webhook投递详情失败")
+	ErrReplayWebhookDelivery = NewHTTPError(6902, "重放webhook投递失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// job log releated Error Range: 6910 - 6919
+	//-----------------------------------------------------------------------------------------------
+	ErrSearchJobLog    = NewHTTPError(6910, "搜索任务日志失败")
+	ErrDownloadJobLogs = NewHTTPError(6911, "下载任务日志失败")
+
+	//-----------------------------------------------------------------------------------------------
+	// env drift releated Error Range: 6920 - 6929
+	//-----------------------------------------------------------------------------------------------
+	ErrDetectEnvDrift  = NewHTTPError(6920, "检测环境漂移失败")
+	ErrListEnvDrifts   = NewHTTPError(6921, "获取环境漂移列表失败")
+	ErrGetEnvDrift     = NewHTTPError(6922, "获取环境漂移详情失败")
+	ErrReapplyEnvDrift = NewHTTPError(6923, "重新应用环境配置失败")
+	ErrAdoptEnvDrift   = NewHTTPError(6924, "采纳集群变更失败")
 )
@@ -17,6 +17,7 @@ limitations under the License.
 package httpclient
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
@@ -78,3 +79,9 @@ func ForceContentType(contentType string) RequestFunc {
 		r.ForceContentType(contentType)
 	}
 }
+
+func SetContext(ctx context.Context) RequestFunc {
+	return func(r *resty.Request) {
+		r.SetContext(ctx)
+	}
+}
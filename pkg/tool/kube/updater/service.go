@@ -19,9 +19,13 @@ package updater
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/koderover/zadig/pkg/tool/kube/util"
 )
 
 func DeleteServices(namespace string, selector labels.Selector, clientset *kubernetes.Clientset) error {
@@ -47,3 +51,27 @@ func DeleteServices(namespace string, selector labels.Selector, clientset *kuber
 
 	return lastErr
 }
+
+func PatchService(ns, name string, patchBytes []byte, cl client.Client) error {
+	return patchObject(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, patchBytes, cl)
+}
+
+func CreateOrPatchService(s *corev1.Service, cl client.Client) error {
+	return createOrPatchObject(s, cl)
+}
+
+func DeleteService(ns, name string, cl client.Client) error {
+	err := deleteObjectWithDefaultOptions(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}, cl)
+
+	return util.IgnoreNotFoundError(err)
+}
@@ -18,7 +18,14 @@ package mail
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
 	"html/template"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
 
 	"gopkg.in/gomail.v2"
 )
@@ -35,18 +42,136 @@ type EmailParams struct {
 }
 
 func SendEmail(param *EmailParams) error {
+	d := gomail.NewDialer(param.Host, param.Port, param.UserName, param.Password)
+
+	if err := d.DialAndSend(newMessage(param)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SendEmailWithContext sends the email like SendEmail does, but the whole smtp conversation is bound to ctx:
+// the connection is closed once ctx is done, so a server which stops answering can not block the caller.
+func SendEmailWithContext(ctx context.Context, param *EmailParams) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(param.Host, strconv.Itoa(param.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	if err := sendEmail(conn, param); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s: %s", ctx.Err(), err)
+		}
+		return err
+	}
+	return nil
+}
+
+func newMessage(param *EmailParams) *gomail.Message {
 	m := gomail.NewMessage()
 	m.SetHeader("From", param.From)
 	m.SetHeader("To", param.To)
 	m.SetHeader("Subject", param.Subject)
 	m.SetBody("text/html", param.Body)
+	return m
+}
 
-	d := gomail.NewDialer(param.Host, param.Port, param.UserName, param.Password)
+// sendEmail talks smtp over conn the same way gomail's dialer does: implicit tls on port 465, STARTTLS otherwise
+// when the server supports it.
+func sendEmail(conn net.Conn, param *EmailParams) error {
+	tlsConfig := &tls.Config{ServerName: param.Host}
+	ssl := param.Port == 465
+	if ssl {
+		conn = tls.Client(conn, tlsConfig)
+	}
 
-	if err := d.DialAndSend(m); err != nil {
+	c, err := smtp.NewClient(conn, param.Host)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer c.Close()
+
+	if !ssl {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if param.UserName != "" {
+		if ok, auths := c.Extension("AUTH"); ok {
+			if err := c.Auth(newAuth(auths, param)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := c.Mail(param.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(param.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := newMessage(param).WriteTo(w); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func newAuth(auths string, param *EmailParams) smtp.Auth {
+	switch {
+	case strings.Contains(auths, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(param.UserName, param.Password)
+	case strings.Contains(auths, "LOGIN") && !strings.Contains(auths, "PLAIN"):
+		return &loginAuth{username: param.UserName, password: param.Password}
+	default:
+		return smtp.PlainAuth("", param.UserName, param.Password, param.Host)
+	}
+}
+
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a *loginAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", []byte{}, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch {
+	case bytes.EqualFold(fromServer, []byte("Username:")):
+		return []byte(a.username), nil
+	case bytes.EqualFold(fromServer, []byte("Password:")):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
 }
 
 type GenerateUrl struct {
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mail
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendEmailWithContext_ServerNotAnswering(t *testing.T) {
+	ast := require.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	ast.NoError(err)
+	defer l.Close()
+	go func() {
+		// accept the connection but never send the smtp greeting
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(5 * time.Second)
+	}()
+
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	ast.NoError(err)
+	p, err := strconv.Atoi(port)
+	ast.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = SendEmailWithContext(ctx, &EmailParams{
+		From: "zadig@example.com",
+		To:   "user@example.com",
+		Host: host,
+		Port: p,
+	})
+	ast.Error(err)
+	ast.Less(time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the prometheus metrics of zadig services, the metrics are defined in the packages
+// which record them and are registered to the default registry.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace is the prefix of all zadig metrics.
+const Namespace = "zadig"
+
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// DurationBuckets are for durations from milliseconds to seconds, such as http requests and database calls.
+var DurationBuckets = prometheus.DefBuckets
+
+// TaskDurationBuckets are for durations from seconds to hours, such as workflow jobs and waiting in the queue.
+var TaskDurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 14400}
+
+// Handler serves the metrics at /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Result returns the result label of an operation.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailed
+	}
+	return ResultSuccess
+}
@@ -95,6 +95,7 @@ func Ping(ctx context.Context) error {
 }
 
 func connect(ctx context.Context, opt *options.ClientOptions) *mongo.Client {
+	opt.SetMonitor(newCommandMonitor())
 	c, err := mongo.Connect(ctx, opt)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+
+	"github.com/koderover/zadig/pkg/tool/metrics"
+)
+
+var callDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "mongo",
+	Name:      "call_duration_seconds",
+	Help:      "Latency of mongodb commands by collection, command and result.",
+	Buckets:   metrics.DurationBuckets,
+}, []string{"collection", "command", "result"})
+
+// commandMonitor records the latency of the commands sent by the repositories, the collection of a command
+// is only known when it starts, so it's kept until the command finishes.
+type commandMonitor struct {
+	collections sync.Map
+}
+
+func newCommandMonitor() *event.CommandMonitor {
+	m := &commandMonitor{}
+	return &event.CommandMonitor{
+		Started: m.started,
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			m.finished(&e.CommandFinishedEvent, metrics.ResultSuccess)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			m.finished(&e.CommandFinishedEvent, metrics.ResultFailed)
+		},
+	}
+}
+
+func (m *commandMonitor) started(_ context.Context, e *event.CommandStartedEvent) {
+	// the value of the command name is the collection for the crud commands, e.g. {"find": "collection"}.
+	collection, _ := e.Command.Lookup(e.CommandName).StringValueOK()
+	m.collections.Store(e.RequestID, collection)
+}
+
+func (m *commandMonitor) finished(e *event.CommandFinishedEvent, result string) {
+	collection, ok := m.collections.LoadAndDelete(e.RequestID)
+	if !ok {
+		return
+	}
+	callDuration.WithLabelValues(collection.(string), e.CommandName, result).Observe(time.Duration(e.DurationNanos).Seconds())
+}
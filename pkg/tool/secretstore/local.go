@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const masterKeyLength = 32
+
+// Keyring holds the master keys, the last one is the primary key used to wrap new data keys.
+// Old keys are kept to unwrap the data keys wrapped before a rotation.
+type Keyring struct {
+	keys    map[string][]byte
+	primary string
+}
+
+// LoadKeyring loads the master keys from the file, or from the comma separated keys if the file is not set.
+// Every key is like <id>:<base64 encoded 32 bytes>. It returns nil if no key is configured.
+func LoadKeyring(file, keys string) (*Keyring, error) {
+	var entries []string
+	if file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %v", err)
+		}
+		entries = strings.Split(string(content), "\n")
+	} else if keys != "" {
+		entries = strings.Split(keys, ",")
+	}
+
+	keyring := &Keyring{keys: make(map[string][]byte)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || strings.ContainsAny(parts[0], "./") {
+			return nil, fmt.Errorf("invalid master key entry, it should be like <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) != masterKeyLength {
+			return nil, fmt.Errorf("master key %s should be %d bytes encoded in base64", parts[0], masterKeyLength)
+		}
+		keyring.keys[parts[0]] = key
+		keyring.primary = parts[0]
+	}
+	if keyring.primary == "" {
+		return nil, nil
+	}
+	return keyring, nil
+}
+
+// LocalStore encrypts every secret with its own data key, and wraps the data key with the master key.
+// The reference carries everything, secret://local/<master key id>.<wrapped data key>.<ciphertext>.
+type LocalStore struct {
+	keyring *Keyring
+}
+
+func NewLocalStore(keyring *Keyring) *LocalStore {
+	return &LocalStore{keyring: keyring}
+}
+
+func (s *LocalStore) Seal(_, plaintext string) (string, error) {
+	dataKey := make([]byte, masterKeyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	ciphertext, err := gcmSeal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return s.reference(dataKey, ciphertext)
+}
+
+func (s *LocalStore) Open(ref string) (string, error) {
+	dataKey, ciphertext, err := s.parse(ref)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcmOpen(dataKey, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// Delete does nothing, the secret lives in the reference.
+func (s *LocalStore) Delete(_ string) error {
+	return nil
+}
+
+// Rewrap wraps the data key of the reference with the primary master key, the ciphertext is kept.
+func (s *LocalStore) Rewrap(ref string) (string, error) {
+	keyID, _, err := s.split(ref)
+	if err != nil {
+		return "", err
+	}
+	if keyID == s.keyring.primary {
+		return ref, nil
+	}
+	dataKey, ciphertext, err := s.parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return s.reference(dataKey, ciphertext)
+}
+
+func (s *LocalStore) reference(dataKey, ciphertext []byte) (string, error) {
+	wrappedKey, err := gcmSeal(s.keyring.keys[s.keyring.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%s/%s.%s.%s", referencePrefix, BackendLocal, s.keyring.primary,
+		base64.RawURLEncoding.EncodeToString(wrappedKey), base64.RawURLEncoding.EncodeToString(ciphertext)), nil
+}
+
+func (s *LocalStore) split(ref string) (string, []string, error) {
+	backend, data, err := backendOf(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	parts := strings.Split(data, ".")
+	if backend != BackendLocal || len(parts) != 3 {
+		return "", nil, fmt.Errorf("invalid local secret reference")
+	}
+	return parts[0], parts[1:], nil
+}
+
+func (s *LocalStore) parse(ref string) ([]byte, []byte, error) {
+	keyID, parts, err := s.split(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	masterKey, ok := s.keyring.keys[keyID]
+	if !ok {
+		return nil, nil, fmt.Errorf("master key %s is not found", keyID)
+	}
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	dataKey, err := gcmOpen(masterKey, wrappedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	return dataKey, ciphertext, nil
+}
+
+// gcmSeal encrypts the data with AES-GCM, the nonce is put before the ciphertext.
+func gcmSeal(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func gcmOpen(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
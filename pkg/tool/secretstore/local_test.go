@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_Rotate(t *testing.T) {
+	ast := require.New(t)
+
+	oldKey := "v1:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", masterKeyLength)))
+	newKey := "v2:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", masterKeyLength)))
+
+	keyring, err := LoadKeyring("", oldKey)
+	ast.Nil(err)
+	oldStore := NewLocalStore(keyring)
+
+	ref, err := oldStore.Seal("private_key/1/private_key", "hello")
+	ast.Nil(err)
+	ast.True(IsReference(ref))
+	ast.NotContains(ref, "hello")
+
+	plaintext, err := oldStore.Open(ref)
+	ast.Nil(err)
+	ast.Equal("hello", plaintext)
+
+	keyring, err = LoadKeyring("", oldKey+","+newKey)
+	ast.Nil(err)
+	newStore := NewLocalStore(keyring)
+
+	rewrapped, err := newStore.Rewrap(ref)
+	ast.Nil(err)
+	ast.True(strings.HasPrefix(rewrapped, "secret://local/v2."))
+
+	plaintext, err = newStore.Open(rewrapped)
+	ast.Nil(err)
+	ast.Equal("hello", plaintext)
+
+	_, err = oldStore.Open(rewrapped)
+	ast.NotNil(err)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/koderover/zadig/pkg/config"
+)
+
+const (
+	BackendLocal = "local"
+	BackendVault = "vault"
+
+	referencePrefix = "secret://"
+)
+
+// Store saves the secrets out of the database, the database only keeps the references to them.
+type Store interface {
+	// Seal saves the plaintext and returns the reference to it, name identifies the secret in the store.
+	Seal(name, plaintext string) (string, error)
+	// Open returns the plaintext the reference points to.
+	Open(ref string) (string, error)
+	// Delete removes the secret the reference points to.
+	Delete(ref string) error
+}
+
+// IsReference tells whether the value is a reference returned by a store, or a plaintext saved before.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, referencePrefix)
+}
+
+// Name returns the name of a secret field in a collection.
+func Name(collection, id, field string) string {
+	return fmt.Sprintf("%s/%s/%s", collection, id, field)
+}
+
+// backendOf returns the backend of the reference, secret://<backend>/<data>.
+func backendOf(ref string) (string, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(ref, referencePrefix), "/", 2)
+	if !IsReference(ref) || len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid secret reference")
+	}
+	return parts[0], parts[1], nil
+}
+
+var (
+	defaultStore Store
+	defaultErr   error
+	once         sync.Once
+)
+
+// Default returns the store of the configured backend, secrets are kept as they are if no backend is configured.
+func Default() (Store, error) {
+	once.Do(func() {
+		defaultStore, defaultErr = newRouter()
+	})
+	return defaultStore, defaultErr
+}
+
+// Seal seals the plaintext with the default store, an empty or sealed value is returned as it is.
+func Seal(name, plaintext string) (string, error) {
+	if plaintext == "" || IsReference(plaintext) {
+		return plaintext, nil
+	}
+	store, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return store.Seal(name, plaintext)
+}
+
+// Open opens the reference with the default store, a plaintext saved before is returned as it is.
+func Open(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	store, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return store.Open(value)
+}
+
+// Delete deletes the secret if the value is a reference.
+func Delete(value string) error {
+	if !IsReference(value) {
+		return nil
+	}
+	store, err := Default()
+	if err != nil {
+		return err
+	}
+	return store.Delete(value)
+}
+
+// router seals secrets with the configured backend, and opens references with the backend they were sealed by.
+type router struct {
+	backend string
+	local   *LocalStore
+	vault   *VaultStore
+}
+
+func newRouter() (*router, error) {
+	r := &router{backend: config.SecretStoreBackend()}
+	keyring, err := LoadKeyring(config.SecretStoreMasterKeyFile(), config.SecretStoreMasterKeys())
+	if err != nil {
+		return nil, err
+	}
+	if keyring != nil {
+		r.local = NewLocalStore(keyring)
+	}
+	if config.VaultAddress() != "" {
+		r.vault = NewVaultStore(config.VaultAddress(), config.VaultToken(), config.VaultMountPath())
+	}
+
+	switch r.backend {
+	case "":
+	case BackendLocal:
+		if r.local == nil {
+			return nil, fmt.Errorf("no master key is configured for the local secret store")
+		}
+	case BackendVault:
+		if r.vault == nil {
+			return nil, fmt.Errorf("no address is configured for the vault secret store")
+		}
+	default:
+		return nil, fmt.Errorf("unknown secret store backend %s", r.backend)
+	}
+	return r, nil
+}
+
+func (r *router) Seal(name, plaintext string) (string, error) {
+	switch r.backend {
+	case BackendLocal:
+		return r.local.Seal(name, plaintext)
+	case BackendVault:
+		return r.vault.Seal(name, plaintext)
+	default:
+		return plaintext, nil
+	}
+}
+
+func (r *router) store(ref string) (Store, error) {
+	backend, _, err := backendOf(ref)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case backend == BackendLocal && r.local != nil:
+		return r.local, nil
+	case backend == BackendVault && r.vault != nil:
+		return r.vault, nil
+	default:
+		return nil, fmt.Errorf("secret store backend %s is not configured", backend)
+	}
+}
+
+func (r *router) Open(ref string) (string, error) {
+	store, err := r.store(ref)
+	if err != nil {
+		return "", err
+	}
+	return store.Open(ref)
+}
+
+func (r *router) Delete(ref string) error {
+	store, err := r.store(ref)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ref)
+}
+
+// Rewrap rewraps a local reference with the primary master key, other values are returned as they are.
+func Rewrap(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	store, err := Default()
+	if err != nil {
+		return "", err
+	}
+	r, ok := store.(*router)
+	if !ok || r.local == nil {
+		return value, nil
+	}
+	if backend, _, _ := backendOf(value); backend != BackendLocal {
+		return value, nil
+	}
+	return r.local.Rewrap(value)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const defaultVaultMountPath = "secret"
+
+// VaultStore saves secrets in the KV version 2 secrets engine of HashiCorp Vault, or any server compatible with its http api.
+// The reference is secret://vault/<path>.
+type VaultStore struct {
+	address   string
+	token     string
+	mountPath string
+	conn      *httpclient.Client
+}
+
+func NewVaultStore(address, token, mountPath string) *VaultStore {
+	if mountPath == "" {
+		mountPath = defaultVaultMountPath
+	}
+	return &VaultStore{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     token,
+		mountPath: strings.Trim(mountPath, "/"),
+		conn:      httpclient.New(),
+	}
+}
+
+type vaultData struct {
+	Data map[string]string `json:"data"`
+}
+
+type vaultSecret struct {
+	Data *vaultData `json:"data"`
+}
+
+func (s *VaultStore) Seal(name, plaintext string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("secret name is required by vault")
+	}
+	body := &vaultData{Data: map[string]string{"value": plaintext}}
+	if _, err := s.conn.Post(s.url("data", name), s.auth(), httpclient.SetBody(body)); err != nil {
+		return "", fmt.Errorf("failed to save secret %s to vault: %v", name, err)
+	}
+	return fmt.Sprintf("%s%s/%s", referencePrefix, BackendVault, name), nil
+}
+
+func (s *VaultStore) Open(ref string) (string, error) {
+	path, err := s.path(ref)
+	if err != nil {
+		return "", err
+	}
+	resp := &vaultSecret{}
+	if _, err := s.conn.Get(s.url("data", path), s.auth(), httpclient.SetResult(resp)); err != nil {
+		return "", fmt.Errorf("failed to read secret %s from vault: %v", path, err)
+	}
+	if resp.Data == nil {
+		return "", fmt.Errorf("secret %s is not found in vault", path)
+	}
+	return resp.Data.Data["value"], nil
+}
+
+// Delete deletes all the versions of the secret.
+func (s *VaultStore) Delete(ref string) error {
+	path, err := s.path(ref)
+	if err != nil {
+		return err
+	}
+	if _, err := s.conn.Delete(s.url("metadata", path), s.auth()); err != nil && !httpclient.IsNotFound(err) {
+		return fmt.Errorf("failed to delete secret %s from vault: %v", path, err)
+	}
+	return nil
+}
+
+func (s *VaultStore) auth() httpclient.RequestFunc {
+	return httpclient.SetHeader("X-Vault-Token", s.token)
+}
+
+func (s *VaultStore) url(kind, path string) string {
+	return fmt.Sprintf("%s/v1/%s/%s/%s", s.address, s.mountPath, kind, path)
+}
+
+func (s *VaultStore) path(ref string) (string, error) {
+	backend, path, err := backendOf(ref)
+	if err != nil {
+		return "", err
+	}
+	if backend != BackendVault {
+		return "", fmt.Errorf("invalid vault secret reference")
+	}
+	return path, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sonar
+
+import (
+	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// Client is the SonarQube web api client, the token is used as the login name.
+type Client struct {
+	Host string
+	Conn *httpclient.Client
+}
+
+func NewSonarClient(host, token string) *Client {
+	return &Client{
+		Host: strings.TrimSuffix(host, "/"),
+		Conn: httpclient.New(httpclient.SetBasicAuth(token, "")),
+	}
+}
+
+const (
+	CETaskPending    = "PENDING"
+	CETaskInProgress = "IN_PROGRESS"
+	CETaskSuccess    = "SUCCESS"
+
+	QualityGateOK    = "OK"
+	QualityGateWarn  = "WARN"
+	QualityGateError = "ERROR"
+	QualityGateNone  = "NONE"
+)
+
+type CETask struct {
+	ID           string `json:"id"`
+	ComponentKey string `json:"componentKey"`
+	AnalysisID   string `json:"analysisId"`
+	Status       string `json:"status"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+type ceTaskResp struct {
+	Task *CETask `json:"task"`
+}
+
+// GetCETask returns the background task of the analysis, the quality gate is computed once it succeeded.
+func (c *Client) GetCETask(id string) (*CETask, error) {
+	resp := &ceTaskResp{}
+	if _, err := c.Conn.Get(c.Host+"/api/ce/task", httpclient.SetResult(resp), httpclient.SetQueryParam("id", id)); err != nil {
+		return nil, err
+	}
+	return resp.Task, nil
+}
+
+type QualityGateCondition struct {
+	Status         string `json:"status"`
+	MetricKey      string `json:"metricKey"`
+	Comparator     string `json:"comparator"`
+	ErrorThreshold string `json:"errorThreshold"`
+	ActualValue    string `json:"actualValue"`
+}
+
+type ProjectStatus struct {
+	Status     string                  `json:"status"`
+	Conditions []*QualityGateCondition `json:"conditions"`
+}
+
+type projectStatusResp struct {
+	ProjectStatus *ProjectStatus `json:"projectStatus"`
+}
+
+// GetQualityGateStatus returns the quality gate status of the analysis.
+func (c *Client) GetQualityGateStatus(analysisID string) (*ProjectStatus, error) {
+	resp := &projectStatusResp{}
+	if _, err := c.Conn.Get(c.Host+"/api/qualitygates/project_status", httpclient.SetResult(resp), httpclient.SetQueryParam("analysisId", analysisID)); err != nil {
+		return nil, err
+	}
+	return resp.ProjectStatus, nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing sets up OpenTelemetry tracing for zadig services, the trace context is propagated
+// over http headers and string maps which are saved along with tasks and queue messages.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterFile   = "file"
+	ExporterStdout = "stdout"
+
+	instrumentationName = "github.com/koderover/zadig"
+)
+
+type Config struct {
+	ServiceName string `yaml:"service_name"`
+	// Exporter is where the spans are exported to, tracing is disabled if it is empty.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the base url of the OTLP/HTTP receiver, such as http://otel-collector:4318.
+	Endpoint string `yaml:"endpoint"`
+	// File is the path of the file which spans are appended to when the exporter is file.
+	File string `yaml:"file"`
+}
+
+var provider *sdktrace.TracerProvider
+
+var propagator = propagation.TraceContext{}
+
+// Init sets up the global tracer provider, spans are still created and propagated if it is not called
+// but they are never recorded or exported.
+func Init(cfg *Config) error {
+	otel.SetTextMapPropagator(propagator)
+
+	if cfg == nil || cfg.Exporter == "" {
+		return nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return err
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+		sdktrace.WithResource(sdkresource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return nil
+}
+
+// Shutdown flushes the pending spans and stops the exporter.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+func newExporter(cfg *Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("endpoint is required by the otlp exporter")
+		}
+		return newOTLPExporter(context.Background(), cfg.Endpoint)
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("file is required by the file exporter")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return newWriterExporter(f, f)
+	case ExporterStdout:
+		return newWriterExporter(os.Stdout, nil)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %s", cfg.Exporter)
+	}
+}
+
+// Start starts a span as the child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer starts a span which handles a request from outside, such as an http request or a queue message.
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// End records err in span if it is not nil and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context in ctx as a string map, it is empty if there is no span in ctx.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns a context with the remote span in carrier as its parent.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// ExtractHTTP returns a context with the remote span in the http headers as its parent.
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectHTTP adds the trace context in ctx to the http headers.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID returns the trace id of the span in ctx, it is empty if there is no valid span.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Field returns the zap field of the trace id in ctx, it is skipped by the logger if there is no trace.
+func Field(ctx context.Context) zap.Field {
+	traceID := TraceID(ctx)
+	if traceID == "" {
+		return zap.Skip()
+	}
+	return zap.String(setting.TraceID, traceID)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestPropagateAndExport(t *testing.T) {
+	ast := require.New(t)
+
+	buf := &bytes.Buffer{}
+	exporter, err := newWriterExporter(buf, nil)
+	ast.Nil(err)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "webhook")
+	carrier := Inject(ctx)
+	ast.Contains(carrier, "traceparent")
+
+	remote := Extract(context.Background(), carrier)
+	ast.Equal(TraceID(ctx), TraceID(remote))
+
+	_, child := tp.Tracer("test").Start(remote, "job")
+	End(child, errors.New("exit code 1"))
+	parent.End()
+
+	type spanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	span := &struct {
+		Name        string
+		SpanContext spanContext
+		Parent      spanContext
+		Status      struct {
+			Code string
+		}
+	}{}
+	ast.Nil(json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], span))
+	ast.Equal("job", span.Name)
+	ast.Equal(TraceID(ctx), span.SpanContext.TraceID)
+	ast.Equal(parent.SpanContext().SpanID().String(), span.Parent.SpanID)
+	ast.Equal("Error", span.Status.Code)
+
+	ast.Empty(TraceID(context.Background()))
+	ast.Nil(Inject(context.Background()))
+}
@@ -16,11 +16,40 @@ limitations under the License.
 
 package job
 
+import "time"
+
 const (
 	JobOutputDir       = "/zadig/results/"
 	JobTerminationFile = "/zadig/termination"
+	// JobOutputsFile is the object name of the outputs uploaded to the object storage,
+	// outputs uploaded in this way are not limited by the size of the termination message.
+	JobOutputsFile = "outputs.json"
+	// JobOutputsInStorage is written to the termination message instead of the outputs which are too large for it,
+	// the outputs can only be got from the object storage then.
+	JobOutputsInStorage = "outputs_in_storage"
+
+	// BreakpointFile exists while the job executor is paused at a breakpoint, it contains the Breakpoint in json.
+	BreakpointFile = "/zadig/debug/breakpoint"
+	// the job executor goes on after BreakpointResumeFile is created, and fails the job after BreakpointAbortFile is created.
+	BreakpointResumeFile = "/zadig/debug/resume"
+	BreakpointAbortFile  = "/zadig/debug/abort"
+	// BreakpointTimeout is how long the job executor waits at a breakpoint before it goes on by itself.
+	BreakpointTimeout = 60 * time.Minute
 )
 
+const (
+	BreakpointBefore  = "before"
+	BreakpointAfter   = "after"
+	BreakpointFailure = "failure"
+)
+
+// Breakpoint is where the job executor is paused, StepName is empty if it's paused after the job failed.
+type Breakpoint struct {
+	Position string `json:"position"`
+	StepName string `json:"step_name"`
+	PausedAt int64  `json:"paused_at"`
+}
+
 type JobOutput struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
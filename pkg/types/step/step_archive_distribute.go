@@ -0,0 +1,32 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+type StepArchiveDistributeSpec struct {
+	// files in the workspace to distribute, envs are rendered.
+	UploadDetail []*Upload                  `bson:"upload_detail"                      json:"upload_detail"                             yaml:"upload_detail"`
+	Targets      []*ArchiveDistributeTarget `bson:"targets"                            json:"targets"                                   yaml:"targets"`
+	// name of the job output to record the urls of the distributed files to, set by aslan.
+	URLsOutput string `bson:"urls_output"                        json:"urls_output"                               yaml:"urls_output"`
+}
+
+type ArchiveDistributeTarget struct {
+	ObjectStorageID string `bson:"object_storage_id"                  json:"object_storage_id"                         yaml:"object_storage_id"`
+	// the files are uploaded under the path, envs are rendered.
+	DestinationPath string `bson:"dest_path"                          json:"dest_path"                                 yaml:"dest_path"`
+	S3              *S3    `bson:"s3_storage"                         json:"s3_storage"                                yaml:"s3_storage"`
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+type StepHtmlReportSpec struct {
+	// directory of the html report, relative to the workspace.
+	ReportDir string `bson:"report_dir"                         json:"report_dir"                                yaml:"report_dir"`
+	// entry file of the html report in the report directory, like index.html.
+	ReportFile string `bson:"report_file"                        json:"report_file"                               yaml:"report_file"`
+	// the report directory is uploaded to S3DestDir/FileName/.
+	S3DestDir string `bson:"s3_dest_dir"                        json:"s3_dest_dir"                               yaml:"s3_dest_dir"`
+	FileName  string `bson:"file_name"                          json:"file_name"                                 yaml:"file_name"`
+	S3        *S3    `bson:"s3_storage"                         json:"s3_storage"                                yaml:"s3_storage"`
+}
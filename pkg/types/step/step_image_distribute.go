@@ -0,0 +1,33 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package step
+
+type StepImageDistributeSpec struct {
+	// full name of the image to distribute, like $IMAGE, envs are rendered.
+	SourceImage    string                   `bson:"source_image"                       json:"source_image"                              yaml:"source_image"`
+	SourceRegistry *DockerRegistry          `bson:"source_registry"                    json:"source_registry"                           yaml:"source_registry"`
+	Targets        []*ImageDistributeTarget `bson:"targets"                            json:"targets"                                   yaml:"targets"`
+	// names of the job outputs to record the digest and the target images to, set by aslan.
+	DigestOutput string `bson:"digest_output"                      json:"digest_output"                             yaml:"digest_output"`
+	ImagesOutput string `bson:"images_output"                      json:"images_output"                             yaml:"images_output"`
+}
+
+type ImageDistributeTarget struct {
+	DockerRegistry *DockerRegistry `bson:"docker_registry"                    json:"docker_registry"                           yaml:"docker_registry"`
+	// tag of the target image, the tag of the source image is used if it is empty.
+	Tag string `bson:"tag"                                json:"tag"                                       yaml:"tag"`
+}
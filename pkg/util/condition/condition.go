@@ -0,0 +1,391 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package condition evaluates the small boolean expressions used by the `if` of workflow jobs, e.g.
+//
+//	workflow.params.env == 'prod' && (trigger.event == 'push' || changed('migrations/'))
+//
+// Supported syntax:
+//   - string literals quoted by ' or ", number literals like 1, and the bool literals true and false
+//   - variables, a variable which is not defined evaluates to an empty string
+//   - ==, != and =~, !~ for regular expression matching
+//   - !, && and || with parentheses
+//   - functions contains(s, sub), startsWith(s, prefix), endsWith(s, suffix) and changed(pattern, ...)
+//
+// A string is treated as false if it is empty, "false" or "0".
+package condition
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type Context struct {
+	Vars map[string]string
+	// ChangedFiles are the files changed by the event which triggered the workflow.
+	ChangedFiles []string
+}
+
+// Validate checks the syntax of the expression.
+func Validate(expression string) error {
+	_, err := Evaluate(expression, &Context{})
+	return err
+}
+
+// Evaluate evaluates the expression against the context.
+func Evaluate(expression string, ctx *Context) (bool, error) {
+	if ctx == nil {
+		ctx = &Context{}
+	}
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return false, err
+	}
+	if len(tokens) == 0 {
+		return false, fmt.Errorf("empty expression")
+	}
+	p := &parser{tokens: tokens, ctx: ctx}
+	v, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return v.bool(), nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+var operators = []string{"==", "!=", "=~", "!~", "&&", "||", "!"}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", offset: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", offset: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", offset: i})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expression[i+1 : i+1+end], offset: i})
+			i += end + 2
+		case isIdentChar(c):
+			start := i
+			for i < len(expression) && isIdentChar(expression[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expression[start:i], offset: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expression[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, offset: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// job names may contain '-', so it is a part of the identifiers.
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// a token of digits only is a number literal instead of a variable.
+func isNumber(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] < '0' || text[i] > '9' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+type value struct {
+	str    string
+	isBool bool
+	b      bool
+}
+
+func boolValue(b bool) value {
+	return value{isBool: true, b: b, str: fmt.Sprintf("%t", b)}
+}
+
+func (v value) bool() bool {
+	if v.isBool {
+		return v.b
+	}
+	return v.str != "" && v.str != "false" && v.str != "0"
+}
+
+// parser evaluates the expression while parsing, both sides of && and || are always parsed
+// so that syntax errors are reported no matter what the variables are.
+type parser struct {
+	tokens []token
+	pos    int
+	ctx    *Context
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) peekOperator(ops ...string) string {
+	t := p.peek()
+	if t == nil || t.kind != tokenOperator {
+		return ""
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.peek()
+	if t == nil {
+		return fmt.Errorf("expect %q but got the end of expression", text)
+	}
+	if t.kind != kind {
+		return fmt.Errorf("expect %q but got %q at position %d", text, t.text, t.offset)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseOr() (value, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return value{}, err
+	}
+	for p.peekOperator("||") != "" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return value{}, err
+		}
+		left = boolValue(left.bool() || right.bool())
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (value, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return value{}, err
+	}
+	for p.peekOperator("&&") != "" {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return value{}, err
+		}
+		left = boolValue(left.bool() && right.bool())
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (value, error) {
+	if p.peekOperator("!") != "" {
+		p.pos++
+		v, err := p.parseNot()
+		if err != nil {
+			return value{}, err
+		}
+		return boolValue(!v.bool()), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (value, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return value{}, err
+	}
+	op := p.peekOperator("==", "!=", "=~", "!~")
+	if op == "" {
+		return left, nil
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return value{}, err
+	}
+	switch op {
+	case "==":
+		return boolValue(left.str == right.str), nil
+	case "!=":
+		return boolValue(left.str != right.str), nil
+	default:
+		re, err := regexp.Compile(right.str)
+		if err != nil {
+			return value{}, fmt.Errorf("invalid regular expression %q: %s", right.str, err)
+		}
+		return boolValue(re.MatchString(left.str) == (op == "=~")), nil
+	}
+}
+
+func (p *parser) parseOperand() (value, error) {
+	t := p.peek()
+	if t == nil {
+		return value{}, fmt.Errorf("unexpected end of expression")
+	}
+	switch t.kind {
+	case tokenString:
+		p.pos++
+		return value{str: t.text}, nil
+	case tokenLParen:
+		p.pos++
+		v, err := p.parseOr()
+		if err != nil {
+			return value{}, err
+		}
+		return v, p.expect(tokenRParen, ")")
+	case tokenIdent:
+		p.pos++
+		if next := p.peek(); next != nil && next.kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		switch t.text {
+		case "true":
+			return boolValue(true), nil
+		case "false":
+			return boolValue(false), nil
+		}
+		if isNumber(t.text) {
+			return value{str: t.text}, nil
+		}
+		return value{str: p.ctx.Vars[t.text]}, nil
+	default:
+		return value{}, fmt.Errorf("unexpected %q at position %d", t.text, t.offset)
+	}
+}
+
+func (p *parser) parseCall(name *token) (value, error) {
+	// skip the left parenthesis
+	p.pos++
+	var args []string
+	if next := p.peek(); next != nil && next.kind == tokenRParen {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return value{}, err
+			}
+			args = append(args, arg.str)
+			if next := p.peek(); next != nil && next.kind == tokenComma {
+				p.pos++
+				continue
+			}
+			if err := p.expect(tokenRParen, ")"); err != nil {
+				return value{}, err
+			}
+			break
+		}
+	}
+
+	switch name.text {
+	case "contains", "startsWith", "endsWith":
+		if len(args) != 2 {
+			return value{}, fmt.Errorf("function %s expects 2 arguments but got %d", name.text, len(args))
+		}
+		switch name.text {
+		case "contains":
+			return boolValue(strings.Contains(args[0], args[1])), nil
+		case "startsWith":
+			return boolValue(strings.HasPrefix(args[0], args[1])), nil
+		default:
+			return boolValue(strings.HasSuffix(args[0], args[1])), nil
+		}
+	case "changed":
+		if len(args) == 0 {
+			return value{}, fmt.Errorf("function changed expects at least 1 argument")
+		}
+		for _, pattern := range args {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return value{}, fmt.Errorf("invalid pattern %q: %s", pattern, err)
+			}
+		}
+		return boolValue(p.changed(args)), nil
+	default:
+		return value{}, fmt.Errorf("unknown function %s at position %d", name.text, name.offset)
+	}
+}
+
+// changed reports whether any changed file is under one of the directories (patterns end with '/')
+// or matches one of the glob patterns.
+func (p *parser) changed(patterns []string) bool {
+	for _, file := range p.ctx.ChangedFiles {
+		for _, pattern := range patterns {
+			if strings.HasSuffix(pattern, "/") {
+				if strings.HasPrefix(file, pattern) {
+					return true
+				}
+				continue
+			}
+			if file == pattern || strings.HasPrefix(file, pattern+"/") {
+				return true
+			}
+			if matched, _ := path.Match(pattern, file); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
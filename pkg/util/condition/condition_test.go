@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/koderover/zadig/pkg/util/condition"
+)
+
+var testCtx = &condition.Context{
+	Vars: map[string]string{
+		"workflow.params.env":     "prod",
+		"workflow.build.IMAGE":    "koderover/aslan:v1.0.0",
+		"workflow.keyvals.DEBUG":  "false",
+		"trigger.event":           "push",
+		"trigger.branch":          "release-1.0",
+		"workflow.unit-test.PASS": "1",
+	},
+	ChangedFiles: []string{"migrations/001_init.sql", "docs/README.md"},
+}
+
+var _ = Describe("Testing condition", func() {
+
+	DescribeTable("Testing Evaluate",
+		func(expression string, expected bool) {
+			result, err := condition.Evaluate(expression, testCtx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result).To(Equal(expected))
+		},
+		Entry("equal", "workflow.params.env == 'prod'", true),
+		Entry("not equal", `workflow.params.env != "prod"`, false),
+		Entry("undefined variable", "workflow.params.foo == ''", true),
+		Entry("truthy variable", "workflow.unit-test.PASS", true),
+		Entry("falsy variable", "workflow.keyvals.DEBUG", false),
+		Entry("number literal", "workflow.unit-test.PASS == 1", true),
+		Entry("number literal not equal", "workflow.unit-test.PASS != 0", true),
+		Entry("not", "!workflow.keyvals.DEBUG", true),
+		Entry("regular expression", "trigger.branch =~ '^release-.*'", true),
+		Entry("negative regular expression", "trigger.branch !~ '^release-.*'", false),
+		Entry("and or with parentheses", "workflow.params.env == 'dev' || (trigger.event == 'push' && trigger.branch != 'main')", true),
+		Entry("and binds tighter than or", "true || false && false", true),
+		Entry("contains", "contains(workflow.build.IMAGE, 'aslan')", true),
+		Entry("startsWith", "startsWith(trigger.branch, 'main')", false),
+		Entry("endsWith", "endsWith(workflow.build.IMAGE, ':v1.0.0')", true),
+		Entry("changed directory", "changed('migrations/')", true),
+		Entry("changed glob", "changed('src/*.go', 'docs/*.md')", true),
+		Entry("not changed", "changed('src')", false),
+	)
+
+	DescribeTable("Testing invalid expressions",
+		func(expression string) {
+			Expect(condition.Validate(expression)).Should(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("unterminated string", "workflow.params.env == 'prod"),
+		Entry("missing right parenthesis", "(true && false"),
+		Entry("dangling operator", "trigger.event =="),
+		Entry("unknown function", "exists('a')"),
+		Entry("wrong number of arguments", "contains('a')"),
+		Entry("invalid regular expression", "trigger.branch =~ '('"),
+		Entry("unexpected character", "trigger.event = 'push'"),
+	)
+})